@@ -0,0 +1,31 @@
+// Package affinity controls which CPUs goroutines run on, where the operating system allows it.
+package affinity
+
+import (
+	"errors"
+	"runtime"
+)
+
+// ErrUnsupported is returned when the operating system does not support setting CPU affinity
+var ErrUnsupported = errors.New("setting CPU affinity is not supported on " + runtime.GOOS)
+
+// Pin locks the calling goroutine to its current OS thread, and restricts that thread to run only on the given logical CPU.
+// The goroutine stays locked to the thread until it exits, which also discards the thread's affinity.
+func Pin(cpu int) error {
+	runtime.LockOSThread()
+	return pin(cpu)
+}
+
+// PhysicalCPUs returns one logical CPU for each physical core, so that compute-bound workers pinned to them don't contend
+// with each other for a core's execution units as hyperthread siblings do.
+// If the topology can't be determined, every logical CPU is returned.
+func PhysicalCPUs() []int {
+	if cpus, err := physicalCPUs(); err == nil && len(cpus) > 0 {
+		return cpus
+	}
+	cpus := make([]int, runtime.NumCPU())
+	for i := range cpus {
+		cpus[i] = i
+	}
+	return cpus
+}
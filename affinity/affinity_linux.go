@@ -0,0 +1,63 @@
+package affinity
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// maxCPUs is the number of CPUs in the affinity mask passed to the kernel
+const maxCPUs = 1024
+
+func pin(cpu int) error {
+	if cpu < 0 || cpu >= maxCPUs {
+		return fmt.Errorf("cpu %d out of range", cpu)
+	}
+	var mask [maxCPUs / 64]uint64
+	mask[cpu/64] |= 1 << (cpu % 64)
+	// A pid of 0 sets the affinity of the calling thread
+	_, _, errno := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, 0, unsafe.Sizeof(mask), uintptr(unsafe.Pointer(&mask)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// physicalCPUs reads the CPU topology from sysfs, and returns the lowest numbered logical CPU of each physical core
+func physicalCPUs() ([]int, error) {
+	dirs, err := filepath.Glob("/sys/devices/system/cpu/cpu[0-9]*")
+	if err != nil {
+		return nil, err
+	}
+	type core struct{ pkg, id string }
+	first := make(map[core]int)
+	for _, dir := range dirs {
+		cpu, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "cpu"))
+		if err != nil {
+			continue
+		}
+		pkg, err := os.ReadFile(filepath.Join(dir, "topology", "physical_package_id"))
+		if err != nil {
+			return nil, err
+		}
+		id, err := os.ReadFile(filepath.Join(dir, "topology", "core_id"))
+		if err != nil {
+			return nil, err
+		}
+		c := core{strings.TrimSpace(string(pkg)), strings.TrimSpace(string(id))}
+		if existing, ok := first[c]; !ok || cpu < existing {
+			first[c] = cpu
+		}
+	}
+	cpus := make([]int, 0, len(first))
+	for _, cpu := range first {
+		cpus = append(cpus, cpu)
+	}
+	sort.Ints(cpus)
+	return cpus, nil
+}
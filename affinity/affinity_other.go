@@ -0,0 +1,11 @@
+//go:build !linux
+
+package affinity
+
+func pin(cpu int) error {
+	return ErrUnsupported
+}
+
+func physicalCPUs() ([]int, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,32 @@
+package affinity
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+)
+
+func TestPhysicalCPUs(t *testing.T) {
+	cpus := PhysicalCPUs()
+	if len(cpus) == 0 || len(cpus) > runtime.NumCPU() {
+		t.Errorf("PhysicalCPUs() returned %d CPUs, want between 1 and %d", len(cpus), runtime.NumCPU())
+	}
+	seen := make(map[int]bool)
+	for _, cpu := range cpus {
+		if seen[cpu] {
+			t.Errorf("PhysicalCPUs() = %v, contains duplicate CPU %d", cpus, cpu)
+		}
+		seen[cpu] = true
+	}
+}
+
+func TestPin(t *testing.T) {
+	done := make(chan error)
+	go func() {
+		// Pinning locks the goroutine to its thread, which is discarded when the goroutine exits
+		done <- Pin(PhysicalCPUs()[0])
+	}()
+	if err := <-done; err != nil && !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Pin() error = %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// The colors of the animation. Stones are dark, except the one just placed, stones just removed by a backtrack, and solutions.
+var animationPalette = color.Palette{
+	color.White,
+	color.RGBA{0xbb, 0xbb, 0xbb, 0xff}, // grid lines
+	color.RGBA{0x33, 0x33, 0x33, 0xff}, // stones
+	color.RGBA{0x22, 0x66, 0xcc, 0xff}, // the stone just placed
+	color.RGBA{0xdd, 0x33, 0x33, 0xff}, // stones just removed
+	color.RGBA{0x22, 0x99, 0x44, 0xff}, // a solution
+}
+
+const (
+	animationWhite = iota
+	animationLine
+	animationStone
+	animationPlaced
+	animationRemoved
+	animationSolution
+)
+
+// animationCell is the side of a cell of the animation in pixels.
+const animationCell = 24
+
+// animationFrame is a sampled step of the search, copied so that it outlives the step.
+type animationFrame struct {
+	kind       solver.StepKind
+	placements grid.Placements
+	removed    grid.Placements
+}
+
+// sampleSearch replays the search and returns up to maxFrames of its steps, evenly spaced, always including the last. Every step is
+// kept while they fit, and then every other one of those kept, and so on.
+func sampleSearch(ctx context.Context, g grid.Grid, spp solver.StartingPointsProvider, spc placer.StonePlacerConstructor, maxFrames int) ([]animationFrame, error) {
+	var frames []animationFrame
+	var last animationFrame
+	stride, step, lastKept := 1, 0, false
+	err := solver.Replay(ctx, g, spp, spc, func(s solver.Step) bool {
+		last = animationFrame{kind: s.Kind, placements: slices.Clone(s.Placements), removed: slices.Clone(s.Removed)}
+		lastKept = step%stride == 0
+		if lastKept {
+			frames = append(frames, last)
+			if len(frames) > maxFrames {
+				// Halve the frames kept so far, and keep half as many from now on
+				for i := 0; 2*i < len(frames); i++ {
+					frames[i] = frames[2*i]
+				}
+				frames = frames[:(len(frames)+1)/2]
+				stride *= 2
+				lastKept = step%stride == 0
+			}
+		}
+		step++
+		return true
+	})
+	if step > 0 && !lastKept {
+		if len(frames) == maxFrames {
+			frames = frames[:maxFrames-1]
+		}
+		frames = append(frames, last)
+	}
+	return frames, err
+}
+
+// fillCell fills a square of the cell at the point, inset by the given number of pixels.
+func fillCell(img *image.Paletted, p grid.Point, inset int, c uint8) {
+	x0, y0 := int(p.Col)*animationCell, int(p.Row)*animationCell
+	for y := y0 + inset; y < y0+animationCell-inset; y++ {
+		for x := x0 + inset; x < x0+animationCell-inset; x++ {
+			img.SetColorIndex(x, y, c)
+		}
+	}
+}
+
+// renderFrame draws a frame as an image of the board.
+func renderFrame(g grid.Grid, f animationFrame) *image.Paletted {
+	size := int(g.Size)*animationCell + 1
+	img := image.NewPaletted(image.Rect(0, 0, size, size), animationPalette)
+	for i := 0; i < size; i += animationCell {
+		for j := 0; j < size; j++ {
+			img.SetColorIndex(i, j, animationLine)
+			img.SetColorIndex(j, i, animationLine)
+		}
+	}
+	stone := uint8(animationStone)
+	if f.kind == solver.StepSolution {
+		stone = animationSolution
+	}
+	for i, p := range f.placements {
+		c := stone
+		if f.kind == solver.StepPlace && i == len(f.placements)-1 {
+			c = animationPlaced
+		}
+		fillCell(img, p, 5, c)
+	}
+	for _, p := range f.removed {
+		fillCell(img, p, 5, animationRemoved)
+	}
+	return img
+}
+
+// animate implements the animate subcommand, which writes an animated GIF of the search for a solution.
+func animate(args []string) {
+	fs := flag.NewFlagSet("animate", flag.ExitOnError)
+	sf := addStrategyFlags(fs, true)
+	maxFrames := fs.Int("frames", 500, "maximum number of frames in the animation, which samples the steps of longer searches evenly")
+	frameDelay := fs.Duration("frame_delay", 50*time.Millisecond, "time each frame of the animation is shown for, in multiples of 10ms")
+	outFile := fs.String("out", "search.gif", "file to write the animation to")
+	parseFlags(fs, fs.Name(), args)
+	g, builder, spc, ctx, stop := sf.setupPlacer()
+	defer stop()
+	spp, err := builder.BuildStartingPoints()
+	if err != nil {
+		fatal(err)
+	}
+	frames, err := writeSearchGIF(ctx, *outFile, g, spp, spc, *maxFrames, *frameDelay)
+	if err != nil {
+		fatal(err)
+	}
+	fmt.Printf("Wrote %d frames of the search of %+v to %s\n", frames, g, *outFile)
+}
+
+// writeSearchGIF writes an animated GIF of the search of the grid, with up to maxFrames frames shown for delay each. The last frame,
+// which is the solution if there is one, is held for a second.
+func writeSearchGIF(ctx context.Context, filename string, g grid.Grid, spp solver.StartingPointsProvider, spc placer.StonePlacerConstructor, maxFrames int, delay time.Duration) (frames int, err error) {
+	sampled, err := sampleSearch(ctx, g, spp, spc, maxFrames)
+	if err != nil {
+		return 0, err
+	}
+	anim := &gif.GIF{}
+	for _, f := range sampled {
+		anim.Image = append(anim.Image, renderFrame(g, f))
+		// GIF delays are in hundredths of a second
+		anim.Delay = append(anim.Delay, int(delay/(10*time.Millisecond)))
+	}
+	if n := len(anim.Delay); n > 0 {
+		anim.Delay[n-1] = 100
+	}
+	out, err := os.Create(filename)
+	if err != nil {
+		return 0, err
+	}
+	if err := gif.EncodeAll(out, anim); err != nil {
+		out.Close()
+		return 0, err
+	}
+	return len(sampled), out.Close()
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+func TestSampleSearch(t *testing.T) {
+	g := grid.Grid{Size: 6}
+	spc := placer.OrderedNoAllocStonePlacerProvider{}
+	all, err := sampleSearch(context.Background(), g, solver.SingleOctantStartingPoints, spc, 1<<20)
+	if err != nil {
+		t.Fatalf("sampleSearch() error = %v", err)
+	}
+	for _, maxFrames := range []int{2, 3, 10, 51, len(all)} {
+		frames, err := sampleSearch(context.Background(), g, solver.SingleOctantStartingPoints, spc, maxFrames)
+		if err != nil {
+			t.Fatalf("sampleSearch() error = %v", err)
+		}
+		if len(frames) > maxFrames || len(frames) < maxFrames/2 {
+			t.Errorf("sampleSearch() with at most %d frames returned %d of %d steps", maxFrames, len(frames), len(all))
+		}
+		if frames[0].kind != solver.StepPlace || len(frames[0].placements) != 1 {
+			t.Errorf("sampleSearch() with at most %d frames starts with %+v, want the first stone", maxFrames, frames[0])
+		}
+		if last := frames[len(frames)-1]; last.kind != solver.StepSolution || grid.CheckValidSolution(g, last.placements) != nil {
+			t.Errorf("sampleSearch() with at most %d frames ends with %+v, want the solution", maxFrames, last)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/pruner"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// batch implements the batch subcommand, which searches each grid size of the -sizes range in turn, and writes the results in the
+// -format. It returns exitStopped if it was interrupted before the last size.
+func batch(args []string) int {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	sf := addStrategyFlags(fs, true)
+	sizes := fs.String("sizes", "1..7", "range of grid sizes to search in turn, such as 2..10, instead of -size")
+	of := addOutputFlags(fs)
+	logResults := fs.String("log_results", "", "append a JSON line to this file for each search that finishes, with its configuration, duration, outcome and nodes, to collect the results of many runs")
+	parseFlags(fs, fs.Name(), args)
+	of.setup()
+	first, last, err := parseSizeRange(*sizes)
+	if err != nil {
+		fatal(err)
+	}
+
+	usePhysicalCores()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results, err := solveSizes(ctx, sf.builder(grid.Grid{Size: first}), sf.pruner == pruner.PrecomputedPrunerName, first, last)
+	metadata := newRunMetadata(fs)
+	if err := writeResults(*of.file, of.format, sf.startingPoints, metadata, true, results); err != nil {
+		fatal(err)
+	}
+	if *logResults != "" {
+		records := make([]resultLogRecord, len(results))
+		for i, r := range results {
+			records[i] = newResultLogRecord(r, sf.startingPoints, metadata, time.Now())
+		}
+		if err := appendResultLog(*logResults, records); err != nil {
+			log.Print(err)
+		}
+	}
+	if err != nil {
+		fatal(err)
+	}
+	if ctx.Err() != nil {
+		return exitStopped
+	}
+	return exitSolved
+}
+
+// parseSizeRange parses a range of grid sizes such as 2..10, or a single size such as 7.
+func parseSizeRange(s string) (from, to uint8, err error) {
+	first, last, isRange := strings.Cut(s, "..")
+	if !isRange {
+		last = first
+	}
+	lo, err := strconv.ParseUint(strings.TrimSpace(first), 10, 8)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid size range %q: %w", s, err)
+	}
+	hi, err := strconv.ParseUint(strings.TrimSpace(last), 10, 8)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid size range %q: %w", s, err)
+	}
+	if lo < 1 || lo > hi || hi > grid.MaxGridSize {
+		return 0, 0, fmt.Errorf("invalid size range %q: want sizes from 1 to %d, smallest first", s, grid.MaxGridSize)
+	}
+	return uint8(lo), uint8(hi), nil
+}
+
+// batchResult is the result of searching one grid size of a batch.
+type batchResult struct {
+	Size           uint8
+	Placer, Solver string
+	Solution       grid.Placements
+	Err            error
+	Duration       time.Duration
+	Nodes          int64
+	// DepthNodes is the number of nodes at each depth, for the results log
+	DepthNodes []int64
+}
+
+// solveSizes searches each grid size from first to last in turn with the strategies of the builder, stopping early if the context is
+// done. The builder's grid and stats are replaced for each size.
+func solveSizes(ctx context.Context, builder *solver.Builder, usePrecomputedPruner bool, first, last uint8) ([]batchResult, error) {
+	var results []batchResult
+	for size := first; size <= last && ctx.Err() == nil; size++ {
+		g := grid.Grid{Size: size}
+		stats := &solver.Stats{}
+		s, err := builder.Grid(g).Stats(stats).Build()
+		if err != nil {
+			return results, fmt.Errorf("%dx%d: %w", size, size, err)
+		}
+		if usePrecomputedPruner && builder.UsesPruner() {
+			pruner.NewPrecomputedPrunerContext(ctx, g)
+		}
+		startTime := time.Now()
+		solution, err := s.SolveContext(ctx, g)
+		r := batchResult{
+			Size: size, Placer: builder.PlacerName(), Solver: builder.SolverName(),
+			Solution: solution, Err: err, Duration: time.Since(startTime), Nodes: stats.Nodes.Load(), DepthNodes: depthNodes(stats.Depths()),
+		}
+		r.Solution.Sort()
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// depthNodes returns the nodes at each depth.
+func depthNodes(depths []solver.DepthStats) []int64 {
+	nodes := make([]int64, len(depths))
+	for i, d := range depths {
+		nodes[i] = d.Nodes
+	}
+	return nodes
+}
+
+// writeBatchSummary writes a table of the result of each size of a batch.
+func writeBatchSummary(w io.Writer, results []batchResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "size\tresult\tduration\tnodes\tsolution\t")
+	for _, r := range results {
+		result, solution := "found", fmt.Sprint(r.Solution)
+		switch {
+		case errors.Is(r.Err, solver.ErrNoSolution):
+			result, solution = "none", ""
+		case errors.Is(r.Err, solver.ErrCanceled):
+			result, solution = "interrupted", ""
+		case r.Err != nil:
+			result, solution = "error", r.Err.Error()
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%v\t%d\t%s\t\n", r.Size, result, r.Duration.Round(time.Microsecond), r.Nodes, solution)
+	}
+	tw.Flush()
+}
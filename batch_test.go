@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+func TestParseSizeRange(t *testing.T) {
+	tests := []struct {
+		s                string
+		wantFrom, wantTo uint8
+		wantErr          bool
+	}{
+		{s: "2..10", wantFrom: 2, wantTo: 10},
+		{s: "7", wantFrom: 7, wantTo: 7},
+		{s: "10..2", wantErr: true},
+		{s: "0..3", wantErr: true},
+		{s: "2..15", wantErr: true},
+		{s: "2-10", wantErr: true},
+	}
+	for _, tt := range tests {
+		from, to, err := parseSizeRange(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSizeRange(%q) error = %v, want error %t", tt.s, err, tt.wantErr)
+			continue
+		}
+		if from != tt.wantFrom || to != tt.wantTo {
+			t.Errorf("parseSizeRange(%q) = %d, %d, want %d, %d", tt.s, from, to, tt.wantFrom, tt.wantTo)
+		}
+	}
+}
+
+func TestSolveSizes(t *testing.T) {
+	results, err := solveSizes(context.Background(), solver.NewBuilder().Solver(solver.SingleThreadedSolverName), true, 2, 6)
+	if err != nil {
+		t.Fatalf("solveSizes() error = %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("solveSizes() returned %d results, want 5", len(results))
+	}
+	for i, r := range results {
+		g := grid.Grid{Size: uint8(i + 2)}
+		if r.Size != g.Size {
+			t.Errorf("solveSizes() result %d is for size %d, want %d", i, r.Size, g.Size)
+		}
+		if err := grid.CheckValidSolution(g, r.Solution); r.Err != nil || err != nil {
+			t.Errorf("solveSizes() for %+v = %v, %v, want a valid solution", g, r.Solution, r.Err)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// benchmarkName returns a name for a solver configuration in the format used by `go test -bench`, including the -GOMAXPROCS suffix
+// which the testing package adds when it is more than 1.
+func benchmarkName(g grid.Grid, placer, solver, pruner string) string {
+	name := fmt.Sprintf("BenchmarkSolve/size=%d/placer=%s/solver=%s/pruner=%s", g.Size, placer, solver, pruner)
+	if procs := runtime.GOMAXPROCS(0); procs > 1 {
+		name = fmt.Sprintf("%s-%d", name, procs)
+	}
+	return name
+}
+
+// writeBenchmarkHeader writes the configuration lines that benchstat uses to label results.
+func writeBenchmarkHeader(w io.Writer) {
+	fmt.Fprintf(w, "goos: %s\n", runtime.GOOS)
+	fmt.Fprintf(w, "goarch: %s\n", runtime.GOARCH)
+	fmt.Fprintf(w, "pkg: github.com/WillMorrison/pegboard-blog\n")
+}
+
+// runBenchmark solves g count times using the testing package's benchmark harness, and writes one result line per run
+// in the standard benchmark format, with the number of placements made per solve as an extra nodes/op metric.
+// Running it with a count of 10 or more gives benchstat enough samples to compare two builds or configurations.
+// Each result is also passed to record, if it isn't nil.
+// It stops early, returning the context's error, if the context is done.
+func runBenchmark(ctx context.Context, w io.Writer, name string, count int, s solver.Solver, g grid.Grid, stats *solver.Stats, record func(testing.BenchmarkResult)) error {
+	for i := 0; i < count; i++ {
+		var err error
+		result := testing.Benchmark(func(b *testing.B) {
+			b.ReportAllocs()
+			nodes := stats.Nodes.Load()
+			for j := 0; j < b.N; j++ {
+				// Not finding a solution is a valid result to benchmark, so only interruptions are errors
+				s.SolveContext(ctx, g)
+				if err = ctx.Err(); err != nil {
+					break
+				}
+			}
+			b.ReportMetric(float64(stats.Nodes.Load()-nodes)/float64(b.N), "nodes/op")
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, result.String(), result.MemString())
+		if record != nil {
+			record(result)
+		}
+	}
+	return nil
+}
+
+// benchConfiguration benchmarks the solver like runBenchmark, and appends the results to the bench store file if it isn't empty,
+// keyed by the commit the binary was built from. It returns the results of the runs that finished.
+func benchConfiguration(ctx context.Context, w io.Writer, name string, count int, s solver.Solver, g grid.Grid, stats *solver.Stats, store string) ([]benchRecord, error) {
+	var records []benchRecord
+	commit := buildCommit()
+	record := func(r testing.BenchmarkResult) {
+		records = append(records, benchRecord{
+			Time: time.Now(), Commit: commit, Name: name,
+			NsPerOp: r.NsPerOp(), NodesPerOp: r.Extra["nodes/op"], AllocsOp: r.AllocsPerOp(), BytesOp: r.AllocedBytesPerOp(),
+		})
+	}
+	err := runBenchmark(ctx, w, name, count, s, g, stats, record)
+	if store != "" {
+		// Keep the results of the runs that finished before an interruption
+		if err := appendBenchRecords(store, records); err != nil {
+			return records, err
+		}
+	}
+	return records, err
+}
+
+// bench implements the bench subcommand, which benchmarks every combination of the placers, solvers and pruners listed, or the
+// configuration of the strategy flags, and compares their time per solve and the rate they place stones at.
+func bench(args []string) {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	sf := addStrategyFlags(fs, true)
+	placers := fs.String("placers", "", "comma separated placers to compare, instead of -placer")
+	solvers := fs.String("solvers", "", "comma separated solvers to compare, instead of -solver")
+	pruners := fs.String("pruners", "", "comma separated pruners to compare, instead of -pruner. Placers that don't prune are benchmarked once")
+	count := fs.Int("count", 10, "number of times to benchmark each configuration, which benchstat needs at least 10 of to compare configurations")
+	store := fs.String("store", "", "also append the benchmark results to this JSON lines file, keyed by commit and configuration, for pegboard bench history")
+	parseFlags(fs, fs.Name(), args)
+	g, ctx, stop := sf.searchContext()
+	defer stop()
+	list := func(names, single string) []string {
+		if names == "" {
+			return []string{single}
+		}
+		return strings.Split(names, ",")
+	}
+	writeBenchmarkHeader(os.Stdout)
+	var comparisons []benchComparison
+	for _, c := range benchMatrix(g, list(*placers, sf.placer), list(*solvers, sf.solver), list(*pruners, sf.pruner)) {
+		stats := &solver.Stats{}
+		builder, s, err := sf.buildSolver(ctx, g, func(b *solver.Builder) {
+			b.Placer(c.Placer).Solver(c.Solver).Stats(stats)
+			if c.UsesPruner {
+				b.Pruner(c.Pruner)
+			}
+		})
+		if err != nil {
+			log.Printf("Skipping %s placer and %s solver: %v", c.Placer, c.Solver, err)
+			continue
+		}
+		c.Placer, c.Solver = builder.PlacerName(), builder.SolverName()
+		records, err := benchConfiguration(ctx, os.Stdout, benchmarkName(g, c.Placer, c.Solver, c.Pruner), *count, s, g, stats, *store)
+		if len(records) > 0 {
+			comparisons = append(comparisons, compareBenchRecords(c, records))
+		}
+		if err != nil {
+			log.Print(err)
+			break
+		}
+	}
+	if len(comparisons) > 1 {
+		fmt.Println()
+		writeBenchComparison(os.Stdout, comparisons)
+	}
+}
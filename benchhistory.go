@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// benchRecord is one benchmark result in a bench store, which is a file of JSON records, one per line, appended to by bench -store.
+// Records are keyed by the commit the binary was built from and the benchmark name, which encodes the configuration.
+type benchRecord struct {
+	Time       time.Time `json:"time"`
+	Commit     string    `json:"commit"`
+	Name       string    `json:"name"`
+	NsPerOp    int64     `json:"ns_per_op"`
+	NodesPerOp float64   `json:"nodes_per_op"`
+	AllocsOp   int64     `json:"allocs_per_op"`
+	BytesOp    int64     `json:"bytes_per_op"`
+}
+
+// buildCommit returns the commit the binary was built from, with a +dirty suffix if the tree had uncommitted changes, or "unknown" if
+// the build didn't record it, e.g. for go run.
+func buildCommit() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	commit, dirty := "unknown", false
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			commit = s.Value
+		case "vcs.modified":
+			dirty = s.Value == "true"
+		}
+	}
+	if dirty {
+		commit += "+dirty"
+	}
+	return commit
+}
+
+// shortCommit abbreviates a commit hash from buildCommit like git does, keeping any +dirty suffix.
+func shortCommit(commit string) string {
+	hash, suffix, _ := strings.Cut(commit, "+")
+	if len(hash) > 12 {
+		hash = hash[:12]
+	}
+	if suffix != "" {
+		return hash + "+" + suffix
+	}
+	return hash
+}
+
+// appendBenchRecords appends the records to the bench store file, creating it if needed.
+func appendBenchRecords(filename string, records []benchRecord) error {
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
+
+// readBenchRecords reads every record in a bench store.
+func readBenchRecords(r io.Reader) ([]benchRecord, error) {
+	var records []benchRecord
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec benchRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// benchSummary is the median time per solve of one configuration at one commit.
+type benchSummary struct {
+	Commit  string
+	Time    time.Time
+	Runs    int
+	NsPerOp int64
+	// Change is the relative change in NsPerOp from the previous commit, and Regression whether it is slower by more than the threshold.
+	Change     float64
+	Regression bool
+}
+
+// summarizeBenchHistory groups the records by configuration, and within each by commit in the order the commits were first
+// benchmarked, comparing each commit's median time per solve with the previous commit's.
+func summarizeBenchHistory(records []benchRecord, threshold float64) map[string][]benchSummary {
+	type key struct{ name, commit string }
+	runs := make(map[key][]int64)
+	var order []key
+	first := make(map[key]time.Time)
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	for _, r := range records {
+		k := key{r.Name, r.Commit}
+		if _, ok := runs[k]; !ok {
+			order = append(order, k)
+			first[k] = r.Time
+		}
+		runs[k] = append(runs[k], r.NsPerOp)
+	}
+
+	history := make(map[string][]benchSummary)
+	for _, k := range order {
+		ns := runs[k]
+		sort.Slice(ns, func(i, j int) bool { return ns[i] < ns[j] })
+		s := benchSummary{Commit: k.commit, Time: first[k], Runs: len(ns), NsPerOp: ns[len(ns)/2]}
+		if prev := history[k.name]; len(prev) > 0 {
+			last := prev[len(prev)-1].NsPerOp
+			s.Change = float64(s.NsPerOp-last) / float64(last)
+			s.Regression = s.Change > threshold
+		}
+		history[k.name] = append(history[k.name], s)
+	}
+	return history
+}
+
+// writeBenchHistory writes a table per configuration of the median time per solve at each commit, flagging regressions.
+func writeBenchHistory(w io.Writer, history map[string][]benchSummary) {
+	names := make([]string, 0, len(history))
+	for name := range history {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(w, name)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "commit\tdate\truns\ttime/op\tchange\t\t")
+		for i, s := range history[name] {
+			change, flag := "", ""
+			if i > 0 {
+				change = fmt.Sprintf("%+.1f%%", 100*s.Change)
+			}
+			if s.Regression {
+				flag = "REGRESSION"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%v\t%s\t%s\t\n", shortCommit(s.Commit), s.Time.Format(time.DateOnly), s.Runs, time.Duration(s.NsPerOp), change, flag)
+		}
+		tw.Flush()
+		fmt.Fprintln(w)
+	}
+}
+
+// benchHistory implements the bench history subcommand, which shows how each configuration's time per solve changed across commits.
+// It exits with a non-zero status if the latest commit of any configuration is a regression, so it can gate a change.
+func benchHistory(args []string) {
+	fs := flag.NewFlagSet("bench history", flag.ExitOnError)
+	store := fs.String("store", "bench.jsonl", "the bench store written by bench -store")
+	threshold := fs.Float64("threshold", 0.05, "the relative slowdown from the previous commit that counts as a regression")
+	parseFlags(fs, fs.Name(), args)
+
+	f, err := os.Open(*store)
+	if err != nil {
+		fatal(err)
+	}
+	records, err := readBenchRecords(f)
+	f.Close()
+	if err != nil {
+		fatalf("%s: %v", *store, err)
+	}
+	history := summarizeBenchHistory(records, *threshold)
+	writeBenchHistory(os.Stdout, history)
+	for name, summaries := range history {
+		if summaries[len(summaries)-1].Regression {
+			fatalf("%s regressed by more than %.1f%% at the latest commit", name, 100**threshold)
+		}
+	}
+}
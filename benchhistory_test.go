@@ -0,0 +1,50 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSummarizeBenchHistory(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	records := []benchRecord{
+		{Time: day(1), Commit: "a", Name: "size=9", NsPerOp: 100},
+		{Time: day(1), Commit: "a", Name: "size=9", NsPerOp: 300},
+		{Time: day(1), Commit: "a", Name: "size=9", NsPerOp: 200},
+		{Time: day(1), Commit: "a", Name: "size=10", NsPerOp: 1000},
+		{Time: day(2), Commit: "b", Name: "size=9", NsPerOp: 204},
+		// Records are ordered by time, not by their position in the store
+		{Time: day(4), Commit: "d", Name: "size=9", NsPerOp: 150},
+		{Time: day(3), Commit: "c", Name: "size=9", NsPerOp: 250},
+	}
+	want := map[string][]benchSummary{
+		"size=9": {
+			{Commit: "a", Time: day(1), Runs: 3, NsPerOp: 200},
+			{Commit: "b", Time: day(2), Runs: 1, NsPerOp: 204, Change: 0.02},
+			{Commit: "c", Time: day(3), Runs: 1, NsPerOp: 250, Change: 250.0/204 - 1, Regression: true},
+			{Commit: "d", Time: day(4), Runs: 1, NsPerOp: 150, Change: -0.4},
+		},
+		"size=10": {
+			{Commit: "a", Time: day(1), Runs: 1, NsPerOp: 1000},
+		},
+	}
+	got := summarizeBenchHistory(records, 0.05)
+	if diff := cmp.Diff(want, got, cmp.Comparer(func(a, b float64) bool { return a-b < 1e-9 && b-a < 1e-9 })); diff != "" {
+		t.Errorf("summarizeBenchHistory() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestShortCommit(t *testing.T) {
+	tests := map[string]string{
+		"1ce0e0ccc74390f8f5e25a882e4150da19b74062":       "1ce0e0ccc743",
+		"1ce0e0ccc74390f8f5e25a882e4150da19b74062+dirty": "1ce0e0ccc743+dirty",
+		"unknown": "unknown",
+	}
+	for commit, want := range tests {
+		if got := shortCommit(commit); got != want {
+			t.Errorf("shortCommit(%q) = %q, want %q", commit, got, want)
+		}
+	}
+}
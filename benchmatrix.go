@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// benchConfig is one configuration of a benchmark matrix.
+type benchConfig struct {
+	Placer, Solver, Pruner string
+	// UsesPruner is whether the placer uses the pruner. Placers that don't are benchmarked once rather than once per pruner.
+	UsesPruner bool
+}
+
+// benchMatrix returns every combination of the placers, solvers and pruners to benchmark on the grid, in the order given.
+func benchMatrix(g grid.Grid, placers, solvers, pruners []string) []benchConfig {
+	var configs []benchConfig
+	for _, p := range placers {
+		usesPruner := solver.NewBuilder().Grid(g).Placer(p).UsesPruner()
+		for _, s := range solvers {
+			if !usesPruner {
+				configs = append(configs, benchConfig{Placer: p, Solver: s, Pruner: pruners[0]})
+				continue
+			}
+			for _, pr := range pruners {
+				configs = append(configs, benchConfig{Placer: p, Solver: s, Pruner: pr, UsesPruner: true})
+			}
+		}
+	}
+	return configs
+}
+
+// benchComparison is the median time per solve of one configuration of a benchmark matrix, and the rate it placed stones at.
+type benchComparison struct {
+	Config         benchConfig
+	Runs           int
+	NsPerOp        int64
+	NodesPerSecond float64
+}
+
+// compareBenchRecords summarizes the records of one configuration. The rate stones are placed at is that of the median run.
+func compareBenchRecords(c benchConfig, records []benchRecord) benchComparison {
+	sorted := append([]benchRecord(nil), records...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].NsPerOp < sorted[j].NsPerOp })
+	bc := benchComparison{Config: c, Runs: len(sorted)}
+	if len(sorted) == 0 {
+		return bc
+	}
+	median := sorted[len(sorted)/2]
+	bc.NsPerOp = median.NsPerOp
+	if median.NsPerOp > 0 {
+		bc.NodesPerSecond = median.NodesPerOp / time.Duration(median.NsPerOp).Seconds()
+	}
+	return bc
+}
+
+// writeBenchComparison writes a table of the configurations from fastest to slowest, with how many times slower than the fastest each is.
+func writeBenchComparison(w io.Writer, comparisons []benchComparison) {
+	sorted := append([]benchComparison(nil), comparisons...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].NsPerOp < sorted[j].NsPerOp })
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "placer\tsolver\tpruner\truns\ttime/op\tnodes/s\tvs fastest\t")
+	for _, c := range sorted {
+		pruner, relative := c.Config.Pruner, "-"
+		if !c.Config.UsesPruner {
+			pruner = "-"
+		}
+		if sorted[0].NsPerOp > 0 {
+			relative = fmt.Sprintf("%.2fx", float64(c.NsPerOp)/float64(sorted[0].NsPerOp))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%v\t%.4g\t%s\t\n", c.Config.Placer, c.Config.Solver, pruner, c.Runs, time.Duration(c.NsPerOp), c.NodesPerSecond, relative)
+	}
+	tw.Flush()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBenchMatrix(t *testing.T) {
+	got := benchMatrix(grid.Grid{Size: 9}, []string{"ordered", "ordered_noalloc_pruning"}, []string{"single_thread", "async"}, []string{"precomputed", "runtime"})
+	want := []benchConfig{
+		{Placer: "ordered", Solver: "single_thread", Pruner: "precomputed"},
+		{Placer: "ordered", Solver: "async", Pruner: "precomputed"},
+		{Placer: "ordered_noalloc_pruning", Solver: "single_thread", Pruner: "precomputed", UsesPruner: true},
+		{Placer: "ordered_noalloc_pruning", Solver: "single_thread", Pruner: "runtime", UsesPruner: true},
+		{Placer: "ordered_noalloc_pruning", Solver: "async", Pruner: "precomputed", UsesPruner: true},
+		{Placer: "ordered_noalloc_pruning", Solver: "async", Pruner: "runtime", UsesPruner: true},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("benchMatrix() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCompareBenchRecords(t *testing.T) {
+	c := benchConfig{Placer: "ordered", Solver: "single_thread", Pruner: "precomputed"}
+	records := []benchRecord{
+		{NsPerOp: 3e6, NodesPerOp: 1000},
+		{NsPerOp: 1e6, NodesPerOp: 1000},
+		{NsPerOp: 2e6, NodesPerOp: 1000},
+	}
+	want := benchComparison{Config: c, Runs: 3, NsPerOp: 2e6, NodesPerSecond: 5e5}
+	if diff := cmp.Diff(want, compareBenchRecords(c, records)); diff != "" {
+		t.Errorf("compareBenchRecords() mismatch (-want +got):\n%s", diff)
+	}
+}
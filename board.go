@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+// Board formats for -show_board. The ASCII format is shared with -markdown_board.
+const (
+	NoBoardFormat    = "none"
+	ColorBoardFormat = "color"
+)
+
+// cellState is what a cell of a partial placement is to the search.
+type cellState uint8
+
+const (
+	// cellStone has a stone
+	cellStone cellState = iota
+	// cellPruned can't take a stone, as its separation from some stone repeats another separation
+	cellPruned
+	// cellCandidate can take a stone, and comes after every stone, so ordered placers will still try it
+	cellCandidate
+	// cellSkipped can take a stone, but comes before the last stone, so ordered placers have already passed it
+	cellSkipped
+)
+
+// cellStates classifies every cell of the grid for the placements, as rows of cells. Which cells are pruned doesn't depend on the
+// placer, but only ordered placers skip the cells before their last stone.
+func cellStates(g grid.Grid, p grid.Placements) [][]cellState {
+	separations := make(map[uint16]bool)
+	for _, s := range p.Separations() {
+		separations[s] = true
+	}
+	var last grid.Point
+	for i, s := range p {
+		if i == 0 || grid.LessThan(last, s) {
+			last = s
+		}
+	}
+	states := make([][]cellState, g.Size)
+	for r := range states {
+		states[r] = make([]cellState, g.Size)
+		for c := range states[r] {
+			cell := grid.Point{Row: uint8(r), Col: uint8(c)}
+			states[r][c] = cellCandidate
+			seen := make(map[uint16]bool, len(p))
+			for _, s := range p {
+				sep := grid.Separation(cell, s)
+				if sep == 0 {
+					states[r][c] = cellStone
+					break
+				}
+				if separations[sep] || seen[sep] {
+					states[r][c] = cellPruned
+				}
+				seen[sep] = true
+			}
+			if states[r][c] == cellCandidate && len(p) > 0 && grid.LessThan(cell, last) {
+				states[r][c] = cellSkipped
+			}
+		}
+	}
+	return states
+}
+
+// ANSI escape sequences for the cells of the color board.
+var cellStyles = map[cellState]string{
+	cellStone:     "\x1b[1;32m●\x1b[0m",
+	cellPruned:    "\x1b[31m×\x1b[0m",
+	cellCandidate: "\x1b[33m○\x1b[0m",
+	cellSkipped:   "\x1b[2m·\x1b[0m",
+}
+
+// writeBoardColor draws the board with ANSI colors, showing which cells have stones, which are pruned because a stone there would
+// repeat a separation, and which remain candidates for the next stone, followed by a legend. It falls back to the plain ASCII board
+// when the NO_COLOR environment variable is set.
+func writeBoardColor(w io.Writer, g grid.Grid, p grid.Placements) {
+	if os.Getenv("NO_COLOR") != "" {
+		writeBoardASCII(w, g, p)
+		return
+	}
+	fmt.Fprint(w, "  ")
+	for c := 0; c < int(g.Size); c++ {
+		fmt.Fprintf(w, "%2d", c)
+	}
+	fmt.Fprintln(w)
+	for r, row := range cellStates(g, p) {
+		fmt.Fprintf(w, "%c ", grid.Point{Row: uint8(r)}.String()[0])
+		for _, state := range row {
+			fmt.Fprintf(w, " %s", cellStyles[state])
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%s stone  %s pruned  %s candidate  %s passed by ordered placers\n",
+		cellStyles[cellStone], cellStyles[cellPruned], cellStyles[cellCandidate], cellStyles[cellSkipped])
+}
+
+// writeBoard draws the board in the format of -show_board.
+func writeBoard(w io.Writer, format string, g grid.Grid, p grid.Placements) {
+	switch format {
+	case ASCIIBoardFormat:
+		writeBoardASCII(w, g, p)
+	case ColorBoardFormat:
+		writeBoardColor(w, g, p)
+	}
+}
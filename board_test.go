@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCellStates(t *testing.T) {
+	const (
+		o = cellStone
+		x = cellPruned
+		c = cellCandidate
+		s = cellSkipped
+	)
+	tests := []struct {
+		name       string
+		placements grid.Placements
+		want       [][]cellState
+	}{
+		{"empty",
+			nil,
+			[][]cellState{{c, c, c}, {c, c, c}, {c, c, c}},
+		},
+		{"one stone",
+			grid.Placements{{Row: 1, Col: 1}},
+			[][]cellState{{s, s, s}, {s, o, c}, {c, c, c}},
+		},
+		{"pruned",
+			grid.Placements{{Row: 0, Col: 0}, {Row: 0, Col: 1}},
+			[][]cellState{{o, o, x}, {x, x, c}, {c, c, c}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cellStates(grid.Grid{Size: 3}, tt.placements)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("cellStates(%v) mismatch (-want +got):\n%s", tt.placements, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/hashicorp/packer/command/enumflag"
+)
+
+const TextReportFormat = "text"
+
+// boundsReport implements the bounds subcommand, which writes the counting bounds for every grid size up to a maximum, and which sizes they rule out.
+func boundsReport(args []string) {
+	fs := flag.NewFlagSet("bounds", flag.ExitOnError)
+	maxSize := fs.Uint("max_size", grid.MaxGridSize+6, "the largest grid size to report on")
+	outFile := fs.String("out", "", "file to write the report to, instead of stdout")
+	format := TextReportFormat
+	fs.Var(enumflag.New(&format, TextReportFormat, JSONReportFormat, CSVReportFormat), "report_format", "format to write the report in")
+	parseFlags(fs, fs.Name(), args)
+	if *maxSize < 1 || *maxSize > 255 {
+		fatalf("The maximum size must be between 1 and 255, got %d", *maxSize)
+	}
+
+	var bounds []grid.CountingBounds
+	for size := 1; size <= int(*maxSize); size++ {
+		bounds = append(bounds, grid.NewCountingBounds(grid.Grid{Size: uint8(size)}))
+	}
+
+	out := os.Stdout
+	if *outFile != "" {
+		f, err := os.Create(*outFile)
+		if err != nil {
+			fatal(err)
+		}
+		defer f.Close()
+		out = f
+	}
+	var err error
+	switch format {
+	case JSONReportFormat:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(bounds)
+	case CSVReportFormat:
+		err = writeBoundsCSV(out, bounds)
+	default:
+		writeBoundsTable(out, bounds)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func writeBoundsCSV(out io.Writer, bounds []grid.CountingBounds) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{"size", "pairs", "separations", "impossible", "single_offset", "slack", "max_stones", "feasible"})
+	for _, b := range bounds {
+		w.Write([]string{
+			strconv.Itoa(int(b.Size)), strconv.Itoa(b.Pairs), strconv.Itoa(b.Separations), strconv.Itoa(b.Impossible),
+			strconv.Itoa(b.SingleOffset), strconv.Itoa(b.Slack), strconv.Itoa(b.MaxStones), strconv.FormatBool(b.Feasible()),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// writeBoundsTable writes the bounds as a table, followed by an explanation of the sizes they rule out.
+func writeBoundsTable(out io.Writer, bounds []grid.CountingBounds) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "size\tpairs\tseparations\timpossible\tsingle offset\tslack\tmax stones\t")
+	for _, b := range bounds {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t%d\t%d\t\n", b.Size, b.Pairs, b.Separations, b.Impossible, b.SingleOffset, b.Slack, b.MaxStones)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "A solution on an NxN grid has N stones, and so N(N-1)/2 pairs of stones, which must all have different separations.")
+	// Find the first size from which every reported size is ruled out
+	first := len(bounds)
+	for first > 0 && !bounds[first-1].Feasible() {
+		first--
+	}
+	if first < len(bounds) {
+		b := bounds[first]
+		fmt.Fprintf(out, "From %dx%d up to %dx%d, there are fewer separations than pairs (%d < %d for %dx%d), so there are no solutions.\n",
+			b.Size, b.Size, bounds[len(bounds)-1].Size, bounds[len(bounds)-1].Size, b.Separations, b.Pairs, b.Size, b.Size)
+	}
+	for _, b := range bounds {
+		if b.Slack == 0 && b.Pairs > 0 {
+			fmt.Fprintf(out, "On a %dx%d grid there are exactly as many separations as pairs (%d), so a solution would have to use every separation once, "+
+				"including the %d that only one offset produces.\n", b.Size, b.Size, b.Pairs, b.SingleOffset)
+		}
+	}
+}
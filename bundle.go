@@ -0,0 +1,199 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// bundleConfig is the effective configuration of a run: every flag's value, whether set or defaulted, and the strategies the
+// auto placer and solver resolved to.
+type bundleConfig struct {
+	Args   []string          `json:"args"`
+	Flags  map[string]string `json:"flags"`
+	Placer string            `json:"placer"`
+	Solver string            `json:"solver"`
+	// Seed is the -seed that shuffled the starting points and the placer's candidates, which a run with it repeats. Runs without a seed
+	// search in the same order every time.
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+// bundleBuild describes the binary and machine a run used.
+type bundleBuild struct {
+	GoVersion  string            `json:"go_version"`
+	Module     string            `json:"module"`
+	Version    string            `json:"version"`
+	Settings   map[string]string `json:"settings,omitempty"`
+	GOOS       string            `json:"goos"`
+	GOARCH     string            `json:"goarch"`
+	NumCPU     int               `json:"num_cpu"`
+	GOMAXPROCS int               `json:"gomaxprocs"`
+	Hostname   string            `json:"hostname"`
+}
+
+// bundleStats is the outcome of a run and the statistics collected during it.
+type bundleStats struct {
+	Size           uint8                       `json:"size"`
+	Outcome        string                      `json:"outcome"`
+	Error          string                      `json:"error,omitempty"`
+	Duration       string                      `json:"duration"`
+	Nodes          int64                       `json:"nodes"`
+	Depths         []solver.DepthStats         `json:"depths"`
+	StartingPoints []solver.StartingPointStats `json:"starting_points"`
+	Memory         *solver.MemoryStats         `json:"memory,omitempty"`
+}
+
+// runBundle collects everything needed to reproduce a run, and writes it as a directory, or a gzipped tarball if its path ends in .tar.gz.
+type runBundle struct {
+	path  string
+	files map[string][]byte
+	// events receives a copy of everything logged during the run
+	events bytes.Buffer
+}
+
+// newRunBundle returns a bundle that will be written to path. It records the log from now on.
+func newRunBundle(path string) *runBundle {
+	b := &runBundle{path: path, files: make(map[string][]byte)}
+	log.SetOutput(io.MultiWriter(os.Stderr, &b.events))
+	return b
+}
+
+// addJSON adds a file containing v as indented JSON.
+func (b *runBundle) addJSON(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	b.files[name] = append(data, '\n')
+	return nil
+}
+
+// record adds the configuration, build, statistics and solution of a finished run with the flags of the flag set.
+func (b *runBundle) record(fs *flag.FlagSet, builder *solver.Builder, g grid.Grid, solution grid.Placements, err error, duration time.Duration, stats *solver.Stats, memory *solver.MemoryStats) error {
+	config := bundleConfig{Args: os.Args, Flags: make(map[string]string), Placer: builder.PlacerName(), Solver: builder.SolverName()}
+	fs.VisitAll(func(f *flag.Flag) { config.Flags[f.Name] = f.Value.String() })
+	if seed, ok := builder.ShuffleSeed(); ok {
+		config.Seed = &seed
+	}
+	if err := b.addJSON("config.json", config); err != nil {
+		return err
+	}
+
+	build := bundleBuild{GoVersion: runtime.Version(), GOOS: runtime.GOOS, GOARCH: runtime.GOARCH, NumCPU: runtime.NumCPU(), GOMAXPROCS: runtime.GOMAXPROCS(0)}
+	build.Hostname, _ = os.Hostname()
+	if info, ok := debug.ReadBuildInfo(); ok {
+		build.Module, build.Version = info.Main.Path, info.Main.Version
+		build.Settings = make(map[string]string)
+		for _, s := range info.Settings {
+			build.Settings[s.Key] = s.Value
+		}
+	}
+	if err := b.addJSON("build.json", build); err != nil {
+		return err
+	}
+
+	result := bundleStats{
+		Size:           g.Size,
+		Outcome:        outcome(err),
+		Duration:       duration.String(),
+		Nodes:          stats.Nodes.Load(),
+		Depths:         stats.Depths(),
+		StartingPoints: stats.StartingPoints(),
+		Memory:         memory,
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if err := b.addJSON("stats.json", result); err != nil {
+		return err
+	}
+
+	if solution != nil {
+		// In the format of the -known files of warm-start
+		b.files["solution.txt"] = []byte(strings.Trim(fmt.Sprint(solution), "[]") + "\n")
+	}
+	return nil
+}
+
+// outcome names the result of a search with the given error.
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return "solved"
+	case errors.Is(err, solver.ErrNoSolution):
+		return "no_solution"
+	case errors.Is(err, solver.ErrNodeBudget):
+		return "node_budget"
+	case errors.Is(err, solver.ErrCanceled):
+		return "canceled"
+	case errors.Is(err, solver.ErrTimeout):
+		return "timeout"
+	default:
+		return "error"
+	}
+}
+
+// write writes the recorded files and the log so far to the bundle's path.
+func (b *runBundle) write() error {
+	b.files["events.log"] = b.events.Bytes()
+	names := make([]string, 0, len(b.files))
+	for name := range b.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if !strings.HasSuffix(b.path, ".tar.gz") {
+		if err := os.MkdirAll(b.path, 0o755); err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := os.WriteFile(filepath.Join(b.path, name), b.files[name], 0o644); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	f, err := os.Create(b.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	// Files are in a directory named after the tarball, so that extracting it doesn't scatter them
+	dir := strings.TrimSuffix(filepath.Base(b.path), ".tar.gz")
+	now := time.Now()
+	for _, name := range names {
+		hdr := &tar.Header{Name: dir + "/" + name, Mode: 0o644, Size: int64(len(b.files[name])), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(b.files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
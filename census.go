@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"github.com/hashicorp/packer/command/enumflag"
+)
+
+const (
+	JSONReportFormat = "json"
+	CSVReportFormat  = "csv"
+)
+
+// census implements the census subcommand, which counts the solutions for every grid size up to -size.
+func census(args []string) {
+	fs := flag.NewFlagSet("census", flag.ExitOnError)
+	sf := addStrategyFlags(fs, false)
+	outFile := fs.String("out", "", "file to write the census to, instead of stdout")
+	reportFormat := JSONReportFormat
+	fs.Var(enumflag.New(&reportFormat, JSONReportFormat, CSVReportFormat), "report_format", "format to write the census in")
+	parseFlags(fs, fs.Name(), args)
+	g, _, spc, ctx, stop := sf.setupPlacer()
+	defer stop()
+	if err := writeCensus(ctx, *outFile, reportFormat, g.Size, spc); err != nil {
+		fatal(err)
+	}
+}
+
+// writeCensus counts the solutions for every grid size from 1 to maxSize, and writes the census to
+// the named file, or stdout if filename is empty, in the given format. If the census is interrupted, the sizes counted so far are written,
+// with the last marked incomplete.
+func writeCensus(ctx context.Context, filename, format string, maxSize uint8, spc placer.StonePlacerConstructor) error {
+	out := os.Stdout
+	if filename != "" {
+		f, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	census, err := solver.TakeCensus(ctx, maxSize, spc)
+	var werr error
+	switch format {
+	case CSVReportFormat:
+		werr = writeCensusCSV(out, census)
+	default:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		werr = enc.Encode(census)
+	}
+	if err != nil {
+		return err
+	}
+	return werr
+}
+
+// writeCensusCSV writes one row per grid size, with a column for the number of classes of each symmetry type, followed by a row of totals.
+func writeCensusCSV(out io.Writer, census solver.Census) error {
+	w := csv.NewWriter(out)
+	header := []string{"size", "solutions", "classes"}
+	for _, t := range grid.SymmetryTypes {
+		header = append(header, string(t))
+	}
+	w.Write(append(header, "complete", "duration"))
+	for _, e := range census.Sizes {
+		row := []string{strconv.Itoa(int(e.Size)), strconv.Itoa(e.Solutions), strconv.Itoa(e.Classes)}
+		for _, t := range grid.SymmetryTypes {
+			row = append(row, strconv.Itoa(e.SymmetryTypes[t]))
+		}
+		w.Write(append(row, strconv.FormatBool(e.Complete), e.Duration))
+	}
+	w.Write([]string{"total", strconv.Itoa(census.TotalSolutions), strconv.Itoa(census.TotalClasses)})
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("writing census: %w", err)
+	}
+	return nil
+}
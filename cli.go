@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/affinity"
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/pruner"
+	"github.com/WillMorrison/pegboard-blog/sets"
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"github.com/hashicorp/packer/command/enumflag"
+)
+
+// usage is the overview of the subcommands, printed before the flags of solve.
+const usage = `Usage: pegboard [subcommand] [flags]
+
+Subcommands, each with its own flags, which -h lists:
+  solve            search for a solution, which is the default without a subcommand
+  watch            search for a solution like solve, redrawing a view of the search on stderr while it runs
+  warm-start       try to extend known solutions for smaller grids, falling back to the search of solve
+  shard            search one shard of the starting points, writing its result for merge
+  merge            merge the results of shards
+  batch            search each grid size of a range in turn, and print a summary table
+  verify           check solutions produced elsewhere
+  enumerate        find every solution
+  classes          find every solution, and report their equivalence classes under rotation and reflection
+  solutions        print several distinct solutions as they are found
+  estimate         estimate the search tree size below each starting point from random probes
+  frontier         write the canonical placements with a number of stones, to distribute as starting points
+  census           count the solutions for every grid size up to -size
+  animate          write an animated GIF of the search
+  bench            benchmark a configuration, or show the history of benchmarks with bench history
+  prove            search exhaustively for a proof that no solution exists
+  serve            serve the HTTP API, which searches with POST /solve, and a web UI at / showing searches live
+  grpc-serve       serve the gRPC API defined in pegboardpb/pegboard.proto
+  pipeline         search for the job on each line of stdin, writing a JSON result line for each to stdout
+  plan             estimate how long searches would take
+  difftest         compare two placers from random partial placements
+  selftest         solve every grid up to 7x7 with every combination of placer, solver and pruner, and check that they agree
+  compare          run two configurations on the same grid, check that they agree, and compare their placements
+  bounds           report the counting bounds for each grid size
+  implementations  list the registered placers, pruners, separation sets, starting points and solvers, with the options each supports
+
+Every flag can also be set with an environment variable named after it, e.g. PEGBOARD_SPLIT_DEPTH=4 for -split_depth, or in the
+-config file. Flags on the command line take precedence over the environment, which takes precedence over the config file.
+
+Exit codes of solve, watch, warm-start, shard, batch, prove and merge:
+  0  a solution was found
+  1  no solution exists: the search was exhaustive
+  2  the search was stopped before it finished, by an interrupt, a timeout or -max_nodes
+  3  invalid input, such as a bad flag or a file that can't be read, or another error
+verify, difftest, selftest and compare exit 1 when a placement is invalid, the placers diverge or the configurations disagree.
+
+Flags of solve:
+`
+
+// strategyFlags are the flags that choose the grid and the strategies of a search, which the subcommands that search share.
+type strategyFlags struct {
+	fs                            *flag.FlagSet
+	size                          *uint
+	separationSet, pruner, placer string
+	startingPoints, solver        string
+	bound, forced                 *bool
+	splitDepth, workers           *int
+	seed                          *int64
+	timeSlice                     *time.Duration
+	startFrom                     grid.Placements
+	hasSolver                     bool
+}
+
+// addStrategyFlags adds the strategy flags to the flag set. The flags that choose the solver and its starting points are only added
+// with withSolver, for subcommands that run a solver.
+func addStrategyFlags(fs *flag.FlagSet, withSolver bool) *strategyFlags {
+	sf := &strategyFlags{
+		fs:             fs,
+		size:           fs.Uint("size", 7, "the side length of square grid to search for solutions on"),
+		separationSet:  sets.BitArraySeparationSetName,
+		pruner:         pruner.PrecomputedPrunerName,
+		placer:         placer.OrderedNoAllocStonePlacerName,
+		startingPoints: solver.SingleOctantStartingPointsName,
+		solver:         solver.AsyncSolverName,
+		bound:          fs.Bool("bound", false, "cut branches that cannot be completed according to the row and column bound (pruning placers only)"),
+		forced:         fs.Bool("forced", false, "place stones that the row and column bound shows every completion needs as soon as they are found (ordered_noalloc_pruning and ordered_noalloc_opportunistic_pruning placers only)"),
+		seed:           fs.Int64("seed", 0, "if set, shuffle the order of the starting points and of the candidates for each stone with this seed, so runs with the same seed search in the same order. The placer defaults to ordered_shuffled, the only one that shuffles candidates"),
+		hasSolver:      withSolver,
+	}
+	fs.Var(enumflag.New(&sf.separationSet, sets.SeparationSetNames()...), "separation_set", "SeparationSet implementation to use")
+	fs.Var(enumflag.New(&sf.pruner, pruner.Names()...), "pruner", "Pruner implementation to use")
+	fs.Var(enumflag.New(&sf.placer, append(placer.Names(), solver.AutoName)...), "placer", "StonePlacer implementation to use. auto chooses the fastest for the grid size, with -bound")
+	if withSolver {
+		fs.Var(enumflag.New(&sf.startingPoints, solver.StartingPointsNames()...), "start", "Starting point for the search")
+		fs.Func("start_from", "partial placement such as \"A0 C4 F2\" to search only the completions of, instead of the starting points of -start. Ordered placers only search the completions whose smallest stones are the placement", func(s string) error {
+			p, err := grid.ParsePlacements(s)
+			sf.startFrom = p
+			return err
+		})
+		fs.Var(enumflag.New(&sf.solver, append(solver.Names(), solver.AutoName)...), "solver", "Solver implementation to use. auto chooses the fastest for the grid size and the number of CPUs")
+		sf.workers = fs.Int("workers", 0, "number of worker goroutines for the async_splitting and fixed_depth solvers, or 0 for one per CPU that Go can use")
+		sf.splitDepth = fs.Int("split_depth", 3, "number of stones placed in each task's prefix for the fixed_depth solver")
+		sf.timeSlice = fs.Duration("time_slice", solver.DefaultTimeSlice, "time spent below each starting point in turn by the time_sliced solver")
+	}
+	return sf
+}
+
+// grid returns the grid of -size, or an error if it is too large to have solutions.
+func (sf *strategyFlags) grid() (grid.Grid, error) {
+	if *sf.size > grid.MaxGridSize {
+		return grid.Grid{}, fmt.Errorf("no solutions exist for %dx%d or larger grids. Not searching", grid.MaxGridSize+1, grid.MaxGridSize+1)
+	}
+	return grid.Grid{Size: uint8(*sf.size)}, nil
+}
+
+// builder returns a builder for the grid with the strategies of the flags. Options that the chosen strategies don't use are errors if
+// they were set explicitly, but not if they are defaults.
+func (sf *strategyFlags) builder(g grid.Grid) *solver.Builder {
+	set := make(map[string]bool)
+	sf.fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	b := solver.NewBuilder().
+		Grid(g).
+		Placer(sf.placer).
+		Bound(*sf.bound).
+		Forced(*sf.forced)
+	if sf.hasSolver {
+		b.StartingPoints(sf.startingPoints).Solver(sf.solver)
+	}
+	if set["start_from"] {
+		b.StartFrom(sf.startFrom)
+	}
+	if set["pruner"] {
+		b.Pruner(sf.pruner)
+	}
+	if set["separation_set"] {
+		b.SeparationSet(sf.separationSet)
+	}
+	if set["workers"] {
+		b.Workers(*sf.workers)
+	}
+	if set["split_depth"] {
+		b.SplitDepth(*sf.splitDepth)
+	}
+	if set["time_slice"] {
+		b.TimeSlice(*sf.timeSlice)
+	}
+	if set["seed"] {
+		b.Seed(*sf.seed)
+		if !set["placer"] {
+			b.Placer(placer.ShuffledStonePlacerName)
+		}
+	}
+	return b
+}
+
+// precomputePruner builds the tables of the precomputed pruner for the grid if the builder's placer uses it, so that their cost is
+// accounted for separately from the search.
+func precomputePruner(ctx context.Context, g grid.Grid, b *solver.Builder) {
+	if b.UsesPruner() && b.PrunerName() == pruner.PrecomputedPrunerName {
+		pruner.NewPrecomputedPrunerContext(ctx, g)
+	}
+}
+
+// searchContext returns the grid of the flags, and a context that an interrupt cancels until stop is called, and uses the physical
+// cores for the searches. An invalid size is fatal.
+func (sf *strategyFlags) searchContext() (grid.Grid, context.Context, context.CancelFunc) {
+	g, err := sf.grid()
+	if err != nil {
+		fatal(err)
+	}
+	usePhysicalCores()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	return g, ctx, stop
+}
+
+// buildSolver builds the solver for the grid with the strategies of the flags, as changed by configure if it isn't nil, then builds the
+// tables of its pruner.
+func (sf *strategyFlags) buildSolver(ctx context.Context, g grid.Grid, configure func(*solver.Builder)) (*solver.Builder, solver.Solver, error) {
+	b := sf.builder(g)
+	if configure != nil {
+		configure(b)
+	}
+	s, err := b.Build()
+	if err != nil {
+		return b, nil, err
+	}
+	precomputePruner(ctx, g, b)
+	return b, s, nil
+}
+
+// setupSearch prepares the search of a subcommand with a single configuration: the grid, the builder as changed by configure and the
+// solver it built, and a context that an interrupt cancels until stop is called. Errors are fatal.
+func (sf *strategyFlags) setupSearch(configure func(*solver.Builder)) (grid.Grid, *solver.Builder, solver.Solver, context.Context, context.CancelFunc) {
+	g, ctx, stop := sf.searchContext()
+	b, s, err := sf.buildSolver(ctx, g, configure)
+	if err != nil {
+		stop()
+		fatal(err)
+	}
+	return g, b, s, ctx, stop
+}
+
+// setupPlacer prepares the search of a subcommand that searches with the placer of the flags rather than a solver: the grid, the builder
+// and the placer's constructor, with the tables of its pruner built, and a context that an interrupt cancels until stop is called.
+// Errors are fatal.
+func (sf *strategyFlags) setupPlacer() (grid.Grid, *solver.Builder, placer.StonePlacerConstructor, context.Context, context.CancelFunc) {
+	g, ctx, stop := sf.searchContext()
+	b := sf.builder(g)
+	spc, err := b.BuildPlacer()
+	if err != nil {
+		stop()
+		fatal(err)
+	}
+	precomputePruner(ctx, g, b)
+	return g, b, spc, ctx, stop
+}
+
+// usePhysicalCores sets GOMAXPROCS to the number of physical cores, unless the GOMAXPROCS environment variable sets it. This workload is
+// compute bound, so hyperthread siblings mostly contend with each other for the same execution units.
+func usePhysicalCores() {
+	if os.Getenv("GOMAXPROCS") == "" {
+		runtime.GOMAXPROCS(len(affinity.PhysicalCPUs()))
+	}
+}
+
+// printUsage prints the overview of the subcommands and the flags of solve, which are in the flag set.
+func printUsage(fs *flag.FlagSet) {
+	fmt.Fprint(fs.Output(), usage)
+	fs.PrintDefaults()
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+func TestStrategyFlags(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		withSolver bool
+		wantPlacer string
+		wantSolver string
+		wantErr    bool
+	}{
+		{name: "defaults", withSolver: true, wantPlacer: "ordered_noalloc", wantSolver: "async"},
+		{name: "auto", args: []string{"-size", "10", "-placer", "auto", "-solver", "single_thread"}, withSolver: true, wantPlacer: "ordered_noalloc_pruning", wantSolver: "single_thread"},
+		{name: "explicit unused option", args: []string{"-placer", "ordered", "-pruner", "runtime"}, withSolver: true, wantErr: true},
+		{name: "explicit unused solver option", args: []string{"-solver", "single_thread", "-split_depth", "2"}, withSolver: true, wantErr: true},
+		{name: "workers", args: []string{"-solver", "async_splitting", "-workers", "3"}, withSolver: true, wantPlacer: "ordered_noalloc", wantSolver: "async_splitting"},
+		{name: "workers unused by solver", args: []string{"-solver", "single_thread", "-workers", "3"}, withSolver: true, wantErr: true},
+		{name: "start from", args: []string{"-start_from", "A0 A2"}, withSolver: true, wantPlacer: "ordered_noalloc", wantSolver: "async"},
+		{name: "start from invalid prefix", args: []string{"-start_from", "A0 A1 A2"}, withSolver: true, wantErr: true},
+		{name: "seed", args: []string{"-seed", "7"}, withSolver: true, wantPlacer: "ordered_shuffled", wantSolver: "async"},
+		{name: "seed with unshuffled placer", args: []string{"-seed", "7", "-placer", "ordered"}, withSolver: true, wantErr: true},
+		{name: "without solver", args: []string{"-placer", "ordered_bitboard"}, wantPlacer: "ordered_bitboard", wantSolver: "async"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet(tt.name, flag.ContinueOnError)
+			sf := addStrategyFlags(fs, tt.withSolver)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			g, err := sf.grid()
+			if err != nil {
+				t.Fatal(err)
+			}
+			b := sf.builder(g)
+			_, err = b.Build()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if b.PlacerName() != tt.wantPlacer || b.SolverName() != tt.wantSolver {
+				t.Errorf("builder has placer %s and solver %s, want %s and %s", b.PlacerName(), b.SolverName(), tt.wantPlacer, tt.wantSolver)
+			}
+		})
+	}
+}
+
+func TestStrategyFlags_BuildSolver(t *testing.T) {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	sf := addStrategyFlags(fs, true)
+	if err := fs.Parse([]string{"-size", "6", "-solver", "single_thread"}); err != nil {
+		t.Fatal(err)
+	}
+	g, err := sf.grid()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The configuration overrides the flags
+	b, s, err := sf.buildSolver(context.Background(), g, func(b *solver.Builder) { b.Placer(placer.OrderedNoAllocPruningStonePlacerName) })
+	if err != nil {
+		t.Fatalf("buildSolver() error = %v", err)
+	}
+	if b.PlacerName() != placer.OrderedNoAllocPruningStonePlacerName || b.SolverName() != solver.SingleThreadedSolverName {
+		t.Errorf("buildSolver() has placer %s and solver %s, want %s and %s", b.PlacerName(), b.SolverName(), placer.OrderedNoAllocPruningStonePlacerName, solver.SingleThreadedSolverName)
+	}
+	if solution, err := s.Solve(g); err != nil || grid.CheckValidSolution(g, solution) != nil {
+		t.Errorf("Solve() = %v, %v, want a valid solution", solution, err)
+	}
+	if _, _, err := sf.buildSolver(context.Background(), g, func(b *solver.Builder) { b.Placer("random") }); err == nil {
+		t.Errorf("buildSolver() with an unknown placer succeeded, want error")
+	}
+}
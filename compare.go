@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// overrideStrategyFlags returns strategy flags with the values set in sf, overridden by the comma separated name=value pairs in
+// overrides. A name without a value sets a boolean flag. The size can't be overridden, as configurations are compared on the same grid.
+func overrideStrategyFlags(sf *strategyFlags, overrides string) (*strategyFlags, error) {
+	fs := flag.NewFlagSet("compare -b", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	b := addStrategyFlags(fs, sf.hasSolver)
+	var err error
+	sf.fs.Visit(func(f *flag.Flag) {
+		if err != nil || fs.Lookup(f.Name) == nil {
+			return
+		}
+		value := f.Value.String()
+		if f.Name == "start_from" {
+			// Func flags don't print their values
+			value = fmt.Sprint(sf.startFrom)
+		}
+		err = fs.Set(f.Name, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, override := range strings.Split(overrides, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(override), "=")
+		if !ok {
+			value = "true"
+		}
+		name = strings.TrimPrefix(name, "-")
+		if name == "size" {
+			return nil, errors.New("-b can't change the size, as both configurations search the same grid")
+		}
+		if fs.Lookup(name) == nil {
+			return nil, fmt.Errorf("-b: unknown strategy flag -%s", name)
+		}
+		if err := fs.Set(name, value); err != nil {
+			return nil, fmt.Errorf("-b: -%s: %v", name, err)
+		}
+	}
+	return b, nil
+}
+
+// compareResult is the outcome of one of the configurations that the compare subcommand runs.
+type compareResult struct {
+	// Name describes the configuration's strategies
+	Name     string
+	Solvable bool
+	// Solution is the solution found when not enumerating
+	Solution grid.Placements
+	// Classes are the canonical solutions of the equivalence classes found when enumerating
+	Classes  []grid.Placements
+	Nodes    int64
+	Duration time.Duration
+}
+
+// runComparison searches the grid with the builder's configuration, or enumerates every solution from its starting points with its
+// placer, checking every solution found.
+func runComparison(ctx context.Context, b *solver.Builder, g grid.Grid, enumerate bool) (r compareResult, err error) {
+	stats := &solver.Stats{}
+	b.Stats(stats)
+	r.Name = b.PlacerName() + "/" + b.SolverName()
+	start := time.Now()
+	defer func() { r.Duration, r.Nodes = time.Since(start), stats.Nodes.Load() }()
+	if enumerate {
+		r.Name = b.PlacerName() + " enumerating"
+		spc, err := b.BuildPlacer()
+		if err != nil {
+			return r, err
+		}
+		spp, err := b.BuildStartingPoints()
+		if err != nil {
+			return r, err
+		}
+		var solutions []grid.Placements
+		var invalid error
+		err = solver.Enumerate(ctx, g, spp, spc, stats, func(p grid.Placements) bool {
+			if invalid = grid.CheckValidSolution(g, p); invalid != nil {
+				invalid = fmt.Errorf("invalid solution %v: %w", p, invalid)
+				return false
+			}
+			solutions = append(solutions, p)
+			return true
+		})
+		if err := errors.Join(invalid, err); err != nil {
+			return r, err
+		}
+		for _, c := range solver.ClassifySolutions(g, solutions) {
+			r.Classes = append(r.Classes, c.Canonical)
+		}
+		r.Solvable = len(r.Classes) > 0
+		return r, nil
+	}
+
+	s, err := b.Build()
+	if err != nil {
+		return r, err
+	}
+	solution, err := s.SolveContext(ctx, g)
+	switch {
+	case err == nil:
+		if err := grid.CheckValidSolution(g, solution); err != nil {
+			return r, fmt.Errorf("invalid solution %v: %w", solution, err)
+		}
+		solution.Sort()
+		r.Solvable, r.Solution = true, solution
+	case !errors.Is(err, solver.ErrNoSolution):
+		return r, err
+	}
+	return r, nil
+}
+
+// diffClasses returns the canonical solutions found by only one of the configurations. Both must be sorted, as ClassifySolutions sorts
+// them.
+func diffClasses(a, b []grid.Placements) (onlyA, onlyB []grid.Placements) {
+	for len(a) > 0 || len(b) > 0 {
+		switch {
+		case len(b) == 0 || len(a) > 0 && a[0].Compare(b[0]) < 0:
+			onlyA, a = append(onlyA, a[0]), a[1:]
+		case len(a) == 0 || b[0].Compare(a[0]) < 0:
+			onlyB, b = append(onlyB, b[0]), b[1:]
+		default:
+			a, b = a[1:], b[1:]
+		}
+	}
+	return onlyA, onlyB
+}
+
+// writeComparison writes a table of the results of the configurations, the difference in their placements, and whether they agree. It
+// returns whether they agree: on whether a solution exists, and when enumerating on the equivalence classes of the solutions.
+func writeComparison(w io.Writer, g grid.Grid, a, b compareResult, enumerate bool) bool {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "\tconfiguration\tsolvable\tclasses\tnodes\ttime\t")
+	for i, r := range []compareResult{a, b} {
+		classes := "-"
+		if enumerate {
+			classes = fmt.Sprint(len(r.Classes))
+		}
+		fmt.Fprintf(tw, "%c\t%s\t%t\t%s\t%d\t%v\t\n", 'a'+i, r.Name, r.Solvable, classes, r.Nodes, r.Duration.Round(time.Microsecond))
+	}
+	tw.Flush()
+	diff := b.Nodes - a.Nodes
+	switch {
+	case a.Nodes > 0:
+		fmt.Fprintf(w, "b made %+d placements compared with a, %.3f times as many\n", diff, float64(b.Nodes)/float64(a.Nodes))
+	default:
+		fmt.Fprintf(w, "b made %+d placements compared with a\n", diff)
+	}
+
+	onlyA, onlyB := diffClasses(a.Classes, b.Classes)
+	for _, c := range onlyA {
+		fmt.Fprintf(w, "Only a found the solutions equivalent to %v\n", c)
+	}
+	for _, c := range onlyB {
+		fmt.Fprintf(w, "Only b found the solutions equivalent to %v\n", c)
+	}
+	if a.Solvable != b.Solvable || len(onlyA) > 0 || len(onlyB) > 0 {
+		fmt.Fprintf(w, "DISAGREE: the configurations reached different conclusions on %dx%d\n", g.Size, g.Size)
+		return false
+	}
+	conclusion := "no solution exists"
+	if a.Solvable {
+		conclusion = "a solution exists"
+	}
+	if enumerate {
+		conclusion += fmt.Sprintf(", with the same %d classes of solutions", len(a.Classes))
+	}
+	fmt.Fprintf(w, "AGREE: %s on %dx%d\n", conclusion, g.Size, g.Size)
+	return true
+}
+
+// compare implements the compare subcommand, which runs two configurations on the same grid and checks that they reach the same
+// conclusion. The first is chosen by the strategy flags, and the second by overriding some of them with -b, so a new pruning rule can be
+// checked against the search without it. With -enumerate, both enumerate every solution from their starting points, and must find the
+// same solutions up to rotation and reflection. Either way the difference in placements shows how much a rule prunes.
+func compare(args []string) int {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	sf := addStrategyFlags(fs, true)
+	overrides := fs.String("b", "", "comma separated name=value strategy flags for the second configuration, which otherwise has the flags of the first, e.g. placer=ordered_noalloc_pruning,bound. A name alone sets a boolean flag")
+	enumerate := fs.Bool("enumerate", false, "enumerate every solution with both configurations' placers and starting points, and check that they find the same solutions up to rotation and reflection, rather than just whether one exists")
+	parseFlags(fs, fs.Name(), args)
+	if *overrides == "" {
+		fatal("compare needs -b to choose the second configuration")
+	}
+	sfB, err := overrideStrategyFlags(sf, *overrides)
+	if err != nil {
+		fatal(err)
+	}
+	g, err := sf.grid()
+	if err != nil {
+		fatal(err)
+	}
+	usePhysicalCores()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var results [2]compareResult
+	for i, sf := range []*strategyFlags{sf, sfB} {
+		results[i], err = runComparison(ctx, sf.builder(g), g, *enumerate)
+		if ctx.Err() != nil {
+			fmt.Printf("Stopped while running configuration %c: %v\n", 'a'+i, err)
+			return exitStopped
+		}
+		if err != nil {
+			fatalf("Configuration %c: %v", 'a'+i, err)
+		}
+	}
+	if !writeComparison(os.Stdout, g, results[0], results[1], *enumerate) {
+		return 1
+	}
+	return 0
+}
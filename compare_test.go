@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOverrideStrategyFlags(t *testing.T) {
+	tests := []struct {
+		name, overrides string
+		wantPlacer      string
+		wantBound       bool
+		wantErr         bool
+	}{
+		{name: "placer", overrides: "placer=ordered_noalloc_pruning", wantPlacer: "ordered_noalloc_pruning", wantBound: false},
+		{name: "boolean without value", overrides: "placer=ordered_bitboard, bound", wantPlacer: "ordered_bitboard", wantBound: true},
+		{name: "size", overrides: "size=6", wantErr: true},
+		{name: "unknown flag", overrides: "plaser=ordered", wantErr: true},
+		{name: "invalid value", overrides: "placer=sideways", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+			sf := addStrategyFlags(fs, true)
+			if err := fs.Parse([]string{"-size", "8", "-solver", "async_splitting", "-start_from", "A0 B2"}); err != nil {
+				t.Fatal(err)
+			}
+			b, err := overrideStrategyFlags(sf, tt.overrides)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("overrideStrategyFlags(%q) error = %v, want error %t", tt.overrides, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if b.placer != tt.wantPlacer || *b.bound != tt.wantBound {
+				t.Errorf("overrideStrategyFlags(%q) has placer %s and bound %t, want %s and %t", tt.overrides, b.placer, *b.bound, tt.wantPlacer, tt.wantBound)
+			}
+			// The flags of the first configuration are kept
+			if *b.size != 8 || b.solver != "async_splitting" || !cmp.Equal(b.startFrom, sf.startFrom) {
+				t.Errorf("overrideStrategyFlags(%q) has size %d, solver %s and start_from %v, want those of the first configuration", tt.overrides, *b.size, b.solver, b.startFrom)
+			}
+		})
+	}
+}
+
+func TestWriteComparison(t *testing.T) {
+	g := grid.Grid{Size: 6}
+	class := func(s string) grid.Placements {
+		p, err := grid.ParsePlacements(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	a := compareResult{Name: "ordered_noalloc enumerating", Solvable: true, Classes: []grid.Placements{class("A0 A1"), class("A0 A2")}, Nodes: 200}
+	b := compareResult{Name: "ordered_noalloc_pruning enumerating", Solvable: true, Classes: []grid.Placements{class("A0 A2")}, Nodes: 50}
+	var out strings.Builder
+	if writeComparison(&out, g, a, b, true) {
+		t.Errorf("writeComparison() = true for configurations finding different classes, want false")
+	}
+	for _, want := range []string{"b made -150 placements compared with a, 0.250 times as many", "Only a found the solutions equivalent to [A0 A1]", "DISAGREE"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("writeComparison() output %q doesn't contain %q", out.String(), want)
+		}
+	}
+
+	b.Classes = a.Classes
+	out.Reset()
+	if !writeComparison(&out, g, a, b, true) || !strings.Contains(out.String(), "AGREE: a solution exists, with the same 2 classes of solutions on 6x6") {
+		t.Errorf("writeComparison() of configurations finding the same classes wrote %q, want agreement", out.String())
+	}
+}
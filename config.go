@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// parseFlags parses the arguments of a subcommand, then sets the flags that they leave unset from PEGBOARD_* environment variables,
+// then from the -config file if one is given. So flags on the command line override the environment, which overrides the file. section
+// is the name of the subcommand, whose table in the file applies after the top level keys, which every subcommand shares.
+func parseFlags(fs *flag.FlagSet, section string, args []string) {
+	configFile := fs.String("config", "", "file of flag values to use for the flags not set on the command line or environment, as flat TOML (name = value) or, if it ends in .yaml or .yml, YAML (name: value). Values in a [subcommand] table or subcommand: mapping only apply to that subcommand")
+	// The flag package exits with 2 for bad flags, which is the exit code of a stopped search
+	fs.Init(fs.Name(), flag.ContinueOnError)
+	if err := fs.Parse(args); errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	} else if err != nil {
+		os.Exit(exitInvalid)
+	}
+	if err := applyEnvironment(fs, os.LookupEnv); err != nil {
+		fatal(err)
+	}
+	if *configFile == "" {
+		return
+	}
+	values, err := readConfigFile(*configFile, section)
+	if err != nil {
+		fatal(err)
+	}
+	if err := applyConfig(fs, section, values); err != nil {
+		fatalf("%s: %v", *configFile, err)
+	}
+}
+
+// envVar returns the name of the environment variable that sets a flag, e.g. PEGBOARD_SPLIT_DEPTH for -split_depth.
+func envVar(flagName string) string {
+	return "PEGBOARD_" + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnvironment sets the flags that were not set on the command line from the environment variables that lookup finds for them.
+// Variables for flags that the flag set doesn't have are ignored, as every subcommand sees the same environment.
+func applyEnvironment(fs *flag.FlagSet, lookup func(string) (string, bool)) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || set[f.Name] {
+			return
+		}
+		value, ok := lookup(envVar(f.Name))
+		if !ok {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("%s: %v", envVar(f.Name), setErr)
+		}
+	})
+	return err
+}
+
+// configValue is the value of one flag in a config file, with the line it was on for error messages. shared values are the top level
+// keys, which every subcommand reads.
+type configValue struct {
+	name, value string
+	line        int
+	shared      bool
+}
+
+// readConfigFile reads the values of a config file that apply to the section: the top level keys, then the keys of the section's table.
+// The format is chosen by the file's extension.
+func readConfigFile(filename, section string) ([]configValue, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	yaml := false
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		yaml = true
+	}
+
+	var top, sectionValues []configValue
+	current := ""
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		raw := scanner.Text()
+		text := strings.TrimSpace(raw)
+		if text == "" || strings.HasPrefix(text, "#") || (yaml && text == "---") {
+			continue
+		}
+		var name, value string
+		if yaml {
+			indented := strings.TrimLeft(raw, " \t") != raw
+			var ok bool
+			name, value, ok = strings.Cut(text, ":")
+			if !ok {
+				return nil, fmt.Errorf("%s:%d: expected name: value", filename, line)
+			}
+			name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+			if !indented {
+				current = ""
+				if value == "" || strings.HasPrefix(value, "#") {
+					// A mapping of the flags of one subcommand
+					current = name
+					continue
+				}
+			} else if current == "" {
+				return nil, fmt.Errorf("%s:%d: indented value outside a subcommand's mapping", filename, line)
+			}
+		} else {
+			if strings.HasPrefix(text, "[") {
+				table, rest, ok := strings.Cut(text[1:], "]")
+				if !ok || (strings.TrimSpace(rest) != "" && !strings.HasPrefix(strings.TrimSpace(rest), "#")) {
+					return nil, fmt.Errorf("%s:%d: malformed table header %q", filename, line, text)
+				}
+				current = strings.Trim(strings.TrimSpace(table), `"`)
+				continue
+			}
+			var ok bool
+			name, value, ok = strings.Cut(text, "=")
+			if !ok {
+				return nil, fmt.Errorf("%s:%d: expected name = value", filename, line)
+			}
+			name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		}
+		value, err := parseConfigScalar(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filename, line, err)
+		}
+		v := configValue{name: strings.Trim(name, `"'`), value: value, line: line}
+		switch current {
+		case "":
+			v.shared = true
+			top = append(top, v)
+		case section:
+			sectionValues = append(sectionValues, v)
+		}
+	}
+	return append(top, sectionValues...), scanner.Err()
+}
+
+// parseConfigScalar returns the text of a quoted or bare value, without any comment following it. Numbers, booleans and durations are
+// left for the flag to parse.
+func parseConfigScalar(s string) (string, error) {
+	if s == "" {
+		return "", fmt.Errorf("missing value")
+	}
+	switch s[0] {
+	case '"':
+		quoted, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return "", fmt.Errorf("malformed string %s", s)
+		}
+		if rest := strings.TrimSpace(s[len(quoted):]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected %q after string", rest)
+		}
+		return strconv.Unquote(quoted)
+	case '\'':
+		// Literal strings have no escapes
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("malformed string %s", s)
+		}
+		if rest := strings.TrimSpace(s[end+2:]); rest != "" && !strings.HasPrefix(rest, "#") {
+			return "", fmt.Errorf("unexpected %q after string", rest)
+		}
+		return s[1 : end+1], nil
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s), nil
+}
+
+// applyConfig sets the flags that were not set on the command line or environment to their values. Shared values for flags that the flag
+// set doesn't have are skipped, as they are for other subcommands, but the values in the section's own table must be for its flags, so
+// that typos don't go unnoticed.
+func applyConfig(fs *flag.FlagSet, section string, values []configValue) error {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, v := range values {
+		if v.name == "config" {
+			return fmt.Errorf("line %d: config files can't include other config files", v.line)
+		}
+		if fs.Lookup(v.name) == nil {
+			if v.shared {
+				continue
+			}
+			return fmt.Errorf("line %d: %s has no flag -%s", v.line, section, v.name)
+		}
+		if set[v.name] {
+			continue
+		}
+		if err := fs.Set(v.name, v.value); err != nil {
+			return fmt.Errorf("line %d: -%s: %v", v.line, v.name, err)
+		}
+	}
+	return nil
+}
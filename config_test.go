@@ -0,0 +1,167 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfigFile(t *testing.T) {
+	tests := []struct {
+		name, file, contents string
+		args                 []string
+		wantSize             uint
+		wantPlacer           string
+		wantCount            int
+		wantErr              bool
+	}{
+		{
+			name: "toml",
+			file: "run.toml",
+			contents: `# Shared by every subcommand
+size = 9
+placer = "ordered_bitboard" # inline comment
+
+[bench]
+count = 3
+[solve]
+count = 5
+`,
+			wantSize: 9, wantPlacer: "ordered_bitboard", wantCount: 3,
+		},
+		{
+			name: "yaml",
+			file: "run.yaml",
+			contents: `size: 8
+bench:
+  count: 4
+  placer: 'ordered'
+`,
+			wantSize: 8, wantPlacer: "ordered", wantCount: 4,
+		},
+		{
+			name:     "flags override the file",
+			file:     "run.toml",
+			contents: "size = 9\n[bench]\ncount = 3\n",
+			args:     []string{"-size", "6"},
+			wantSize: 6, wantPlacer: "ordered_noalloc", wantCount: 3,
+		},
+		{name: "unknown flag", file: "run.toml", contents: "[bench]\nsise = 9\n", wantErr: true},
+		{name: "unknown yaml flag", file: "run.yaml", contents: "bench:\n  sise: 9\n", wantErr: true},
+		{
+			name:     "shared flag of other subcommands",
+			file:     "run.toml",
+			contents: "size = 9\nmax_size = 12\n[plan]\nprobes = 10\n",
+			wantSize: 9, wantPlacer: "ordered_noalloc", wantCount: 10,
+		},
+		{name: "invalid value", file: "run.toml", contents: "size = nine\n", wantErr: true},
+		{name: "missing value", file: "run.yml", contents: "size:\n  9\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(filename, []byte(tt.contents), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+			sf := addStrategyFlags(fs, true)
+			count := fs.Int("count", 10, "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			values, err := readConfigFile(filename, "bench")
+			if err == nil {
+				err = applyConfig(fs, "bench", values)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("config error = %v, want error %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if *sf.size != tt.wantSize || sf.placer != tt.wantPlacer || *count != tt.wantCount {
+				t.Errorf("got size %d, placer %s and count %d, want %d, %s and %d", *sf.size, sf.placer, *count, tt.wantSize, tt.wantPlacer, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestApplyEnvironment(t *testing.T) {
+	fs := flag.NewFlagSet("solve", flag.ContinueOnError)
+	sf := addStrategyFlags(fs, true)
+	if err := fs.Parse([]string{"-size", "6"}); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"PEGBOARD_SIZE": "9", "PEGBOARD_SPLIT_DEPTH": "4", "PEGBOARD_COUNT": "3"}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	if err := applyEnvironment(fs, lookup); err != nil {
+		t.Fatal(err)
+	}
+	if *sf.size != 6 || *sf.splitDepth != 4 {
+		t.Errorf("got size %d and split depth %d, want 6 from the command line and 4 from the environment", *sf.size, *sf.splitDepth)
+	}
+
+	env["PEGBOARD_WORKERS"] = "many"
+	if err := applyEnvironment(fs, lookup); err == nil {
+		t.Errorf("applyEnvironment() with PEGBOARD_WORKERS=many succeeded, want error")
+	}
+}
+
+func TestConfigFile_SharedBySubcommands(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "run.toml")
+	contents := `size = 6
+placer = "ordered"
+max_size = 12
+
+[plan]
+probes = 10
+`
+	if err := os.WriteFile(filename, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	solve := flag.NewFlagSet("solve", flag.ContinueOnError)
+	sf := addStrategyFlags(solve, true)
+	values, err := readConfigFile(filename, "solve")
+	if err == nil {
+		err = applyConfig(solve, "solve", values)
+	}
+	if err != nil {
+		t.Fatalf("solve: %v", err)
+	}
+	if *sf.size != 6 || sf.placer != "ordered" {
+		t.Errorf("solve got size %d and placer %s, want 6 and ordered", *sf.size, sf.placer)
+	}
+
+	// Flag sets like those of plan and verify, which have only some of the shared flags
+	plan := flag.NewFlagSet("plan", flag.ContinueOnError)
+	maxSize := plan.Uint("max_size", 14, "")
+	probes := plan.Int("probes", 1000, "")
+	values, err = readConfigFile(filename, "plan")
+	if err == nil {
+		err = applyConfig(plan, "plan", values)
+	}
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if *maxSize != 12 || *probes != 10 {
+		t.Errorf("plan got max size %d and probes %d, want 12 and 10", *maxSize, *probes)
+	}
+
+	verify := flag.NewFlagSet("verify", flag.ContinueOnError)
+	size := verify.Uint("size", 0, "")
+	values, err = readConfigFile(filename, "verify")
+	if err == nil {
+		err = applyConfig(verify, "verify", values)
+	}
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if *size != 6 {
+		t.Errorf("verify got size %d, want 6", *size)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// loadDeadPrefixCache reads the dead prefix cache in the named file, or returns an empty cache if the file doesn't exist or was made
+// for another grid or strategy.
+func loadDeadPrefixCache(filename string, g grid.Grid, strategy string) (*solver.DeadPrefixCache, error) {
+	f, err := os.Open(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return solver.NewDeadPrefixCache(g, strategy), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	c, stale, err := solver.ReadDeadPrefixCache(f, g, strategy)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	if stale {
+		log.Printf("Ignoring the dead prefix cache in %s, which was made for another grid size or strategy than %q", filename, strategy)
+	}
+	return c, nil
+}
+
+// saveDeadPrefixCache writes the cache to the named file, replacing it only once the new cache is completely written.
+func saveDeadPrefixCache(filename string, c *solver.DeadPrefixCache) error {
+	f, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := c.WriteTo(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
+
+// writeDeadPrefixCacheStats writes a table of how the dead prefix cache was used.
+func writeDeadPrefixCacheStats(w io.Writer, s solver.DeadPrefixCacheStats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "dead prefixes\tskipped\tsearched\tadded\t")
+	fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t\n", s.Entries, s.Hits, s.Misses, s.Added)
+	tw.Flush()
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// randomPartialPlacement returns sorted placements of fewer stones than the grid needs, with unique separations, chosen at random by
+// adding random stones that keep the separations unique. It may have fewer stones than it aimed for if no cell is left to add.
+func randomPartialPlacement(g grid.Grid, rng *rand.Rand) grid.Placements {
+	stones := rng.Intn(int(g.Size))
+	var p grid.Placements
+	separations := make(map[uint16]bool)
+	for len(p) < stones {
+		var candidates grid.Placements
+		it := g.Iter()
+		for cell, ok := it.Next(); ok; cell, ok = it.Next() {
+			fits := true
+			seen := make(map[uint16]bool, len(p))
+			for _, stone := range p {
+				s := grid.Separation(cell, stone)
+				if s == 0 || separations[s] || seen[s] {
+					fits = false
+					break
+				}
+				seen[s] = true
+			}
+			if fits {
+				candidates = append(candidates, cell)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		cell := candidates[rng.Intn(len(candidates))]
+		for _, stone := range p {
+			separations[grid.Separation(cell, stone)] = true
+		}
+		p = append(p, cell)
+	}
+	p.Sort()
+	return p
+}
+
+// placerChildren tries every stone the placer will place, and returns the states it reaches, as the sorted placements of each child and
+// the number of stones it has remaining. Placers may place stones in any order, so the states are sorted.
+func placerChildren(sp placer.StonePlacer) []string {
+	var children []string
+	for !sp.Done() {
+		child, err := sp.Place()
+		if err != nil {
+			continue
+		}
+		p := child.AppendPlacements(nil)
+		p.Sort()
+		children = append(children, fmt.Sprintf("%v remaining %d", p, child.Remaining()))
+	}
+	sort.Strings(children)
+	return children
+}
+
+// placerDivergence is a partial placement that two placers place different stones from, or reach different states from.
+type placerDivergence struct {
+	Start grid.Placements
+	// OnlyA and OnlyB are the states only one of the placers reached
+	OnlyA, OnlyB []string
+}
+
+// diffPlacers places every stone that the placers a and b will place from the start, and returns how they diverge, if they do.
+func diffPlacers(g grid.Grid, a, b placer.StonePlacerConstructor, start grid.Placements) (placerDivergence, bool) {
+	childrenA := placerChildren(a.New(g, slices.Clone(start)))
+	childrenB := placerChildren(b.New(g, slices.Clone(start)))
+	d := placerDivergence{Start: start}
+	for _, c := range childrenA {
+		if _, found := slices.BinarySearch(childrenB, c); !found {
+			d.OnlyA = append(d.OnlyA, c)
+		}
+	}
+	for _, c := range childrenB {
+		if _, found := slices.BinarySearch(childrenA, c); !found {
+			d.OnlyB = append(d.OnlyB, c)
+		}
+	}
+	return d, len(d.OnlyA) > 0 || len(d.OnlyB) > 0
+}
+
+// writeDivergence describes a divergence between the placers named a and b.
+func writeDivergence(w io.Writer, a, b string, d placerDivergence) {
+	fmt.Fprintf(w, "From %v:\n", d.Start)
+	for _, c := range d.OnlyA {
+		fmt.Fprintf(w, "  only %s reached %s\n", a, c)
+	}
+	for _, c := range d.OnlyB {
+		fmt.Fprintf(w, "  only %s reached %s\n", b, c)
+	}
+}
+
+// difftest implements the difftest subcommand, which checks a placer against a reference placer by giving both the same random partial
+// placements and comparing the stones they place and the states they reach. Placers are only comparable if they promise the same
+// things: ordered placers only place stones after their last stone, and pruning placers reject stones that can't be completed, so an
+// ordered pruning placer should be compared with another one, or differences in the stones it prunes checked by hand.
+func difftest(args []string) {
+	fs := flag.NewFlagSet("difftest", flag.ExitOnError)
+	size := fs.Uint("size", 9, "the side length of the grid")
+	nameA := fs.String("a", placer.OrderedStonePlacerName, "the reference placer")
+	nameB := fs.String("b", placer.OrderedNoAllocStonePlacerName, "the placer to check against the reference")
+	n := fs.Int("n", 10000, "number of random partial placements to compare the placers from")
+	seed := fs.Int64("seed", 0, "seed for the random partial placements, or 0 for a seed from the clock")
+	show := fs.Int("show", 10, "number of divergences to describe")
+	parseFlags(fs, fs.Name(), args)
+	if *size < 1 || *size > grid.MaxGridSize {
+		fatalf("The size must be between 1 and %d, got %d", grid.MaxGridSize, *size)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	g := grid.Grid{Size: uint8(*size)}
+	a, err := solver.NewBuilder().Grid(g).Placer(*nameA).BuildPlacer()
+	if err != nil {
+		fatal(err)
+	}
+	b, err := solver.NewBuilder().Grid(g).Placer(*nameB).BuildPlacer()
+	if err != nil {
+		fatal(err)
+	}
+
+	rng := solver.RandStreams{Seed: *seed}.Worker(0)
+	diverged := 0
+	for i := 0; i < *n; i++ {
+		d, ok := diffPlacers(g, a, b, randomPartialPlacement(g, rng))
+		if !ok {
+			continue
+		}
+		if diverged < *show {
+			writeDivergence(os.Stdout, *nameA, *nameB, d)
+		}
+		diverged++
+	}
+	fmt.Printf("The %s and %s placers diverged from %d of %d random partial placements of %+v (seed %d)\n", *nameA, *nameB, diverged, *n, g, *seed)
+	if diverged > 0 {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/sets"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+func TestRandomPartialPlacement(t *testing.T) {
+	g := grid.Grid{Size: 7}
+	rng := solver.RandStreams{Seed: 1}.Worker(0)
+	for i := 0; i < 100; i++ {
+		p := randomPartialPlacement(g, rng)
+		if len(p) >= int(g.Size) {
+			t.Fatalf("randomPartialPlacement() = %v, want fewer than %d stones", p, g.Size)
+		}
+		for _, v := range grid.Diagnose(g, p).Violations {
+			if v.Constraint != grid.StoneCountConstraint {
+				t.Fatalf("randomPartialPlacement() = %v, which violates %v", p, v)
+			}
+		}
+	}
+}
+
+func TestDiffPlacers(t *testing.T) {
+	g := grid.Grid{Size: 7}
+	ordered := placer.OrderedStonePlacerProvider{SeparationSetConstructor: sets.NewBitArraySeparationSet}
+	tests := []struct {
+		name         string
+		b            placer.StonePlacerConstructor
+		wantDiverged bool
+	}{
+		{"same order", placer.OrderedNoAllocStonePlacerProvider{}, false},
+		{"different order", placer.UnorderedStonePlacerProvider{SeparationSetConstructor: sets.NewBitArraySeparationSet, PointSetConstructor: sets.NewMapPointSet}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng := solver.RandStreams{Seed: 1}.Worker(0)
+			diverged := false
+			for i := 0; i < 100; i++ {
+				_, ok := diffPlacers(g, ordered, tt.b, randomPartialPlacement(g, rng))
+				diverged = diverged || ok
+			}
+			if diverged != tt.wantDiverged {
+				t.Errorf("diffPlacers() diverged = %t, want %t", diverged, tt.wantDiverged)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// writeWorkerStates writes a table of what each worker is doing.
+func writeWorkerStates(w io.Writer, states []solver.WorkerState) {
+	fmt.Fprintf(w, "Worker states at %v:\n", time.Now().Format(time.RFC3339))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "worker\tstate\tstarting point\tdepth\tnodes\ttask time\tplacements\t")
+	for _, s := range states {
+		state := "busy"
+		switch {
+		case s.Idle:
+			state = "idle"
+		case s.Stale:
+			state = "stale"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%v\t%d\t%d\t%v\t%v\t\n", s.Worker, state, s.StartingPoint, len(s.Placements), s.Nodes, s.TaskTime.Round(time.Millisecond), s.Placements)
+	}
+	tw.Flush()
+}
+
+// dumpWorkerStates appends the worker states to the named file, or writes them to stderr if filename is empty.
+func dumpWorkerStates(stats *solver.Stats, filename string) error {
+	if filename == "" {
+		writeWorkerStates(os.Stderr, stats.WorkerStates(time.Second))
+		return nil
+	}
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	writeWorkerStates(f, stats.WorkerStates(time.Second))
+	return f.Close()
+}
+
+// dumpWorkerStatesOnSignal dumps the worker states with dumpWorkerStates each time the process receives dumpSignal, until the returned
+// function is called. The search carries on while the states are written.
+func dumpWorkerStatesOnSignal(stats *solver.Stats, filename string) (stop func()) {
+	if dumpSignal == nil {
+		return func() {}
+	}
+	stats.EnableWorkerStates()
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, dumpSignal)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-signals:
+			case <-done:
+				return
+			}
+			if err := dumpWorkerStates(stats, filename); err != nil {
+				log.Print(err)
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
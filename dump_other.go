@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// dumpSignal is nil where there is no SIGUSR1, so worker states can't be dumped.
+var dumpSignal os.Signal
@@ -0,0 +1,11 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// dumpSignal is the signal that makes the solver dump the state of its workers.
+var dumpSignal os.Signal = syscall.SIGUSR1
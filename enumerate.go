@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"github.com/hashicorp/packer/command/enumflag"
+)
+
+// enumerate implements the enumerate subcommand, which prints every solution for a grid as it is found, rather than stopping at the
+// first.
+func enumerate(args []string) {
+	fs := flag.NewFlagSet("enumerate", flag.ExitOnError)
+	sf := addStrategyFlags(fs, false)
+	distinct := fs.Bool("distinct", false, "only print the first solution found of each equivalence class under rotation and reflection, as its canonical form")
+	separations := fs.Bool("separations", false, "also print a histogram of how many solutions use each of the grid's separations")
+	notation := AlgebraicNotation
+	fs.Var(enumflag.New(&notation, AlgebraicNotation, TupleNotation, MatrixNotation, CompactNotation), "notation", "notation to print the solutions in: a list of stones such as [A0 B3], (row,col) tuples, a 0/1 matrix, or the columns of each row's stones such as 0/3/-")
+	parseFlags(fs, fs.Name(), args)
+	// Enumerating needs only the placer, as it searches every starting point itself
+	g, _, spc, ctx, stop := sf.setupPlacer()
+	defer stop()
+
+	stats := &solver.Stats{}
+	n := 0
+	h := newSeparationHistogram(g)
+	yield := func(p grid.Placements) bool {
+		n++
+		fmt.Printf("Solution %d:%s\n", n, solutionSuffix(notation, g, p))
+		if *separations {
+			h.add(p)
+		}
+		return true
+	}
+	if *distinct {
+		yield = solver.UpToSymmetry(g, yield)
+	}
+	startTime := time.Now()
+	// Every other starting point breaks the symmetry, so only finds some of each solution's rotations and reflections
+	err := solver.Enumerate(ctx, g, solver.EmptyStartingPoint, spc, stats, yield)
+	kind := "solutions"
+	if *distinct {
+		kind = "distinct solutions up to rotation and reflection"
+	}
+	if err != nil {
+		fmt.Printf("Enumeration interrupted for %+v after %v and %d placements with %d %s found so far\n", g, time.Since(startTime), stats.Nodes.Load(), n, kind)
+	} else {
+		fmt.Printf("Found %d %s for %+v in %v after %d placements\n", n, kind, g, time.Since(startTime), stats.Nodes.Load())
+	}
+	if *separations {
+		h.write(os.Stdout)
+	}
+}
+
+// classes implements the classes subcommand, which enumerates every solution for a grid, and reports their equivalence classes under
+// rotation and reflection.
+func classes(args []string) {
+	fs := flag.NewFlagSet("classes", flag.ExitOnError)
+	sf := addStrategyFlags(fs, false)
+	separations := fs.Bool("separations", false, "also print a histogram of how many solutions use each of the grid's separations")
+	parseFlags(fs, fs.Name(), args)
+	g, _, spc, ctx, stop := sf.setupPlacer()
+	defer stop()
+
+	var solutions []grid.Placements
+	startTime := time.Now()
+	err := solver.Enumerate(ctx, g, solver.EmptyStartingPoint, spc, nil, func(p grid.Placements) bool {
+		solutions = append(solutions, p)
+		return true
+	})
+	duration := time.Since(startTime)
+	if err != nil {
+		fmt.Printf("Enumeration interrupted for %+v after %v with %d solutions found so far\n", g, duration, len(solutions))
+	}
+	classes := solver.ClassifySolutions(g, solutions)
+	fmt.Printf("Found %d solutions for %+v in %v, forming %d equivalence classes under rotation and reflection\n", len(solutions), g, duration, len(classes))
+	for _, c := range classes {
+		symmetric := ""
+		if c.OrbitSize < len(grid.Symmetries) {
+			symmetric = " (self-symmetric)"
+		}
+		fmt.Printf("%v\torbit size %d%s\n", c.Canonical, c.OrbitSize, symmetric)
+	}
+	if *separations {
+		h := newSeparationHistogram(g)
+		for _, p := range solutions {
+			h.add(p)
+		}
+		h.write(os.Stdout)
+	}
+}
+
+// solutions implements the solutions subcommand, which prints up to -count distinct solutions for a grid as they are found, searching
+// below each starting point in parallel.
+func solutions(args []string) {
+	fs := flag.NewFlagSet("solutions", flag.ExitOnError)
+	sf := addStrategyFlags(fs, true)
+	count := fs.Int("count", 10, "maximum number of distinct solutions to print")
+	separations := fs.Bool("separations", false, "also print which of the grid's separations each solution uses and which it leaves unused, and a histogram of how many solutions use each separation")
+	parseFlags(fs, fs.Name(), args)
+	if *count <= 0 {
+		fatal("-count must be positive")
+	}
+	g, builder, spc, ctx, stop := sf.setupPlacer()
+	defer stop()
+	spp, err := builder.BuildStartingPoints()
+	if err != nil {
+		fatal(err)
+	}
+
+	s := solver.AsyncSolver{StartingPointsProvider: spp, StonePlacerConstructor: spc, Stats: &solver.Stats{}}
+	startTime := time.Now()
+	n := 0
+	h := newSeparationHistogram(g)
+	for solution := range s.Solutions(ctx, g, *count) {
+		n++
+		fmt.Printf("Solution %d found for %+v after %v: %v\n", n, g, time.Since(startTime), solution)
+		if *separations {
+			writeSeparationUsage(os.Stdout, g, solution)
+			h.add(solution)
+		}
+	}
+	if n < *count && ctx.Err() == nil {
+		fmt.Printf("Search ended with %d distinct solutions found for %+v in %v\n", n, g, time.Since(startTime))
+	}
+	if *separations {
+		h.write(os.Stdout)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"runtime"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// estimate implements the estimate subcommand, which estimates the size of the search tree below each starting point from random probes
+// instead of searching it. Its output can be used as the -weights file of a search.
+func estimate(args []string) {
+	fs := flag.NewFlagSet("estimate", flag.ExitOnError)
+	sf := addStrategyFlags(fs, true)
+	probes := fs.Int("probes", progressProbes, "number of random probes below each starting point")
+	probeSeed := fs.Int64("probe_seed", 0, "seed for the random probes, or 0 for a seed from the clock. The estimates are reproducible given the seed and GOMAXPROCS")
+	parseFlags(fs, fs.Name(), args)
+	if *probes <= 0 {
+		fatal("-probes must be positive")
+	}
+	g, builder, spc, _, stop := sf.setupPlacer()
+	defer stop()
+	spp, err := builder.BuildStartingPoints()
+	if err != nil {
+		fatal(err)
+	}
+
+	seed := *probeSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	workers := runtime.GOMAXPROCS(0)
+	estimates := solver.EstimateTreeSizeParallel(g, spp, spc, *probes, solver.RandStreams{Seed: seed}, workers)
+	total := 0.0
+	// Comment lines are prefixed with # so that the output can be used as a -weights file
+	fmt.Printf("# Estimated search tree sizes for %+v from %d probes per starting point (seed %d, workers %d):\n", g, *probes, seed, workers)
+	for _, e := range estimates {
+		fmt.Printf("%v\t%.4g nodes\n", e.StartingPoint, e.Nodes)
+		total += e.Nodes
+	}
+	fmt.Printf("# Total\t%.4g nodes\n", total)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// writeEvents subscribes to the bus and writes its events to the named file as JSON lines until the returned function is called,
+// which waits for the events published so far to be written.
+func writeEvents(bus *solver.EventBus, filename string) (stop func() error, err error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	events, unsubscribe := bus.Subscribe(1024)
+	written := make(chan error, 1)
+	go func() {
+		enc := json.NewEncoder(f)
+		var err error
+		for e := range events {
+			if err == nil {
+				err = enc.Encode(e)
+			}
+		}
+		written <- err
+	}()
+	return func() error {
+		if dropped := unsubscribe(); dropped > 0 {
+			log.Printf("%d events were dropped because %s was written too slowly", dropped, filename)
+		}
+		err := <-written
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}, nil
+}
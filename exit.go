@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// Exit codes of solve and the other subcommands that search, so that scripts can branch on the outcome of a search.
+const (
+	// exitSolved is the exit code when a solution was found.
+	exitSolved = 0
+	// exitNoSolution is the exit code when the search was exhaustive, proving that no solution exists.
+	exitNoSolution = 1
+	// exitStopped is the exit code when the search was stopped before it finished, by an interrupt, a timeout or -max_nodes.
+	exitStopped = 2
+	// exitInvalid is the exit code for invalid input, such as a bad flag or a file that can't be read, and for other errors.
+	exitInvalid = 3
+)
+
+// exitCode returns the exit code for the outcome of a search that returned err.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitSolved
+	case errors.Is(err, solver.ErrNoSolution):
+		return exitNoSolution
+	case errors.Is(err, solver.ErrCanceled), errors.Is(err, solver.ErrTimeout):
+		return exitStopped
+	default:
+		return exitInvalid
+	}
+}
+
+// fatal is like log.Fatal, but exits with exitInvalid rather than 1, which is the exit code of a search that found no solution.
+func fatal(v ...any) {
+	log.Output(2, fmt.Sprint(v...))
+	os.Exit(exitInvalid)
+}
+
+// fatalf is like log.Fatalf, but exits with exitInvalid rather than 1, which is the exit code of a search that found no solution.
+func fatalf(format string, v ...any) {
+	log.Output(2, fmt.Sprintf(format, v...))
+	os.Exit(exitInvalid)
+}
+
+// silenceStdout discards everything written to stdout from now on, for -quiet. Errors are still logged to stderr.
+func silenceStdout() {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		fatal(err)
+	}
+	os.Stdout = devNull
+}
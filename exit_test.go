@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{nil, exitSolved},
+		{solver.ErrNoSolution, exitNoSolution},
+		{fmt.Errorf("%w: %w", solver.ErrCanceled, context.Canceled), exitStopped},
+		{fmt.Errorf("%w: %w: %w", solver.ErrCanceled, solver.ErrNodeBudget, context.Canceled), exitStopped},
+		{fmt.Errorf("%w: %w", solver.ErrTimeout, context.DeadlineExceeded), exitStopped},
+		{errors.New("invalid prefix"), exitInvalid},
+	}
+	for _, tt := range tests {
+		if got := exitCode(tt.err); got != tt.want {
+			t.Errorf("exitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
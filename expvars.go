@@ -0,0 +1,50 @@
+package main
+
+import (
+	"expvar"
+	"log"
+	"net/http"
+
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// statsVars are the counters of a search published by -expvar_addr.
+type statsVars struct {
+	Nodes int64 `json:"nodes"`
+	// Rejected counts the stones that placers tried and rejected for breaking a constraint
+	Rejected    int64 `json:"rejected"`
+	Splits      int64 `json:"splits"`
+	IdleWorkers int64 `json:"idle_workers"`
+	TasksDone   int64 `json:"tasks_done"`
+	TasksTotal  int64 `json:"tasks_total"`
+	// TasksQueued are the tasks the search was split into up front that are not done yet
+	TasksQueued int64 `json:"tasks_queued"`
+	Deepest     int   `json:"deepest"`
+}
+
+// newStatsVars returns a snapshot of the counters of the statistics.
+func newStatsVars(st *solver.Stats) statsVars {
+	p := st.Progress()
+	v := statsVars{
+		Nodes: p.Nodes, Splits: st.Splits.Load(), IdleWorkers: st.IdleWorkers.Load(),
+		TasksDone: p.TasksDone, TasksTotal: p.TasksTotal, TasksQueued: p.TasksTotal - p.TasksDone, Deepest: len(p.Deepest),
+	}
+	for _, d := range st.Depths() {
+		v.Rejected += d.Tried - d.Placed
+	}
+	return v
+}
+
+// serveExpvars publishes the counters of the search as the expvar pegboard, and serves every expvar, including the runtime's memory
+// statistics, at /debug/vars on the address, so a running process can be polled with standard tools such as expvarmon.
+func serveExpvars(addr string, st *solver.Stats) {
+	expvar.Publish("pegboard", expvar.Func(func() any { return newStatsVars(st) }))
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	go func() {
+		log.Printf("Serving expvars on http://%s/debug/vars", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("Could not serve expvars: %v", err)
+		}
+	}()
+}
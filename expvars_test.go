@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+func TestNewStatsVars(t *testing.T) {
+	g := grid.Grid{Size: 6}
+	stats := &solver.Stats{}
+	if err := solver.Enumerate(context.Background(), g, solver.SingleOctantStartingPoints, placer.OrderedNoAllocStonePlacerProvider{}, stats, func(grid.Placements) bool { return true }); err != nil {
+		t.Fatal(err)
+	}
+	v := newStatsVars(stats)
+	var tried int64
+	for _, d := range stats.Depths() {
+		tried += d.Tried
+	}
+	if v.Nodes != stats.Nodes.Load() || v.Rejected != tried-v.Nodes {
+		t.Errorf("newStatsVars() = %+v, want Nodes %d and the other %d stones tried rejected", v, stats.Nodes.Load(), tried-stats.Nodes.Load())
+	}
+	if v.TasksTotal == 0 || v.TasksQueued != 0 || v.Deepest != int(g.Size) {
+		t.Errorf("newStatsVars() = %+v, want every task done and the deepest placement a solution of %d stones", v, g.Size)
+	}
+}
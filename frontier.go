@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// frontier implements the frontier subcommand, which writes the canonical placements with -depth stones.
+func frontier(args []string) {
+	fs := flag.NewFlagSet("frontier", flag.ExitOnError)
+	sf := addStrategyFlags(fs, false)
+	depth := fs.Int("depth", 3, "number of stones in each placement on the frontier")
+	outFile := fs.String("out", "", "file to write the frontier to, instead of stdout")
+	parseFlags(fs, fs.Name(), args)
+	g, _, spc, ctx, stop := sf.setupPlacer()
+	defer stop()
+	if err := writeFrontier(ctx, *outFile, g, spc, *depth); err != nil {
+		fatal(err)
+	}
+}
+
+// writeFrontier writes every canonical placement with depth stones to the named file, or stdout if filename
+// is empty, one per line followed by a tab and its number of children, then the size of the frontier at each depth as comments.
+// The placements can be read back with readPlacements, e.g. to distribute them as starting points.
+func writeFrontier(ctx context.Context, filename string, g grid.Grid, spc placer.StonePlacerConstructor, depth int) error {
+	out := os.Stdout
+	if filename != "" {
+		f, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+	fmt.Fprintf(w, "# Canonical placements of %d stones on %+v, with their number of children\n", depth, g)
+	sizes, err := solver.Frontier(ctx, g, spc, depth, func(e solver.FrontierEntry) bool {
+		fmt.Fprintf(w, "%v\t%d\n", e.Prefix, e.Children)
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	for k, n := range sizes {
+		fmt.Fprintf(w, "# Depth %d\t%d canonical placements\n", k, n)
+	}
+	return nil
+}
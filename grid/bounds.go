@@ -0,0 +1,81 @@
+package grid
+
+import "slices"
+
+// AllSeparations returns every separation between two distinct points of the grid, in increasing order.
+func AllSeparations(g Grid) []uint32 {
+	seen := make(map[uint32]bool)
+	for dr := uint32(0); dr < uint32(g.Size); dr++ {
+		for dc := uint32(0); dc <= dr; dc++ {
+			if dr > 0 {
+				seen[dr*dr+dc*dc] = true
+			}
+		}
+	}
+	separations := make([]uint32, 0, len(seen))
+	for s := range seen {
+		separations = append(separations, s)
+	}
+	slices.Sort(separations)
+	return separations
+}
+
+// Decompose returns the row and column offsets, with dr >= dc, which separate points of the grid by the given separation.
+func Decompose(g Grid, separation uint32) [][2]uint8 {
+	var offsets [][2]uint8
+	for dr := uint32(0); dr < uint32(g.Size); dr++ {
+		for dc := uint32(0); dc <= dr; dc++ {
+			if dr*dr+dc*dc == separation {
+				offsets = append(offsets, [2]uint8{uint8(dr), uint8(dc)})
+			}
+		}
+	}
+	return offsets
+}
+
+// CountingBounds are the limits that counting separations puts on solutions for a grid. Every pair of stones in a solution needs its own
+// separation, so a solution can only exist if the grid has at least as many separations as a solution has pairs of stones.
+type CountingBounds struct {
+	Size uint8 `json:"size"`
+	// Pairs is the number of pairs of stones in a solution, Size*(Size-1)/2
+	Pairs int `json:"pairs"`
+	// Separations is the number of distinct separations between points of the grid
+	Separations int `json:"separations"`
+	// Impossible is the number of squared distances up to the largest separation that no two points of the grid are separated by
+	Impossible int `json:"impossible"`
+	// SingleOffset is the number of separations that only one row and column offset (up to swapping them) produces
+	SingleOffset int `json:"single_offset"`
+	// Slack is Separations - Pairs, the number of separations a solution doesn't use. Solutions can't exist if it is negative, and must use
+	// every separation if it is 0.
+	Slack int `json:"slack"`
+	// MaxStones is the largest number of stones whose pairs could all have different separations on the grid
+	MaxStones int `json:"max_stones"`
+}
+
+// NewCountingBounds computes the counting bounds for the grid.
+func NewCountingBounds(g Grid) CountingBounds {
+	separations := AllSeparations(g)
+	b := CountingBounds{
+		Size:        g.Size,
+		Pairs:       int(g.Size) * (int(g.Size) - 1) / 2,
+		Separations: len(separations),
+	}
+	if len(separations) > 0 {
+		b.Impossible = int(separations[len(separations)-1]) - len(separations)
+	}
+	for _, s := range separations {
+		if len(Decompose(g, s)) == 1 {
+			b.SingleOffset++
+		}
+	}
+	b.Slack = b.Separations - b.Pairs
+	for (b.MaxStones+1)*b.MaxStones/2 <= b.Separations {
+		b.MaxStones++
+	}
+	return b
+}
+
+// Feasible returns whether the counting bounds allow a solution.
+func (b CountingBounds) Feasible() bool {
+	return b.Slack >= 0
+}
@@ -0,0 +1,51 @@
+package grid
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestAllSeparations(t *testing.T) {
+	if got, want := AllSeparations(Grid{Size: 3}), []uint32{1, 2, 4, 5, 8}; !slices.Equal(got, want) {
+		t.Errorf("AllSeparations(3x3) = %v, want %v", got, want)
+	}
+	if got := AllSeparations(Grid{Size: 1}); len(got) != 0 {
+		t.Errorf("AllSeparations(1x1) = %v, want none", got)
+	}
+}
+
+func TestDecompose(t *testing.T) {
+	if got, want := Decompose(Grid{Size: 6}, 25), [][2]uint8{{4, 3}, {5, 0}}; !slices.Equal(got, want) {
+		t.Errorf("Decompose(6x6, 25) = %v, want %v", got, want)
+	}
+	if got := Decompose(Grid{Size: 5}, 25); !slices.Equal(got, [][2]uint8{{4, 3}}) {
+		t.Errorf("Decompose(5x5, 25) = %v, want [[4 3]]", got)
+	}
+	if got := Decompose(Grid{Size: 6}, 3); len(got) != 0 {
+		t.Errorf("Decompose(6x6, 3) = %v, want none", got)
+	}
+}
+
+func TestNewCountingBounds(t *testing.T) {
+	tests := []struct {
+		g            Grid
+		want         CountingBounds
+		wantFeasible bool
+	}{
+		{g: Grid{Size: 3}, want: CountingBounds{Size: 3, Pairs: 3, Separations: 5, Impossible: 3, SingleOffset: 5, Slack: 2, MaxStones: 3}, wantFeasible: true},
+		{g: Grid{Size: 15}, want: CountingBounds{Size: 15, Pairs: 105, Separations: 105, Impossible: 287, SingleOffset: 91, Slack: 0, MaxStones: 15}, wantFeasible: true},
+		{g: Grid{Size: 16}, want: CountingBounds{Size: 16, Pairs: 120, Separations: 119, Impossible: 331, SingleOffset: 103, Slack: -1, MaxStones: 15}, wantFeasible: false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.g.Size), func(t *testing.T) {
+			got := NewCountingBounds(tt.g)
+			if got != tt.want {
+				t.Errorf("NewCountingBounds(%v) = %+v, want %+v", tt.g, got, tt.want)
+			}
+			if got.Feasible() != tt.wantFeasible {
+				t.Errorf("NewCountingBounds(%v).Feasible() = %t, want %t", tt.g, got.Feasible(), tt.wantFeasible)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package grid
+
+// MaxDiagnosedStones is the most stones that Diagnose looks for stones to remove from. Finding the fewest is exponential in the number
+// of stones in conflict, so larger placements only have their violations listed.
+const MaxDiagnosedStones = 2 * MaxGridSize
+
+// maxExactRemovals is the largest set of stones Diagnose finds the smallest of exhaustively. Larger sets are found greedily.
+const maxExactRemovals = 6
+
+// Alternative lists the cells that an offending stone could be moved to, keeping the other stones that don't need to be removed.
+type Alternative struct {
+	Stone Point      `json:"stone"`
+	Cells Placements `json:"cells"`
+}
+
+// Diagnosis describes everything that is wrong with proposed placements, and how they could be fixed.
+type Diagnosis struct {
+	// Violations are every violated constraint, in the order CheckValidSolution checks them, so the first is the error it returns.
+	// Each duplicated separation is reported once for every pair of stones after the first that has it.
+	Violations []*ValidationError
+	// Remove is a smallest set of stones whose removal leaves stones that are all in bounds, distinct and have unique separations.
+	// More stones may still be needed for a solution.
+	Remove Placements
+	// Alternatives are the cells each stone of Remove could be moved to instead, keeping the stones that aren't removed.
+	Alternatives []Alternative
+}
+
+// Valid returns whether the placements are a solution.
+func (d Diagnosis) Valid() bool {
+	return len(d.Violations) == 0
+}
+
+// Diagnose checks the placements against every constraint, rather than stopping at the first violation like CheckValidSolution, and
+// finds which stones to remove or move to fix them. Removals and alternatives are only found for up to MaxDiagnosedStones stones.
+func Diagnose(g Grid, p Placements) Diagnosis {
+	var d Diagnosis
+	if len(p) != int(g.Size) {
+		d.Violations = append(d.Violations, &ValidationError{Constraint: StoneCountConstraint, Placed: len(p), Want: int(g.Size)})
+	}
+
+	// conflicts are sets of stones, by index, at least one of which must be removed
+	var conflicts [][]int
+	resolve := len(p) <= MaxDiagnosedStones
+	pairs := make(map[uint16][][2]int)
+	for i, p1 := range p {
+		if !IsInBounds(g, p1) {
+			d.Violations = append(d.Violations, &ValidationError{Constraint: InBoundsConstraint, Point: p1})
+			if resolve {
+				conflicts = append(conflicts, []int{i})
+			}
+		}
+		for j := i + 1; j < len(p); j++ {
+			p2 := p[j]
+			s := Separation(p1, p2)
+			if s == 0 {
+				d.Violations = append(d.Violations, &ValidationError{Constraint: DistinctPointsConstraint, Point: p1})
+				if resolve {
+					conflicts = append(conflicts, []int{i, j})
+				}
+				continue
+			}
+			reported := false
+			for _, other := range pairs[s] {
+				o1, o2 := p[other[0]], p[other[1]]
+				if (o1 == p1 && o2 == p2) || (o1 == p2 && o2 == p1) {
+					// The same pair of points again, because of a stone placed twice, which is already reported
+					continue
+				}
+				if !reported {
+					d.Violations = append(d.Violations, &ValidationError{Constraint: UniqueSeparationsConstraint, Separation: s, Pairs: [2]Placements{{o1, o2}, {p1, p2}}})
+					reported = true
+				}
+				if resolve {
+					conflicts = append(conflicts, []int{other[0], other[1], i, j})
+				}
+			}
+			pairs[s] = append(pairs[s], [2]int{i, j})
+		}
+	}
+	if len(conflicts) == 0 {
+		return d
+	}
+
+	removed := fewestRemovals(len(p), conflicts)
+	var kept Placements
+	for i, stone := range p {
+		if removed[i] {
+			d.Remove = append(d.Remove, stone)
+		} else {
+			kept = append(kept, stone)
+		}
+	}
+	cells := alternativeCells(g, kept)
+	for _, stone := range d.Remove {
+		d.Alternatives = append(d.Alternatives, Alternative{Stone: stone, Cells: cells})
+	}
+	return d
+}
+
+// fewestRemovals returns which of n stones to remove so that every conflict has a stone removed, removing as few as possible if that is
+// at most maxExactRemovals, and otherwise repeatedly removing the stone in the most remaining conflicts.
+func fewestRemovals(n int, conflicts [][]int) []bool {
+	removed := make([]bool, n)
+	unresolved := func() []int {
+		for _, c := range conflicts {
+			resolved := false
+			for _, i := range c {
+				resolved = resolved || removed[i]
+			}
+			if !resolved {
+				return c
+			}
+		}
+		return nil
+	}
+	// search tries removing each stone of the first unresolved conflict in turn, with up to budget removals
+	var search func(budget int) bool
+	search = func(budget int) bool {
+		c := unresolved()
+		if c == nil {
+			return true
+		}
+		if budget == 0 {
+			return false
+		}
+		for _, i := range c {
+			if removed[i] {
+				continue
+			}
+			removed[i] = true
+			if search(budget - 1) {
+				return true
+			}
+			removed[i] = false
+		}
+		return false
+	}
+	for budget := 1; budget <= maxExactRemovals; budget++ {
+		if search(budget) {
+			return removed
+		}
+	}
+
+	for unresolved() != nil {
+		counts := make([]int, n)
+		for _, c := range conflicts {
+			resolved := false
+			for _, i := range c {
+				resolved = resolved || removed[i]
+			}
+			if !resolved {
+				for _, i := range c {
+					counts[i]++
+				}
+			}
+		}
+		best := 0
+		for i := range counts {
+			if counts[i] > counts[best] {
+				best = i
+			}
+		}
+		removed[best] = true
+	}
+	return removed
+}
+
+// alternativeCells returns the empty cells of the grid that a stone could be added to without breaking the constraints, given stones
+// that are in bounds, distinct and have unique separations.
+func alternativeCells(g Grid, stones Placements) Placements {
+	separations := make(map[uint16]bool)
+	for _, s := range stones.Separations() {
+		separations[s] = true
+	}
+	var cells Placements
+	it := g.Iter()
+	for cell, ok := it.Next(); ok; cell, ok = it.Next() {
+		fits := true
+		seen := make(map[uint16]bool, len(stones))
+		for _, stone := range stones {
+			s := Separation(cell, stone)
+			if s == 0 || separations[s] || seen[s] {
+				fits = false
+				break
+			}
+			seen[s] = true
+		}
+		if fits {
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
@@ -0,0 +1,68 @@
+package grid
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiagnose(t *testing.T) {
+	tests := []struct {
+		name       string
+		placements string
+		want       Diagnosis
+	}{
+		{"valid",
+			"A0 B1 B2",
+			Diagnosis{},
+		},
+		{"duplicate separation",
+			"A0 B1 A2",
+			Diagnosis{
+				Violations:   []*ValidationError{{Constraint: UniqueSeparationsConstraint, Separation: 2, Pairs: [2]Placements{{Point{0, 0}, Point{1, 1}}, {Point{1, 1}, Point{0, 2}}}}},
+				Remove:       Placements{{0, 0}},
+				Alternatives: []Alternative{{Stone: Point{0, 0}, Cells: Placements{{1, 0}, {2, 1}}}},
+			},
+		},
+		{"every violation",
+			"A0 A0 C5 B1",
+			Diagnosis{
+				Violations: []*ValidationError{
+					{Constraint: StoneCountConstraint, Placed: 4, Want: 3},
+					{Constraint: DistinctPointsConstraint, Point: Point{0, 0}},
+					{Constraint: InBoundsConstraint, Point: Point{2, 5}},
+				},
+				Remove:       Placements{{0, 0}, {2, 5}},
+				Alternatives: []Alternative{{Stone: Point{0, 0}, Cells: Placements{{1, 2}, {2, 1}}}, {Stone: Point{2, 5}, Cells: Placements{{1, 2}, {2, 1}}}},
+			},
+		},
+		{"fewest removals",
+			"A0 A1 A2 B0",
+			Diagnosis{
+				Violations: []*ValidationError{
+					{Constraint: StoneCountConstraint, Placed: 4, Want: 3},
+					{Constraint: UniqueSeparationsConstraint, Separation: 1, Pairs: [2]Placements{{Point{0, 0}, Point{0, 1}}, {Point{0, 0}, Point{1, 0}}}},
+					{Constraint: UniqueSeparationsConstraint, Separation: 1, Pairs: [2]Placements{{Point{0, 0}, Point{0, 1}}, {Point{0, 1}, Point{0, 2}}}},
+				},
+				// A0 is in both pairs of stones that are 1 apart, and the rest have no room for another stone
+				Remove:       Placements{{0, 0}},
+				Alternatives: []Alternative{{Stone: Point{0, 0}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePlacements(tt.placements)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := Diagnose(Grid{3}, p)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Diagnose(%v) mismatch (-want +got):\n%s", p, diff)
+			}
+			if got.Valid() != (CheckValidSolution(Grid{3}, p) == nil) {
+				t.Errorf("Diagnose(%v).Valid() = %t, but CheckValidSolution() = %v", p, got.Valid(), CheckValidSolution(Grid{3}, p))
+			}
+		})
+	}
+}
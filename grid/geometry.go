@@ -0,0 +1,76 @@
+package grid
+
+// Center returns the points at the center of the grid: a single point if the size is odd, or the four points around the center if it is even.
+func (g Grid) Center() Placements {
+	if g.Size == 0 {
+		return Placements{}
+	}
+	mid := g.Size / 2
+	if g.Size%2 == 1 {
+		return Placements{{Row: mid, Col: mid}}
+	}
+	return Placements{{Row: mid - 1, Col: mid - 1}, {Row: mid - 1, Col: mid}, {Row: mid, Col: mid - 1}, {Row: mid, Col: mid}}
+}
+
+// Corners returns the four corners of the grid, clockwise from the top left.
+func (g Grid) Corners() [4]Point {
+	last := g.Size - 1
+	return [4]Point{{Row: 0, Col: 0}, {Row: 0, Col: last}, {Row: last, Col: last}, {Row: last, Col: 0}}
+}
+
+// RowIter returns an iterator over the points of a row, from left to right.
+func (g Grid) RowIter(row uint8) PointIterator {
+	start := Point{Row: row, Col: 0}
+	return newLinePointIterator(g, start, IsInBounds(g, start), 0, 1)
+}
+
+// ColIter returns an iterator over the points of a column, from top to bottom.
+func (g Grid) ColIter(col uint8) PointIterator {
+	start := Point{Row: 0, Col: col}
+	return newLinePointIterator(g, start, IsInBounds(g, start), 1, 0)
+}
+
+// DiagonalIter returns an iterator over the points on the diagonal where Col - Row == offset, from top left to bottom right.
+// Offset 0 is the main diagonal through A0.
+func (g Grid) DiagonalIter(offset int) PointIterator {
+	start, ok := Point{}.OffsetIn(g, max(-offset, 0), max(offset, 0))
+	return newLinePointIterator(g, start, ok, 1, 1)
+}
+
+// AntiDiagonalIter returns an iterator over the points on the anti-diagonal where Row + Col == sum, from top right to bottom left.
+// Sum Size-1 is the main anti-diagonal through the top right corner.
+func (g Grid) AntiDiagonalIter(sum int) PointIterator {
+	last := int(g.Size) - 1
+	start, ok := Point{}.OffsetIn(g, max(sum-last, 0), min(sum, last))
+	return newLinePointIterator(g, start, ok, 1, -1)
+}
+
+// linePointIterator iterates over points in a straight line until it leaves the grid
+type linePointIterator struct {
+	grid   Grid
+	start  Point
+	inGrid bool // whether start is on the grid
+	next   Point
+	dr, dc int
+	done   bool
+}
+
+func newLinePointIterator(g Grid, start Point, inGrid bool, dr, dc int) *linePointIterator {
+	li := &linePointIterator{grid: g, start: start, inGrid: inGrid, dr: dr, dc: dc}
+	li.Reset()
+	return li
+}
+
+func (li *linePointIterator) Reset() {
+	li.next, li.done = li.start, !li.inGrid
+}
+
+func (li *linePointIterator) Next() (Point, bool) {
+	if li.done {
+		return Point{}, false
+	}
+	p := li.next
+	next, ok := p.OffsetIn(li.grid, li.dr, li.dc)
+	li.next, li.done = next, !ok
+	return p, true
+}
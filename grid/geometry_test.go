@@ -0,0 +1,71 @@
+package grid
+
+import (
+	"reflect"
+	"testing"
+)
+
+// collect returns all the points from an iterator
+func collect(it PointIterator) Placements {
+	p := Placements{}
+	for point, ok := it.Next(); ok; point, ok = it.Next() {
+		p = append(p, point)
+	}
+	return p
+}
+
+func TestGrid_Center(t *testing.T) {
+	tests := []struct {
+		g    Grid
+		want Placements
+	}{
+		{Grid{Size: 1}, Placements{{Row: 0, Col: 0}}},
+		{Grid{Size: 5}, Placements{{Row: 2, Col: 2}}},
+		{Grid{Size: 4}, Placements{{Row: 1, Col: 1}, {Row: 1, Col: 2}, {Row: 2, Col: 1}, {Row: 2, Col: 2}}},
+	}
+	for _, tt := range tests {
+		if got := tt.g.Center(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v.Center() = %v, want %v", tt.g, got, tt.want)
+		}
+	}
+}
+
+func TestGrid_Corners(t *testing.T) {
+	want := [4]Point{{Row: 0, Col: 0}, {Row: 0, Col: 3}, {Row: 3, Col: 3}, {Row: 3, Col: 0}}
+	if got := (Grid{Size: 4}).Corners(); got != want {
+		t.Errorf("Corners() = %v, want %v", got, want)
+	}
+}
+
+func TestGrid_LineIters(t *testing.T) {
+	g := Grid{Size: 3}
+	tests := []struct {
+		name string
+		it   PointIterator
+		want Placements
+	}{
+		{"row", g.RowIter(1), Placements{{Row: 1, Col: 0}, {Row: 1, Col: 1}, {Row: 1, Col: 2}}},
+		{"col", g.ColIter(2), Placements{{Row: 0, Col: 2}, {Row: 1, Col: 2}, {Row: 2, Col: 2}}},
+		{"row off grid", g.RowIter(3), Placements{}},
+		{"main diagonal", g.DiagonalIter(0), Placements{{Row: 0, Col: 0}, {Row: 1, Col: 1}, {Row: 2, Col: 2}}},
+		{"upper diagonal", g.DiagonalIter(1), Placements{{Row: 0, Col: 1}, {Row: 1, Col: 2}}},
+		{"lower diagonal", g.DiagonalIter(-2), Placements{{Row: 2, Col: 0}}},
+		{"diagonal off grid", g.DiagonalIter(3), Placements{}},
+		{"main anti-diagonal", g.AntiDiagonalIter(2), Placements{{Row: 0, Col: 2}, {Row: 1, Col: 1}, {Row: 2, Col: 0}}},
+		{"corner anti-diagonal", g.AntiDiagonalIter(0), Placements{{Row: 0, Col: 0}}},
+		{"lower anti-diagonal", g.AntiDiagonalIter(3), Placements{{Row: 1, Col: 2}, {Row: 2, Col: 1}}},
+		{"anti-diagonal off grid", g.AntiDiagonalIter(5), Placements{}},
+		{"negative anti-diagonal", g.AntiDiagonalIter(-1), Placements{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := collect(tt.it); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("iterator returned %v, want %v", got, tt.want)
+			}
+			tt.it.Reset()
+			if got := collect(tt.it); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("iterator returned %v after Reset(), want %v", got, tt.want)
+			}
+		})
+	}
+}
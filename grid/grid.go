@@ -4,6 +4,9 @@ package grid
 import (
 	"fmt"
 	"slices"
+	"strconv"
+	"strings"
+	"unicode"
 )
 
 const (
@@ -22,6 +25,13 @@ func (g Grid) Iter() PointIterator {
 	return &gridPointIterator{grid: g, nextPoint: Point{}}
 }
 
+// ReverseIter returns an iterator over the points of the grid from bottom right to top left, the reverse of Iter.
+func (g Grid) ReverseIter() PointIterator {
+	it := &reverseGridPointIterator{grid: g}
+	it.Reset()
+	return it
+}
+
 // Point is the coordinate of a stone on a grid
 type Point struct {
 	Row uint8
@@ -32,6 +42,70 @@ func (p Point) String() string {
 	return string('A'+p.Row) + fmt.Sprint(p.Col)
 }
 
+// MarshalText encodes a Point in the same format as String, so Points appear as e.g. "E2" in JSON.
+func (p Point) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText decodes a Point in the format produced by MarshalText.
+func (p *Point) UnmarshalText(text []byte) error {
+	point, err := ParsePoint(string(text))
+	if err != nil {
+		return err
+	}
+	*p = point
+	return nil
+}
+
+// ParsePoint parses a Point in the format produced by Point.String, e.g. "E2"
+func ParsePoint(s string) (Point, error) {
+	if len(s) < 2 || s[0] < 'A' || s[0] > 'Z' {
+		return Point{}, fmt.Errorf("invalid point %q: want a row letter followed by a column number, e.g. E2", s)
+	}
+	col, err := strconv.ParseUint(s[1:], 10, 8)
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid point %q: %w", s, err)
+	}
+	return Point{Row: s[0] - 'A', Col: uint8(col)}, nil
+}
+
+// ParsePlacements parses Placements in the format produced by fmt.Sprint, e.g. "[A0 B2 C1]".
+// The surrounding brackets are optional, and points may also be separated by commas.
+func ParsePlacements(s string) (Placements, error) {
+	s = strings.TrimSpace(s)
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "["), "]")
+	fields := strings.FieldsFunc(s, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+	p := make(Placements, 0, len(fields))
+	for _, f := range fields {
+		point, err := ParsePoint(f)
+		if err != nil {
+			return nil, err
+		}
+		p = append(p, point)
+	}
+	return p, nil
+}
+
+// Delta returns the number of rows and columns from p to other, which may be negative.
+func (p Point) Delta(other Point) (dr, dc int) {
+	return int(other.Row) - int(p.Row), int(other.Col) - int(p.Col)
+}
+
+// Offset returns the point dr rows and dc columns away. The result wraps around if it would have a negative coordinate,
+// so it is only meaningful if the caller knows it is on the grid. Use OffsetIn otherwise.
+func (p Point) Offset(dr, dc int) Point {
+	return Point{Row: uint8(int(p.Row) + dr), Col: uint8(int(p.Col) + dc)}
+}
+
+// OffsetIn returns the point dr rows and dc columns away, and whether it is on the grid.
+func (p Point) OffsetIn(g Grid, dr, dc int) (Point, bool) {
+	row, col := int(p.Row)+dr, int(p.Col)+dc
+	if row < 0 || col < 0 || row >= int(g.Size) || col >= int(g.Size) {
+		return Point{}, false
+	}
+	return Point{Row: uint8(row), Col: uint8(col)}, true
+}
+
 // IsInBounds returns whether a Point is contained within a given Grid
 func IsInBounds(g Grid, p Point) bool {
 	return p.Row < g.Size && p.Col < g.Size
@@ -47,6 +121,15 @@ func AdvanceStone(g Grid, p Point) Point {
 	return p2
 }
 
+// RetreatStone returns the previous point in an ordered left to right, top to bottom traversal of the grid, undoing AdvanceStone.
+// The returned point is *not* guaranteed to be on the grid.
+func RetreatStone(g Grid, p Point) Point {
+	if p.Col == 0 {
+		return Point{Row: p.Row - 1, Col: g.Size - 1}
+	}
+	return Point{Row: p.Row, Col: p.Col - 1}
+}
+
 func LessThan(p1, p2 Point) bool {
 	return p1.Row < p2.Row || p1.Row == p2.Row && p1.Col < p2.Col
 }
@@ -55,6 +138,8 @@ func LessThan(p1, p2 Point) bool {
 type PointIterator interface {
 	// Next returns the next Point and whether or not it was valid
 	Next() (Point, bool)
+	// Reset restarts the iteration from the first Point, so that an iterator can be reused instead of allocating a new one
+	Reset()
 }
 
 type gridPointIterator struct {
@@ -71,6 +156,28 @@ func (pi *gridPointIterator) Next() (Point, bool) {
 	return next, true
 }
 
+func (pi *gridPointIterator) Reset() {
+	pi.nextPoint = Point{}
+}
+
+type reverseGridPointIterator struct {
+	grid      Grid
+	nextPoint Point
+}
+
+func (pi *reverseGridPointIterator) Next() (Point, bool) {
+	next := pi.nextPoint
+	if !IsInBounds(pi.grid, next) {
+		return next, false
+	}
+	pi.nextPoint = RetreatStone(pi.grid, pi.nextPoint)
+	return next, true
+}
+
+func (pi *reverseGridPointIterator) Reset() {
+	pi.nextPoint = Point{Row: pi.grid.Size - 1, Col: pi.grid.Size - 1}
+}
+
 // Placements represents a set of stones placed on the grid
 type Placements []Point
 
@@ -87,23 +194,129 @@ func (p Placements) Sort() {
 	})
 }
 
+// SeparationPair is the separation between a pair of stones
+type SeparationPair struct {
+	Separation uint16
+	Pair       [2]Point
+}
+
+// Separations returns the separations between every pair of stones, sorted in increasing order.
+// The placements are a solution when there are no duplicates.
+func (p Placements) Separations() []uint16 {
+	separations := make([]uint16, 0, len(p)*(len(p)-1)/2)
+	for i, p1 := range p {
+		for _, p2 := range p[i+1:] {
+			separations = append(separations, Separation(p1, p2))
+		}
+	}
+	slices.Sort(separations)
+	return separations
+}
+
+// SeparationPairs returns the separations between every pair of stones with the pair they separate, sorted by separation.
+// Pairs with equal separations are in the order of their stones in the placements.
+func (p Placements) SeparationPairs() []SeparationPair {
+	pairs := make([]SeparationPair, 0, len(p)*(len(p)-1)/2)
+	for i, p1 := range p {
+		for _, p2 := range p[i+1:] {
+			pairs = append(pairs, SeparationPair{Separation(p1, p2), [2]Point{p1, p2}})
+		}
+	}
+	slices.SortStableFunc(pairs, func(a, b SeparationPair) int { return int(a.Separation) - int(b.Separation) })
+	return pairs
+}
+
+// Render draws the stones on the grid as text, for terminals and debugging partial placements: a row of column numbers, then a line
+// per row starting with its letter, with a ● for each stone and a · for each empty cell. Stones off the grid aren't drawn.
+func (p Placements) Render(g Grid) string {
+	var b strings.Builder
+	b.WriteString("  ")
+	for c := 0; c < int(g.Size); c++ {
+		fmt.Fprintf(&b, "%2d", c)
+	}
+	b.WriteByte('\n')
+	for r := uint8(0); r < g.Size; r++ {
+		b.WriteByte('A' + r)
+		b.WriteByte(' ')
+		for c := uint8(0); c < g.Size; c++ {
+			cell := "·"
+			if slices.Contains(p, Point{Row: r, Col: c}) {
+				cell = "●"
+			}
+			b.WriteString(" " + cell)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
 // Separation is the squared distance between 2 grid points
 func Separation(p1, p2 Point) uint16 {
-	return uint16((int16(p1.Row)-int16(p2.Row))*(int16(p1.Row)-int16(p2.Row)) + (int16(p1.Col)-int16(p2.Col))*(int16(p1.Col)-int16(p2.Col)))
+	dr, dc := p1.Delta(p2)
+	return uint16(dr*dr + dc*dc)
+}
+
+// Constraint identifies one of the rules that a solution must satisfy
+type Constraint string
+
+const (
+	// StoneCountConstraint requires exactly Size stones to be placed on the grid
+	StoneCountConstraint Constraint = "stone_count"
+	// InBoundsConstraint requires every stone to be on the grid
+	InBoundsConstraint Constraint = "in_bounds"
+	// DistinctPointsConstraint requires no two stones to be placed on the same point
+	DistinctPointsConstraint Constraint = "distinct_points"
+	// UniqueSeparationsConstraint requires the separations between every pair of stones to be unique
+	UniqueSeparationsConstraint Constraint = "unique_separations"
+)
+
+// ValidationError describes the constraint that a proposed solution violates, and the stones that violate it.
+type ValidationError struct {
+	Constraint Constraint
+	// Placed and Want are the number of stones placed and needed, for StoneCountConstraint
+	Placed, Want int
+	// Point is the offending stone, for InBoundsConstraint and DistinctPointsConstraint
+	Point Point
+	// Separation is the duplicated separation, and Pairs are the two pairs of stones that share it, for UniqueSeparationsConstraint
+	Separation uint16
+	Pairs      [2]Placements
 }
 
-// Checks that a proposed solution to the problem is valid
+func (e *ValidationError) Error() string {
+	switch e.Constraint {
+	case StoneCountConstraint:
+		return fmt.Sprintf("%d stones have been placed, but need %d", e.Placed, e.Want)
+	case InBoundsConstraint:
+		return fmt.Sprintf("%s is out of bounds", e.Point)
+	case DistinctPointsConstraint:
+		return fmt.Sprintf("Multiple stones placed at %s", e.Point)
+	case UniqueSeparationsConstraint:
+		return fmt.Sprintf("Duplicated separation with squared distance %d between both %v and %v", e.Separation, e.Pairs[0], e.Pairs[1])
+	}
+	return fmt.Sprintf("constraint %s violated", e.Constraint)
+}
+
+// Checks that a proposed solution to the problem is valid. The returned error is a *ValidationError describing the first violated constraint.
 func CheckValidSolution(g Grid, p Placements) error {
 	// Check that the required number of stones have been placed
 	if len(p) != int(g.Size) {
-		return fmt.Errorf("%d stones have been placed, but need %d", len(p), g.Size)
+		return &ValidationError{Constraint: StoneCountConstraint, Placed: len(p), Want: int(g.Size)}
+	}
+	return CheckValidPartial(g, p)
+}
+
+// CheckValidPartial checks that placements of up to the grid's size stones, such as the prefix of a search, violate no constraint
+// that placing more stones couldn't fix. The returned error is a *ValidationError describing the first violated constraint.
+func CheckValidPartial(g Grid, p Placements) error {
+	if len(p) > int(g.Size) {
+		return &ValidationError{Constraint: StoneCountConstraint, Placed: len(p), Want: int(g.Size)}
 	}
 
 	separations := make(map[uint16]Placements)
 	for i, p1 := range p {
 		// Check that all stones are in bounds
 		if !IsInBounds(g, p1) {
-			return fmt.Errorf("%s is out of bounds", p1)
+			return &ValidationError{Constraint: InBoundsConstraint, Point: p1}
 		}
 
 		for j := i + 1; j < len(p); j++ {
@@ -111,11 +324,11 @@ func CheckValidSolution(g Grid, p Placements) error {
 			s := Separation(p1, p2)
 			// Check that no two stones are placed on the same point
 			if s == 0 {
-				return fmt.Errorf("Multiple stones placed at %s", p1)
+				return &ValidationError{Constraint: DistinctPointsConstraint, Point: p1}
 			}
 			// Check that all separations are unique
 			if previous, exists := separations[s]; exists {
-				return fmt.Errorf("Duplicated separation with squared distance %d between both %v and %v", s, previous, Placements{p1, p2})
+				return &ValidationError{Constraint: UniqueSeparationsConstraint, Separation: s, Pairs: [2]Placements{previous, {p1, p2}}}
 			}
 			separations[s] = Placements{p1, p2}
 		}
@@ -1,6 +1,9 @@
 package grid
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"reflect"
 	"slices"
 	"testing"
@@ -84,43 +87,81 @@ func TestCheckValidSolution(t *testing.T) {
 	tests := []struct {
 		name    string
 		args    args
-		wantErr bool
+		wantErr *ValidationError
 	}{
 		{"valid 3x3",
 			args{
 				Grid{3},
 				Placements{Point{0, 0}, Point{1, 1}, Point{1, 2}},
 			},
-			false},
+			nil},
 		{"invalid 3x3 not enough stones",
 			args{
 				Grid{3},
 				Placements{Point{0, 0}, Point{1, 1}},
 			},
-			true},
+			&ValidationError{Constraint: StoneCountConstraint, Placed: 2, Want: 3}},
 		{"invalid 3x3 out of bounds stone",
 			args{
 				Grid{3},
 				Placements{Point{0, 0}, Point{1, 1}, Point{0, 4}},
 			},
-			true},
+			&ValidationError{Constraint: InBoundsConstraint, Point: Point{Row: 0, Col: 4}}},
 		{"invalid 2x2 colliding stones",
 			args{
 				Grid{2},
 				Placements{Point{0, 0}, Point{0, 0}},
 			},
-			true},
+			&ValidationError{Constraint: DistinctPointsConstraint, Point: Point{Row: 0, Col: 0}}},
 		{"invalid 3x3 duplicate separations",
 			args{
 				Grid{3},
 				Placements{Point{0, 0}, Point{1, 1}, Point{0, 2}},
 			},
-			true},
+			&ValidationError{Constraint: UniqueSeparationsConstraint, Separation: 2, Pairs: [2]Placements{{Point{Row: 0, Col: 0}, Point{Row: 1, Col: 1}}, {Point{Row: 1, Col: 1}, Point{Row: 0, Col: 2}}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := CheckValidSolution(tt.args.g, tt.args.p)
+			if tt.wantErr == nil {
+				if got != nil {
+					t.Errorf("CheckValidSolution() error = %v, want nil", got)
+				}
+				return
+			}
+			var gotErr *ValidationError
+			if !errors.As(got, &gotErr) {
+				t.Fatalf("CheckValidSolution() error = %v, want a *ValidationError", got)
+			}
+			if diff := cmp.Diff(tt.wantErr, gotErr); diff != "" {
+				t.Errorf("CheckValidSolution() error mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCheckValidPartial(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       Placements
+		wantErr Constraint
+	}{
+		{"empty", Placements{}, ""},
+		{"partial", Placements{Point{0, 0}, Point{1, 1}}, ""},
+		{"too many stones", Placements{Point{0, 0}, Point{0, 1}, Point{1, 0}, Point{2, 2}}, StoneCountConstraint},
+		{"out of bounds", Placements{Point{0, 0}, Point{3, 0}}, InBoundsConstraint},
+		{"duplicate separations", Placements{Point{0, 0}, Point{1, 1}, Point{0, 2}}, UniqueSeparationsConstraint},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := CheckValidSolution(tt.args.g, tt.args.p); tt.wantErr == (got == nil) {
-				t.Errorf("CheckValidSolution() error = %v, want %v", got, tt.wantErr)
+			err := CheckValidPartial(Grid{3}, tt.p)
+			var gotErr *ValidationError
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckValidPartial() error = %v, want nil", err)
+				}
+			} else if !errors.As(err, &gotErr) || gotErr.Constraint != tt.wantErr {
+				t.Errorf("CheckValidPartial() error = %v, want a *ValidationError for %s", err, tt.wantErr)
 			}
 		})
 	}
@@ -187,4 +228,171 @@ func TestGrid_Iter(t *testing.T) {
 	if !reflect.DeepEqual(got, want) {
 		t.Errorf("Iter() produced %v, want %v", got, want)
 	}
+	it.Reset()
+	if p, ok := it.Next(); !ok || p != want[0] {
+		t.Errorf("Next() after Reset() = %v, %t, want %v, true", p, ok, want[0])
+	}
+}
+
+func TestGrid_ReverseIter(t *testing.T) {
+	g := Grid{2}
+	var got Placements
+	it := g.ReverseIter()
+	for p, ok := it.Next(); ok; p, ok = it.Next() {
+		got = append(got, p)
+	}
+	want := Placements{Point{1, 1}, Point{1, 0}, Point{0, 1}, Point{0, 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReverseIter() produced %v, want %v", got, want)
+	}
+}
+
+func TestRetreatStone(t *testing.T) {
+	g := Grid{4}
+	for p := (Point{Row: 3, Col: 3}); IsInBounds(g, p); p = RetreatStone(g, p) {
+		if got := AdvanceStone(g, RetreatStone(g, p)); p != (Point{}) && got != p {
+			t.Errorf("AdvanceStone(RetreatStone(%v)) = %v, want %v", p, got, p)
+		}
+	}
+	if got := RetreatStone(g, Point{}); IsInBounds(g, got) {
+		t.Errorf("RetreatStone(A0) = %v, want a point off the grid", got)
+	}
+}
+
+func TestParsePlacements(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    Placements
+		wantErr bool
+	}{
+		{"empty", "", Placements{}, false},
+		{"empty brackets", "[]", Placements{}, false},
+		{"fmt output", "[A0 B2 C10]", Placements{Point{Row: 0, Col: 0}, Point{Row: 1, Col: 2}, Point{Row: 2, Col: 10}}, false},
+		{"no brackets", " A0 B2 ", Placements{Point{Row: 0, Col: 0}, Point{Row: 1, Col: 2}}, false},
+		{"commas", "A0,B2, C1", Placements{Point{Row: 0, Col: 0}, Point{Row: 1, Col: 2}, Point{Row: 2, Col: 1}}, false},
+		{"lowercase row", "[a0]", nil, true},
+		{"missing column", "[A]", nil, true},
+		{"bad column", "[Ax]", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePlacements(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePlacements(%q) error = %v, wantErr %v", tt.s, err, tt.wantErr)
+			}
+			if !tt.wantErr && !cmp.Equal(got, tt.want) {
+				t.Errorf("ParsePlacements(%q) = %v, want %v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePlacements_RoundTrip(t *testing.T) {
+	p := Placements{Point{Row: 0, Col: 0}, Point{Row: 13, Col: 13}, Point{Row: 4, Col: 11}}
+	got, err := ParsePlacements(fmt.Sprint(p))
+	if err != nil {
+		t.Fatalf("ParsePlacements(%q) error = %v", fmt.Sprint(p), err)
+	}
+	if !cmp.Equal(got, p) {
+		t.Errorf("ParsePlacements(%q) = %v, want %v", fmt.Sprint(p), got, p)
+	}
+}
+
+func TestPoint_JSON(t *testing.T) {
+	p := Placements{{Row: 0, Col: 0}, {Row: 4, Col: 12}}
+	b, err := json.Marshal(p)
+	if err != nil {
+		t.Fatalf("json.Marshal() returned error %v", err)
+	}
+	if want := `["A0","E12"]`; string(b) != want {
+		t.Errorf("json.Marshal() = %s, want %s", b, want)
+	}
+	var got Placements
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("json.Unmarshal() returned error %v", err)
+	}
+	if !reflect.DeepEqual(got, p) {
+		t.Errorf("json.Unmarshal() = %v, want %v", got, p)
+	}
+	if err := json.Unmarshal([]byte(`["A"]`), &got); err == nil {
+		t.Errorf("json.Unmarshal() of an invalid point returned no error")
+	}
+}
+
+func TestPlacements_Separations(t *testing.T) {
+	tests := []struct {
+		name      string
+		p         Placements
+		want      []uint16
+		wantPairs []SeparationPair
+	}{
+		{"empty", Placements{}, []uint16{}, []SeparationPair{}},
+		{"single stone", Placements{{Row: 1, Col: 1}}, []uint16{}, []SeparationPair{}},
+		{"valid 3x3",
+			Placements{{Row: 0, Col: 0}, {Row: 1, Col: 1}, {Row: 1, Col: 2}},
+			[]uint16{1, 2, 5},
+			[]SeparationPair{
+				{1, [2]Point{{Row: 1, Col: 1}, {Row: 1, Col: 2}}},
+				{2, [2]Point{{Row: 0, Col: 0}, {Row: 1, Col: 1}}},
+				{5, [2]Point{{Row: 0, Col: 0}, {Row: 1, Col: 2}}},
+			},
+		},
+		{"duplicate separation",
+			Placements{{Row: 0, Col: 0}, {Row: 1, Col: 1}, {Row: 0, Col: 2}},
+			[]uint16{2, 2, 4},
+			[]SeparationPair{
+				{2, [2]Point{{Row: 0, Col: 0}, {Row: 1, Col: 1}}},
+				{2, [2]Point{{Row: 1, Col: 1}, {Row: 0, Col: 2}}},
+				{4, [2]Point{{Row: 0, Col: 0}, {Row: 0, Col: 2}}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.Separations(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Separations() = %v, want %v", got, tt.want)
+			}
+			if got := tt.p.SeparationPairs(); !reflect.DeepEqual(got, tt.wantPairs) {
+				t.Errorf("SeparationPairs() = %v, want %v", got, tt.wantPairs)
+			}
+		})
+	}
+}
+
+func TestPlacements_Render(t *testing.T) {
+	p := Placements{{Row: 0, Col: 0}, {Row: 1, Col: 2}, {Row: 5, Col: 5}}
+	want := "   0 1 2\n" +
+		"A  ● · ·\n" +
+		"B  · · ●\n" +
+		"C  · · ·\n"
+	if diff := cmp.Diff(want, p.Render(Grid{Size: 3})); diff != "" {
+		t.Errorf("%v.Render() mismatch (-want +got):\n%s", p, diff)
+	}
+}
+
+func TestPoint_Offset(t *testing.T) {
+	g := Grid{Size: 3}
+	p := Point{Row: 1, Col: 2}
+	if dr, dc := p.Delta(Point{Row: 0, Col: 0}); dr != -1 || dc != -2 {
+		t.Errorf("%v.Delta(A0) = %d, %d, want -1, -2", p, dr, dc)
+	}
+	if got := p.Offset(-1, -2); got != (Point{Row: 0, Col: 0}) {
+		t.Errorf("%v.Offset(-1, -2) = %v, want A0", p, got)
+	}
+	tests := []struct {
+		dr, dc int
+		want   Point
+		wantOk bool
+	}{
+		{0, 0, p, true},
+		{1, -2, Point{Row: 2, Col: 0}, true},
+		{0, 1, Point{}, false},
+		{-2, 0, Point{}, false},
+	}
+	for _, tt := range tests {
+		if got, ok := p.OffsetIn(g, tt.dr, tt.dc); got != tt.want || ok != tt.wantOk {
+			t.Errorf("%v.OffsetIn(%d, %d) = %v, %v, want %v, %v", p, tt.dr, tt.dc, got, ok, tt.want, tt.wantOk)
+		}
+	}
 }
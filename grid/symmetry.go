@@ -0,0 +1,157 @@
+package grid
+
+import "slices"
+
+// Symmetry maps a point on a grid to its image under one of the rotations or reflections of the square
+type Symmetry func(g Grid, p Point) Point
+
+// Symmetries are the 8 rotations and reflections of a square grid (the dihedral group D4), starting with the identity.
+var Symmetries = [8]Symmetry{
+	func(g Grid, p Point) Point { return p },
+	func(g Grid, p Point) Point { return p.Rotate90(g) },
+	func(g Grid, p Point) Point { return p.Rotate180(g) },
+	func(g Grid, p Point) Point { return p.Rotate270(g) },
+	func(g Grid, p Point) Point { return p.ReflectLeftRight(g) },
+	func(g Grid, p Point) Point { return p.ReflectTopBottom(g) },
+	func(g Grid, p Point) Point { return p.ReflectDiagonal(g) },
+	func(g Grid, p Point) Point { return p.ReflectAntiDiagonal(g) },
+}
+
+// Rotate90 returns the image of the point when the grid is rotated a quarter turn clockwise.
+func (p Point) Rotate90(g Grid) Point {
+	return Point{Row: p.Col, Col: g.Size - 1 - p.Row}
+}
+
+// Rotate180 returns the image of the point when the grid is rotated a half turn.
+func (p Point) Rotate180(g Grid) Point {
+	return Point{Row: g.Size - 1 - p.Row, Col: g.Size - 1 - p.Col}
+}
+
+// Rotate270 returns the image of the point when the grid is rotated a quarter turn anticlockwise.
+func (p Point) Rotate270(g Grid) Point {
+	return Point{Row: g.Size - 1 - p.Col, Col: p.Row}
+}
+
+// ReflectLeftRight returns the image of the point when the grid is mirrored about its vertical center line.
+func (p Point) ReflectLeftRight(g Grid) Point {
+	return Point{Row: p.Row, Col: g.Size - 1 - p.Col}
+}
+
+// ReflectTopBottom returns the image of the point when the grid is mirrored about its horizontal center line.
+func (p Point) ReflectTopBottom(g Grid) Point {
+	return Point{Row: g.Size - 1 - p.Row, Col: p.Col}
+}
+
+// ReflectDiagonal returns the image of the point when the grid is mirrored about the diagonal through A0.
+func (p Point) ReflectDiagonal(g Grid) Point {
+	return Point{Row: p.Col, Col: p.Row}
+}
+
+// ReflectAntiDiagonal returns the image of the point when the grid is mirrored about the other diagonal.
+func (p Point) ReflectAntiDiagonal(g Grid) Point {
+	return Point{Row: g.Size - 1 - p.Col, Col: g.Size - 1 - p.Row}
+}
+
+// Transform returns new, sorted Placements containing the image of each point under the symmetry.
+func (p Placements) Transform(g Grid, s Symmetry) Placements {
+	t := make(Placements, len(p))
+	for i, point := range p {
+		t[i] = s(g, point)
+	}
+	t.Sort()
+	return t
+}
+
+// Compare orders sorted Placements lexicographically, returning -1, 0 or 1 like slices.Compare.
+func (p Placements) Compare(p2 Placements) int {
+	return slices.CompareFunc(p, p2, func(p1, p2 Point) int {
+		if LessThan(p1, p2) {
+			return -1
+		} else if LessThan(p2, p1) {
+			return 1
+		}
+		return 0
+	})
+}
+
+// Canonical returns the lexicographically smallest image of the placements under all symmetries of the grid.
+// Two placements are equivalent up to rotation and reflection exactly when their canonical forms are equal.
+func Canonical(g Grid, p Placements) Placements {
+	var canonical Placements
+	for _, s := range Symmetries {
+		if t := p.Transform(g, s); canonical == nil || t.Compare(canonical) < 0 {
+			canonical = t
+		}
+	}
+	return canonical
+}
+
+// OrbitSize returns the number of distinct placements that the placements can be rotated or reflected into, including themselves.
+// It is 8 unless the placements are symmetric.
+func OrbitSize(g Grid, p Placements) int {
+	var images []Placements
+	for _, s := range Symmetries {
+		t := p.Transform(g, s)
+		if !slices.ContainsFunc(images, func(i Placements) bool { return i.Compare(t) == 0 }) {
+			images = append(images, t)
+		}
+	}
+	return len(images)
+}
+
+// SymmetryType names the group of Symmetries that map placements onto themselves. The orbit size of the placements is 8 divided by the
+// size of the group.
+type SymmetryType string
+
+const (
+	// NoSymmetry means only the identity maps the placements onto themselves
+	NoSymmetry SymmetryType = "none"
+	// HalfTurnSymmetry means the placements are unchanged by a half turn
+	HalfTurnSymmetry SymmetryType = "half_turn"
+	// QuarterTurnSymmetry means the placements are unchanged by every rotation
+	QuarterTurnSymmetry SymmetryType = "quarter_turn"
+	// MirrorSymmetry means the placements are unchanged by reflection about one of the center lines
+	MirrorSymmetry SymmetryType = "mirror"
+	// DiagonalSymmetry means the placements are unchanged by reflection about one of the diagonals
+	DiagonalSymmetry SymmetryType = "diagonal"
+	// DoubleMirrorSymmetry means the placements are unchanged by reflection about both center lines, and so by a half turn
+	DoubleMirrorSymmetry SymmetryType = "double_mirror"
+	// DoubleDiagonalSymmetry means the placements are unchanged by reflection about both diagonals, and so by a half turn
+	DoubleDiagonalSymmetry SymmetryType = "double_diagonal"
+	// FullSymmetry means the placements are unchanged by every rotation and reflection
+	FullSymmetry SymmetryType = "full"
+)
+
+// SymmetryTypes lists the symmetry types from least to most symmetric.
+var SymmetryTypes = []SymmetryType{NoSymmetry, HalfTurnSymmetry, MirrorSymmetry, DiagonalSymmetry, QuarterTurnSymmetry, DoubleMirrorSymmetry, DoubleDiagonalSymmetry, FullSymmetry}
+
+// ClassifySymmetry returns the type of the group of Symmetries that map the placements onto themselves.
+func ClassifySymmetry(g Grid, p Placements) SymmetryType {
+	sorted := slices.Clone(p)
+	sorted.Sort()
+	var fixed [len(Symmetries)]bool
+	count := 0
+	for i, s := range Symmetries {
+		if fixed[i] = p.Transform(g, s).Compare(sorted) == 0; fixed[i] {
+			count++
+		}
+	}
+	// The indexes are those of the rotations and reflections in Symmetries
+	switch {
+	case count == 8:
+		return FullSymmetry
+	case count == 4 && fixed[1]:
+		return QuarterTurnSymmetry
+	case count == 4 && fixed[4]:
+		return DoubleMirrorSymmetry
+	case count == 4:
+		return DoubleDiagonalSymmetry
+	case count == 2 && fixed[2]:
+		return HalfTurnSymmetry
+	case count == 2 && (fixed[4] || fixed[5]):
+		return MirrorSymmetry
+	case count == 2:
+		return DiagonalSymmetry
+	}
+	return NoSymmetry
+}
@@ -0,0 +1,118 @@
+package grid
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSymmetries(t *testing.T) {
+	g := Grid{Size: 3}
+	p := Point{Row: 0, Col: 1}
+	var got Placements
+	for _, s := range Symmetries {
+		got = append(got, s(g, p))
+	}
+	// The images of an edge point visit each edge twice
+	want := Placements{
+		Point{Row: 0, Col: 1}, Point{Row: 1, Col: 2}, Point{Row: 2, Col: 1}, Point{Row: 1, Col: 0},
+		Point{Row: 0, Col: 1}, Point{Row: 2, Col: 1}, Point{Row: 1, Col: 0}, Point{Row: 1, Col: 2},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Symmetries images had diff (-got, +want): %s", diff)
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	g := Grid{Size: 3}
+	// All of these are rotations or reflections of each other
+	equivalent := []Placements{
+		{Point{Row: 0, Col: 0}, Point{Row: 1, Col: 1}, Point{Row: 1, Col: 2}},
+		{Point{Row: 0, Col: 2}, Point{Row: 1, Col: 1}, Point{Row: 2, Col: 1}},
+		{Point{Row: 2, Col: 2}, Point{Row: 1, Col: 1}, Point{Row: 1, Col: 0}},
+		{Point{Row: 0, Col: 1}, Point{Row: 1, Col: 1}, Point{Row: 2, Col: 2}},
+	}
+	want := Canonical(g, equivalent[0])
+	for _, p := range equivalent {
+		if got := Canonical(g, p); got.Compare(want) != 0 {
+			t.Errorf("Canonical(%v) = %v, want %v", p, got, want)
+		}
+	}
+	other := Placements{Point{Row: 0, Col: 0}, Point{Row: 0, Col: 1}, Point{Row: 2, Col: 1}}
+	if got := Canonical(g, other); got.Compare(want) == 0 {
+		t.Errorf("Canonical(%v) = %v, want different from %v", other, got, want)
+	}
+}
+
+func TestOrbitSize(t *testing.T) {
+	tests := []struct {
+		name string
+		g    Grid
+		p    Placements
+		want int
+	}{
+		{"asymmetric", Grid{Size: 3}, Placements{Point{Row: 0, Col: 0}, Point{Row: 1, Col: 1}, Point{Row: 1, Col: 2}}, 8},
+		{"diagonal reflection", Grid{Size: 3}, Placements{Point{Row: 0, Col: 0}, Point{Row: 0, Col: 1}, Point{Row: 1, Col: 0}}, 4},
+		{"center", Grid{Size: 3}, Placements{Point{Row: 1, Col: 1}}, 1},
+		{"empty", Grid{Size: 3}, Placements{}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := OrbitSize(tt.g, tt.p); got != tt.want {
+				t.Errorf("OrbitSize(%v) = %d, want %d", tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoint_Symmetries(t *testing.T) {
+	g := Grid{Size: 4}
+	p := Point{Row: 0, Col: 1}
+	tests := []struct {
+		name string
+		got  Point
+		want Point
+	}{
+		{"Rotate90", p.Rotate90(g), Point{Row: 1, Col: 3}},
+		{"Rotate180", p.Rotate180(g), Point{Row: 3, Col: 2}},
+		{"Rotate270", p.Rotate270(g), Point{Row: 2, Col: 0}},
+		{"ReflectLeftRight", p.ReflectLeftRight(g), Point{Row: 0, Col: 2}},
+		{"ReflectTopBottom", p.ReflectTopBottom(g), Point{Row: 3, Col: 1}},
+		{"ReflectDiagonal", p.ReflectDiagonal(g), Point{Row: 1, Col: 0}},
+		{"ReflectAntiDiagonal", p.ReflectAntiDiagonal(g), Point{Row: 2, Col: 3}},
+		{"Rotate90 four times", p.Rotate90(g).Rotate90(g).Rotate90(g).Rotate90(g), p},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("%s(%v) = %v, want %v", tt.name, p, tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifySymmetry(t *testing.T) {
+	tests := []struct {
+		name string
+		g    Grid
+		p    Placements
+		want SymmetryType
+	}{
+		{name: "asymmetric", g: Grid{Size: 3}, p: Placements{{Row: 0, Col: 0}, {Row: 1, Col: 1}, {Row: 1, Col: 2}}, want: NoSymmetry},
+		{name: "half turn", g: Grid{Size: 3}, p: Placements{{Row: 0, Col: 1}, {Row: 2, Col: 1}, {Row: 0, Col: 0}, {Row: 2, Col: 2}}, want: HalfTurnSymmetry},
+		{name: "border", g: Grid{Size: 3}, p: Placements{{Row: 0, Col: 1}, {Row: 1, Col: 2}, {Row: 2, Col: 1}, {Row: 1, Col: 0}, {Row: 0, Col: 0}, {Row: 0, Col: 2}, {Row: 2, Col: 2}, {Row: 2, Col: 0}}, want: FullSymmetry},
+		{name: "pinwheel", g: Grid{Size: 4}, p: Placements{{Row: 0, Col: 1}, {Row: 1, Col: 3}, {Row: 3, Col: 2}, {Row: 2, Col: 0}}, want: QuarterTurnSymmetry},
+		{name: "mirror", g: Grid{Size: 3}, p: Placements{{Row: 0, Col: 0}, {Row: 0, Col: 2}, {Row: 2, Col: 1}}, want: MirrorSymmetry},
+		{name: "diagonal", g: Grid{Size: 3}, p: Placements{{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 1, Col: 0}}, want: DiagonalSymmetry},
+		{name: "double mirror", g: Grid{Size: 3}, p: Placements{{Row: 0, Col: 1}, {Row: 2, Col: 1}}, want: DoubleMirrorSymmetry},
+		{name: "double diagonal", g: Grid{Size: 3}, p: Placements{{Row: 0, Col: 0}, {Row: 2, Col: 2}}, want: DoubleDiagonalSymmetry},
+		{name: "center", g: Grid{Size: 3}, p: Placements{{Row: 1, Col: 1}}, want: FullSymmetry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifySymmetry(tt.g, tt.p); got != tt.want {
+				t.Errorf("ClassifySymmetry(%v) = %s, want %s", tt.p, got, tt.want)
+			}
+		})
+	}
+}
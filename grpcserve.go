@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/pegboardpb"
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServer serves the gRPC API of pegboardpb. Its searches share the limits of the solve service with each other.
+type grpcServer struct {
+	pegboardpb.UnimplementedSolverServer
+	solves *solveService
+}
+
+// grpcOutcomes are the gRPC outcomes of the outcomes of searches, as named by outcome.
+var grpcOutcomes = map[string]pegboardpb.Outcome{
+	"solved":      pegboardpb.Outcome_OUTCOME_SOLVED,
+	"no_solution": pegboardpb.Outcome_OUTCOME_NO_SOLUTION,
+	"canceled":    pegboardpb.Outcome_OUTCOME_CANCELED,
+	"node_budget": pegboardpb.Outcome_OUTCOME_CANCELED,
+	"timeout":     pegboardpb.Outcome_OUTCOME_TIMEOUT,
+	"error":       pegboardpb.Outcome_OUTCOME_ERROR,
+}
+
+// cellNames returns the names of the cells of the placements, such as A0.
+func cellNames(p grid.Placements) []string {
+	names := make([]string, len(p))
+	for i, cell := range p {
+		names[i] = cell.String()
+	}
+	return names
+}
+
+// newJobRequest converts a gRPC request to the request the solve service takes.
+func newJobRequest(req *pegboardpb.SolveRequest) (jobRequest, error) {
+	if req.GetSize() > math.MaxUint8 {
+		return jobRequest{}, status.Errorf(codes.InvalidArgument, "size must be between 1 and %d", grid.MaxGridSize)
+	}
+	return jobRequest{Size: uint8(req.GetSize()), Placer: req.GetPlacer(), Solver: req.GetSolver(), NoCache: req.GetNoCache()}, nil
+}
+
+// newSolveResponse describes the result of a search.
+func newSolveResponse(r batchResult) *pegboardpb.SolveResponse {
+	resp := &pegboardpb.SolveResponse{
+		Size: uint32(r.Size), Placer: r.Placer, Solver: r.Solver, Outcome: grpcOutcomes[outcome(r.Err)],
+		DurationNs: r.Duration.Nanoseconds(), Nodes: r.Nodes,
+	}
+	if r.Err == nil {
+		resp.Solution = cellNames(r.Solution)
+	} else {
+		resp.Error = r.Err.Error()
+	}
+	return resp
+}
+
+// grpcError returns the gRPC status for an error of the solve service.
+func grpcError(err error) error {
+	if errors.Is(err, errBusy) {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+func (s *grpcServer) Solve(ctx context.Context, req *pegboardpb.SolveRequest) (*pegboardpb.SolveResponse, error) {
+	if req.GetEnumerate() {
+		return nil, status.Error(codes.InvalidArgument, "enumerate is only supported by SolveStream")
+	}
+	jr, err := newJobRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.solves.solve(ctx, jr, &solver.Stats{})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return newSolveResponse(result), nil
+}
+
+func (s *grpcServer) SolveStream(req *pegboardpb.SolveRequest, stream pegboardpb.Solver_SolveStreamServer) error {
+	jr, err := newJobRequest(req)
+	if err != nil {
+		return err
+	}
+	interval := time.Second
+	if req.GetProgressIntervalMs() > 0 {
+		interval = time.Duration(req.GetProgressIntervalMs()) * time.Millisecond
+	}
+	stats := &solver.Stats{}
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	// Streams can't be sent on concurrently, so the progress and solutions are sent one at a time, and the progress stops being sent
+	// before the result is
+	var mu sync.Mutex
+	send := func(event *pegboardpb.SolveEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return stream.Send(event)
+	}
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		for p := range solver.ReportProgress(ctx, stats, interval) {
+			progress := &pegboardpb.Progress{Nodes: p.Nodes, TasksDone: p.TasksDone, TasksTotal: p.TasksTotal, Deepest: cellNames(p.Deepest)}
+			if err := send(&pegboardpb.SolveEvent{Event: &pegboardpb.SolveEvent_Progress{Progress: progress}}); err != nil {
+				return
+			}
+		}
+	}()
+	var resp *pegboardpb.SolveResponse
+	if req.GetEnumerate() {
+		maxSolutions := int(req.GetMaxSolutions())
+		sentSolutions := 0
+		var result batchResult
+		var n int
+		result, n, err = s.solves.enumerate(ctx, jr, stats, func(p grid.Placements) bool {
+			if send(&pegboardpb.SolveEvent{Event: &pegboardpb.SolveEvent_Solution{Solution: &pegboardpb.Solution{Cells: cellNames(p)}}}) != nil {
+				// The client is gone, so its context ends the enumeration too
+				return false
+			}
+			sentSolutions++
+			return maxSolutions == 0 || sentSolutions < maxSolutions
+		})
+		if err == nil {
+			resp = newSolveResponse(result)
+			resp.Solutions = uint32(n)
+		}
+	} else {
+		var result batchResult
+		result, err = s.solves.solve(ctx, jr, stats)
+		if err == nil {
+			resp = newSolveResponse(result)
+		}
+	}
+	cancel()
+	<-sent
+	if err != nil {
+		return grpcError(err)
+	}
+	return stream.Send(&pegboardpb.SolveEvent{Event: &pegboardpb.SolveEvent_Result{Result: resp}})
+}
+
+// grpcServe implements the grpc-serve subcommand, which serves the gRPC API defined in pegboardpb/pegboard.proto.
+func grpcServe(args []string) {
+	fs := flag.NewFlagSet("grpc-serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:9090", "address to serve the gRPC API on")
+	config := addServerFlags(fs)
+	parseFlags(fs, fs.Name(), args)
+	usePhysicalCores()
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fatal(err)
+	}
+	server := grpc.NewServer()
+	pegboardpb.RegisterSolverServer(server, &grpcServer{solves: newSolveService(config())})
+	log.Printf("Serving gRPC on %s", lis.Addr())
+	fatal(server.Serve(lis))
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/pegboardpb"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCClient serves the gRPC API in memory, and returns a client for it.
+func newGRPCClient(t *testing.T, cfg serverConfig) pegboardpb.SolverClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	pegboardpb.RegisterSolverServer(server, &grpcServer{solves: newSolveService(cfg)})
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pegboardpb.NewSolverClient(conn)
+}
+
+func TestGRPCSolve(t *testing.T) {
+	client := newGRPCClient(t, serverConfig{SolveTimeout: 50 * time.Millisecond})
+	ctx := context.Background()
+
+	resp, err := client.Solve(ctx, &pegboardpb.SolveRequest{Size: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Outcome != pegboardpb.Outcome_OUTCOME_SOLVED {
+		t.Fatalf("Solve() outcome = %v, want solved", resp.Outcome)
+	}
+	p, err := grid.ParsePlacements(fmt.Sprint(resp.Solution))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := grid.CheckValidSolution(grid.Grid{Size: 6}, p); err != nil {
+		t.Errorf("Solve() solution %v is invalid: %v", resp.Solution, err)
+	}
+
+	// 11x11 grids take far longer than the timeout to search
+	resp, err = client.Solve(ctx, &pegboardpb.SolveRequest{Size: 11, Solver: "single_thread"})
+	if err != nil || resp.Outcome != pegboardpb.Outcome_OUTCOME_TIMEOUT {
+		t.Errorf("Solve() for 11x11 = %v, %v, want the timeout outcome", resp, err)
+	}
+
+	for _, req := range []*pegboardpb.SolveRequest{{Size: 15}, {Size: 300}, {Size: 6, Placer: "random"}, {Size: 6, Enumerate: true}} {
+		if _, err := client.Solve(ctx, req); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Solve(%v) error = %v, want code %v", req, err, codes.InvalidArgument)
+		}
+	}
+}
+
+func TestGRPCSolveStream(t *testing.T) {
+	client := newGRPCClient(t, serverConfig{SolveTimeout: 50 * time.Millisecond})
+	stream, err := client.SolveStream(context.Background(), &pegboardpb.SolveRequest{Size: 11, Solver: "single_thread", ProgressIntervalMs: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []*pegboardpb.SolveEvent
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+	if len(events) < 2 {
+		t.Fatalf("SolveStream() sent %d events, want progress and the result", len(events))
+	}
+	if p := events[0].GetProgress(); p == nil {
+		t.Errorf("SolveStream() first event = %v, want progress", events[0])
+	}
+	if r := events[len(events)-1].GetResult(); r.GetOutcome() != pegboardpb.Outcome_OUTCOME_TIMEOUT {
+		t.Errorf("SolveStream() last event = %v, want a result with the timeout outcome", events[len(events)-1])
+	}
+}
+
+func TestGRPCSolveStream_Enumerate(t *testing.T) {
+	client := newGRPCClient(t, serverConfig{SolveTimeout: 10 * time.Second})
+	g := grid.Grid{Size: 5}
+	var want int
+	if err := solver.Enumerate(context.Background(), g, solver.EmptyStartingPoint, placer.OrderedNoAllocStonePlacerProvider{}, nil, func(grid.Placements) bool {
+		want++
+		return true
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		maxSolutions  uint32
+		wantSolutions int
+	}{
+		{name: "all", wantSolutions: want},
+		{name: "max_solutions", maxSolutions: 3, wantSolutions: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := client.SolveStream(context.Background(), &pegboardpb.SolveRequest{Size: uint32(g.Size), Placer: "ordered_noalloc", Enumerate: true, MaxSolutions: tt.maxSolutions})
+			if err != nil {
+				t.Fatal(err)
+			}
+			seen := make(map[string]bool)
+			var result *pegboardpb.SolveResponse
+			for {
+				e, err := stream.Recv()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r := e.GetResult(); r != nil {
+					result = r
+				}
+				s := e.GetSolution()
+				if s == nil {
+					continue
+				}
+				p, err := grid.ParsePlacements(fmt.Sprint(s.Cells))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := grid.CheckValidSolution(g, p); err != nil {
+					t.Errorf("SolveStream() sent invalid solution %v: %v", s.Cells, err)
+				}
+				seen[fmt.Sprint(s.Cells)] = true
+			}
+			if len(seen) != tt.wantSolutions {
+				t.Errorf("SolveStream() sent %d distinct solutions, want %d", len(seen), tt.wantSolutions)
+			}
+			if result.GetOutcome() != pegboardpb.Outcome_OUTCOME_SOLVED || int(result.GetSolutions()) != tt.wantSolutions {
+				t.Errorf("SolveStream() result = %v, want the solved outcome with %d solutions", result, tt.wantSolutions)
+			}
+		})
+	}
+}
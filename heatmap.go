@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// heatmapShades are the characters used for cells with increasing numbers of nodes
+const heatmapShades = " .:-=+*#%@"
+
+// heatmapLevel returns how hot a cell with n nodes is, from 0 for no nodes to 1 for max nodes.
+// Subtree sizes vary by orders of magnitude, so the scale is logarithmic.
+func heatmapLevel(n, max int64) float64 {
+	if n <= 0 || max <= 0 {
+		return 0
+	}
+	return math.Log1p(float64(n)) / math.Log1p(float64(max))
+}
+
+// writeHeatmapASCII draws the heatmap as a grid of shaded cells, with the row letters and column numbers of the grid, followed by a legend.
+func writeHeatmapASCII(w io.Writer, title string, h solver.Heatmap) {
+	max := h.Max()
+	fmt.Fprintln(w, title)
+	fmt.Fprint(w, "  ")
+	for c := range h {
+		fmt.Fprintf(w, "%2d", c)
+	}
+	fmt.Fprintln(w)
+	for r, row := range h {
+		fmt.Fprintf(w, "%c ", grid.Point{Row: uint8(r)}.String()[0])
+		for _, n := range row {
+			shade := heatmapShades[0]
+			if n > 0 {
+				// Any nodes at all get at least the lightest visible shade
+				shade = heatmapShades[1+int(heatmapLevel(n, max)*float64(len(heatmapShades)-2)+0.5)]
+			}
+			fmt.Fprintf(w, " %c", shade)
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "Shades %q run from 1 node to %d nodes on a log scale\n", heatmapShades[1:], max)
+}
+
+// writeHeatmapSVG writes the heatmap as an SVG image, with each cell coloured by its number of nodes and labelled with it.
+func writeHeatmapSVG(filename, title string, h solver.Heatmap) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	const cell = 48
+	max := h.Max()
+	size := len(h) * cell
+	fmt.Fprintf(f, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="10" text-anchor="middle">`+"\n", size, size+cell/2)
+	fmt.Fprintf(f, "<title>%s</title>\n", title)
+	fmt.Fprintf(f, `<text x="%d" y="%d" font-size="14">%s</text>`+"\n", size/2, cell/3, title)
+	for r, row := range h {
+		for c, n := range row {
+			x, y := c*cell, r*cell+cell/2
+			// Shade from white through yellow to red as the cell gets hotter
+			level := heatmapLevel(n, max)
+			green, blue := 255-int(level*255), 255-int(math.Min(1, 2*level)*255)
+			fmt.Fprintf(f, `<rect x="%d" y="%d" width="%d" height="%d" fill="rgb(255,%d,%d)" stroke="#999"/>`+"\n", x, y, cell, cell, green, blue)
+			label := grid.Point{Row: uint8(r), Col: uint8(c)}.String()
+			fmt.Fprintf(f, `<text x="%d" y="%d">%s</text>`+"\n", x+cell/2, y+cell/2-4, label)
+			fmt.Fprintf(f, `<text x="%d" y="%d">%d</text>`+"\n", x+cell/2, y+cell/2+10, n)
+		}
+	}
+	fmt.Fprintln(f, "</svg>")
+	return nil
+}
+
+// writeHeatmaps writes the first stone heatmap, or the second stone heatmap for the given first stone if it isn't empty,
+// as ASCII to stdout and, if svgFile isn't empty, as SVG.
+func writeHeatmaps(g grid.Grid, stats *solver.Stats, first string, svgFile string) error {
+	h := stats.Heatmap(g)
+	title := fmt.Sprintf("Nodes by first stone for %dx%d", g.Size, g.Size)
+	if first != "" {
+		p, err := grid.ParsePoint(first)
+		if err != nil {
+			return err
+		}
+		h = stats.SecondStoneHeatmap(g, p)
+		title = fmt.Sprintf("Nodes with first stone %v by second stone for %dx%d", p, g.Size, g.Size)
+	}
+	writeHeatmapASCII(os.Stdout, title, h)
+	if svgFile != "" {
+		return writeHeatmapSVG(svgFile, title, h)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/pruner"
+	"github.com/WillMorrison/pegboard-blog/sets"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// implementations implements the implementations subcommand, which lists the registered placers, pruners, separation sets, starting
+// points and solvers, with the options each supports.
+func implementations(args []string) {
+	fs := flag.NewFlagSet("implementations", flag.ExitOnError)
+	parseFlags(fs, fs.Name(), args)
+	writeImplementations(os.Stdout)
+}
+
+// placerOptions lists the options that the registered placer supports, and its largest grid size if it is limited.
+func placerOptions(reg placer.Registration) string {
+	var options []string
+	if reg.UsesSeparationSet {
+		options = append(options, "-separation_set")
+	}
+	if reg.UsesPruner {
+		options = append(options, "-pruner")
+	}
+	if reg.SupportsBound {
+		options = append(options, "-bound")
+	}
+	if reg.SupportsForced {
+		options = append(options, "-forced")
+	}
+	if reg.SupportsSeed {
+		options = append(options, "-seed")
+	}
+	if reg.MaxGridSize > 0 {
+		options = append(options, fmt.Sprintf("grids up to %dx%d", reg.MaxGridSize, reg.MaxGridSize))
+	}
+	return strings.Join(options, ", ")
+}
+
+// solverOptions lists the options that the registered solver supports.
+func solverOptions(reg solver.Registration) string {
+	var options []string
+	if reg.SupportsWorkers {
+		options = append(options, "-workers")
+	}
+	if reg.SupportsSplitDepth {
+		options = append(options, "-split_depth")
+	}
+	if reg.SupportsTimeSlice {
+		options = append(options, "-time_slice")
+	}
+	return strings.Join(options, ", ")
+}
+
+// writeImplementations writes every registered implementation by the flag that chooses it, with the options each placer and solver
+// supports. Implementations registered by packages that main imports are listed with the built in ones.
+func writeImplementations(w io.Writer) {
+	var b strings.Builder
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "-placer:")
+	for _, name := range placer.Names() {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, placerOptions(placer.Placers[name]))
+	}
+	fmt.Fprintln(tw, "-pruner:")
+	for _, name := range pruner.Names() {
+		fmt.Fprintf(tw, "  %s\n", name)
+	}
+	fmt.Fprintln(tw, "-separation_set:")
+	for _, name := range sets.SeparationSetNames() {
+		fmt.Fprintf(tw, "  %s\n", name)
+	}
+	fmt.Fprintln(tw, "-start:")
+	for _, name := range solver.StartingPointsNames() {
+		fmt.Fprintf(tw, "  %s\n", name)
+	}
+	fmt.Fprintln(tw, "-solver:")
+	for _, name := range solver.Names() {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, solverOptions(solver.Solvers[name]))
+	}
+	tw.Flush()
+	// Implementations without options would leave trailing spaces
+	for _, line := range strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n") {
+		fmt.Fprintln(w, strings.TrimRight(line, " "))
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+func TestWriteImplementations(t *testing.T) {
+	var b strings.Builder
+	writeImplementations(&b)
+	got := b.String()
+	for _, name := range append(placer.Names(), solver.Names()...) {
+		if !strings.Contains(got, "  "+name) {
+			t.Errorf("writeImplementations() doesn't list %s:\n%s", name, got)
+		}
+	}
+	for _, want := range []string{"  ordered_bitboard", "-bound, grids up to 8x8\n", "  fixed_depth", "-workers, -split_depth\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("writeImplementations() doesn't contain %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, " \n") {
+		t.Errorf("writeImplementations() has trailing spaces:\n%s", got)
+	}
+}
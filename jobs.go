@@ -0,0 +1,355 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// Job states, as reported by the jobs API.
+const (
+	JobRunning    = "running"
+	JobSolved     = "solved"
+	JobNoSolution = "no_solution"
+	JobCanceled   = "canceled"
+	JobFailed     = "failed"
+)
+
+// Defaults for the limits of serverConfig that are left zero.
+const (
+	defaultMaxJobs  = 4
+	defaultKeepJobs = 100
+)
+
+// errTooManyJobs is returned by jobQueue.submit when as many jobs as it runs at once are already running.
+var errTooManyJobs = errors.New("too many jobs running, wait for one to finish or cancel one")
+
+// jobRequest is the JSON body of a POST /jobs request. The placer and solver default to auto. StartFrom, if set, is a partial placement
+// to search only the completions of, as with -start_from. Results are reused from earlier jobs with the same size, strategies and
+// partial placement, unless NoCache is set.
+type jobRequest struct {
+	Size      uint8           `json:"size"`
+	Placer    string          `json:"placer,omitempty"`
+	Solver    string          `json:"solver,omitempty"`
+	StartFrom grid.Placements `json:"start_from,omitempty"`
+	NoCache   bool            `json:"no_cache,omitempty"`
+}
+
+// startingPoints describes where the request's search starts, for results: the partial placement, or the default starting points.
+func (req *jobRequest) startingPoints() string {
+	if len(req.StartFrom) > 0 {
+		return fmt.Sprint(req.StartFrom)
+	}
+	return solver.SingleOctantStartingPointsName
+}
+
+// jobProgress is the progress of a running job's search.
+type jobProgress struct {
+	Nodes      int64           `json:"nodes"`
+	TasksDone  int64           `json:"tasks_done"`
+	TasksTotal int64           `json:"tasks_total"`
+	Deepest    grid.Placements `json:"deepest"`
+}
+
+// jobStatus is the response to requests about a job.
+type jobStatus struct {
+	ID       string          `json:"id"`
+	Size     uint8           `json:"size"`
+	Placer   string          `json:"placer"`
+	Solver   string          `json:"solver"`
+	State    string          `json:"state"`
+	Started  time.Time       `json:"started"`
+	Duration string          `json:"duration"`
+	Progress jobProgress     `json:"progress"`
+	Solution grid.Placements `json:"solution,omitempty"`
+	Error    string          `json:"error,omitempty"`
+}
+
+// job is a search running in the background of the server.
+type job struct {
+	seq     int
+	id      string
+	req     jobRequest
+	stats   *solver.Stats
+	cancel  context.CancelFunc
+	started time.Time
+	// done is closed when the search finishes, after the fields below are set
+	done chan struct{}
+
+	// The fields below are set when the search finishes, and guarded by mu.
+	mu       sync.Mutex
+	state    string
+	ended    time.Time
+	solution grid.Placements
+	err      error
+}
+
+// status returns a snapshot of the job's status.
+func (j *job) status() jobStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	progress := j.stats.Progress()
+	s := jobStatus{
+		ID:       j.id,
+		Size:     j.req.Size,
+		Placer:   j.req.Placer,
+		Solver:   j.req.Solver,
+		State:    j.state,
+		Started:  j.started,
+		Progress: jobProgress{Nodes: progress.Nodes, TasksDone: progress.TasksDone, TasksTotal: progress.TasksTotal, Deepest: progress.Deepest},
+		Solution: j.solution,
+	}
+	end := j.ended
+	if j.state == JobRunning {
+		end = time.Now()
+	}
+	s.Duration = end.Sub(j.started).String()
+	if j.err != nil {
+		s.Error = j.err.Error()
+	}
+	return s
+}
+
+// jobQueue runs searches in the background for the jobs API. At most as many jobs as it has slots run at once, and it keeps the results
+// of the most recent keep finished jobs, for up to ttl if that isn't 0.
+type jobQueue struct {
+	// cache, if not nil, holds the results of earlier searches, which are shared between jobs
+	cache *solver.ResultCache
+	slots chan struct{}
+	keep  int
+	ttl   time.Duration
+
+	mu     sync.Mutex
+	nextID int
+	jobs   map[string]*job
+}
+
+func newJobQueue(cfg serverConfig) *jobQueue {
+	maxJobs, keep := cfg.MaxJobs, cfg.KeepJobs
+	if maxJobs <= 0 {
+		maxJobs = defaultMaxJobs
+	}
+	if keep <= 0 {
+		keep = defaultKeepJobs
+	}
+	return &jobQueue{cache: cfg.Cache, slots: make(chan struct{}, maxJobs), keep: keep, ttl: cfg.JobTTL, jobs: make(map[string]*job)}
+}
+
+// evictLocked removes the finished jobs that are older than the TTL, then the oldest finished jobs beyond those to keep. Running jobs
+// are never evicted. q.mu must be held.
+func (q *jobQueue) evictLocked(now time.Time) {
+	var finished []*job
+	for _, j := range q.jobs {
+		select {
+		case <-j.done:
+			finished = append(finished, j)
+		default:
+		}
+	}
+	sort.Slice(finished, func(a, b int) bool { return finished[a].seq > finished[b].seq })
+	for i, j := range finished {
+		j.mu.Lock()
+		expired := q.ttl > 0 && now.Sub(j.ended) > q.ttl
+		j.mu.Unlock()
+		if i >= q.keep || expired {
+			delete(q.jobs, j.id)
+		}
+	}
+}
+
+// builder returns the request's grid, and a Builder for its strategies that records statistics to stats, or an error if the size is
+// invalid.
+func (req *jobRequest) builder(stats *solver.Stats) (grid.Grid, *solver.Builder, error) {
+	if req.Size == 0 || req.Size > grid.MaxGridSize {
+		return grid.Grid{}, nil, fmt.Errorf("size must be between 1 and %d", grid.MaxGridSize)
+	}
+	if req.Placer == "" {
+		req.Placer = solver.AutoName
+	}
+	if req.Solver == "" {
+		req.Solver = solver.AutoName
+	}
+	g := grid.Grid{Size: req.Size}
+	builder := solver.NewBuilder().Grid(g).Placer(req.Placer).Solver(req.Solver).Stats(stats)
+	if len(req.StartFrom) > 0 {
+		builder.StartFrom(req.StartFrom)
+	}
+	return g, builder, nil
+}
+
+// build returns the request's grid, and a solver for it that records its statistics to stats and reuses the results in the cache, unless
+// NoCache is set. It fills in the strategies that auto chose, or returns an error if the request is invalid.
+func (req *jobRequest) build(cache *solver.ResultCache, stats *solver.Stats) (grid.Grid, solver.Solver, error) {
+	g, builder, err := req.builder(stats)
+	if err != nil {
+		return grid.Grid{}, nil, err
+	}
+	s, err := builder.Build()
+	if err != nil {
+		return grid.Grid{}, nil, err
+	}
+	// Report the strategies that auto chose
+	req.Placer, req.Solver = builder.PlacerName(), builder.SolverName()
+	if !req.NoCache {
+		s = solver.CachingSolver{Solver: s, Cache: cache, Strategy: req.Placer + " " + req.Solver, Prefix: req.StartFrom}
+	}
+	return g, s, nil
+}
+
+// submit starts a search for the request. It returns an error if the request is invalid, or errTooManyJobs if every slot is taken.
+func (q *jobQueue) submit(req jobRequest) (*job, error) {
+	stats := &solver.Stats{}
+	// Workers record their partial placements for the live view
+	stats.EnableWorkerStates()
+	g, s, err := req.build(q.cache, stats)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case q.slots <- struct{}{}:
+	default:
+		return nil, errTooManyJobs
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.mu.Lock()
+	q.nextID++
+	j := &job{seq: q.nextID, id: strconv.Itoa(q.nextID), req: req, stats: stats, cancel: cancel, started: time.Now(), done: make(chan struct{}), state: JobRunning}
+	q.jobs[j.id] = j
+	q.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		solution, err := s.SolveContext(ctx, g)
+		j.finish(solution, err)
+		// Free the slot before the job is seen to finish, so a job can be submitted as soon as one is canceled
+		<-q.slots
+		close(j.done)
+		q.mu.Lock()
+		q.evictLocked(time.Now())
+		q.mu.Unlock()
+	}()
+	return j, nil
+}
+
+// finish records the result of the job's search.
+func (j *job) finish(solution grid.Placements, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.ended = time.Now()
+	switch {
+	case err == nil:
+		solution.Sort()
+		j.state, j.solution = JobSolved, solution
+	case errors.Is(err, solver.ErrNoSolution):
+		j.state = JobNoSolution
+	case errors.Is(err, solver.ErrCanceled):
+		j.state = JobCanceled
+	default:
+		j.state, j.err = JobFailed, err
+	}
+}
+
+// get returns the job with the given ID, or nil if there is none or it was evicted.
+func (q *jobQueue) get(id string) *job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.evictLocked(time.Now())
+	return q.jobs[id]
+}
+
+// list returns the status of every job, oldest first.
+func (q *jobQueue) list() []jobStatus {
+	q.mu.Lock()
+	q.evictLocked(time.Now())
+	jobs := make([]*job, 0, len(q.jobs))
+	for _, j := range q.jobs {
+		jobs = append(jobs, j)
+	}
+	q.mu.Unlock()
+	sort.Slice(jobs, func(a, b int) bool { return jobs[a].seq < jobs[b].seq })
+	statuses := make([]jobStatus, len(jobs))
+	for i, j := range jobs {
+		statuses[i] = j.status()
+	}
+	return statuses
+}
+
+// writeJSON writes v as the JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// handleJobs serves the collection of jobs. POST starts a job from a JSON jobRequest, e.g. {"size": 12}, and responds with its
+// status, whose id is used to poll it, or a 503 status if too many jobs are running. GET lists every job that hasn't been evicted.
+func (q *jobQueue) handleJobs(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, q.list())
+	case http.MethodPost:
+		var req jobRequest
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid JSON request: %v", err), http.StatusBadRequest)
+			return
+		}
+		j, err := q.submit(req)
+		if errors.Is(err, errTooManyJobs) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Location", "/jobs/"+j.id)
+		writeJSON(w, http.StatusAccepted, j.status())
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleJob serves a single job at /jobs/{id}. GET returns its status, including progress while it runs and the solution once
+// found. DELETE cancels it if it is still running, and returns its status. /jobs/{id}/live is a WebSocket streaming the job's progress
+// and its workers' partial placements.
+func (q *jobQueue) handleJob(w http.ResponseWriter, r *http.Request) {
+	id, live := strings.CutSuffix(strings.TrimPrefix(r.URL.Path, "/jobs/"), "/live")
+	j := q.get(id)
+	if j == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if live {
+		liveUpdates(j, liveInterval).ServeHTTP(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, j.status())
+	case http.MethodDelete:
+		j.cancel()
+		// Wait for the search to stop, so the response shows the job canceled rather than still running
+		select {
+		case <-j.done:
+		case <-r.Context().Done():
+			return
+		}
+		writeJSON(w, http.StatusOK, j.status())
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+// jsonResult is the result of a search as printed by -json, for scripts and dashboards.
+type jsonResult struct {
+	Size           uint8  `json:"size"`
+	Placer         string `json:"placer"`
+	Solver         string `json:"solver"`
+	StartingPoints string `json:"starting_points"`
+	// Outcome is one of solved, no_solution, canceled, node_budget, timeout or error, as in run bundles
+	Outcome       string          `json:"outcome"`
+	Solution      grid.Placements `json:"solution,omitempty"`
+	Duration      string          `json:"duration"`
+	DurationNanos int64           `json:"duration_ns"`
+	Nodes         int64           `json:"nodes"`
+	Error         string          `json:"error,omitempty"`
+	// Metadata describes the run, for the results that a run writes about itself
+	Metadata *runMetadata `json:"metadata,omitempty"`
+}
+
+// newJSONResult describes the result of searching one grid size, from the given starting points.
+func newJSONResult(r batchResult, startingPoints string) jsonResult {
+	j := jsonResult{
+		Size: r.Size, Placer: r.Placer, Solver: r.Solver, StartingPoints: startingPoints,
+		Outcome: outcome(r.Err), Duration: r.Duration.String(), DurationNanos: r.Duration.Nanoseconds(), Nodes: r.Nodes,
+	}
+	if r.Err == nil {
+		j.Solution = r.Solution
+	} else {
+		j.Error = r.Err.Error()
+	}
+	return j
+}
+
+// writeJSONResult writes v, a jsonResult or a slice of them, as indented JSON.
+func writeJSONResult(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
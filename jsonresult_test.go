@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewJSONResult(t *testing.T) {
+	solution := grid.Placements{{Row: 0, Col: 0}, {Row: 0, Col: 1}}
+	tests := []struct {
+		name string
+		r    batchResult
+		want jsonResult
+	}{
+		{"solved",
+			batchResult{Size: 2, Placer: "ordered", Solver: "async", Solution: solution, Duration: time.Millisecond, Nodes: 1},
+			jsonResult{Size: 2, Placer: "ordered", Solver: "async", StartingPoints: "first_octant", Outcome: "solved", Solution: solution, Duration: "1ms", DurationNanos: 1e6, Nodes: 1},
+		},
+		{"interrupted",
+			batchResult{Size: 9, Placer: "ordered", Solver: "async", Solution: solution, Err: fmt.Errorf("%w: interrupt", solver.ErrCanceled), Duration: time.Second, Nodes: 5},
+			jsonResult{Size: 9, Placer: "ordered", Solver: "async", StartingPoints: "first_octant", Outcome: "canceled", Duration: "1s", DurationNanos: 1e9, Nodes: 5, Error: "search canceled: interrupt"},
+		},
+		{"failed",
+			batchResult{Size: 9, Err: errors.New("oops")},
+			jsonResult{Size: 9, StartingPoints: "first_octant", Outcome: "error", Duration: "0s", Error: "oops"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, newJSONResult(tt.r, "first_octant")); diff != "" {
+				t.Errorf("newJSONResult() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
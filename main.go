@@ -1,178 +1,173 @@
 package main
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
-	"log"
 	"os"
-	"runtime/pprof"
-	"runtime/trace"
-	"time"
+	"strconv"
+	"strings"
 
 	"github.com/WillMorrison/pegboard-blog/grid"
-	"github.com/WillMorrison/pegboard-blog/placer"
-	"github.com/WillMorrison/pegboard-blog/pruner"
-	"github.com/WillMorrison/pegboard-blog/sets"
 	"github.com/WillMorrison/pegboard-blog/solver"
-	"github.com/hashicorp/packer/command/enumflag"
-)
-
-const (
-	UnorderedStonePlacer                          = "unordered"
-	OrderedStonePlacer                            = "ordered"
-	OrderedNoAllocStonePlacer                     = "ordered_noalloc"
-	OrderedNoAllocPruningStonePlacer              = "ordered_noalloc_pruning"
-	OrderedNoAllocOpportunisticPruningStonePlacer = "ordered_noalloc_opportunistic_pruning"
-
-	EmptyStartingPoint         = "empty_grid"
-	SingleOctantStartingPoints = "first_octant"
-
-	MapSeparationSet = "map"
-	BitSeparationSet = "array"
-
-	RuntimePruner     = "runtime"
-	PrecomputedPruner = "precomputed"
-
-	SingleThreadedSolver = "single_thread"
-	AsyncSolver          = "async"
-	AsyncSplittingSolver = "async_splitting"
 )
 
 func main() {
-	size := flag.Uint("size", 7, "the side length of square grid to search for solutions on")
-
-	var cpuprofile = flag.String("cpuprofile", "", "write cpu profile to file")
-	var memprofile = flag.String("memprofile", "", "write memory profile to this file")
-	var tracefile = flag.String("trace", "", "write trace to this file")
-
-	separationSet := BitSeparationSet
-	flag.Var(enumflag.New(&separationSet, MapSeparationSet, BitSeparationSet), "separation_set", "SeparationSet implementation to use")
-
-	prunerImpl := PrecomputedPruner
-	flag.Var(enumflag.New(&prunerImpl, RuntimePruner, PrecomputedPruner), "pruner", "Pruner implementation to use")
-
-	stonePlacer := OrderedNoAllocStonePlacer
-	flag.Var(enumflag.New(&stonePlacer, UnorderedStonePlacer, OrderedStonePlacer, OrderedNoAllocStonePlacer, OrderedNoAllocPruningStonePlacer, OrderedNoAllocOpportunisticPruningStonePlacer), "placer", "StonePlacer implementation to use")
-
-	startingPoint := SingleOctantStartingPoints
-	flag.Var(enumflag.New(&startingPoint, EmptyStartingPoint, SingleOctantStartingPoints), "start", "Starting point for the search")
-
-	solverImpl := AsyncSolver
-	flag.Var(enumflag.New(&solverImpl, SingleThreadedSolver, AsyncSolver, AsyncSplittingSolver), "solver", "Solver implementation to use")
-
-	flag.Parse()
-
-	if *size > grid.MaxGridSize {
-		log.Fatal("No solutions exist for 15x15 or larger grids. Not searching.")
-	}
-	g := grid.Grid{Size: uint8(*size)}
-
-	var startingPointsProvider solver.StartingPointsProvider
-	switch startingPoint {
-	case EmptyStartingPoint:
-		startingPointsProvider = solver.EmptyStartingPoint
-	case SingleOctantStartingPoints:
-		startingPointsProvider = solver.SingleOctantStartingPoints
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "solve":
+			os.Exit(solve(os.Args[2:]))
+		case "watch":
+			os.Exit(watch(os.Args[2:]))
+		case "warm-start":
+			os.Exit(warmStart(os.Args[2:]))
+		case "shard":
+			os.Exit(shard(os.Args[2:]))
+		case "merge":
+			mergeShards(os.Args[2:])
+			return
+		case "batch":
+			os.Exit(batch(os.Args[2:]))
+		case "verify":
+			verify(os.Args[2:])
+			return
+		case "enumerate":
+			enumerate(os.Args[2:])
+			return
+		case "classes":
+			classes(os.Args[2:])
+			return
+		case "solutions":
+			solutions(os.Args[2:])
+			return
+		case "estimate":
+			estimate(os.Args[2:])
+			return
+		case "frontier":
+			frontier(os.Args[2:])
+			return
+		case "census":
+			census(os.Args[2:])
+			return
+		case "animate":
+			animate(os.Args[2:])
+			return
+		case "bench":
+			if len(os.Args) > 2 && os.Args[2] == "history" {
+				benchHistory(os.Args[3:])
+				return
+			}
+			bench(os.Args[2:])
+			return
+		case "prove":
+			os.Exit(prove(os.Args[2:]))
+		case "serve":
+			serve(os.Args[2:])
+			return
+		case "grpc-serve":
+			grpcServe(os.Args[2:])
+			return
+		case "pipeline":
+			pipeline(os.Args[2:])
+			return
+		case "plan":
+			plan(os.Args[2:])
+			return
+		case "difftest":
+			difftest(os.Args[2:])
+			return
+		case "selftest":
+			selftest(os.Args[2:])
+			return
+		case "compare":
+			os.Exit(compare(os.Args[2:]))
+		case "bounds":
+			boundsReport(os.Args[2:])
+			return
+		case "implementations":
+			implementations(os.Args[2:])
+			return
+		}
 	}
+	// Without a subcommand, the arguments are the flags of solve, as they were before there were subcommands
+	os.Exit(solve(os.Args[1:]))
+}
 
-	var separationSetConstructor sets.SeparationSetConstructor
-	switch separationSet {
-	case MapSeparationSet:
-		separationSetConstructor = sets.NewMapSeparationSet
-	case BitSeparationSet:
-		separationSetConstructor = sets.NewBitArraySeparationSet
-	}
+// solve implements the solve subcommand, which searches for a solution and reports it. It returns the exit code for the outcome of the
+// search, after the deferred reports are written.
+func solve(args []string) int {
+	fs := flag.NewFlagSet("solve", flag.ExitOnError)
+	sf := addSearchFlags(fs, false)
+	rf := addResultFlags(fs)
+	fs.Usage = func() { printUsage(fs) }
+	parseFlags(fs, fs.Name(), args)
+	rf.output.setup()
+
+	r := sf.start()
+	defer r.close()
+	solution, duration, err := r.search()
+	return rf.report(r, solution, duration, err)
+}
 
-	var prunerConstructor func(grid.Grid) pruner.Pruner
-	switch prunerImpl {
-	case RuntimePruner:
-		prunerConstructor = pruner.NewRuntimePruner
-	case PrecomputedPruner:
-		prunerConstructor = pruner.NewPrecomputedPruner
+// readPlacements reads one Placements per line from a file, skipping blank lines and lines starting with #.
+// Anything after a tab on a line is ignored, so files with extra columns, such as frontiers, can be read.
+func readPlacements(filename string) ([]grid.Placements, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
 	}
-
-	var stonePlacerConstructor placer.StonePlacerConstructor
-	switch stonePlacer {
-	case UnorderedStonePlacer:
-		stonePlacerConstructor = placer.UnorderedStonePlacerProvider{
-			SeparationSetConstructor: separationSetConstructor,
-			PointSetConstructor:      sets.NewMapPointSet}
-	case OrderedStonePlacer:
-		stonePlacerConstructor = placer.OrderedStonePlacerProvider{
-			SeparationSetConstructor: separationSetConstructor}
-	case OrderedNoAllocStonePlacer:
-		stonePlacerConstructor = placer.OrderedNoAllocStonePlacerProvider{}
-	case OrderedNoAllocPruningStonePlacer:
-		stonePlacerConstructor = placer.OrderedPruningNoAllocStonePlacerProvider{
-			PrunerConstructor: prunerConstructor,
+	defer f.Close()
+
+	var placements []grid.Placements
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
 		}
-	case OrderedNoAllocOpportunisticPruningStonePlacer:
-		stonePlacerConstructor = placer.OrderedOpportunisticPruningNoAllocStonePlacerProvider{
-			PrunerConstructor: prunerConstructor,
+		text, _, _ = strings.Cut(text, "\t")
+		p, err := grid.ParsePlacements(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", filename, line, err)
 		}
+		placements = append(placements, p)
 	}
+	return placements, scanner.Err()
+}
 
-	var s solver.Solver
-	switch solverImpl {
-	case SingleThreadedSolver:
-		s = solver.SingleThreadedSolver{
-			StartingPointsProvider: startingPointsProvider,
-			StonePlacerConstructor: stonePlacerConstructor,
-		}
-	case AsyncSolver:
-		s = solver.AsyncSolver{
-			StartingPointsProvider: startingPointsProvider,
-			StonePlacerConstructor: stonePlacerConstructor,
-		}
-	case AsyncSplittingSolver:
-		s = solver.AsyncSplittingSolver{
-			StartingPointsProvider: startingPointsProvider,
-			StonePlacerConstructor: stonePlacerConstructor,
-		}
+// readWeights reads lines of a placements followed by a tab and the expected tree size below it, optionally followed by other text.
+// Blank lines and lines starting with # are skipped.
+func readWeights(filename string) (map[string]float64, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
 	}
-
-	if *cpuprofile != "" {
-		f, err := os.Create(*cpuprofile)
-		if err != nil {
-			log.Fatal(err)
+	defer f.Close()
+
+	weights := make(map[string]float64)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
 		}
-		pprof.StartCPUProfile(f)
-		defer pprof.StopCPUProfile()
-	}
-
-	if *tracefile != "" {
-		f, err := os.Create(*tracefile)
-		if err != nil {
-			log.Fatal(err)
+		fields := strings.Split(text, "\t")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected a placements and a weight separated by a tab", filename, line)
 		}
-		trace.Start(f)
-		defer trace.Stop()
-	}
-
-	startTime := time.Now()
-	solution, err := s.Solve(g)
-	duration := time.Since(startTime)
-
-	if *memprofile != "" {
-		f, err := os.Create(*memprofile)
-		defer f.Close()
+		p, err := grid.ParsePlacements(fields[0])
 		if err != nil {
-			log.Fatal(err)
+			return nil, fmt.Errorf("%s:%d: %w", filename, line, err)
 		}
-		err = pprof.WriteHeapProfile(f)
+		weightFields := strings.Fields(fields[1])
+		if len(weightFields) == 0 {
+			return nil, fmt.Errorf("%s:%d: missing weight after the placements", filename, line)
+		}
+		weight, err := strconv.ParseFloat(weightFields[0], 64)
 		if err != nil {
-			log.Fatal(err)
+			return nil, fmt.Errorf("%s:%d: %w", filename, line, err)
 		}
+		weights[solver.WeightKey(p)] = weight
 	}
-
-	if err != nil {
-		fmt.Printf("Search ended with no solution found for %+v in %v\n", g, duration)
-		return
-	}
-	solution.Sort()
-	if err := grid.CheckValidSolution(g, solution); err == nil {
-		fmt.Printf("Solution found for %+v in %v: %v\n", g, duration, solution)
-	} else {
-		fmt.Printf("We found a solution %v for %+v in %v but it was invalid! %s\n", solution, g, duration, err)
-	}
+	return weights, scanner.Err()
 }
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+func TestReadWeights(t *testing.T) {
+	tests := []struct {
+		name, contents string
+		want           map[string]float64
+		wantErr        bool
+	}{
+		{
+			name:     "weights",
+			contents: "# starting point\ttree size\n[A0]\t120 estimated\n\n[A1]\t30\n",
+			want:     map[string]float64{"A0": 120, "A1": 30},
+		},
+		{name: "missing weight", contents: "[A0]\t\tnote\n", wantErr: true},
+		{name: "blank weight", contents: "[A1]\t30\n[A0]\t   \tnote\n", wantErr: true},
+		{name: "missing tab", contents: "[A0] 120\n", wantErr: true},
+		{name: "malformed weight", contents: "[A0]\tmany\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "weights.tsv")
+			if err := os.WriteFile(filename, []byte(tt.contents), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := readWeights(filename)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readWeights() error = %v, want error %t", err, tt.wantErr)
+			}
+			for name, want := range tt.want {
+				p, err := grid.ParsePlacements("[" + name + "]")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if w := got[solver.WeightKey(p)]; w != want {
+					t.Errorf("weight of %s = %v, want %v", name, w, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// Board formats for -markdown_board.
+const (
+	ASCIIBoardFormat = "ascii"
+	SVGBoardFormat   = "svg"
+)
+
+// markdownResult is what a Markdown export describes: the result of a search and the strategies that found it.
+type markdownResult struct {
+	Grid     grid.Grid
+	Solution grid.Placements
+	Err      error
+	Duration time.Duration
+	Nodes    int64
+	// Strategy lists the settings of the search, such as the placer and solver, in the order they are shown
+	Strategy [][2]string
+	// Board is the format of the board diagram, ASCIIBoardFormat or SVGBoardFormat
+	Board string
+}
+
+// writeBoardASCII draws the board in the style of the heatmap, with a ● for each stone and a · for each empty cell.
+func writeBoardASCII(w io.Writer, g grid.Grid, p grid.Placements) {
+	io.WriteString(w, p.Render(g))
+}
+
+// writeBoardSVG draws the board as an SVG image, with the row letters and column numbers around it and a disc for each stone.
+func writeBoardSVG(w io.Writer, g grid.Grid, p grid.Placements) {
+	const cell = 32
+	size := (int(g.Size) + 1) * cell
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12" text-anchor="middle">`+"\n", size, size)
+	fmt.Fprintf(w, "<title>%dx%d board with stones at %v</title>\n", g.Size, g.Size, p)
+	for i := 0; i < int(g.Size); i++ {
+		fmt.Fprintf(w, `<text x="%d" y="%d">%d</text>`+"\n", (i+1)*cell+cell/2, cell/2+4, i)
+		fmt.Fprintf(w, `<text x="%d" y="%d">%c</text>`+"\n", cell/2, (i+1)*cell+cell/2+4, grid.Point{Row: uint8(i)}.String()[0])
+	}
+	for r := 0; r < int(g.Size); r++ {
+		for c := 0; c < int(g.Size); c++ {
+			fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="#fff" stroke="#999"/>`+"\n", (c+1)*cell, (r+1)*cell, cell, cell)
+		}
+	}
+	for _, s := range p {
+		fmt.Fprintf(w, `<circle cx="%d" cy="%d" r="%d" fill="#333"/>`+"\n", (int(s.Col)+1)*cell+cell/2, (int(s.Row)+1)*cell+cell/2, cell/3)
+	}
+	fmt.Fprintln(w, "</svg>")
+}
+
+// writeMarkdown writes the result as a Markdown fragment for a blog post: a heading, the board, the stones and the separations
+// between them, and a table of the strategies and timing.
+func writeMarkdown(w io.Writer, r markdownResult) {
+	fmt.Fprintf(w, "### %dx%d grid\n\n", r.Grid.Size, r.Grid.Size)
+	switch {
+	case r.Err == nil:
+		fmt.Fprintf(w, "Solution found in %v after %d placements.\n\n", r.Duration.Round(time.Microsecond), r.Nodes)
+		if r.Board == SVGBoardFormat {
+			// Markdown renderers pass HTML blocks through until the next blank line, which the image doesn't contain
+			writeBoardSVG(w, r.Grid, r.Solution)
+		} else {
+			fmt.Fprintln(w, "```")
+			writeBoardASCII(w, r.Grid, r.Solution)
+			fmt.Fprintln(w, "```")
+		}
+		fmt.Fprintln(w)
+		stones := make([]string, len(r.Solution))
+		for i, s := range r.Solution {
+			stones[i] = s.String()
+		}
+		fmt.Fprintf(w, "Stones: `%s`\n\n", strings.Join(stones, " "))
+		fmt.Fprintln(w, "| Stones | Squared separation |")
+		fmt.Fprintln(w, "| --- | ---: |")
+		for _, sp := range r.Solution.SeparationPairs() {
+			fmt.Fprintf(w, "| %v–%v | %d |\n", sp.Pair[0], sp.Pair[1], sp.Separation)
+		}
+	case errors.Is(r.Err, solver.ErrNoSolution):
+		fmt.Fprintf(w, "No solution exists: the search tried every placement in %v, making %d placements.\n", r.Duration.Round(time.Microsecond), r.Nodes)
+	default:
+		fmt.Fprintf(w, "The search stopped after %v and %d placements without a result: %v.\n", r.Duration.Round(time.Microsecond), r.Nodes, r.Err)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "| Setting | Value |")
+	fmt.Fprintln(w, "| --- | --- |")
+	for _, s := range r.Strategy {
+		fmt.Fprintf(w, "| %s | %s |\n", s[0], s[1])
+	}
+}
+
+// writeMarkdownFile writes the Markdown export to the file, or to stdout if it is "-".
+func writeMarkdownFile(filename string, r markdownResult) error {
+	if filename == "-" {
+		writeMarkdown(os.Stdout, r)
+		return nil
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	writeMarkdown(f, r)
+	return f.Close()
+}
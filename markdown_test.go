@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+func TestWriteMarkdown(t *testing.T) {
+	solution := grid.Placements{{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 1, Col: 2}}
+	tests := []struct {
+		name   string
+		result markdownResult
+		want   []string
+	}{
+		{"ascii",
+			markdownResult{Grid: grid.Grid{Size: 3}, Solution: solution, Duration: time.Millisecond, Nodes: 6, Board: ASCIIBoardFormat, Strategy: [][2]string{{"Placer", "ordered_noalloc"}}},
+			[]string{
+				"### 3x3 grid\n",
+				"Solution found in 1ms after 6 placements.",
+				"```\n   0 1 2\nA  ● ● ·\nB  · · ●\nC  · · ·\n```\n",
+				"Stones: `A0 A1 B2`",
+				"| A0–A1 | 1 |\n| A1–B2 | 2 |\n| A0–B2 | 5 |\n",
+				"| Placer | ordered_noalloc |\n",
+			},
+		},
+		{"svg",
+			markdownResult{Grid: grid.Grid{Size: 3}, Solution: solution, Board: SVGBoardFormat},
+			[]string{"<svg ", `<circle cx="112" cy="80" r="10" fill="#333"/>`, "</svg>\n\nStones: `A0 A1 B2`"},
+		},
+		{"no solution",
+			markdownResult{Grid: grid.Grid{Size: 8}, Err: fmt.Errorf("wrapped: %w", solver.ErrNoSolution), Duration: time.Second, Nodes: 1000},
+			[]string{"### 8x8 grid\n", "No solution exists: the search tried every placement in 1s, making 1000 placements."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			writeMarkdown(&b, tt.result)
+			for _, want := range tt.want {
+				if !strings.Contains(b.String(), want) {
+					t.Errorf("writeMarkdown() wrote\n%s\nwhich doesn't contain %q", b.String(), want)
+				}
+			}
+			if strings.Contains(b.String(), "\n\n\n") {
+				t.Errorf("writeMarkdown() wrote\n%s\nwith more than one blank line in a row", b.String())
+			}
+		})
+	}
+}
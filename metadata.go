@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// runMetadata describes the binary, machine and configuration of a run, so that results collected over months can be interpreted and
+// reproduced. It is embedded in the structured results.
+type runMetadata struct {
+	// Version is the module version of the binary, which is (devel) unless it was installed with go install at a version, and Commit
+	// the git commit it was built from, if it was built in a git checkout. Modified is whether the checkout had uncommitted changes.
+	Version    string `json:"version"`
+	Commit     string `json:"commit,omitempty"`
+	Modified   bool   `json:"modified,omitempty"`
+	GoVersion  string `json:"go_version"`
+	Hostname   string `json:"hostname"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	// Config is the effective value of every flag of the subcommand, whether set on the command line, by the environment, by the config
+	// file or defaulted
+	Config map[string]string `json:"config"`
+}
+
+// newRunMetadata returns the metadata of a run with the flags of the flag set. Call it once the flags are parsed and GOMAXPROCS is set.
+func newRunMetadata(fs *flag.FlagSet) *runMetadata {
+	m := &runMetadata{GoVersion: runtime.Version(), GOMAXPROCS: runtime.GOMAXPROCS(0), Config: make(map[string]string)}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		m.Version = info.Main.Version
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				m.Commit = s.Value
+			case "vcs.modified":
+				m.Modified = s.Value == "true"
+			}
+		}
+	}
+	// An unknown hostname is left empty rather than failing the run
+	m.Hostname, _ = os.Hostname()
+	fs.VisitAll(func(f *flag.Flag) { m.Config[f.Name] = f.Value.String() })
+	return m
+}
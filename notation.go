@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+// Notations for -notation, which prints solutions for different tools.
+const (
+	// AlgebraicNotation lists the stones by row letter and column number, e.g. [A0 B3 C1], as everywhere else in the program
+	AlgebraicNotation = "algebraic"
+	// TupleNotation lists the stones as zero based (row,col) tuples, e.g. (0,0) (1,3) (2,1)
+	TupleNotation = "tuples"
+	// MatrixNotation draws the grid as rows of 0s and 1s, with a 1 for each stone, one row per line
+	MatrixNotation = "matrix"
+	// CompactNotation lists the columns of the stones in each row as hexadecimal digits, with rows separated by / and - for a row
+	// without stones, e.g. 0/3/1
+	CompactNotation = "compact"
+)
+
+// formatSolution returns the placements in the notation. The stones are listed in sorted order.
+func formatSolution(notation string, g grid.Grid, p grid.Placements) string {
+	sorted := append(grid.Placements(nil), p...)
+	sorted.Sort()
+	var b strings.Builder
+	switch notation {
+	case TupleNotation:
+		for i, s := range sorted {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			fmt.Fprintf(&b, "(%d,%d)", s.Row, s.Col)
+		}
+	case MatrixNotation:
+		for row := uint8(0); row < g.Size; row++ {
+			if row > 0 {
+				b.WriteByte('\n')
+			}
+			for col := uint8(0); col < g.Size; col++ {
+				if slices.Contains(sorted, grid.Point{Row: row, Col: col}) {
+					b.WriteByte('1')
+				} else {
+					b.WriteByte('0')
+				}
+			}
+		}
+	case CompactNotation:
+		for row := uint8(0); row < g.Size; row++ {
+			if row > 0 {
+				b.WriteByte('/')
+			}
+			empty := true
+			for _, s := range sorted {
+				if s.Row == row {
+					b.WriteString(strconv.FormatUint(uint64(s.Col), 16))
+					empty = false
+				}
+			}
+			if empty {
+				b.WriteByte('-')
+			}
+		}
+	default:
+		return fmt.Sprint(sorted)
+	}
+	return b.String()
+}
+
+// solutionSuffix returns the placements in the notation for the end of a line of text: after a space, or for the matrix notation, on the
+// lines after it.
+func solutionSuffix(notation string, g grid.Grid, p grid.Placements) string {
+	if notation == MatrixNotation {
+		return "\n" + formatSolution(notation, g, p)
+	}
+	return " " + formatSolution(notation, g, p)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+func TestFormatSolution(t *testing.T) {
+	g := grid.Grid{Size: 6}
+	// Unsorted, to check that every notation sorts the stones
+	p := grid.Placements{{Row: 5, Col: 5}, {Row: 0, Col: 1}, {Row: 0, Col: 0}, {Row: 1, Col: 3}, {Row: 3, Col: 5}, {Row: 5, Col: 2}}
+	tests := []struct {
+		notation string
+		want     string
+	}{
+		{AlgebraicNotation, "[A0 A1 B3 D5 F2 F5]"},
+		{TupleNotation, "(0,0) (0,1) (1,3) (3,5) (5,2) (5,5)"},
+		{MatrixNotation, "110000\n000100\n000000\n000001\n000000\n001001"},
+		{CompactNotation, "01/3/-/5/-/25"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.notation, func(t *testing.T) {
+			if got := formatSolution(tc.notation, g, p); got != tc.want {
+				t.Errorf("formatSolution(%q) = %q, want %q", tc.notation, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormatSolution_CompactHexColumns(t *testing.T) {
+	g := grid.Grid{Size: 12}
+	p := grid.Placements{{Row: 0, Col: 10}, {Row: 0, Col: 11}, {Row: 11, Col: 0}}
+	want := "ab/-/-/-/-/-/-/-/-/-/-/0"
+	if got := formatSolution(CompactNotation, g, p); got != want {
+		t.Errorf("formatSolution(compact) = %q, want %q", got, want)
+	}
+}
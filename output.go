@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+// Formats for -format, which writes the results of searches to -output.
+const (
+	TextOutputFormat = "text"
+	JSONOutputFormat = "json"
+	CSVOutputFormat  = "csv"
+	SVGOutputFormat  = "svg"
+)
+
+// resultFormatter writes the results of searches, one per grid size searched, in one format.
+type resultFormatter interface {
+	Format(w io.Writer, results []batchResult) error
+}
+
+// newResultFormatter returns the formatter for the format. The starting points are those the searches used, and metadata describes the
+// run to the formats that record it, if it isn't nil. A batch is the results of the batch subcommand, which formats with one value per result write as
+// a list even if it has only one result.
+func newResultFormatter(format, startingPoints string, metadata *runMetadata, batch bool) (resultFormatter, error) {
+	switch format {
+	case TextOutputFormat:
+		return textFormatter{}, nil
+	case JSONOutputFormat:
+		return jsonFormatter{startingPoints: startingPoints, metadata: metadata, batch: batch}, nil
+	case CSVOutputFormat:
+		return csvFormatter{startingPoints: startingPoints, metadata: metadata}, nil
+	case SVGOutputFormat:
+		return svgFormatter{}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", format)
+}
+
+// writeResults writes the results in the format to the named file, or stdout if filename is empty.
+func writeResults(filename, format, startingPoints string, metadata *runMetadata, batch bool, results []batchResult) error {
+	f, err := newResultFormatter(format, startingPoints, metadata, batch)
+	if err != nil {
+		return err
+	}
+	if filename == "" {
+		return f.Format(os.Stdout, results)
+	}
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := f.Format(out, results); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// textFormatter writes a table of the results, as printed by the batch subcommand.
+type textFormatter struct{}
+
+func (textFormatter) Format(w io.Writer, results []batchResult) error {
+	writeBatchSummary(w, results)
+	return nil
+}
+
+// jsonFormatter writes each result as a jsonResult, and a batch as a list of them. Each result has the run's metadata, so that it
+// describes itself when results are collected from many runs.
+type jsonFormatter struct {
+	startingPoints string
+	metadata       *runMetadata
+	batch          bool
+}
+
+func (f jsonFormatter) Format(w io.Writer, results []batchResult) error {
+	j := make([]jsonResult, len(results))
+	for i, r := range results {
+		j[i] = newJSONResult(r, f.startingPoints)
+		j[i].Metadata = f.metadata
+	}
+	if !f.batch && len(j) == 1 {
+		return writeJSONResult(w, j[0])
+	}
+	return writeJSONResult(w, j)
+}
+
+// csvFormatter writes a row for each result, with the fields of jsonResult as columns, and the solution's stones separated by spaces.
+// The metadata's columns other than the configuration follow, empty if there is no metadata.
+type csvFormatter struct {
+	startingPoints string
+	metadata       *runMetadata
+}
+
+func (f csvFormatter) Format(w io.Writer, results []batchResult) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"size", "placer", "solver", "starting_points", "outcome", "solution", "duration_ns", "nodes", "error", "version", "commit", "hostname", "gomaxprocs"})
+	var metadata []string
+	if m := f.metadata; m != nil {
+		metadata = []string{m.Version, m.Commit, m.Hostname, strconv.Itoa(m.GOMAXPROCS)}
+	} else {
+		metadata = make([]string, 4)
+	}
+	for _, r := range results {
+		j := newJSONResult(r, f.startingPoints)
+		cw.Write(append([]string{
+			strconv.Itoa(int(j.Size)), j.Placer, j.Solver, j.StartingPoints, j.Outcome, strings.Trim(fmt.Sprint(j.Solution), "[]"),
+			strconv.FormatInt(j.DurationNanos, 10), strconv.FormatInt(j.Nodes, 10), j.Error,
+		}, metadata...))
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing CSV: %w", err)
+	}
+	return nil
+}
+
+// svgFormatter draws the board of each result as an SVG image, as for -markdown_board svg. A single result is drawn on its own, and a
+// batch as a column of boards, each captioned with its outcome.
+type svgFormatter struct{}
+
+func (svgFormatter) Format(w io.Writer, results []batchResult) error {
+	if len(results) == 1 {
+		writeBoardSVG(w, grid.Grid{Size: results[0].Size}, results[0].Solution)
+		return nil
+	}
+	const cell, caption = 32, 24
+	// Wide enough for the captions
+	width, height := 320, 0
+	for _, r := range results {
+		width = max(width, (int(r.Size)+1)*cell)
+		height += caption + (int(r.Size)+1)*cell
+	}
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="14">`+"\n", width, height)
+	y := 0
+	for _, r := range results {
+		fmt.Fprintf(w, `<text x="4" y="%d">%dx%d: %s in %v after %d placements</text>`+"\n", y+caption-6, r.Size, r.Size, outcome(r.Err), r.Duration.Round(time.Microsecond), r.Nodes)
+		fmt.Fprintf(w, `<g transform="translate(0,%d)">`+"\n", y+caption)
+		writeBoardSVG(w, grid.Grid{Size: r.Size}, r.Solution)
+		fmt.Fprintln(w, "</g>")
+		y += caption + (int(r.Size)+1)*cell
+	}
+	fmt.Fprintln(w, "</svg>")
+	return nil
+}
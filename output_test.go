@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestResultFormatters(t *testing.T) {
+	solved := batchResult{Size: 2, Placer: "ordered", Solver: "async", Solution: grid.Placements{{Row: 0, Col: 0}, {Row: 0, Col: 1}}, Duration: time.Millisecond, Nodes: 1}
+	none := batchResult{Size: 8, Placer: "ordered", Solver: "async", Err: solver.ErrNoSolution, Duration: time.Second, Nodes: 9}
+	tests := []struct {
+		name, format string
+		batch        bool
+		results      []batchResult
+		wantPrefix   string
+	}{
+		{"csv", CSVOutputFormat, false, []batchResult{solved, none}, `size,placer,solver,starting_points,outcome,solution,duration_ns,nodes,error,version,commit,hostname,gomaxprocs
+2,ordered,async,first_octant,solved,A0 A1,1000000,1,,v1.2.0,abc123,host,4
+8,ordered,async,first_octant,no_solution,,1000000000,9,no solutions exist,v1.2.0,abc123,host,4
+`},
+		{"json", JSONOutputFormat, false, []batchResult{solved}, "{\n  \"size\": 2,"},
+		{"json batch", JSONOutputFormat, true, []batchResult{solved}, "[\n  {\n    \"size\": 2,"},
+		{"json metadata", JSONOutputFormat, false, []batchResult{none}, `{
+  "size": 8,
+  "placer": "ordered",
+  "solver": "async",
+  "starting_points": "first_octant",
+  "outcome": "no_solution",
+  "duration": "1s",
+  "duration_ns": 1000000000,
+  "nodes": 9,
+  "error": "no solutions exist",
+  "metadata": {
+    "version": "v1.2.0",
+    "commit": "abc123",
+    "go_version": "go1.22",
+    "hostname": "host",
+    "gomaxprocs": 4,
+    "config": {
+      "size": "8"
+    }
+  }
+}`},
+		{"svg", SVGOutputFormat, false, []batchResult{solved}, `<svg xmlns="http://www.w3.org/2000/svg" width="96" height="96"`},
+		{"text", TextOutputFormat, true, []batchResult{solved, none}, "  size  result"},
+	}
+	metadata := &runMetadata{Version: "v1.2.0", Commit: "abc123", GoVersion: "go1.22", Hostname: "host", GOMAXPROCS: 4, Config: map[string]string{"size": "8"}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := newResultFormatter(tt.format, "first_octant", metadata, tt.batch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			if err := f.Format(&b, tt.results); err != nil {
+				t.Fatal(err)
+			}
+			if got := b.String(); !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("Format() output mismatch (-want +got):\n%s", cmp.Diff(tt.wantPrefix, got))
+			}
+		})
+	}
+}
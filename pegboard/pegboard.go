@@ -0,0 +1,82 @@
+// Package pegboard solves the pegboard problem with sensible default strategies, for callers that don't want to choose between the
+// placer, pruner and solver implementations: place N stones on an NxN grid so that no two pairs of stones are the same distance apart.
+package pegboard
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// newGrid returns the grid of the given size, or an error if no grid of that size can be searched.
+func newGrid(size int) (grid.Grid, error) {
+	if size < 1 || size > grid.MaxGridSize {
+		return grid.Grid{}, fmt.Errorf("grid size %d is not between 1 and %d", size, grid.MaxGridSize)
+	}
+	return grid.Grid{Size: uint8(size)}, nil
+}
+
+// builderFor returns a builder for the fastest placer and solver for the grid.
+func builderFor(g grid.Grid) *solver.Builder {
+	return solver.NewBuilder().Grid(g).Placer(solver.AutoName).Solver(solver.AutoName)
+}
+
+// Solve returns a solution for the grid of the given size, or an error if there is none, which matches solver.ErrNoSolution with errors.Is.
+func Solve(size int) (grid.Placements, error) {
+	return SolveContext(context.Background(), size)
+}
+
+// SolveContext is like Solve, but stops searching when the context is done and returns an error matching both the context's error and
+// solver.ErrCanceled or solver.ErrTimeout.
+// Grids larger than 8x8 are searched using every CPU Go can use, which takes seconds for 9x9, and much longer for larger grids.
+func SolveContext(ctx context.Context, size int) (grid.Placements, error) {
+	g, err := newGrid(size)
+	if err != nil {
+		return nil, err
+	}
+	s, err := builderFor(g).Build()
+	if err != nil {
+		return nil, err
+	}
+	solution, err := s.SolveContext(ctx, g)
+	if err != nil {
+		return nil, err
+	}
+	solution.Sort()
+	return solution, nil
+}
+
+// Enumerate returns every solution for the grid of the given size, including all the rotations and reflections of each, in order.
+// If the context is done, the search is aborted and the context's error is returned with the solutions found so far.
+func Enumerate(ctx context.Context, size int) ([]grid.Placements, error) {
+	g, err := newGrid(size)
+	if err != nil {
+		return nil, err
+	}
+	spc, err := builderFor(g).BuildPlacer()
+	if err != nil {
+		return nil, err
+	}
+	var solutions []grid.Placements
+	err = solver.Enumerate(ctx, g, solver.EmptyStartingPoint, spc, nil, func(p grid.Placements) bool {
+		solutions = append(solutions, p)
+		return true
+	})
+	return solutions, err
+}
+
+// Validate checks whether placements, in the format "A0 B1 B2", are a solution for the grid of the given size.
+// If they are not, the error describes the problem, and is a *grid.ValidationError if they could be parsed.
+func Validate(size int, placements string) error {
+	g, err := newGrid(size)
+	if err != nil {
+		return err
+	}
+	p, err := grid.ParsePlacements(placements)
+	if err != nil {
+		return err
+	}
+	return grid.CheckValidSolution(g, p)
+}
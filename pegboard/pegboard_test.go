@@ -0,0 +1,74 @@
+package pegboard
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+func TestSolve(t *testing.T) {
+	for _, size := range []int{1, 5, 7} {
+		solution, err := Solve(size)
+		if err != nil {
+			t.Fatalf("Solve(%d) error = %v", size, err)
+		}
+		if err := grid.CheckValidSolution(grid.Grid{Size: uint8(size)}, solution); err != nil {
+			t.Errorf("Solve(%d) = %v, want valid solution: %v", size, solution, err)
+		}
+	}
+}
+
+func TestSolve_InvalidSize(t *testing.T) {
+	for _, size := range []int{0, -1, grid.MaxGridSize + 1} {
+		if _, err := Solve(size); err == nil {
+			t.Errorf("Solve(%d) error = nil, want error", size)
+		}
+	}
+}
+
+func TestEnumerate(t *testing.T) {
+	solutions, err := Enumerate(context.Background(), 4)
+	if err != nil {
+		t.Fatalf("Enumerate(4) error = %v", err)
+	}
+	if len(solutions) == 0 {
+		t.Fatal("Enumerate(4) found no solutions")
+	}
+	for i, s := range solutions {
+		if err := grid.CheckValidSolution(grid.Grid{Size: 4}, s); err != nil {
+			t.Errorf("Enumerate(4) found invalid solution %v: %v", s, err)
+		}
+		if i > 0 && solutions[i-1].Compare(s) >= 0 {
+			t.Errorf("Enumerate(4) found %v after %v, want solutions in order", s, solutions[i-1])
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name           string
+		size           int
+		placements     string
+		wantErr        bool
+		wantConstraint grid.Constraint
+	}{
+		{name: "valid", size: 3, placements: "A0 B1 B2"},
+		{name: "unparseable", size: 3, placements: "A0 ??", wantErr: true},
+		{name: "repeated separation", size: 3, placements: "A0 A1 A2", wantErr: true, wantConstraint: grid.UniqueSeparationsConstraint},
+		{name: "invalid size", size: 0, placements: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.size, tt.placements)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate(%d, %q) error = %v, wantErr %t", tt.size, tt.placements, err, tt.wantErr)
+			}
+			var verr *grid.ValidationError
+			if tt.wantConstraint != "" && (!errors.As(err, &verr) || verr.Constraint != tt.wantConstraint) {
+				t.Errorf("Validate(%d, %q) error = %v, want %s constraint violated", tt.size, tt.placements, err, tt.wantConstraint)
+			}
+		})
+	}
+}
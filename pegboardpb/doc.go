@@ -0,0 +1,5 @@
+// Package pegboardpb is the gRPC API of the solver, served by the grpc-serve subcommand, for driving searches from other languages
+// and remote tools.
+package pegboardpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative pegboard.proto
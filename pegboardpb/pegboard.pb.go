@@ -0,0 +1,731 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: pegboard.proto
+
+package pegboardpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Outcome is how a search ended.
+type Outcome int32
+
+const (
+	Outcome_OUTCOME_UNSPECIFIED Outcome = 0
+	Outcome_OUTCOME_SOLVED      Outcome = 1
+	Outcome_OUTCOME_NO_SOLUTION Outcome = 2
+	Outcome_OUTCOME_CANCELED    Outcome = 3
+	Outcome_OUTCOME_TIMEOUT     Outcome = 4
+	Outcome_OUTCOME_ERROR       Outcome = 5
+)
+
+// Enum value maps for Outcome.
+var (
+	Outcome_name = map[int32]string{
+		0: "OUTCOME_UNSPECIFIED",
+		1: "OUTCOME_SOLVED",
+		2: "OUTCOME_NO_SOLUTION",
+		3: "OUTCOME_CANCELED",
+		4: "OUTCOME_TIMEOUT",
+		5: "OUTCOME_ERROR",
+	}
+	Outcome_value = map[string]int32{
+		"OUTCOME_UNSPECIFIED": 0,
+		"OUTCOME_SOLVED":      1,
+		"OUTCOME_NO_SOLUTION": 2,
+		"OUTCOME_CANCELED":    3,
+		"OUTCOME_TIMEOUT":     4,
+		"OUTCOME_ERROR":       5,
+	}
+)
+
+func (x Outcome) Enum() *Outcome {
+	p := new(Outcome)
+	*p = x
+	return p
+}
+
+func (x Outcome) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Outcome) Descriptor() protoreflect.EnumDescriptor {
+	return file_pegboard_proto_enumTypes[0].Descriptor()
+}
+
+func (Outcome) Type() protoreflect.EnumType {
+	return &file_pegboard_proto_enumTypes[0]
+}
+
+func (x Outcome) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Outcome.Descriptor instead.
+func (Outcome) EnumDescriptor() ([]byte, []int) {
+	return file_pegboard_proto_rawDescGZIP(), []int{0}
+}
+
+// SolveRequest chooses the grid and strategies of a search.
+type SolveRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The side length of the grid.
+	Size uint32 `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	// The names of the placer and solver, which default to auto, choosing the fastest for the grid.
+	Placer string `protobuf:"bytes,2,opt,name=placer,proto3" json:"placer,omitempty"`
+	Solver string `protobuf:"bytes,3,opt,name=solver,proto3" json:"solver,omitempty"`
+	// Search again rather than reusing the result of an earlier search with the same size and strategies.
+	NoCache bool `protobuf:"varint,4,opt,name=no_cache,json=noCache,proto3" json:"no_cache,omitempty"`
+	// The time between progress events of SolveStream, or 0 for one a second.
+	ProgressIntervalMs uint32 `protobuf:"varint,5,opt,name=progress_interval_ms,json=progressIntervalMs,proto3" json:"progress_interval_ms,omitempty"`
+	// Enumerate every solution rather than searching for one, sending each as a solution event of SolveStream. Enumerations search the
+	// whole tree with the placer, so the solver and no_cache are unused. Solve doesn't support it.
+	Enumerate bool `protobuf:"varint,6,opt,name=enumerate,proto3" json:"enumerate,omitempty"`
+	// The number of solutions an enumeration stops after, or 0 for all of them.
+	MaxSolutions uint32 `protobuf:"varint,7,opt,name=max_solutions,json=maxSolutions,proto3" json:"max_solutions,omitempty"`
+}
+
+func (x *SolveRequest) Reset() {
+	*x = SolveRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pegboard_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SolveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SolveRequest) ProtoMessage() {}
+
+func (x *SolveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pegboard_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SolveRequest.ProtoReflect.Descriptor instead.
+func (*SolveRequest) Descriptor() ([]byte, []int) {
+	return file_pegboard_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SolveRequest) GetSize() uint32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *SolveRequest) GetPlacer() string {
+	if x != nil {
+		return x.Placer
+	}
+	return ""
+}
+
+func (x *SolveRequest) GetSolver() string {
+	if x != nil {
+		return x.Solver
+	}
+	return ""
+}
+
+func (x *SolveRequest) GetNoCache() bool {
+	if x != nil {
+		return x.NoCache
+	}
+	return false
+}
+
+func (x *SolveRequest) GetProgressIntervalMs() uint32 {
+	if x != nil {
+		return x.ProgressIntervalMs
+	}
+	return 0
+}
+
+func (x *SolveRequest) GetEnumerate() bool {
+	if x != nil {
+		return x.Enumerate
+	}
+	return false
+}
+
+func (x *SolveRequest) GetMaxSolutions() uint32 {
+	if x != nil {
+		return x.MaxSolutions
+	}
+	return 0
+}
+
+// SolveResponse is the result of a search.
+type SolveResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Size uint32 `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`
+	// The strategies searched with, with auto resolved.
+	Placer  string  `protobuf:"bytes,2,opt,name=placer,proto3" json:"placer,omitempty"`
+	Solver  string  `protobuf:"bytes,3,opt,name=solver,proto3" json:"solver,omitempty"`
+	Outcome Outcome `protobuf:"varint,4,opt,name=outcome,proto3,enum=pegboard.v1.Outcome" json:"outcome,omitempty"`
+	// The cells of the stones of the solution, such as A0, sorted, if one was found.
+	Solution   []string `protobuf:"bytes,5,rep,name=solution,proto3" json:"solution,omitempty"`
+	DurationNs int64    `protobuf:"varint,6,opt,name=duration_ns,json=durationNs,proto3" json:"duration_ns,omitempty"`
+	// The number of stones placed during the search.
+	Nodes int64 `protobuf:"varint,7,opt,name=nodes,proto3" json:"nodes,omitempty"`
+	// The error, if the outcome is not solved.
+	Error string `protobuf:"bytes,8,opt,name=error,proto3" json:"error,omitempty"`
+	// The number of solutions an enumeration found. Its outcome is solved if there were any, and the solution is the first.
+	Solutions uint32 `protobuf:"varint,9,opt,name=solutions,proto3" json:"solutions,omitempty"`
+}
+
+func (x *SolveResponse) Reset() {
+	*x = SolveResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pegboard_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SolveResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SolveResponse) ProtoMessage() {}
+
+func (x *SolveResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pegboard_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SolveResponse.ProtoReflect.Descriptor instead.
+func (*SolveResponse) Descriptor() ([]byte, []int) {
+	return file_pegboard_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SolveResponse) GetSize() uint32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *SolveResponse) GetPlacer() string {
+	if x != nil {
+		return x.Placer
+	}
+	return ""
+}
+
+func (x *SolveResponse) GetSolver() string {
+	if x != nil {
+		return x.Solver
+	}
+	return ""
+}
+
+func (x *SolveResponse) GetOutcome() Outcome {
+	if x != nil {
+		return x.Outcome
+	}
+	return Outcome_OUTCOME_UNSPECIFIED
+}
+
+func (x *SolveResponse) GetSolution() []string {
+	if x != nil {
+		return x.Solution
+	}
+	return nil
+}
+
+func (x *SolveResponse) GetDurationNs() int64 {
+	if x != nil {
+		return x.DurationNs
+	}
+	return 0
+}
+
+func (x *SolveResponse) GetNodes() int64 {
+	if x != nil {
+		return x.Nodes
+	}
+	return 0
+}
+
+func (x *SolveResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *SolveResponse) GetSolutions() uint32 {
+	if x != nil {
+		return x.Solutions
+	}
+	return 0
+}
+
+// Progress is a snapshot of a search in progress.
+type Progress struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Nodes int64 `protobuf:"varint,1,opt,name=nodes,proto3" json:"nodes,omitempty"`
+	// The tasks the search was split into up front, which not every solver counts.
+	TasksDone  int64 `protobuf:"varint,2,opt,name=tasks_done,json=tasksDone,proto3" json:"tasks_done,omitempty"`
+	TasksTotal int64 `protobuf:"varint,3,opt,name=tasks_total,json=tasksTotal,proto3" json:"tasks_total,omitempty"`
+	// The cells of the deepest partial placement reached so far.
+	Deepest []string `protobuf:"bytes,4,rep,name=deepest,proto3" json:"deepest,omitempty"`
+}
+
+func (x *Progress) Reset() {
+	*x = Progress{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pegboard_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Progress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Progress) ProtoMessage() {}
+
+func (x *Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_pegboard_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Progress.ProtoReflect.Descriptor instead.
+func (*Progress) Descriptor() ([]byte, []int) {
+	return file_pegboard_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Progress) GetNodes() int64 {
+	if x != nil {
+		return x.Nodes
+	}
+	return 0
+}
+
+func (x *Progress) GetTasksDone() int64 {
+	if x != nil {
+		return x.TasksDone
+	}
+	return 0
+}
+
+func (x *Progress) GetTasksTotal() int64 {
+	if x != nil {
+		return x.TasksTotal
+	}
+	return 0
+}
+
+func (x *Progress) GetDeepest() []string {
+	if x != nil {
+		return x.Deepest
+	}
+	return nil
+}
+
+// Solution is a solution found by an enumeration.
+type Solution struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The cells of the stones, such as A0, sorted.
+	Cells []string `protobuf:"bytes,1,rep,name=cells,proto3" json:"cells,omitempty"`
+}
+
+func (x *Solution) Reset() {
+	*x = Solution{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pegboard_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Solution) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Solution) ProtoMessage() {}
+
+func (x *Solution) ProtoReflect() protoreflect.Message {
+	mi := &file_pegboard_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Solution.ProtoReflect.Descriptor instead.
+func (*Solution) Descriptor() ([]byte, []int) {
+	return file_pegboard_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Solution) GetCells() []string {
+	if x != nil {
+		return x.Cells
+	}
+	return nil
+}
+
+// SolveEvent is one message of SolveStream.
+type SolveEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Types that are assignable to Event:
+	//	*SolveEvent_Progress
+	//	*SolveEvent_Result
+	//	*SolveEvent_Solution
+	Event isSolveEvent_Event `protobuf_oneof:"event"`
+}
+
+func (x *SolveEvent) Reset() {
+	*x = SolveEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_pegboard_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SolveEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SolveEvent) ProtoMessage() {}
+
+func (x *SolveEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_pegboard_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SolveEvent.ProtoReflect.Descriptor instead.
+func (*SolveEvent) Descriptor() ([]byte, []int) {
+	return file_pegboard_proto_rawDescGZIP(), []int{4}
+}
+
+func (m *SolveEvent) GetEvent() isSolveEvent_Event {
+	if m != nil {
+		return m.Event
+	}
+	return nil
+}
+
+func (x *SolveEvent) GetProgress() *Progress {
+	if x, ok := x.GetEvent().(*SolveEvent_Progress); ok {
+		return x.Progress
+	}
+	return nil
+}
+
+func (x *SolveEvent) GetResult() *SolveResponse {
+	if x, ok := x.GetEvent().(*SolveEvent_Result); ok {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *SolveEvent) GetSolution() *Solution {
+	if x, ok := x.GetEvent().(*SolveEvent_Solution); ok {
+		return x.Solution
+	}
+	return nil
+}
+
+type isSolveEvent_Event interface {
+	isSolveEvent_Event()
+}
+
+type SolveEvent_Progress struct {
+	Progress *Progress `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type SolveEvent_Result struct {
+	Result *SolveResponse `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+type SolveEvent_Solution struct {
+	Solution *Solution `protobuf:"bytes,3,opt,name=solution,proto3,oneof"`
+}
+
+func (*SolveEvent_Progress) isSolveEvent_Event() {}
+
+func (*SolveEvent_Result) isSolveEvent_Event() {}
+
+func (*SolveEvent_Solution) isSolveEvent_Event() {}
+
+var File_pegboard_proto protoreflect.FileDescriptor
+
+var file_pegboard_proto_rawDesc = []byte{
+	0x0a, 0x0e, 0x70, 0x65, 0x67, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x12, 0x0b, 0x70, 0x65, 0x67, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x2e, 0x76, 0x31, 0x22, 0xe2, 0x01,
+	0x0a, 0x0c, 0x53, 0x6f, 0x6c, 0x76, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12,
+	0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x04, 0x73, 0x69,
+	0x7a, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x72, 0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f,
+	0x6c, 0x76, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x6f, 0x6c, 0x76,
+	0x65, 0x72, 0x12, 0x19, 0x0a, 0x08, 0x6e, 0x6f, 0x5f, 0x63, 0x61, 0x63, 0x68, 0x65, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x08, 0x52, 0x07, 0x6e, 0x6f, 0x43, 0x61, 0x63, 0x68, 0x65, 0x12, 0x30, 0x0a,
+	0x14, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x5f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x76,
+	0x61, 0x6c, 0x5f, 0x6d, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0d, 0x52, 0x12, 0x70, 0x72, 0x6f,
+	0x67, 0x72, 0x65, 0x73, 0x73, 0x49, 0x6e, 0x74, 0x65, 0x72, 0x76, 0x61, 0x6c, 0x4d, 0x73, 0x12,
+	0x1c, 0x0a, 0x09, 0x65, 0x6e, 0x75, 0x6d, 0x65, 0x72, 0x61, 0x74, 0x65, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x09, 0x65, 0x6e, 0x75, 0x6d, 0x65, 0x72, 0x61, 0x74, 0x65, 0x12, 0x23, 0x0a,
+	0x0d, 0x6d, 0x61, 0x78, 0x5f, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x0c, 0x6d, 0x61, 0x78, 0x53, 0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x22, 0x8a, 0x02, 0x0a, 0x0d, 0x53, 0x6f, 0x6c, 0x76, 0x65, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0d, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x6c, 0x61, 0x63,
+	0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x6c, 0x61, 0x63, 0x65, 0x72,
+	0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x6c, 0x76, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x73, 0x6f, 0x6c, 0x76, 0x65, 0x72, 0x12, 0x2e, 0x0a, 0x07, 0x6f, 0x75, 0x74, 0x63,
+	0x6f, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x14, 0x2e, 0x70, 0x65, 0x67, 0x62,
+	0x6f, 0x61, 0x72, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x4f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x52,
+	0x07, 0x6f, 0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x73, 0x6f, 0x6c, 0x75,
+	0x74, 0x69, 0x6f, 0x6e, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x73, 0x6f, 0x6c, 0x75,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x5f, 0x6e, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x64, 0x75, 0x72, 0x61, 0x74,
+	0x69, 0x6f, 0x6e, 0x4e, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x6e, 0x6f, 0x64, 0x65, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65,
+	0x72, 0x72, 0x6f, 0x72, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f,
+	0x72, 0x12, 0x1c, 0x0a, 0x09, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x09,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x09, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22,
+	0x7a, 0x0a, 0x08, 0x50, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6e,
+	0x6f, 0x64, 0x65, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x6e, 0x6f, 0x64, 0x65,
+	0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x61, 0x73, 0x6b, 0x73, 0x5f, 0x64, 0x6f, 0x6e, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x74, 0x61, 0x73, 0x6b, 0x73, 0x44, 0x6f, 0x6e, 0x65,
+	0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x61, 0x73, 0x6b, 0x73, 0x5f, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x74, 0x61, 0x73, 0x6b, 0x73, 0x54, 0x6f, 0x74, 0x61,
+	0x6c, 0x12, 0x18, 0x0a, 0x07, 0x64, 0x65, 0x65, 0x70, 0x65, 0x73, 0x74, 0x18, 0x04, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x07, 0x64, 0x65, 0x65, 0x70, 0x65, 0x73, 0x74, 0x22, 0x20, 0x0a, 0x08, 0x53,
+	0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x14, 0x0a, 0x05, 0x63, 0x65, 0x6c, 0x6c, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x63, 0x65, 0x6c, 0x6c, 0x73, 0x22, 0xb5, 0x01,
+	0x0a, 0x0a, 0x53, 0x6f, 0x6c, 0x76, 0x65, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x33, 0x0a, 0x08,
+	0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x70, 0x65, 0x67, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x50, 0x72, 0x6f,
+	0x67, 0x72, 0x65, 0x73, 0x73, 0x48, 0x00, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73,
+	0x73, 0x12, 0x34, 0x0a, 0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x1a, 0x2e, 0x70, 0x65, 0x67, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x2e, 0x76, 0x31, 0x2e,
+	0x53, 0x6f, 0x6c, 0x76, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x48, 0x00, 0x52,
+	0x06, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x12, 0x33, 0x0a, 0x08, 0x73, 0x6f, 0x6c, 0x75, 0x74,
+	0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x70, 0x65, 0x67, 0x62,
+	0x6f, 0x61, 0x72, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f, 0x6e,
+	0x48, 0x00, 0x52, 0x08, 0x73, 0x6f, 0x6c, 0x75, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x07, 0x0a, 0x05,
+	0x65, 0x76, 0x65, 0x6e, 0x74, 0x2a, 0x8d, 0x01, 0x0a, 0x07, 0x4f, 0x75, 0x74, 0x63, 0x6f, 0x6d,
+	0x65, 0x12, 0x17, 0x0a, 0x13, 0x4f, 0x55, 0x54, 0x43, 0x4f, 0x4d, 0x45, 0x5f, 0x55, 0x4e, 0x53,
+	0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x12, 0x0a, 0x0e, 0x4f, 0x55,
+	0x54, 0x43, 0x4f, 0x4d, 0x45, 0x5f, 0x53, 0x4f, 0x4c, 0x56, 0x45, 0x44, 0x10, 0x01, 0x12, 0x17,
+	0x0a, 0x13, 0x4f, 0x55, 0x54, 0x43, 0x4f, 0x4d, 0x45, 0x5f, 0x4e, 0x4f, 0x5f, 0x53, 0x4f, 0x4c,
+	0x55, 0x54, 0x49, 0x4f, 0x4e, 0x10, 0x02, 0x12, 0x14, 0x0a, 0x10, 0x4f, 0x55, 0x54, 0x43, 0x4f,
+	0x4d, 0x45, 0x5f, 0x43, 0x41, 0x4e, 0x43, 0x45, 0x4c, 0x45, 0x44, 0x10, 0x03, 0x12, 0x13, 0x0a,
+	0x0f, 0x4f, 0x55, 0x54, 0x43, 0x4f, 0x4d, 0x45, 0x5f, 0x54, 0x49, 0x4d, 0x45, 0x4f, 0x55, 0x54,
+	0x10, 0x04, 0x12, 0x11, 0x0a, 0x0d, 0x4f, 0x55, 0x54, 0x43, 0x4f, 0x4d, 0x45, 0x5f, 0x45, 0x52,
+	0x52, 0x4f, 0x52, 0x10, 0x05, 0x32, 0x8d, 0x01, 0x0a, 0x06, 0x53, 0x6f, 0x6c, 0x76, 0x65, 0x72,
+	0x12, 0x3e, 0x0a, 0x05, 0x53, 0x6f, 0x6c, 0x76, 0x65, 0x12, 0x19, 0x2e, 0x70, 0x65, 0x67, 0x62,
+	0x6f, 0x61, 0x72, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x6f, 0x6c, 0x76, 0x65, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x1a, 0x1a, 0x2e, 0x70, 0x65, 0x67, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x6f, 0x6c, 0x76, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x43, 0x0a, 0x0b, 0x53, 0x6f, 0x6c, 0x76, 0x65, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x12,
+	0x19, 0x2e, 0x70, 0x65, 0x67, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x6f,
+	0x6c, 0x76, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x17, 0x2e, 0x70, 0x65, 0x67,
+	0x62, 0x6f, 0x61, 0x72, 0x64, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x6f, 0x6c, 0x76, 0x65, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x30, 0x01, 0x42, 0x32, 0x5a, 0x30, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e,
+	0x63, 0x6f, 0x6d, 0x2f, 0x57, 0x69, 0x6c, 0x6c, 0x4d, 0x6f, 0x72, 0x72, 0x69, 0x73, 0x6f, 0x6e,
+	0x2f, 0x70, 0x65, 0x67, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x2d, 0x62, 0x6c, 0x6f, 0x67, 0x2f, 0x70,
+	0x65, 0x67, 0x62, 0x6f, 0x61, 0x72, 0x64, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_pegboard_proto_rawDescOnce sync.Once
+	file_pegboard_proto_rawDescData = file_pegboard_proto_rawDesc
+)
+
+func file_pegboard_proto_rawDescGZIP() []byte {
+	file_pegboard_proto_rawDescOnce.Do(func() {
+		file_pegboard_proto_rawDescData = protoimpl.X.CompressGZIP(file_pegboard_proto_rawDescData)
+	})
+	return file_pegboard_proto_rawDescData
+}
+
+var file_pegboard_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pegboard_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_pegboard_proto_goTypes = []any{
+	(Outcome)(0),          // 0: pegboard.v1.Outcome
+	(*SolveRequest)(nil),  // 1: pegboard.v1.SolveRequest
+	(*SolveResponse)(nil), // 2: pegboard.v1.SolveResponse
+	(*Progress)(nil),      // 3: pegboard.v1.Progress
+	(*Solution)(nil),      // 4: pegboard.v1.Solution
+	(*SolveEvent)(nil),    // 5: pegboard.v1.SolveEvent
+}
+var file_pegboard_proto_depIdxs = []int32{
+	0, // 0: pegboard.v1.SolveResponse.outcome:type_name -> pegboard.v1.Outcome
+	3, // 1: pegboard.v1.SolveEvent.progress:type_name -> pegboard.v1.Progress
+	2, // 2: pegboard.v1.SolveEvent.result:type_name -> pegboard.v1.SolveResponse
+	4, // 3: pegboard.v1.SolveEvent.solution:type_name -> pegboard.v1.Solution
+	1, // 4: pegboard.v1.Solver.Solve:input_type -> pegboard.v1.SolveRequest
+	1, // 5: pegboard.v1.Solver.SolveStream:input_type -> pegboard.v1.SolveRequest
+	2, // 6: pegboard.v1.Solver.Solve:output_type -> pegboard.v1.SolveResponse
+	5, // 7: pegboard.v1.Solver.SolveStream:output_type -> pegboard.v1.SolveEvent
+	6, // [6:8] is the sub-list for method output_type
+	4, // [4:6] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_pegboard_proto_init() }
+func file_pegboard_proto_init() {
+	if File_pegboard_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_pegboard_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*SolveRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pegboard_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*SolveResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pegboard_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*Progress); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pegboard_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*Solution); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_pegboard_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*SolveEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_pegboard_proto_msgTypes[4].OneofWrappers = []any{
+		(*SolveEvent_Progress)(nil),
+		(*SolveEvent_Result)(nil),
+		(*SolveEvent_Solution)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_pegboard_proto_rawDesc,
+			NumEnums:      1,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pegboard_proto_goTypes,
+		DependencyIndexes: file_pegboard_proto_depIdxs,
+		EnumInfos:         file_pegboard_proto_enumTypes,
+		MessageInfos:      file_pegboard_proto_msgTypes,
+	}.Build()
+	File_pegboard_proto = out.File
+	file_pegboard_proto_rawDesc = nil
+	file_pegboard_proto_goTypes = nil
+	file_pegboard_proto_depIdxs = nil
+}
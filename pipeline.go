@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// parseJobLine parses one job of the pipeline. A line starting with { is a JSON jobRequest, as POST /solve takes. Otherwise it is the
+// grid size followed by options and the stones of a partial placement to start from, in any order, e.g.
+// "9 placer=ordered_noalloc_pruning A0 C4". The options are placer, solver and no_cache.
+func parseJobLine(line string) (jobRequest, error) {
+	var req jobRequest
+	if strings.HasPrefix(line, "{") {
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			return jobRequest{}, fmt.Errorf("invalid JSON job: %w", err)
+		}
+		return req, nil
+	}
+	fields := strings.Fields(line)
+	size, err := strconv.ParseUint(fields[0], 10, 8)
+	if err != nil {
+		return jobRequest{}, fmt.Errorf("invalid size %q: %w", fields[0], err)
+	}
+	req.Size = uint8(size)
+	for _, f := range fields[1:] {
+		key, value, isOption := strings.Cut(f, "=")
+		if !isOption {
+			p, err := grid.ParsePoint(f)
+			if err != nil {
+				return jobRequest{}, err
+			}
+			req.StartFrom = append(req.StartFrom, p)
+			continue
+		}
+		switch key {
+		case "placer":
+			req.Placer = value
+		case "solver":
+			req.Solver = value
+		case "no_cache":
+			if req.NoCache, err = strconv.ParseBool(value); err != nil {
+				return jobRequest{}, fmt.Errorf("invalid no_cache %q: %w", value, err)
+			}
+		default:
+			return jobRequest{}, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return req, nil
+}
+
+// runPipeline searches for the job on each line of r in turn, and writes the result of each to w as one line of JSON, in the format of
+// -json. Jobs that can't be parsed or searched have the error outcome, so there is a result line for every job. Blank lines and lines
+// starting with # are skipped. It stops early if the context is done.
+func runPipeline(ctx context.Context, r io.Reader, w io.Writer, ss *solveService) error {
+	enc := json.NewEncoder(w)
+	scanner := bufio.NewScanner(r)
+	for ctx.Err() == nil && scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var result jsonResult
+		req, err := parseJobLine(line)
+		if err == nil {
+			var res batchResult
+			res, err = ss.solve(ctx, req, &solver.Stats{})
+			result = newJSONResult(res, req.startingPoints())
+		}
+		if err != nil {
+			result = jsonResult{Size: req.Size, Placer: req.Placer, Solver: req.Solver, Outcome: outcome(err), Error: err.Error()}
+		}
+		if err := enc.Encode(result); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// pipeline implements the pipeline subcommand, which reads one job per line from stdin and writes one result per line to stdout, in
+// the same order, so that searches can be driven from shell pipelines and other programs.
+func pipeline(args []string) {
+	fs := flag.NewFlagSet("pipeline", flag.ExitOnError)
+	timeout := fs.Duration("timeout", 0, "how long each job searches for before it is stopped with the timeout outcome, or 0 for as long as it takes")
+	resultCache := fs.Int("result_cache", 256, "number of search results kept to answer identical jobs, or 0 to search for every job")
+	parseFlags(fs, fs.Name(), args)
+	usePhysicalCores()
+
+	cfg := serverConfig{MaxSolves: 1, SolveTimeout: *timeout}
+	if *resultCache > 0 {
+		cfg.Cache = solver.NewResultCache(solver.ResultCacheOptions{MaxEntries: *resultCache})
+	}
+	// Interrupting stops the current job, which still gets a result line, and the jobs after it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := runPipeline(ctx, os.Stdin, os.Stdout, newSolveService(cfg)); err != nil {
+		fatal(err)
+	}
+}
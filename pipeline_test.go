@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseJobLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    jobRequest
+		wantErr bool
+	}{
+		{name: "size", line: "7", want: jobRequest{Size: 7}},
+		{name: "options and prefix", line: "9 placer=ordered A0 solver=async C4 no_cache=true", want: jobRequest{Size: 9, Placer: "ordered", Solver: "async", StartFrom: grid.Placements{{Row: 0, Col: 0}, {Row: 2, Col: 4}}, NoCache: true}},
+		{name: "JSON", line: `{"size": 8, "start_from": ["B1"]}`, want: jobRequest{Size: 8, StartFrom: grid.Placements{{Row: 1, Col: 1}}}},
+		{name: "invalid size", line: "seven", wantErr: true},
+		{name: "invalid point", line: "7 Z", wantErr: true},
+		{name: "unknown option", line: "7 depth=3", wantErr: true},
+		{name: "invalid JSON", line: `{"size": -1}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseJobLine(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseJobLine(%q) error = %v, want error %t", tt.line, err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseJobLine(%q) mismatch (-want +got):\n%s", tt.line, diff)
+			}
+		})
+	}
+}
+
+func TestRunPipeline(t *testing.T) {
+	input := "6 placer=ordered\n\n# skipped\n6 placer=ordered A0 A1\n6 A0 A1 A2\n"
+	var out strings.Builder
+	if err := runPipeline(context.Background(), strings.NewReader(input), &out, newSolveService(serverConfig{MaxSolves: 1})); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("runPipeline() wrote %d lines, want one per job:\n%s", len(lines), out.String())
+	}
+	var results []jsonResult
+	for _, line := range lines {
+		var r jsonResult
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("invalid result line %q: %v", line, err)
+		}
+		results = append(results, r)
+	}
+	g := grid.Grid{Size: 6}
+	for _, r := range results[:2] {
+		if err := grid.CheckValidSolution(g, r.Solution); err != nil {
+			t.Errorf("result %+v is not a valid solution: %v", r, err)
+		}
+	}
+	if got := results[1].StartingPoints; got != "[A0 A1]" {
+		t.Errorf("second job starting points = %q, want the partial placement [A0 A1]", got)
+	}
+	if got := results[2].Outcome; got != "error" || results[2].Error == "" {
+		t.Errorf("invalid job outcome = %q with error %q, want error", got, results[2].Error)
+	}
+}
@@ -0,0 +1,137 @@
+package placer
+
+import (
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/sets"
+)
+
+// bidirectionalStonePlacer grows placements from both ends of the grid at once. Placers alternate between placing the next stone
+// forwards from the top left, after the last stone placed from that end, and backwards from the bottom right, before the last stone
+// placed from that end, until the two ends meet in the middle. Every set of stones is placed once, as its smallest stone, its largest
+// stone, its second smallest stone and so on.
+//
+// Points are handled as their index in a left to right, top to bottom traversal of the grid.
+type bidirectionalStonePlacer struct {
+	grid        grid.Grid
+	stones      grid.Placements
+	separations sets.BitArraySeparationSet
+	// front and back are the indexes of the last stones placed from each end, or just off the grid if none have been.
+	// Stones can only be placed between them.
+	front, back int
+	// fromBack is whether this placer places stones from the back
+	fromBack   bool
+	next       int
+	nextPlacer *bidirectionalStonePlacer
+}
+
+// point returns the point with the given index
+func (sp *bidirectionalStonePlacer) point(i int) grid.Point {
+	return grid.Point{Row: uint8(i / int(sp.grid.Size)), Col: uint8(i % int(sp.grid.Size))}
+}
+
+// index returns the index of the given point
+func (sp *bidirectionalStonePlacer) index(p grid.Point) int {
+	return int(p.Row)*int(sp.grid.Size) + int(p.Col)
+}
+
+// reset moves next to the first candidate from the placer's end
+func (sp *bidirectionalStonePlacer) reset() {
+	if sp.fromBack {
+		sp.next = sp.back - 1
+	} else {
+		sp.next = sp.front + 1
+	}
+}
+
+func (sp *bidirectionalStonePlacer) advance() {
+	if sp.fromBack {
+		sp.next--
+	} else {
+		sp.next++
+	}
+}
+
+func (sp *bidirectionalStonePlacer) Place() (StonePlacer, error) {
+	defer sp.advance()
+	stone := sp.point(sp.next)
+
+	// Check that placing the next stone doesn't result in duplicate separations with the stones from either end
+	sp.nextPlacer.separations.Clone(&sp.separations)
+	for _, p := range sp.stones {
+		s := grid.Separation(stone, p)
+		if sp.nextPlacer.separations.Has(s) {
+			return nil, ErrConstraintViolated
+		}
+		sp.nextPlacer.separations.Add(s)
+	}
+
+	copy(sp.nextPlacer.stones, sp.stones)
+	sp.nextPlacer.stones[len(sp.stones)] = stone
+	sp.nextPlacer.front, sp.nextPlacer.back = sp.front, sp.back
+	if sp.fromBack {
+		sp.nextPlacer.back = sp.next
+	} else {
+		sp.nextPlacer.front = sp.next
+	}
+	sp.nextPlacer.reset()
+	return sp.nextPlacer, nil
+}
+
+func (sp bidirectionalStonePlacer) Done() bool {
+	return sp.next <= sp.front || sp.next >= sp.back
+}
+
+func (sp bidirectionalStonePlacer) Grid() grid.Grid {
+	return sp.grid
+}
+
+func (sp bidirectionalStonePlacer) Placements() grid.Placements {
+	return sp.stones
+}
+
+func (sp bidirectionalStonePlacer) Depth() int {
+	return len(sp.stones)
+}
+
+func (sp bidirectionalStonePlacer) Remaining() int {
+	return int(sp.grid.Size) - len(sp.stones)
+}
+
+func (sp bidirectionalStonePlacer) AppendPlacements(buf grid.Placements) grid.Placements {
+	return append(buf, sp.stones...)
+}
+
+// BidirectionalStonePlacerProvider constructs placers which grow placements from both ends of the grid.
+// Of the existing stones, the larger half (rounded down) are taken to have been placed from the back, so placers made from the
+// placements of another bidirectional placer continue its search.
+type BidirectionalStonePlacerProvider struct{}
+
+func (spp BidirectionalStonePlacerProvider) New(g grid.Grid, p grid.Placements) StonePlacer {
+	// Create a singly linked list of placers. the first will have 0 stones placed, the second 1 stone placed, and so on.
+	placers := make([]bidirectionalStonePlacer, g.Size+1)
+	for i := 0; i < len(placers); i++ {
+		placers[i] = bidirectionalStonePlacer{
+			grid:     g,
+			stones:   make(grid.Placements, i),
+			front:    -1,
+			back:     int(g.Size) * int(g.Size),
+			fromBack: i%2 == 1,
+		}
+		placers[i].reset()
+		if i+1 < len(placers) {
+			placers[i].nextPlacer = &(placers[i+1])
+		}
+	}
+	// Place the stones, alternating between the smallest and largest remaining stones.
+	p.Sort()
+	for i := range p {
+		stone := p[i/2]
+		if placers[i].fromBack {
+			stone = p[len(p)-1-i/2]
+		}
+		placers[i].next = placers[i].index(stone)
+		placers[i].Place()
+	}
+	// Return the placer with all the starting stones placed.
+	return &placers[len(p)]
+}
@@ -0,0 +1,182 @@
+package placer
+
+import (
+	"math/bits"
+	"slices"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/pruner"
+	"github.com/WillMorrison/pegboard-blog/sets"
+)
+
+// impactCandidate is a point a stone could be placed on, with the number of the child's candidate points that placing it would prune
+type impactCandidate struct {
+	point  grid.Point
+	impact int
+}
+
+// impactOrderedPruningStonePlacer places stones in increasing order like orderedOpportunisticPruningNoAllocStonePlacer, so each set of stones
+// is placed once, but tries its children in a different order. Among the legal candidates, it first tries the one whose placement prunes the
+// most of the candidates that its own children could use, so that the most constrained branches are searched, and fail, first.
+// Impacts are counted with the same opportunistic pruning that placing the stone does, so ordering costs about as much as trying every child once.
+type impactOrderedPruningStonePlacer struct {
+	grid        grid.Grid
+	stones      grid.Placements
+	separations sets.BitArraySeparationSet
+	pruner      pruner.Pruner
+	pruned      sets.BitArrayPointSet
+	bound       bool
+	// order holds the legal candidates, most impactful first. Place tries order[next:count].
+	order       [grid.MaxGridSize * grid.MaxGridSize]impactCandidate
+	count, next int
+	nextPlacer  *impactOrderedPruningStonePlacer
+	// candidateIter is reused by candidates, so that checking the completion bound doesn't allocate
+	candidateIter unprunedPointIterator
+}
+
+// firstCandidate returns the first point after the placed stones
+func (sp *impactOrderedPruningStonePlacer) firstCandidate() grid.Point {
+	if len(sp.stones) == 0 {
+		return grid.Point{}
+	}
+	return grid.AdvanceStone(sp.grid, sp.stones[len(sp.stones)-1])
+}
+
+// candidates returns an iterator over the points that stones could still be placed on
+func (sp *impactOrderedPruningStonePlacer) candidates() grid.PointIterator {
+	sp.candidateIter = unprunedPointIterator{grid: sp.grid, pruned: &sp.pruned, start: sp.firstCandidate()}
+	sp.candidateIter.Reset()
+	return &sp.candidateIter
+}
+
+// prune adds the points that placing stone prunes to pruned, and reports whether stone can be placed without repeating a separation.
+func (sp *impactOrderedPruningStonePlacer) prune(pruned *sets.BitArrayPointSet, separations *sets.BitArraySeparationSet, stone grid.Point) bool {
+	for _, p := range sp.stones {
+		s := grid.Separation(stone, p)
+		if separations.Has(s) {
+			return false
+		}
+		separations.Add(s)
+		sp.pruner.PruneIsoceles(pruned, p, stone)
+		sp.pruner.PruneCircles(pruned, p, s)
+		sp.pruner.PruneCircles(pruned, stone, s)
+	}
+	return true
+}
+
+// orderCandidates finds the legal candidates for the next stone and sorts them by decreasing impact. Ties keep grid order.
+func (sp *impactOrderedPruningStonePlacer) orderCandidates() {
+	sp.count, sp.next = 0, 0
+	it := sp.candidates()
+	for c, ok := it.Next(); ok; c, ok = it.Next() {
+		pruned, separations := sp.pruned, sp.separations
+		if !sp.prune(&pruned, &separations, c) {
+			continue
+		}
+		// Only the candidates after c are open to c's children
+		impact := 0
+		for r := c.Row; r < sp.grid.Size; r++ {
+			newlyPruned := pruned[r] &^ sp.pruned[r]
+			if r == c.Row {
+				newlyPruned &= 0x7fff >> c.Col
+			}
+			impact += bits.OnesCount16(newlyPruned)
+		}
+		sp.order[sp.count] = impactCandidate{point: c, impact: impact}
+		sp.count++
+	}
+	slices.SortStableFunc(sp.order[:sp.count], func(a, b impactCandidate) int { return b.impact - a.impact })
+}
+
+func (sp *impactOrderedPruningStonePlacer) Place() (StonePlacer, error) {
+	stone := sp.order[sp.next].point
+	sp.next++
+	return sp.place(stone)
+}
+
+func (sp *impactOrderedPruningStonePlacer) place(stone grid.Point) (StonePlacer, error) {
+	sp.nextPlacer.separations.Clone(&sp.separations)
+	sp.nextPlacer.pruned.Clone(&sp.pruned)
+	if !sp.prune(&sp.nextPlacer.pruned, &sp.nextPlacer.separations, stone) {
+		return nil, ErrConstraintViolated
+	}
+
+	// Add stone to placements
+	copy(sp.nextPlacer.stones, sp.stones)
+	sp.nextPlacer.stones[len(sp.stones)] = stone
+
+	if sp.bound && pruner.CompletionBound(sp.grid, sp.nextPlacer.stones, sp.nextPlacer.candidates()) < int(sp.grid.Size)-len(sp.nextPlacer.stones) {
+		return nil, ErrCannotComplete
+	}
+	// A full placer can't place more stones
+	if len(sp.nextPlacer.stones) < int(sp.grid.Size) {
+		sp.nextPlacer.orderCandidates()
+	} else {
+		sp.nextPlacer.count, sp.nextPlacer.next = 0, 0
+	}
+	return sp.nextPlacer, nil
+}
+
+func (sp impactOrderedPruningStonePlacer) Done() bool {
+	return sp.next >= sp.count
+}
+
+func (sp impactOrderedPruningStonePlacer) Grid() grid.Grid {
+	return sp.grid
+}
+
+func (sp impactOrderedPruningStonePlacer) Placements() grid.Placements {
+	return sp.stones
+}
+
+func (sp impactOrderedPruningStonePlacer) Depth() int {
+	return len(sp.stones)
+}
+
+func (sp impactOrderedPruningStonePlacer) Remaining() int {
+	return int(sp.grid.Size) - len(sp.stones)
+}
+
+func (sp impactOrderedPruningStonePlacer) AppendPlacements(buf grid.Placements) grid.Placements {
+	return append(buf, sp.stones...)
+}
+
+// ImpactOrderedPruningStonePlacerProvider constructs placers which try the candidate that prunes the most future candidates first.
+type ImpactOrderedPruningStonePlacerProvider struct {
+	PrunerConstructor func(grid.Grid) pruner.Pruner
+	// Bound enables cutting branches where pruner.CompletionBound shows the placements cannot be completed
+	Bound bool
+}
+
+func (spp ImpactOrderedPruningStonePlacerProvider) New(g grid.Grid, p grid.Placements) StonePlacer {
+	pruner := spp.PrunerConstructor(g)
+
+	// Create a singly linked list of placers. the first will have 0 stones placed, the second 1 stone placed, and so on.
+	placers := make([]impactOrderedPruningStonePlacer, g.Size+1)
+	for i := 0; i < len(placers); i++ {
+		placers[i] = impactOrderedPruningStonePlacer{
+			grid:   g,
+			stones: make(grid.Placements, i),
+			pruner: pruner,
+			bound:  spp.Bound,
+		}
+		if i+1 < len(placers) {
+			placers[i].nextPlacer = &(placers[i+1])
+		}
+	}
+	// Place the stones, in order.
+	p.Sort()
+	placers[0].orderCandidates()
+	for i, stone := range p {
+		if !placers[i].pruned.Has(stone) {
+			if _, err := placers[i].place(stone); err == nil {
+				continue
+			}
+		}
+		// The prefix can't be completed, so return a placer with nothing left to try, which NewFromPrefix reports as an error
+		placers[i].next = placers[i].count
+		return &placers[i]
+	}
+	// Return the placer with all the starting stones placed.
+	return &placers[len(p)]
+}
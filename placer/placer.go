@@ -1,7 +1,10 @@
 package placer
 
 import (
+	"errors"
 	"fmt"
+	"math/bits"
+	"slices"
 
 	"github.com/WillMorrison/pegboard-blog/grid"
 	"github.com/WillMorrison/pegboard-blog/pruner"
@@ -9,13 +12,14 @@ import (
 )
 
 var (
-	errDistanceConstraintViolated = fmt.Errorf("cannot place stone, unique distance constraint would be violated")
+	// ErrConstraintViolated is returned by Place when the stone would repeat the separation between two stones.
+	ErrConstraintViolated = errors.New("cannot place stone, unique distance constraint would be violated")
+	// ErrCannotComplete is returned by Place when the stone could be placed, but too few candidate points would remain to complete the placements.
+	ErrCannotComplete = errors.New("cannot place stone, not enough candidate points would remain to complete the placements")
 )
 
-type StonePlacer interface {
-	// Place attempts to place a stone. If placement is successful, it returns a new StonePlacer, otherwise it returns an error.
-	Place() (StonePlacer, error)
-
+// PlacementState is the state of the placements of a StonePlacer or StonePlacerV2, which share all but their Place methods.
+type PlacementState interface {
 	// Done returns whether any more placements are possible.
 	Done() bool
 
@@ -24,13 +28,48 @@ type StonePlacer interface {
 
 	// Placements returns the placements made so far.
 	Placements() grid.Placements
+
+	// Depth returns the number of stones placed so far. Unlike len(Placements()), it never allocates or copies.
+	Depth() int
+
+	// Remaining returns the number of stones that still need to be placed to complete the placements.
+	Remaining() int
+
+	// AppendPlacements appends the placements made so far to the given slice and returns the extended slice, like the builtin append.
+	AppendPlacements(grid.Placements) grid.Placements
+}
+
+type StonePlacer interface {
+	// Place attempts to place a stone. If placement is successful, it returns a new StonePlacer, otherwise it returns an error.
+	Place() (StonePlacer, error)
+
+	PlacementState
 }
 
 type StonePlacerConstructor interface {
-	// New returns a new StonePlacer that places on the given grid, with the given existing stones.
+	// New returns a new StonePlacer that places on the given grid, with the given existing stones. The stones must not break a
+	// constraint, but pruning placers accept stones they would have pruned, and return a placer with nothing left to place.
 	New(grid.Grid, grid.Placements) StonePlacer
 }
 
+// NewFromPrefix returns a new StonePlacer from the constructor that places the completions of any prefix, such as one given by a user.
+// It returns an error wrapping a *grid.ValidationError if the prefix breaks a constraint, or ErrCannotComplete if the placer prunes
+// one of its stones, which means the placer would find no completions of it.
+func NewFromPrefix(spc StonePlacerConstructor, g grid.Grid, prefix grid.Placements) (StonePlacer, error) {
+	if err := grid.CheckValidPartial(g, prefix); err != nil {
+		return nil, fmt.Errorf("invalid prefix %v: %w", prefix, err)
+	}
+	sp := spc.New(g, slices.Clone(prefix))
+	// Placers may place more stones than the prefix, such as those that place forced stones
+	placed := sp.AppendPlacements(nil)
+	for _, stone := range prefix {
+		if !slices.Contains(placed, stone) {
+			return nil, fmt.Errorf("prefix %v: %v is pruned: %w", prefix, stone, ErrCannotComplete)
+		}
+	}
+	return sp, nil
+}
+
 // orderedStonePlacer attempts to place stones from top to bottom, left to right, checking that they are valid placements each time.
 type orderedStonePlacer struct {
 	grid        grid.Grid
@@ -47,7 +86,7 @@ func (sp *orderedStonePlacer) Place() (StonePlacer, error) {
 	for _, p := range sp.stones {
 		s := grid.Separation(sp.nextStone, p)
 		if separations.Has(s) {
-			return sp, errDistanceConstraintViolated
+			return sp, ErrConstraintViolated
 		}
 		separations.Add(s)
 	}
@@ -72,6 +111,18 @@ func (sp orderedStonePlacer) Placements() grid.Placements {
 	return sp.stones
 }
 
+func (sp orderedStonePlacer) Depth() int {
+	return len(sp.stones)
+}
+
+func (sp orderedStonePlacer) Remaining() int {
+	return int(sp.grid.Size) - len(sp.stones)
+}
+
+func (sp orderedStonePlacer) AppendPlacements(buf grid.Placements) grid.Placements {
+	return append(buf, sp.stones...)
+}
+
 type OrderedStonePlacerProvider struct {
 	SeparationSetConstructor sets.SeparationSetConstructor
 }
@@ -90,6 +141,8 @@ type unorderedStonePlacer struct {
 	stones      sets.PointSet
 	separations sets.SeparationSet
 	nextStone   grid.Point
+	// depth is the number of stones, which is kept so that Depth doesn't need to copy them
+	depth int
 }
 
 // advance moves nextStone to a point that is not already occupied
@@ -111,7 +164,7 @@ func (sp *unorderedStonePlacer) Place() (StonePlacer, error) {
 	for _, p := range sp.stones.Elements() {
 		s := grid.Separation(sp.nextStone, p)
 		if separations.Has(s) {
-			return sp, errDistanceConstraintViolated
+			return sp, ErrConstraintViolated
 		}
 		separations.Add(s)
 	}
@@ -120,7 +173,7 @@ func (sp *unorderedStonePlacer) Place() (StonePlacer, error) {
 	newStones := sp.stones.Copy()
 	newStones.Add(sp.nextStone)
 
-	return &unorderedStonePlacer{sp.grid, newStones, separations, grid.Point{}}, nil
+	return &unorderedStonePlacer{sp.grid, newStones, separations, grid.Point{}, sp.depth + 1}, nil
 }
 
 func (sp unorderedStonePlacer) Done() bool {
@@ -135,13 +188,25 @@ func (sp unorderedStonePlacer) Placements() grid.Placements {
 	return sp.stones.Elements()
 }
 
+func (sp unorderedStonePlacer) Depth() int {
+	return sp.depth
+}
+
+func (sp unorderedStonePlacer) Remaining() int {
+	return int(sp.grid.Size) - sp.depth
+}
+
+func (sp unorderedStonePlacer) AppendPlacements(buf grid.Placements) grid.Placements {
+	return sp.stones.AppendElements(buf)
+}
+
 type UnorderedStonePlacerProvider struct {
 	SeparationSetConstructor sets.SeparationSetConstructor
 	PointSetConstructor      sets.PointSetConstructor
 }
 
 func (spp UnorderedStonePlacerProvider) New(g grid.Grid, p grid.Placements) StonePlacer {
-	return &unorderedStonePlacer{grid: g, stones: spp.PointSetConstructor(p), separations: spp.SeparationSetConstructor(p), nextStone: grid.Point{}}
+	return &unorderedStonePlacer{grid: g, stones: spp.PointSetConstructor(p), separations: spp.SeparationSetConstructor(p), nextStone: grid.Point{}, depth: len(p)}
 }
 
 type orderedNoAllocStonePlacer struct {
@@ -160,7 +225,7 @@ func (sp *orderedNoAllocStonePlacer) Place() (StonePlacer, error) {
 	for _, p := range sp.stones {
 		s := grid.Separation(sp.nextStone, p)
 		if sp.nextPlacer.separations.Has(s) {
-			return nil, errDistanceConstraintViolated
+			return nil, ErrConstraintViolated
 		}
 		sp.nextPlacer.separations.Add(s)
 	}
@@ -183,6 +248,18 @@ func (sp orderedNoAllocStonePlacer) Placements() grid.Placements {
 	return sp.stones
 }
 
+func (sp orderedNoAllocStonePlacer) Depth() int {
+	return len(sp.stones)
+}
+
+func (sp orderedNoAllocStonePlacer) Remaining() int {
+	return int(sp.grid.Size) - len(sp.stones)
+}
+
+func (sp orderedNoAllocStonePlacer) AppendPlacements(buf grid.Placements) grid.Placements {
+	return append(buf, sp.stones...)
+}
+
 type OrderedNoAllocStonePlacerProvider struct{}
 
 func (spp OrderedNoAllocStonePlacerProvider) New(g grid.Grid, p grid.Placements) StonePlacer {
@@ -203,7 +280,11 @@ func (spp OrderedNoAllocStonePlacerProvider) New(g grid.Grid, p grid.Placements)
 	p.Sort()
 	for i, stone := range p {
 		placers[i].nextStone = stone
-		placers[i].Place()
+		if _, err := placers[i].Place(); err != nil {
+			// The prefix breaks a constraint, so return a placer with nothing left to try, which NewFromPrefix reports as an error
+			placers[i].nextStone = grid.Point{Row: g.Size}
+			return &placers[i]
+		}
 	}
 	// Return the placer with all the starting stones placed.
 	return &placers[len(p)]
@@ -215,8 +296,14 @@ type orderedPruningNoAllocStonePlacer struct {
 	separations sets.BitArraySeparationSet
 	pruner      pruner.Pruner
 	pruned      sets.BitArrayPointSet
+	bound       bool
 	nextStone   grid.Point
 	nextPlacer  *orderedPruningNoAllocStonePlacer
+	// candidateIter is reused by candidates, so that checking the completion bound doesn't allocate
+	candidateIter unprunedPointIterator
+	// forced enables placing forced stones. limit is the last candidate worth trying, since later ones leave out a forced stone.
+	forced bool
+	limit  grid.Point
 }
 
 // Advance moves nextStone to the next non-pruned position, or leaves it out of bounds
@@ -228,6 +315,31 @@ func (sp *orderedPruningNoAllocStonePlacer) advance() {
 	}
 }
 
+// placeForced limits the candidates to try to those up to the first point that every completion must contain.
+// If that point is the next candidate, it is the only child worth trying, so it is placed straight away, and so on until no stone is forced.
+func (sp *orderedPruningNoAllocStonePlacer) placeForced() (StonePlacer, error) {
+	sp.limit = grid.Point{Row: sp.grid.Size - 1, Col: sp.grid.Size - 1}
+	if len(sp.stones) == int(sp.grid.Size) {
+		return sp, nil
+	}
+	forced, ok := pruner.ForcedPoint(sp.grid, sp.stones, sp.candidates())
+	if !ok {
+		return sp, nil
+	}
+	sp.limit = forced
+	if forced != sp.nextStone {
+		return sp, nil
+	}
+	return sp.Place()
+}
+
+// candidates returns an iterator over the points that stones could still be placed on
+func (sp *orderedPruningNoAllocStonePlacer) candidates() grid.PointIterator {
+	sp.candidateIter = unprunedPointIterator{grid: sp.grid, pruned: &sp.pruned, start: sp.nextStone}
+	sp.candidateIter.Reset()
+	return &sp.candidateIter
+}
+
 func (sp *orderedPruningNoAllocStonePlacer) Place() (StonePlacer, error) {
 	defer sp.advance()
 
@@ -239,7 +351,7 @@ func (sp *orderedPruningNoAllocStonePlacer) Place() (StonePlacer, error) {
 	for i, p := range sp.stones {
 		s := grid.Separation(sp.nextStone, p)
 		if sp.nextPlacer.separations.Has(s) {
-			return nil, errDistanceConstraintViolated
+			return nil, ErrConstraintViolated
 		}
 		sp.nextPlacer.separations.Add(s)
 		newSeparations[i] = s
@@ -254,8 +366,7 @@ func (sp *orderedPruningNoAllocStonePlacer) Place() (StonePlacer, error) {
 	}
 
 	// prune circles around nextStone with existing+new separations
-	allSepIter := sets.NewSeparationSetIteratorForGrid(&sp.nextPlacer.separations, sp.grid)
-	for sep, ok := allSepIter.Next(); ok; sep, ok = allSepIter.Next() {
+	for sep, ok := sp.nextPlacer.separations.NextAbove(0); ok; sep, ok = sp.nextPlacer.separations.NextAbove(sep) {
 		sp.nextPlacer.pruner.PruneCircles(&sp.nextPlacer.pruned, sp.nextStone, sep)
 	}
 
@@ -265,11 +376,17 @@ func (sp *orderedPruningNoAllocStonePlacer) Place() (StonePlacer, error) {
 
 	sp.nextPlacer.nextStone = sp.nextStone
 	sp.nextPlacer.advance()
+	if sp.bound && pruner.CompletionBound(sp.grid, sp.nextPlacer.stones, sp.nextPlacer.candidates()) < int(sp.grid.Size)-len(sp.nextPlacer.stones) {
+		return nil, ErrCannotComplete
+	}
+	if sp.forced {
+		return sp.nextPlacer.placeForced()
+	}
 	return sp.nextPlacer, nil
 }
 
 func (sp orderedPruningNoAllocStonePlacer) Done() bool {
-	return !grid.IsInBounds(sp.grid, sp.nextStone)
+	return !grid.IsInBounds(sp.grid, sp.nextStone) || sp.forced && grid.LessThan(sp.limit, sp.nextStone)
 }
 
 func (sp orderedPruningNoAllocStonePlacer) Grid() grid.Grid {
@@ -280,8 +397,25 @@ func (sp orderedPruningNoAllocStonePlacer) Placements() grid.Placements {
 	return sp.stones
 }
 
+func (sp orderedPruningNoAllocStonePlacer) Depth() int {
+	return len(sp.stones)
+}
+
+func (sp orderedPruningNoAllocStonePlacer) Remaining() int {
+	return int(sp.grid.Size) - len(sp.stones)
+}
+
+func (sp orderedPruningNoAllocStonePlacer) AppendPlacements(buf grid.Placements) grid.Placements {
+	return append(buf, sp.stones...)
+}
+
 type OrderedPruningNoAllocStonePlacerProvider struct {
 	PrunerConstructor func(grid.Grid) pruner.Pruner
+	// Bound enables cutting branches where pruner.CompletionBound shows the placements cannot be completed
+	Bound bool
+	// Forced enables placing stones that pruner.ForcedPoint shows every completion must contain as soon as they are found, skipping the
+	// branches without them. Placers then return placers more than one stone deeper, so they don't suit searches that stop at a fixed depth.
+	Forced bool
 }
 
 func (spp OrderedPruningNoAllocStonePlacerProvider) New(g grid.Grid, p grid.Placements) StonePlacer {
@@ -296,6 +430,7 @@ func (spp OrderedPruningNoAllocStonePlacerProvider) New(g grid.Grid, p grid.Plac
 			separations: sets.BitArraySeparationSet{},
 			pruner:      pruner,
 			pruned:      sets.BitArrayPointSet{},
+			bound:       spp.Bound,
 			nextStone:   grid.Point{},
 		}
 		if i+1 < len(placers) {
@@ -305,11 +440,24 @@ func (spp OrderedPruningNoAllocStonePlacerProvider) New(g grid.Grid, p grid.Plac
 	// Place the stones, in order.
 	p.Sort()
 	for i, stone := range p {
-		if placers[i].pruned.Has(stone) {
-			panic("Invalid placement, already pruned")
+		if !placers[i].pruned.Has(stone) {
+			placers[i].nextStone = stone
+			if _, err := placers[i].Place(); err == nil {
+				continue
+			}
+		}
+		// The prefix can't be completed, so return a placer with nothing left to try, which NewFromPrefix reports as an error
+		placers[i].nextStone = grid.Point{Row: g.Size}
+		return &placers[i]
+	}
+	// Forced stones are only placed once the starting stones are, so that the starting placer isn't skipped over.
+	if spp.Forced {
+		for i := range placers {
+			placers[i].forced = true
+		}
+		if start, err := placers[len(p)].placeForced(); err == nil {
+			return start
 		}
-		placers[i].nextStone = stone
-		placers[i].Place()
 	}
 	// Return the placer with all the starting stones placed.
 	return &placers[len(p)]
@@ -321,8 +469,14 @@ type orderedOpportunisticPruningNoAllocStonePlacer struct {
 	separations sets.BitArraySeparationSet
 	pruner      pruner.Pruner
 	pruned      sets.BitArrayPointSet
+	bound       bool
 	nextStone   grid.Point
 	nextPlacer  *orderedOpportunisticPruningNoAllocStonePlacer
+	// candidateIter is reused by candidates, so that checking the completion bound doesn't allocate
+	candidateIter unprunedPointIterator
+	// forced enables placing forced stones. limit is the last candidate worth trying, since later ones leave out a forced stone.
+	forced bool
+	limit  grid.Point
 }
 
 func (sp *orderedOpportunisticPruningNoAllocStonePlacer) advance() {
@@ -333,6 +487,31 @@ func (sp *orderedOpportunisticPruningNoAllocStonePlacer) advance() {
 	}
 }
 
+// placeForced limits the candidates to try to those up to the first point that every completion must contain.
+// If that point is the next candidate, it is the only child worth trying, so it is placed straight away, and so on until no stone is forced.
+func (sp *orderedOpportunisticPruningNoAllocStonePlacer) placeForced() (StonePlacer, error) {
+	sp.limit = grid.Point{Row: sp.grid.Size - 1, Col: sp.grid.Size - 1}
+	if len(sp.stones) == int(sp.grid.Size) {
+		return sp, nil
+	}
+	forced, ok := pruner.ForcedPoint(sp.grid, sp.stones, sp.candidates())
+	if !ok {
+		return sp, nil
+	}
+	sp.limit = forced
+	if forced != sp.nextStone {
+		return sp, nil
+	}
+	return sp.Place()
+}
+
+// candidates returns an iterator over the points that stones could still be placed on
+func (sp *orderedOpportunisticPruningNoAllocStonePlacer) candidates() grid.PointIterator {
+	sp.candidateIter = unprunedPointIterator{grid: sp.grid, pruned: &sp.pruned, start: sp.nextStone}
+	sp.candidateIter.Reset()
+	return &sp.candidateIter
+}
+
 func (sp *orderedOpportunisticPruningNoAllocStonePlacer) Place() (StonePlacer, error) {
 	defer sp.advance()
 
@@ -343,7 +522,7 @@ func (sp *orderedOpportunisticPruningNoAllocStonePlacer) Place() (StonePlacer, e
 	for _, p := range sp.stones {
 		s := grid.Separation(sp.nextStone, p)
 		if sp.nextPlacer.separations.Has(s) {
-			return nil, errDistanceConstraintViolated
+			return nil, ErrConstraintViolated
 		}
 		sp.nextPlacer.separations.Add(s)
 		sp.nextPlacer.pruner.PruneIsoceles(&sp.nextPlacer.pruned, p, sp.nextStone)
@@ -357,11 +536,17 @@ func (sp *orderedOpportunisticPruningNoAllocStonePlacer) Place() (StonePlacer, e
 
 	sp.nextPlacer.nextStone = sp.nextStone
 	sp.nextPlacer.advance()
+	if sp.bound && pruner.CompletionBound(sp.grid, sp.nextPlacer.stones, sp.nextPlacer.candidates()) < int(sp.grid.Size)-len(sp.nextPlacer.stones) {
+		return nil, ErrCannotComplete
+	}
+	if sp.forced {
+		return sp.nextPlacer.placeForced()
+	}
 	return sp.nextPlacer, nil
 }
 
 func (sp orderedOpportunisticPruningNoAllocStonePlacer) Done() bool {
-	return !grid.IsInBounds(sp.grid, sp.nextStone)
+	return !grid.IsInBounds(sp.grid, sp.nextStone) || sp.forced && grid.LessThan(sp.limit, sp.nextStone)
 }
 
 func (sp orderedOpportunisticPruningNoAllocStonePlacer) Grid() grid.Grid {
@@ -372,8 +557,25 @@ func (sp orderedOpportunisticPruningNoAllocStonePlacer) Placements() grid.Placem
 	return sp.stones
 }
 
+func (sp orderedOpportunisticPruningNoAllocStonePlacer) Depth() int {
+	return len(sp.stones)
+}
+
+func (sp orderedOpportunisticPruningNoAllocStonePlacer) Remaining() int {
+	return int(sp.grid.Size) - len(sp.stones)
+}
+
+func (sp orderedOpportunisticPruningNoAllocStonePlacer) AppendPlacements(buf grid.Placements) grid.Placements {
+	return append(buf, sp.stones...)
+}
+
 type OrderedOpportunisticPruningNoAllocStonePlacerProvider struct {
 	PrunerConstructor func(grid.Grid) pruner.Pruner
+	// Bound enables cutting branches where pruner.CompletionBound shows the placements cannot be completed
+	Bound bool
+	// Forced enables placing stones that pruner.ForcedPoint shows every completion must contain as soon as they are found, skipping the
+	// branches without them. Placers then return placers more than one stone deeper, so they don't suit searches that stop at a fixed depth.
+	Forced bool
 }
 
 func (spp OrderedOpportunisticPruningNoAllocStonePlacerProvider) New(g grid.Grid, p grid.Placements) StonePlacer {
@@ -389,6 +591,7 @@ func (spp OrderedOpportunisticPruningNoAllocStonePlacerProvider) New(g grid.Grid
 			separations: sets.BitArraySeparationSet{},
 			pruner:      pruner,
 			pruned:      sets.BitArrayPointSet{},
+			bound:       spp.Bound,
 			nextStone:   grid.Point{},
 		}
 		if i+1 < len(placers) {
@@ -398,12 +601,178 @@ func (spp OrderedOpportunisticPruningNoAllocStonePlacerProvider) New(g grid.Grid
 	// Place the stones, in order.
 	p.Sort()
 	for i, stone := range p {
-		if placers[i].pruned.Has(stone) {
-			panic("Invalid placement, already pruned")
+		if !placers[i].pruned.Has(stone) {
+			placers[i].nextStone = stone
+			if _, err := placers[i].Place(); err == nil {
+				continue
+			}
 		}
-		placers[i].nextStone = stone
-		placers[i].Place()
+		// The prefix can't be completed, so return a placer with nothing left to try, which NewFromPrefix reports as an error
+		placers[i].nextStone = grid.Point{Row: g.Size}
+		return &placers[i]
+	}
+	// Forced stones are only placed once the starting stones are, so that the starting placer isn't skipped over.
+	if spp.Forced {
+		for i := range placers {
+			placers[i].forced = true
+		}
+		if start, err := placers[len(p)].placeForced(); err == nil {
+			return start
+		}
+	}
+	// Return the placer with all the starting stones placed.
+	return &placers[len(p)]
+}
+
+// orderedBitboardStonePlacer is a pruning placer specialized for grids no larger than sets.BitboardMaxGridSize.
+// The whole board fits in a single word, so pruning is a handful of bitwise ors and finding the next unpruned point is a single bit scan.
+// Pruning is opportunistic, since anything more thorough costs more than trying and failing to place on such small grids.
+type orderedBitboardStonePlacer struct {
+	grid        grid.Grid
+	stones      grid.Placements
+	separations [2]uint64 // Little endian bit array, large enough for all separations on a grid no larger than sets.BitboardMaxGridSize
+	pruner      *pruner.BitboardPruner
+	pruned      sets.BitboardPointSet
+	gridMask    sets.BitboardPointSet
+	bound       bool
+	nextStone   grid.Point
+	nextPlacer  *orderedBitboardStonePlacer
+}
+
+// advance moves nextStone to the next non-pruned position, or leaves it out of bounds
+func (sp *orderedBitboardStonePlacer) advance() {
+	// Mask out every point up to and including nextStone, as well as pruned points and points off the grid
+	after := ^uint64(0) << sets.BitboardIndex(sp.nextStone) << 1
+	candidates := uint64(sp.gridMask&^sp.pruned) & after
+	if candidates == 0 {
+		sp.nextStone = grid.Point{Row: sp.grid.Size, Col: 0}
+		return
+	}
+	sp.nextStone = sets.BitboardPoint(uint8(bits.TrailingZeros64(candidates)))
+}
+
+// candidates returns an iterator over the points that stones could still be placed on
+func (sp *orderedBitboardStonePlacer) candidates() grid.PointIterator {
+	return (sp.gridMask &^ sp.pruned & sets.BitboardPointSet(^uint64(0)<<sets.BitboardIndex(sp.nextStone))).Iter()
+}
+
+func (sp *orderedBitboardStonePlacer) Place() (StonePlacer, error) {
+	defer sp.advance()
+
+	sp.nextPlacer.separations = sp.separations
+	sp.nextPlacer.pruned = sp.pruned
+
+	// prune isoceles triangles between nextStone and all previous stones, and circles around both with the new separations
+	for _, p := range sp.stones {
+		s := grid.Separation(sp.nextStone, p)
+		if sp.nextPlacer.separations[s>>6]&(1<<(s&0x3f)) != 0 {
+			return nil, ErrConstraintViolated
+		}
+		sp.nextPlacer.separations[s>>6] |= 1 << (s & 0x3f)
+		sp.nextPlacer.pruned |= sp.pruner.Isoceles(p, sp.nextStone) | sp.pruner.Circle(p, s) | sp.pruner.Circle(sp.nextStone, s)
+	}
+
+	// Add stone to placements
+	copy(sp.nextPlacer.stones, sp.stones)
+	sp.nextPlacer.stones[len(sp.stones)] = sp.nextStone
+
+	sp.nextPlacer.nextStone = sp.nextStone
+	sp.nextPlacer.advance()
+	if sp.bound && pruner.CompletionBound(sp.grid, sp.nextPlacer.stones, sp.nextPlacer.candidates()) < int(sp.grid.Size)-len(sp.nextPlacer.stones) {
+		return nil, ErrCannotComplete
+	}
+	return sp.nextPlacer, nil
+}
+
+func (sp orderedBitboardStonePlacer) Done() bool {
+	return !grid.IsInBounds(sp.grid, sp.nextStone)
+}
+
+func (sp orderedBitboardStonePlacer) Grid() grid.Grid {
+	return sp.grid
+}
+
+func (sp orderedBitboardStonePlacer) Placements() grid.Placements {
+	return sp.stones
+}
+
+func (sp orderedBitboardStonePlacer) Depth() int {
+	return len(sp.stones)
+}
+
+func (sp orderedBitboardStonePlacer) Remaining() int {
+	return int(sp.grid.Size) - len(sp.stones)
+}
+
+func (sp orderedBitboardStonePlacer) AppendPlacements(buf grid.Placements) grid.Placements {
+	return append(buf, sp.stones...)
+}
+
+// OrderedBitboardStonePlacerProvider constructs placers for grids no larger than sets.BitboardMaxGridSize. Its Registration in Placers
+// has that MaxGridSize, so that larger grids are refused before searching; New panics if given one.
+type OrderedBitboardStonePlacerProvider struct {
+	// Bound enables cutting branches where pruner.CompletionBound shows the placements cannot be completed
+	Bound bool
+}
+
+func (spp OrderedBitboardStonePlacerProvider) New(g grid.Grid, p grid.Placements) StonePlacer {
+	pruner, err := pruner.NewBitboardPruner(g)
+	if err != nil {
+		panic(err)
+	}
+	gridMask := sets.BitboardGridMask(g)
+
+	// Create a singly linked list of placers. the first will have 0 stones placed, the second 1 stone placed, and so on.
+	placers := make([]orderedBitboardStonePlacer, g.Size+1)
+	for i := 0; i < len(placers); i++ {
+		placers[i] = orderedBitboardStonePlacer{
+			grid:      g,
+			stones:    make(grid.Placements, i),
+			pruner:    pruner,
+			gridMask:  gridMask,
+			bound:     spp.Bound,
+			nextStone: grid.Point{},
+		}
+		if i+1 < len(placers) {
+			placers[i].nextPlacer = &(placers[i+1])
+		}
+	}
+	// Place the stones, in order.
+	p.Sort()
+	for i, stone := range p {
+		if !placers[i].pruned.Has(stone) {
+			placers[i].nextStone = stone
+			if _, err := placers[i].Place(); err == nil {
+				continue
+			}
+		}
+		// The prefix can't be completed, so return a placer with nothing left to try, which NewFromPrefix reports as an error
+		placers[i].nextStone = grid.Point{Row: g.Size}
+		return &placers[i]
 	}
 	// Return the placer with all the starting stones placed.
 	return &placers[len(p)]
 }
+
+// unprunedPointIterator iterates over the points on a grid from a starting point onwards, in order, skipping pruned points
+type unprunedPointIterator struct {
+	grid   grid.Grid
+	pruned *sets.BitArrayPointSet
+	start  grid.Point
+	next   grid.Point
+}
+
+func (pi *unprunedPointIterator) Reset() {
+	pi.next = pi.start
+}
+
+func (pi *unprunedPointIterator) Next() (grid.Point, bool) {
+	for ; grid.IsInBounds(pi.grid, pi.next); pi.next = grid.AdvanceStone(pi.grid, pi.next) {
+		if !pi.pruned.Has(pi.next) {
+			next := pi.next
+			pi.next = grid.AdvanceStone(pi.grid, pi.next)
+			return next, true
+		}
+	}
+	return pi.next, false
+}
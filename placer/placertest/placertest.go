@@ -0,0 +1,290 @@
+// Package placertest provides a conformance suite for placer.StonePlacer implementations, so that new and experimental placers
+// can check that they keep the StonePlacer contract, and any stronger promises they make, by running a single function from a test.
+package placertest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+// Properties describes what a StonePlacer implementation promises beyond the StonePlacer contract, which Run then checks.
+type Properties struct {
+	// Unique is whether the tree below an empty grid places every set of stones at most once, rather than once per order of its stones.
+	Unique bool
+	// Ordered is whether stones are placed in increasing order, as sorted by grid.Placements.Sort. The placer made from a prefix then
+	// searches exactly the solutions whose smallest stones are the prefix.
+	Ordered bool
+	// Skips is whether New and Place may place more stones than asked, e.g. stones that every completion needs, so Place may return
+	// placers more than one stone deeper.
+	Skips bool
+	// MaxGridSize is the largest grid size the placer supports, or 0 if it supports them all.
+	MaxGridSize uint8
+}
+
+// maxSize is the largest grid size the suite searches. Every placer searches a 6x6 grid in well under a second.
+const maxSize = 6
+
+// Run runs the conformance suite against the placers that spc constructs, as subtests of t.
+func Run(t *testing.T, spc placer.StonePlacerConstructor, props Properties) {
+	sizes := make([]grid.Grid, 0, maxSize)
+	for size := uint8(1); size <= maxSize && (props.MaxGridSize == 0 || size <= props.MaxGridSize); size++ {
+		sizes = append(sizes, grid.Grid{Size: size})
+	}
+
+	t.Run("Empty", func(t *testing.T) {
+		for _, g := range sizes {
+			sp := spc.New(g, nil)
+			if props.Skips {
+				// Stones that every solution needs may be placed straight away
+				continue
+			}
+			if sp.Grid() != g || sp.Depth() != 0 || sp.Remaining() != int(g.Size) || len(sp.Placements()) != 0 {
+				t.Errorf("New(%+v, nil) has Grid() %+v, Depth() %d, Remaining() %d and Placements() %v, want %+v, 0, %d and none",
+					g, sp.Grid(), sp.Depth(), sp.Remaining(), sp.Placements(), g, g.Size)
+			}
+		}
+	})
+
+	t.Run("Tree", func(t *testing.T) {
+		for _, g := range sizes {
+			walk(t, spc.New(g, nil), props)
+		}
+	})
+
+	t.Run("Complete", func(t *testing.T) {
+		for _, g := range sizes {
+			want := Solutions(g)
+			got := walk(t, spc.New(g, nil), props)
+			checkSolutions(t, fmt.Sprintf("below New(%+v, nil)", g), want, got, props.Unique)
+		}
+	})
+
+	t.Run("Prefix", func(t *testing.T) {
+		for _, g := range sizes {
+			all := Solutions(g)
+			for depth := 1; depth <= 2 && depth < int(g.Size); depth++ {
+				for _, prefix := range prefixes(all, depth) {
+					sp := spc.New(g, slices.Clone(prefix))
+					if sp.Grid() != g || !containsAll(sp.Placements(), prefix) || (!props.Skips && sp.Depth() != depth) {
+						t.Fatalf("New(%+v, %v) has Grid() %+v, Depth() %d and Placements() %v, want the prefix's stones", g, prefix, sp.Grid(), sp.Depth(), sp.Placements())
+					}
+					got := walk(t, sp, props)
+					for _, s := range got {
+						if !containsAll(s, prefix) {
+							t.Fatalf("New(%+v, %v) found solution %v, which doesn't contain the prefix", g, prefix, s)
+						}
+					}
+					if props.Ordered {
+						var want []grid.Placements
+						for _, s := range all {
+							if slices.Equal(s[:depth], prefix) {
+								want = append(want, s)
+							}
+						}
+						checkSolutions(t, fmt.Sprintf("below New(%+v, %v)", g, prefix), want, got, props.Unique)
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("ArbitraryPrefix", func(t *testing.T) {
+		for _, g := range sizes {
+			all := Solutions(g)
+			for _, prefix := range pairs(g) {
+				sp, err := placer.NewFromPrefix(spc, g, slices.Clone(prefix))
+				var want []grid.Placements
+				for _, s := range all {
+					if props.Ordered && slices.Equal(s[:2], prefix) || !props.Ordered && containsAll(s, prefix) {
+						want = append(want, s)
+					}
+				}
+				if err != nil {
+					// Only ordered placers promise to search every completion of the prefix, so others may prune ones that have some
+					if !errors.Is(err, placer.ErrCannotComplete) || props.Ordered && len(want) > 0 {
+						t.Fatalf("NewFromPrefix(%+v, %v) error = %v, want a placer for the completions %v", g, prefix, err, want)
+					}
+					continue
+				}
+				got := walk(t, sp, props)
+				if props.Ordered {
+					checkSolutions(t, fmt.Sprintf("below NewFromPrefix(%+v, %v)", g, prefix), want, got, props.Unique)
+				}
+				for _, s := range got {
+					if !containsAll(s, prefix) {
+						t.Fatalf("NewFromPrefix(%+v, %v) found solution %v, which doesn't contain the prefix", g, prefix, s)
+					}
+				}
+			}
+		}
+		for _, g := range sizes {
+			if g.Size < 3 {
+				continue
+			}
+			invalid := grid.Placements{{Row: 0, Col: 0}, {Row: 1, Col: 1}, {Row: 0, Col: 2}}
+			var verr *grid.ValidationError
+			if _, err := placer.NewFromPrefix(spc, g, invalid); !errors.As(err, &verr) {
+				t.Errorf("NewFromPrefix(%+v, %v) error = %v, want a *grid.ValidationError", g, invalid, err)
+			}
+		}
+	})
+}
+
+// pairs returns every sorted pair of distinct cells of the grid, which are all valid prefixes, though not all have completions.
+func pairs(g grid.Grid) []grid.Placements {
+	var out []grid.Placements
+	it := g.Iter()
+	for a, ok := it.Next(); ok; a, ok = it.Next() {
+		for b := grid.AdvanceStone(g, a); grid.IsInBounds(g, b); b = grid.AdvanceStone(g, b) {
+			out = append(out, grid.Placements{a, b})
+		}
+	}
+	return out
+}
+
+// walk searches the whole tree below sp, checking the StonePlacer contract at every node, and returns the solutions found, sorted.
+func walk(t *testing.T, sp placer.StonePlacer, props Properties) []grid.Placements {
+	t.Helper()
+	var solutions []grid.Placements
+	var visit func(sp placer.StonePlacer)
+	visit = func(sp placer.StonePlacer) {
+		p := sp.Placements()
+		if sp.Depth() != len(p) || sp.Depth()+sp.Remaining() != int(sp.Grid().Size) {
+			t.Fatalf("placer with placements %v has Depth() %d and Remaining() %d, which don't add up to the grid size %d", p, sp.Depth(), sp.Remaining(), sp.Grid().Size)
+		}
+		if err := checkPartial(sp.Grid(), p); err != nil {
+			t.Fatalf("placer has invalid placements %v: %v", p, err)
+		}
+		buf := grid.Placements{{Row: 99, Col: 99}}
+		if got := sp.AppendPlacements(buf); got[0] != buf[0] || !sameSet(got[1:], p) {
+			t.Fatalf("placer with placements %v has AppendPlacements(%v) = %v, want them appended", p, buf, got)
+		}
+		if props.Ordered && !slices.Equal(p, sorted(p)) {
+			t.Fatalf("ordered placer has placements %v, which are not in increasing order", p)
+		}
+		if sp.Remaining() == 0 {
+			solutions = append(solutions, sorted(p))
+			return
+		}
+		for !sp.Done() {
+			before := slices.Clone(p)
+			child, err := sp.Place()
+			if err != nil {
+				if !errors.Is(err, placer.ErrConstraintViolated) && !errors.Is(err, placer.ErrCannotComplete) {
+					t.Fatalf("Place() below %v returned error %v, want one matching placer.ErrConstraintViolated or placer.ErrCannotComplete", before, err)
+				}
+				continue
+			}
+			if child == nil {
+				t.Fatalf("Place() below %v returned a nil placer without an error", before)
+			}
+			if d := child.Depth() - len(before); d < 1 || (d > 1 && !props.Skips) {
+				t.Fatalf("Place() below %v returned a placer with placements %v, %d stones deeper", before, child.Placements(), d)
+			}
+			if !containsAll(child.Placements(), before) {
+				t.Fatalf("Place() below %v returned a placer with placements %v, which don't contain its parent's", before, child.Placements())
+			}
+			visit(child)
+		}
+	}
+	visit(sp)
+	slices.SortFunc(solutions, grid.Placements.Compare)
+	return solutions
+}
+
+// checkSolutions reports an error unless got contains every solution in want, and nothing else. Solutions may be repeated in got
+// unless unique is set.
+func checkSolutions(t *testing.T, where string, want, got []grid.Placements, unique bool) {
+	t.Helper()
+	deduplicated := slices.CompactFunc(slices.Clone(got), func(a, b grid.Placements) bool { return slices.Equal(a, b) })
+	if unique && len(deduplicated) != len(got) {
+		t.Errorf("%s found %d solutions, of which only %d are distinct, but the placer should find each once", where, len(got), len(deduplicated))
+	}
+	if len(deduplicated) != len(want) {
+		t.Errorf("%s found %d distinct solutions, want %d", where, len(deduplicated), len(want))
+		return
+	}
+	for i := range want {
+		if !slices.Equal(deduplicated[i], want[i]) {
+			t.Errorf("%s found solution %v, want %v", where, deduplicated[i], want[i])
+			return
+		}
+	}
+}
+
+// Solutions returns every solution for the grid, each sorted, in increasing order. It is a plain search which shares no code with the
+// placers, to check them against.
+func Solutions(g grid.Grid) []grid.Placements {
+	var solutions []grid.Placements
+	var search func(stones grid.Placements, next int)
+	search = func(stones grid.Placements, next int) {
+		if len(stones) == int(g.Size) {
+			solutions = append(solutions, slices.Clone(stones))
+			return
+		}
+		for i := next; i < int(g.Size)*int(g.Size); i++ {
+			p := grid.Point{Row: uint8(i / int(g.Size)), Col: uint8(i % int(g.Size))}
+			if checkPartial(g, append(stones, p)) == nil {
+				search(append(stones, p), i+1)
+			}
+		}
+	}
+	search(make(grid.Placements, 0, g.Size), 0)
+	return solutions
+}
+
+// checkPartial returns an error if the stones are not a valid partial solution: in bounds, distinct, and with unique separations.
+func checkPartial(g grid.Grid, stones grid.Placements) error {
+	separations := make(map[uint16]bool)
+	for i, p1 := range stones {
+		if !grid.IsInBounds(g, p1) {
+			return fmt.Errorf("%v is out of bounds", p1)
+		}
+		for _, p2 := range stones[i+1:] {
+			s := grid.Separation(p1, p2)
+			if s == 0 {
+				return fmt.Errorf("%v is placed twice", p1)
+			}
+			if separations[s] {
+				return fmt.Errorf("separation %d is repeated", s)
+			}
+			separations[s] = true
+		}
+	}
+	return nil
+}
+
+// prefixes returns the distinct sets of the smallest depth stones of the solutions.
+func prefixes(solutions []grid.Placements, depth int) []grid.Placements {
+	var out []grid.Placements
+	for _, s := range solutions {
+		if len(out) == 0 || !slices.Equal(out[len(out)-1], s[:depth]) {
+			out = append(out, s[:depth])
+		}
+	}
+	return out
+}
+
+func sorted(p grid.Placements) grid.Placements {
+	s := slices.Clone(p)
+	s.Sort()
+	return s
+}
+
+func sameSet(a, b grid.Placements) bool {
+	return slices.Equal(sorted(a), sorted(b))
+}
+
+func containsAll(p, subset grid.Placements) bool {
+	for _, s := range subset {
+		if !slices.Contains(p, s) {
+			return false
+		}
+	}
+	return true
+}
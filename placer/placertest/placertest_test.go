@@ -0,0 +1,46 @@
+package placertest_test
+
+import (
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/placer/placertest"
+	"github.com/WillMorrison/pegboard-blog/pruner"
+	"github.com/WillMorrison/pegboard-blog/sets"
+)
+
+func TestPlacers(t *testing.T) {
+	properties := map[string]placertest.Properties{
+		// The unordered placer searches every order of the stones, which takes too long on a 6x6 grid
+		placer.UnorderedStonePlacerName:                          {MaxGridSize: 5},
+		placer.OrderedStonePlacerName:                            {Unique: true, Ordered: true},
+		placer.OrderedNoAllocStonePlacerName:                     {Unique: true, Ordered: true},
+		placer.OrderedNoAllocPruningStonePlacerName:              {Unique: true, Ordered: true},
+		placer.OrderedNoAllocOpportunisticPruningStonePlacerName: {Unique: true, Ordered: true},
+		placer.OrderedBitboardStonePlacerName:                    {Unique: true, Ordered: true},
+		placer.ImpactOrderedPruningStonePlacerName:               {Unique: true},
+		placer.BidirectionalStonePlacerName:                      {Unique: true},
+		placer.ShuffledStonePlacerName:                           {Unique: true, Ordered: true},
+	}
+	for _, name := range placer.Names() {
+		props, ok := properties[name]
+		if !ok {
+			t.Errorf("placer %s has no properties to check", name)
+			continue
+		}
+		reg := placer.Placers[name]
+		if props.MaxGridSize == 0 {
+			props.MaxGridSize = reg.MaxGridSize
+		}
+		t.Run(name, func(t *testing.T) {
+			placertest.Run(t, reg.New(placer.Options{SeparationSetConstructor: sets.NewBitArraySeparationSet, PrunerConstructor: pruner.NewRuntimePruner}), props)
+		})
+		if reg.SupportsBound || reg.SupportsForced {
+			forced := props
+			forced.Skips = reg.SupportsForced
+			t.Run(name+"/bound_forced", func(t *testing.T) {
+				placertest.Run(t, reg.New(placer.Options{PrunerConstructor: pruner.NewRuntimePruner, Bound: true, Forced: reg.SupportsForced}), forced)
+			})
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package placer
+
+import (
+	"slices"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/pruner"
+	"github.com/WillMorrison/pegboard-blog/sets"
+)
+
+// Names of the StonePlacer implementations in Placers, as used on the command line.
+const (
+	UnorderedStonePlacerName                          = "unordered"
+	OrderedStonePlacerName                            = "ordered"
+	OrderedNoAllocStonePlacerName                     = "ordered_noalloc"
+	OrderedNoAllocPruningStonePlacerName              = "ordered_noalloc_pruning"
+	OrderedNoAllocOpportunisticPruningStonePlacerName = "ordered_noalloc_opportunistic_pruning"
+	OrderedBitboardStonePlacerName                    = "ordered_bitboard"
+	ImpactOrderedPruningStonePlacerName               = "impact_ordered_pruning"
+	BidirectionalStonePlacerName                      = "bidirectional"
+	ShuffledStonePlacerName                           = "ordered_shuffled"
+)
+
+// Options holds the settings of the placers in Placers. Each placer ignores the options it doesn't support.
+type Options struct {
+	SeparationSetConstructor sets.SeparationSetConstructor
+	PrunerConstructor        func(grid.Grid) pruner.Pruner
+	Bound                    bool
+	Forced                   bool
+	// Seed orders the candidates of placers that shuffle them
+	Seed int64
+}
+
+// Registration describes a StonePlacer implementation: how to construct it, and which Options it supports.
+type Registration struct {
+	New func(Options) StonePlacerConstructor
+
+	UsesSeparationSet bool
+	UsesPruner        bool
+	SupportsBound     bool
+	SupportsForced    bool
+	SupportsSeed      bool
+	// MaxGridSize is the largest grid size the placer supports, or 0 if it supports them all.
+	MaxGridSize uint8
+}
+
+// Placers maps the name of each StonePlacer implementation to its Registration, so that programs and config files can choose one by name.
+var Placers = map[string]Registration{
+	UnorderedStonePlacerName: {
+		New: func(o Options) StonePlacerConstructor {
+			return UnorderedStonePlacerProvider{SeparationSetConstructor: o.SeparationSetConstructor, PointSetConstructor: sets.NewMapPointSet}
+		},
+		UsesSeparationSet: true,
+	},
+	OrderedStonePlacerName: {
+		New: func(o Options) StonePlacerConstructor {
+			return OrderedStonePlacerProvider{SeparationSetConstructor: o.SeparationSetConstructor}
+		},
+		UsesSeparationSet: true,
+	},
+	OrderedNoAllocStonePlacerName: {
+		New: func(Options) StonePlacerConstructor { return OrderedNoAllocStonePlacerProvider{} },
+	},
+	OrderedNoAllocPruningStonePlacerName: {
+		New: func(o Options) StonePlacerConstructor {
+			return OrderedPruningNoAllocStonePlacerProvider{PrunerConstructor: o.PrunerConstructor, Bound: o.Bound, Forced: o.Forced}
+		},
+		UsesPruner:     true,
+		SupportsBound:  true,
+		SupportsForced: true,
+	},
+	OrderedNoAllocOpportunisticPruningStonePlacerName: {
+		New: func(o Options) StonePlacerConstructor {
+			return OrderedOpportunisticPruningNoAllocStonePlacerProvider{PrunerConstructor: o.PrunerConstructor, Bound: o.Bound, Forced: o.Forced}
+		},
+		UsesPruner:     true,
+		SupportsBound:  true,
+		SupportsForced: true,
+	},
+	OrderedBitboardStonePlacerName: {
+		New:           func(o Options) StonePlacerConstructor { return OrderedBitboardStonePlacerProvider{Bound: o.Bound} },
+		SupportsBound: true,
+		MaxGridSize:   sets.BitboardMaxGridSize,
+	},
+	ImpactOrderedPruningStonePlacerName: {
+		New: func(o Options) StonePlacerConstructor {
+			return ImpactOrderedPruningStonePlacerProvider{PrunerConstructor: o.PrunerConstructor, Bound: o.Bound}
+		},
+		UsesPruner:    true,
+		SupportsBound: true,
+	},
+	BidirectionalStonePlacerName: {
+		New: func(Options) StonePlacerConstructor { return BidirectionalStonePlacerProvider{} },
+	},
+	ShuffledStonePlacerName: {
+		New:          func(o Options) StonePlacerConstructor { return ShuffledStonePlacerProvider{Seed: o.Seed} },
+		SupportsSeed: true,
+	},
+}
+
+// Register adds a StonePlacer implementation to Placers, so that it can be chosen by name like the built in ones, without changes to the
+// programs that choose them. Call it from an init function, as the names are read when flags are defined. It panics if the name is
+// empty or already registered, or the Registration has no New function.
+func Register(name string, reg Registration) {
+	if name == "" || reg.New == nil {
+		panic("placer: Register called without a name or New function")
+	}
+	if _, dup := Placers[name]; dup {
+		panic("placer: Register called twice for " + name)
+	}
+	Placers[name] = reg
+}
+
+// Names returns the names in Placers, sorted.
+func Names() []string {
+	names := make([]string, 0, len(Placers))
+	for name := range Placers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
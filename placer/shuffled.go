@@ -0,0 +1,114 @@
+package placer
+
+import (
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/sets"
+)
+
+// shuffledStonePlacer places stones in increasing order like orderedStonePlacer, so that it places every set of stones once, but tries
+// the candidates for each stone in a random order. The order below each placement depends only on the seed and the placement, so the
+// tree is searched in the same order however a solver splits it between workers.
+type shuffledStonePlacer struct {
+	grid        grid.Grid
+	seed        uint64
+	stones      grid.Placements
+	separations sets.BitArraySeparationSet
+	// candidates are the stones after the last one, in the order they are still to be tried
+	candidates []grid.Point
+}
+
+func (sp *shuffledStonePlacer) Place() (StonePlacer, error) {
+	next := sp.candidates[0]
+	sp.candidates = sp.candidates[1:]
+
+	// Check that placing the next stone doesn't result in duplicate separations
+	separations := sp.separations
+	for _, p := range sp.stones {
+		s := grid.Separation(next, p)
+		if separations.Has(s) {
+			return nil, ErrConstraintViolated
+		}
+		separations.Add(s)
+	}
+
+	// Add the stone to a fresh copy of the placements slice
+	stones := make(grid.Placements, len(sp.stones), len(sp.stones)+1)
+	copy(stones, sp.stones)
+	stones = append(stones, next)
+	return newShuffledStonePlacer(sp.grid, sp.seed, stones, separations), nil
+}
+
+func (sp *shuffledStonePlacer) Done() bool {
+	return len(sp.candidates) == 0
+}
+
+func (sp *shuffledStonePlacer) Grid() grid.Grid {
+	return sp.grid
+}
+
+func (sp *shuffledStonePlacer) Placements() grid.Placements {
+	return sp.stones
+}
+
+func (sp *shuffledStonePlacer) Depth() int {
+	return len(sp.stones)
+}
+
+func (sp *shuffledStonePlacer) Remaining() int {
+	return int(sp.grid.Size) - len(sp.stones)
+}
+
+func (sp *shuffledStonePlacer) AppendPlacements(buf grid.Placements) grid.Placements {
+	return append(buf, sp.stones...)
+}
+
+// newShuffledStonePlacer returns a placer below the stones, with the stones after the last one as its candidates in the order given by
+// the seed and the stones.
+func newShuffledStonePlacer(g grid.Grid, seed uint64, stones grid.Placements, separations sets.BitArraySeparationSet) *shuffledStonePlacer {
+	sp := &shuffledStonePlacer{grid: g, seed: seed, stones: stones, separations: separations}
+	if len(stones) == int(g.Size) {
+		return sp
+	}
+	next := grid.Point{}
+	if len(stones) > 0 {
+		next = grid.AdvanceStone(g, stones[len(stones)-1])
+	}
+	for ; grid.IsInBounds(g, next); next = grid.AdvanceStone(g, next) {
+		sp.candidates = append(sp.candidates, next)
+	}
+
+	// A Fisher-Yates shuffle, with a SplitMix64 stream seeded from the seed and the stones
+	state := mix64(seed)
+	for _, p := range stones {
+		state = mix64(state ^ (uint64(p.Row)<<8 | uint64(p.Col) + 1))
+	}
+	for i := len(sp.candidates) - 1; i > 0; i-- {
+		state += 0x9e3779b97f4a7c15
+		j := mix64(state) % uint64(i+1)
+		sp.candidates[i], sp.candidates[j] = sp.candidates[j], sp.candidates[i]
+	}
+	return sp
+}
+
+// mix64 is the output function of the SplitMix64 generator, a bijection which scrambles the bits of its input.
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// ShuffledStonePlacerProvider constructs placers which place stones in increasing order, trying the candidates for each stone in an
+// order shuffled by Seed. Searches with the same seed try the same candidates in the same order.
+type ShuffledStonePlacerProvider struct {
+	Seed int64
+}
+
+func (spp ShuffledStonePlacerProvider) New(g grid.Grid, p grid.Placements) StonePlacer {
+	var separations sets.BitArraySeparationSet
+	for i, p1 := range p {
+		for _, p2 := range p[:i] {
+			separations.Add(grid.Separation(p1, p2))
+		}
+	}
+	return newShuffledStonePlacer(g, uint64(spp.Seed), p, separations)
+}
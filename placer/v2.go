@@ -0,0 +1,127 @@
+package placer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+// StonePlacerV2 is a StonePlacer whose Place observes a context, and reports why a stone was not placed with a *RejectionError. The
+// solvers search with the StonePlacerV2s that AdaptV2Constructor adapts from their StonePlacerConstructor, so that each placement
+// checks whether the search was canceled.
+type StonePlacerV2 interface {
+	// Place attempts to place a stone. If placement is successful, it returns a new StonePlacerV2, otherwise it returns a *RejectionError.
+	// If the context is done, no stone is placed and the error's Reason is RejectedCanceled.
+	Place(context.Context) (StonePlacerV2, error)
+
+	PlacementState
+}
+
+type StonePlacerV2Constructor interface {
+	// New returns a new StonePlacerV2 that places on the given grid, with the given existing stones.
+	New(grid.Grid, grid.Placements) StonePlacerV2
+}
+
+// RejectionReason identifies why a StonePlacerV2 did not place a stone
+type RejectionReason string
+
+const (
+	// RejectedConstraintViolated means the stone would repeat the separation between two stones, see ErrConstraintViolated
+	RejectedConstraintViolated RejectionReason = "constraint_violated"
+	// RejectedCannotComplete means too few candidate points would remain to complete the placements, see ErrCannotComplete
+	RejectedCannotComplete RejectionReason = "cannot_complete"
+	// RejectedCanceled means the context was done before the stone was placed
+	RejectedCanceled RejectionReason = "canceled"
+	// RejectedOther is for errors that the other reasons don't describe
+	RejectedOther RejectionReason = "other"
+)
+
+// RejectionError describes why a stone was not placed. It wraps the underlying error, such as ErrConstraintViolated or the context's error,
+// so errors.Is can be used on it too.
+type RejectionError struct {
+	Reason RejectionReason
+	Err    error
+}
+
+func (e *RejectionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RejectionError) Unwrap() error {
+	return e.Err
+}
+
+// The rejections of the sentinel errors, which are shared so that rejecting a stone doesn't allocate
+var (
+	rejectedConstraintViolated = &RejectionError{Reason: RejectedConstraintViolated, Err: ErrConstraintViolated}
+	rejectedCannotComplete     = &RejectionError{Reason: RejectedCannotComplete, Err: ErrCannotComplete}
+)
+
+// IsCanceled returns whether an error returned by a StonePlacerV2's Place means that the context was done, rather than that the
+// stone was rejected, so the search should stop.
+func IsCanceled(err error) bool {
+	rerr, ok := err.(*RejectionError)
+	return ok && rerr.Reason == RejectedCanceled
+}
+
+// rejection returns the *RejectionError for an error returned by a StonePlacer's Place
+func rejection(err error) *RejectionError {
+	switch {
+	case err == ErrConstraintViolated:
+		return rejectedConstraintViolated
+	case err == ErrCannotComplete:
+		return rejectedCannotComplete
+	case errors.Is(err, ErrConstraintViolated):
+		return &RejectionError{Reason: RejectedConstraintViolated, Err: err}
+	case errors.Is(err, ErrCannotComplete):
+		return &RejectionError{Reason: RejectedCannotComplete, Err: err}
+	}
+	return &RejectionError{Reason: RejectedOther, Err: err}
+}
+
+// v2StonePlacer adapts a StonePlacer to StonePlacerV2. The adapters of a search share their memory like the placers of
+// OrderedNoAllocStonePlacerProvider do, with one for each depth, so that placing a stone doesn't allocate one. Placing a stone
+// reuses the adapter at the new placer's depth, so like a StonePlacer, a placer returned by Place is only valid until its parent
+// places another stone.
+type v2StonePlacer struct {
+	StonePlacer
+	adapters []v2StonePlacer
+}
+
+// AdaptV2 returns a StonePlacerV2 which places stones with sp, checking the context before each placement.
+func AdaptV2(sp StonePlacer) StonePlacerV2 {
+	adapters := make([]v2StonePlacer, sp.Grid().Size+1)
+	a := &adapters[sp.Depth()]
+	*a = v2StonePlacer{StonePlacer: sp, adapters: adapters}
+	return a
+}
+
+func (sp *v2StonePlacer) Place(ctx context.Context) (StonePlacerV2, error) {
+	select {
+	case <-ctx.Done():
+		return nil, &RejectionError{Reason: RejectedCanceled, Err: ctx.Err()}
+	default:
+	}
+	next, err := sp.StonePlacer.Place()
+	if err != nil {
+		return nil, rejection(err)
+	}
+	a := &sp.adapters[next.Depth()]
+	*a = v2StonePlacer{StonePlacer: next, adapters: sp.adapters}
+	return a, nil
+}
+
+// v2StonePlacerConstructor adapts a StonePlacerConstructor to StonePlacerV2Constructor
+type v2StonePlacerConstructor struct {
+	spc StonePlacerConstructor
+}
+
+// AdaptV2Constructor returns a StonePlacerV2Constructor which adapts the placers that spc constructs with AdaptV2.
+func AdaptV2Constructor(spc StonePlacerConstructor) StonePlacerV2Constructor {
+	return v2StonePlacerConstructor{spc}
+}
+
+func (spc v2StonePlacerConstructor) New(g grid.Grid, p grid.Placements) StonePlacerV2 {
+	return AdaptV2(spc.spc.New(g, p))
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/pruner"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// planEstimate is the planner's estimate of how long one configuration takes to search one grid size.
+type planEstimate struct {
+	Size           uint8
+	Placer, Solver string
+	// NodesPerSecond is the rate the calibration run placed stones at, and Nodes the estimated size of the whole search tree
+	NodesPerSecond float64
+	Nodes          float64
+	// Finished is whether the calibration run finished the search, in which case Time is how long it took rather than an estimate
+	Finished bool
+	Time     time.Duration
+}
+
+// planConfiguration calibrates one configuration on a grid: it searches for up to the calibration time to measure how fast stones are
+// placed, and estimates the size of the search tree from random probes. The estimate is of the time to search the whole tree, which is an
+// upper bound for sizes with a solution, and assumes the rate at the start of the search holds for the rest of it.
+func planConfiguration(ctx context.Context, g grid.Grid, placerName, solverName string, calibration time.Duration, probes int, seed int64, workers int) (planEstimate, error) {
+	stats := &solver.Stats{}
+	builder := solver.NewBuilder().Grid(g).Placer(placerName).Solver(solverName).Stats(stats)
+	spc, err := builder.BuildPlacer()
+	if err != nil {
+		return planEstimate{}, err
+	}
+	spp, err := builder.BuildStartingPoints()
+	if err != nil {
+		return planEstimate{}, err
+	}
+	s, err := builder.Build()
+	if err != nil {
+		return planEstimate{}, err
+	}
+	e := planEstimate{Size: g.Size, Placer: builder.PlacerName(), Solver: builder.SolverName()}
+	// Build the pruner's tables before the calibration run, so they don't count against its rate
+	if builder.UsesPruner() {
+		pruner.NewPrecomputedPrunerContext(ctx, g)
+	}
+
+	calibrationCtx, cancel := context.WithTimeout(ctx, calibration)
+	defer cancel()
+	start := time.Now()
+	_, err = s.SolveContext(calibrationCtx, g)
+	elapsed := time.Since(start)
+	switch {
+	case err == nil || errors.Is(err, solver.ErrNoSolution):
+		e.Finished, e.Time = true, elapsed
+	case !errors.Is(err, solver.ErrTimeout):
+		return planEstimate{}, err
+	}
+	e.NodesPerSecond = float64(stats.Nodes.Load()) / elapsed.Seconds()
+
+	for _, estimate := range solver.EstimateTreeSizeParallel(g, spp, spc, probes, solver.RandStreams{Seed: seed}, workers) {
+		e.Nodes += estimate.Nodes
+	}
+	if !e.Finished && e.NodesPerSecond > 0 {
+		e.Time = time.Duration(e.Nodes / e.NodesPerSecond * float64(time.Second))
+	}
+	return e, nil
+}
+
+// formatPlanTime formats a time in the largest units that keep it readable, as week-long searches are poorly served by hours.
+func formatPlanTime(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return d.Round(time.Millisecond).String()
+	case d < 48*time.Hour:
+		return d.Round(time.Second).String()
+	case d < 2*365*24*time.Hour:
+		return fmt.Sprintf("%.1f days", d.Hours()/24)
+	}
+	return fmt.Sprintf("%.1f years", d.Hours()/24/365)
+}
+
+// writePlan writes a table of the estimates.
+func writePlan(w io.Writer, estimates []planEstimate) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "size\tplacer\tsolver\tnodes/s\ttree size\ttime\t")
+	for _, e := range estimates {
+		t := "~" + formatPlanTime(e.Time)
+		if e.Finished {
+			t = formatPlanTime(e.Time) + " (measured)"
+		} else if e.NodesPerSecond == 0 {
+			t = "unknown"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%.4g\t%.4g\t%s\t\n", e.Size, e.Placer, e.Solver, e.NodesPerSecond, e.Nodes, t)
+	}
+	tw.Flush()
+}
+
+// plan implements the plan subcommand, which estimates how long each combination of grid size, placer and solver would take to search,
+// from short calibration runs, before committing to a full run.
+func plan(args []string) {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	minSize := fs.Uint("min_size", 10, "the smallest grid size to plan for")
+	maxSize := fs.Uint("max_size", grid.MaxGridSize, "the largest grid size to plan for")
+	placers := fs.String("placers", solver.AutoName, "comma separated placers to plan for")
+	solvers := fs.String("solvers", solver.AutoName, "comma separated solvers to plan for")
+	calibration := fs.Duration("calibration", 2*time.Second, "how long to search with each configuration to measure the rate stones are placed at")
+	probes := fs.Int("probes", 1000, "number of random probes per starting point to estimate the search tree size from")
+	seed := fs.Int64("seed", 0, "seed for the random probes, or 0 for a seed from the clock")
+	parseFlags(fs, fs.Name(), args)
+	if *minSize < 1 || *maxSize > grid.MaxGridSize || *minSize > *maxSize {
+		fatalf("The sizes must be between 1 and %d, got %d to %d", grid.MaxGridSize, *minSize, *maxSize)
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	// Plan with the same workers as a search would use
+	usePhysicalCores()
+	workers := runtime.GOMAXPROCS(0)
+	fmt.Printf("# Calibrating each configuration for %v, with %d probes per starting point (seed %d, workers %d)\n", *calibration, *probes, *seed, workers)
+	var estimates []planEstimate
+	for size := *minSize; size <= *maxSize; size++ {
+		for _, placerName := range strings.Split(*placers, ",") {
+			for _, solverName := range strings.Split(*solvers, ",") {
+				e, err := planConfiguration(context.Background(), grid.Grid{Size: uint8(size)}, placerName, solverName, *calibration, *probes, *seed, workers)
+				if err != nil {
+					log.Printf("Skipping %s placer and %s solver for %dx%d: %v", placerName, solverName, size, size, err)
+					continue
+				}
+				estimates = append(estimates, e)
+			}
+		}
+	}
+	writePlan(os.Stdout, estimates)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+func TestFormatPlanTime(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{1500 * time.Microsecond, "2ms"},
+		{90*time.Minute + 400*time.Millisecond, "1h30m0s"},
+		{7 * 24 * time.Hour, "7.0 days"},
+		{3 * 365 * 24 * time.Hour, "3.0 years"},
+	}
+	for _, tt := range tests {
+		if got := formatPlanTime(tt.d); got != tt.want {
+			t.Errorf("formatPlanTime(%v) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestPlanConfiguration(t *testing.T) {
+	g := grid.Grid{Size: 5}
+	e, err := planConfiguration(context.Background(), g, placer.OrderedNoAllocStonePlacerName, solver.SingleThreadedSolverName, time.Minute, 10, 1, 1)
+	if err != nil {
+		t.Fatalf("planConfiguration() error = %v", err)
+	}
+	if !e.Finished {
+		t.Errorf("planConfiguration() didn't finish searching %+v within a minute", g)
+	}
+	if e.Nodes <= 0 || e.NodesPerSecond <= 0 {
+		t.Errorf("planConfiguration() = %+v, want positive nodes and nodes per second", e)
+	}
+	if e.Placer != placer.OrderedNoAllocStonePlacerName || e.Solver != solver.SingleThreadedSolverName {
+		t.Errorf("planConfiguration() planned for the %s placer and %s solver, want %s and %s", e.Placer, e.Solver, placer.OrderedNoAllocStonePlacerName, solver.SingleThreadedSolverName)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"net/http/pprof"
+)
+
+// pprofHandler returns a handler serving the runtime's profiles at /debug/pprof/, as net/http/pprof does on the default mux.
+func pprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
+
+// servePprof serves the runtime's profiles at /debug/pprof/ on the address, so CPU, heap and goroutine profiles can be taken from a long
+// search with go tool pprof without deciding on -cpuprofile or -memprofile before it starts.
+func servePprof(addr string) {
+	go func() {
+		log.Printf("Serving profiles on http://%s/debug/pprof/", addr)
+		if err := http.ListenAndServe(addr, pprofHandler()); err != nil {
+			log.Printf("Could not serve profiles: %v", err)
+		}
+	}()
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// progressProbes is the number of random probes per starting point that -progress estimates the size of the search tree from.
+const progressProbes = 1000
+
+// progressReport describes the progress of a search at one point in time.
+type progressReport struct {
+	Elapsed        time.Duration
+	Nodes          int64
+	NodesPerSecond float64
+	// DepthNodes are the nodes placed at each depth since the previous report, which shows where in the tree the search is working.
+	DepthNodes []int64
+	TasksDone  int64
+	TasksTotal int64
+	// Completion is the estimated fraction of the search done, or negative if there is no estimate, and ETA the estimated time to finish
+	// it, or 0 if there is no estimate.
+	Completion float64
+	ETA        time.Duration
+}
+
+// progressReporter turns snapshots of a search's statistics into reports of its progress since the previous snapshot.
+type progressReporter struct {
+	start time.Time
+	// estimatedNodes is the estimated size of the whole search tree, or 0 if it wasn't estimated
+	estimatedNodes float64
+	last           time.Time
+	lastNodes      int64
+	lastDepths     []int64
+}
+
+func newProgressReporter(start time.Time, estimatedNodes float64) *progressReporter {
+	return &progressReporter{start: start, estimatedNodes: estimatedNodes, last: start}
+}
+
+// report returns the progress at now, given a snapshot of the statistics and the depth statistics. The completion is the fraction of
+// the estimated tree searched if there is an estimate, which overestimates the time left for sizes with a solution, as the search stops
+// at the first. Otherwise it is the fraction of tasks done, if the solver counts them.
+func (pr *progressReporter) report(now time.Time, p solver.Progress, depths []solver.DepthStats) progressReport {
+	r := progressReport{
+		Elapsed:    now.Sub(pr.start),
+		Nodes:      p.Nodes,
+		TasksDone:  p.TasksDone,
+		TasksTotal: p.TasksTotal,
+		DepthNodes: make([]int64, len(depths)),
+		Completion: -1,
+	}
+	if interval := now.Sub(pr.last).Seconds(); interval > 0 {
+		r.NodesPerSecond = float64(p.Nodes-pr.lastNodes) / interval
+	}
+	for i, d := range depths {
+		r.DepthNodes[i] = d.Nodes
+		if i < len(pr.lastDepths) {
+			r.DepthNodes[i] -= pr.lastDepths[i]
+		}
+	}
+	switch {
+	case pr.estimatedNodes > 0:
+		// The estimate is rough, so never claim the search is done before it is
+		r.Completion = min(float64(p.Nodes)/pr.estimatedNodes, 0.99)
+	case p.TasksTotal > 0:
+		r.Completion = float64(p.TasksDone) / float64(p.TasksTotal)
+	}
+	if r.Completion > 0 {
+		r.ETA = time.Duration(float64(r.Elapsed) * (1 - r.Completion) / r.Completion).Round(time.Second)
+	}
+
+	pr.last, pr.lastNodes = now, p.Nodes
+	pr.lastDepths = pr.lastDepths[:0]
+	for _, d := range depths {
+		pr.lastDepths = append(pr.lastDepths, d.Nodes)
+	}
+	return r
+}
+
+// writeProgress writes a report as one line.
+func writeProgress(w io.Writer, r progressReport) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%v: %d nodes, %.4g nodes/s", r.Elapsed.Round(time.Second), r.Nodes, r.NodesPerSecond)
+	var depths []string
+	for depth, n := range r.DepthNodes {
+		if n > 0 {
+			depths = append(depths, fmt.Sprintf("%d:%.3g", depth, float64(n)))
+		}
+	}
+	if len(depths) > 0 {
+		fmt.Fprintf(&b, ", depths %s", strings.Join(depths, " "))
+	}
+	if r.TasksTotal > 0 {
+		fmt.Fprintf(&b, ", %d/%d tasks done", r.TasksDone, r.TasksTotal)
+	}
+	if r.Completion >= 0 {
+		fmt.Fprintf(&b, ", ~%.1f%% done", 100*r.Completion)
+	}
+	if r.ETA > 0 {
+		fmt.Fprintf(&b, ", ETA %v", r.ETA)
+	}
+	fmt.Fprintln(w, b.String())
+}
+
+// reportProgressEvery writes a report of the search's progress to w every interval until the returned function is called.
+func reportProgressEvery(ctx context.Context, w io.Writer, st *solver.Stats, interval time.Duration, estimatedNodes float64) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	pr := newProgressReporter(time.Now(), estimatedNodes)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for p := range solver.ReportProgress(ctx, st, interval) {
+			writeProgress(w, pr.report(time.Now(), p, st.Depths()))
+		}
+	}()
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestProgressReporter(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		estimatedNodes float64
+		want           []progressReport
+	}{
+		{
+			name: "from tasks",
+			want: []progressReport{
+				{Elapsed: time.Second, Nodes: 100, NodesPerSecond: 100, DepthNodes: []int64{1, 99}, TasksTotal: 4, Completion: 0},
+				{Elapsed: 3 * time.Second, Nodes: 300, NodesPerSecond: 100, DepthNodes: []int64{0, 150, 50}, TasksDone: 1, TasksTotal: 4, Completion: 0.25, ETA: 9 * time.Second},
+			},
+		},
+		{
+			name:           "from estimated tree size",
+			estimatedNodes: 1000,
+			want: []progressReport{
+				{Elapsed: time.Second, Nodes: 100, NodesPerSecond: 100, DepthNodes: []int64{1, 99}, TasksTotal: 4, Completion: 0.1, ETA: 9 * time.Second},
+				{Elapsed: 3 * time.Second, Nodes: 300, NodesPerSecond: 100, DepthNodes: []int64{0, 150, 50}, TasksDone: 1, TasksTotal: 4, Completion: 0.3, ETA: 7 * time.Second},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr := newProgressReporter(start, tt.estimatedNodes)
+			got := []progressReport{
+				pr.report(start.Add(time.Second), solver.Progress{Nodes: 100, TasksTotal: 4}, []solver.DepthStats{{Nodes: 1}, {Nodes: 99}}),
+				pr.report(start.Add(3*time.Second), solver.Progress{Nodes: 300, TasksDone: 1, TasksTotal: 4}, []solver.DepthStats{{Nodes: 1}, {Nodes: 249}, {Nodes: 50}}),
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.Comparer(func(a, b float64) bool { return a-b < 1e-9 && b-a < 1e-9 })); diff != "" {
+				t.Errorf("report() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteProgress(t *testing.T) {
+	var b strings.Builder
+	writeProgress(&b, progressReport{Elapsed: 3 * time.Second, Nodes: 300, NodesPerSecond: 100, DepthNodes: []int64{0, 150, 50}, TasksDone: 1, TasksTotal: 4, Completion: 0.25, ETA: 9 * time.Second})
+	want := "3s: 300 nodes, 100 nodes/s, depths 1:150 2:50, 1/4 tasks done, ~25.0% done, ETA 9s\n"
+	if got := b.String(); got != want {
+		t.Errorf("writeProgress() = %q, want %q", got, want)
+	}
+}
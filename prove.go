@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/pruner"
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"github.com/hashicorp/packer/command/enumflag"
+)
+
+// proofStartingPoint is the search below one starting point of a proof.
+type proofStartingPoint struct {
+	StartingPoint grid.Placements `json:"starting_point"`
+	Nodes         int64           `json:"nodes"`
+	DurationNanos int64           `json:"duration_ns"`
+	// Subtrees is the number of subtrees the solver split the search below the starting point into, and Finished the number searched
+	// to the end
+	Subtrees  int64 `json:"subtrees"`
+	Finished  int64 `json:"finished"`
+	Exhausted bool  `json:"exhausted"`
+}
+
+// proofSummary is the record of a prove run: the strategies searched with, what the search found, and the evidence that it was
+// exhaustive. Proved is only true if the search found no solution and every starting point was exhausted.
+type proofSummary struct {
+	Size           uint8  `json:"size"`
+	Placer         string `json:"placer"`
+	Solver         string `json:"solver"`
+	StartingPoints string `json:"starting_points"`
+	// Outcome is one of solved, no_solution, canceled, node_budget, timeout or error, as in run bundles
+	Outcome       string          `json:"outcome"`
+	Proved        bool            `json:"proved"`
+	Solution      grid.Placements `json:"solution,omitempty"`
+	Error         string          `json:"error,omitempty"`
+	Duration      string          `json:"duration"`
+	DurationNanos int64           `json:"duration_ns"`
+	Nodes         int64           `json:"nodes"`
+	// Tried is the number of stones the placers tried to place, and Rejections the number of them rejected by each rule
+	Tried      int64                            `json:"tried"`
+	Rejections map[placer.RejectionReason]int64 `json:"rejections"`
+	// Pruned is the number of candidate points pruned by each rule of the pruner, which the placer then skips without trying. It is
+	// only set for the placers that use a pruner.
+	Pruned               map[string]int64     `json:"pruned,omitempty"`
+	StartingPointResults []proofStartingPoint `json:"starting_point_results"`
+	// Metadata describes the run, so that the proof can be reproduced
+	Metadata *runMetadata `json:"metadata,omitempty"`
+}
+
+// newProofSummary summarizes a search from its result and statistics.
+// pruneCounts are the points pruned by the pruner, or nil if the placer doesn't use one.
+func newProofSummary(builder *solver.Builder, startingPoints string, g grid.Grid, solution grid.Placements, err error, duration time.Duration, stats *solver.Stats, pruneCounts *pruner.Counts) proofSummary {
+	p := proofSummary{
+		Size: g.Size, Placer: builder.PlacerName(), Solver: builder.SolverName(), StartingPoints: startingPoints, Outcome: outcome(err),
+		Duration: duration.String(), DurationNanos: duration.Nanoseconds(), Nodes: stats.Nodes.Load(), Rejections: stats.Rejections(),
+	}
+	if err == nil {
+		p.Solution = solution
+	} else {
+		p.Error = err.Error()
+	}
+	for _, d := range stats.Depths() {
+		p.Tried += d.Tried
+	}
+	if pruneCounts != nil {
+		p.Pruned = map[string]int64{"isoceles": pruneCounts.Isoceles.Load(), "circles": pruneCounts.Circles.Load()}
+	}
+	p.Proved = errors.Is(err, solver.ErrNoSolution)
+	for _, sp := range stats.StartingPoints() {
+		p.StartingPointResults = append(p.StartingPointResults, proofStartingPoint{
+			StartingPoint: sp.StartingPoint, Nodes: sp.Nodes, DurationNanos: sp.Time.Nanoseconds(),
+			Subtrees: sp.Subtrees, Finished: sp.Finished, Exhausted: sp.Exhausted(),
+		})
+		p.Proved = p.Proved && sp.Exhausted()
+	}
+	return p
+}
+
+// writeProofSummary writes the summary as text: the verdict, the rejections by rule, and a table of the starting points.
+func writeProofSummary(w io.Writer, p proofSummary) {
+	duration := time.Duration(p.DurationNanos)
+	switch {
+	case p.Proved:
+		fmt.Fprintf(w, "Proved that no solution exists for %dx%d: searched below all %d starting points exhaustively, making %d placements in %v\n",
+			p.Size, p.Size, len(p.StartingPointResults), p.Nodes, duration)
+	case p.Outcome == "solved":
+		fmt.Fprintf(w, "No proof: found solution %v for %dx%d in %v\n", p.Solution, p.Size, p.Size, duration)
+	case p.Outcome == "no_solution":
+		// The solver's verdict and its statistics disagree, which is a bug in the solver
+		fmt.Fprintf(w, "No proof: the search of %dx%d found no solution, but not every starting point was searched to the end\n", p.Size, p.Size)
+	default:
+		fmt.Fprintf(w, "No proof: the search of %dx%d stopped after %v and %d placements: %s\n", p.Size, p.Size, duration, p.Nodes, p.Error)
+	}
+	fmt.Fprintf(w, "Strategy: %s placer, %s solver, %s starting points\n", p.Placer, p.Solver, p.StartingPoints)
+	fmt.Fprintf(w, "Stones tried: %d, placed: %d, rejected by the separation constraint: %d, by the completion bound: %d\n",
+		p.Tried, p.Nodes, p.Rejections[placer.RejectedConstraintViolated], p.Rejections[placer.RejectedCannotComplete])
+	if p.Pruned != nil {
+		fmt.Fprintf(w, "Candidates pruned by isoceles triangles: %d, by circles: %d\n", p.Pruned["isoceles"], p.Pruned["circles"])
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "starting point\tnodes\ttime\tsubtrees\texhausted\t")
+	for _, sp := range p.StartingPointResults {
+		exhausted := "no"
+		if sp.Exhausted {
+			exhausted = "yes"
+		}
+		fmt.Fprintf(tw, "%v\t%d\t%v\t%d/%d\t%s\t\n", sp.StartingPoint, sp.Nodes, time.Duration(sp.DurationNanos).Round(time.Microsecond), sp.Finished, sp.Subtrees, exhausted)
+	}
+	tw.Flush()
+}
+
+// writeProof writes the summary in the format to the named file, or stdout if filename is empty.
+func writeProof(filename, format string, p proofSummary) error {
+	write := func(w io.Writer) error {
+		if format == JSONOutputFormat {
+			return writeJSONResult(w, p)
+		}
+		writeProofSummary(w, p)
+		return nil
+	}
+	if filename == "" {
+		return write(os.Stdout)
+	}
+	out, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := write(out); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// prove implements the prove subcommand, which searches a grid exhaustively to prove that it has no solution. The search stops at the
+// first solution, which disproves the claim, and the proof only holds if the starting points cover every solution up to symmetry.
+// It writes a summary of the search as the proof: its placements, the stones and candidates each rule rejected or pruned, and whether
+// the search below each starting point was exhausted.
+// It returns the exit code for the outcome of the search, which is exitNoSolution for a proof.
+func prove(args []string) int {
+	fs := flag.NewFlagSet("prove", flag.ExitOnError)
+	sf := addStrategyFlags(fs, true)
+	quiet := fs.Bool("quiet", false, "print nothing to stdout, for scripts which only need the exit code, which is 1 for a proof")
+	outputFile := fs.String("output", "", "also write the proof summary to this file in the -format, to keep as the record of the proof")
+	format := TextOutputFormat
+	fs.Var(enumflag.New(&format, TextOutputFormat, JSONOutputFormat), "format", "format to write the proof summary to -output in")
+	parseFlags(fs, fs.Name(), args)
+	if *quiet {
+		silenceStdout()
+	}
+	stats := &solver.Stats{}
+	var pruneCounts *pruner.Counts
+	g, builder, s, ctx, stop := sf.setupSearch(func(b *solver.Builder) {
+		b.Stats(stats)
+		if b.UsesPruner() {
+			pruneCounts = &pruner.Counts{}
+			b.PruneCounts(pruneCounts)
+		}
+	})
+	defer stop()
+
+	startTime := time.Now()
+	solution, err := s.SolveContext(ctx, g)
+	duration := time.Since(startTime)
+	if err == nil {
+		solution.Sort()
+	}
+	p := newProofSummary(builder, sf.startingPoints, g, solution, err, duration, stats, pruneCounts)
+	p.Metadata = newRunMetadata(fs)
+	writeProofSummary(os.Stdout, p)
+	if *outputFile != "" {
+		if err := writeProof(*outputFile, format, p); err != nil {
+			fatal(err)
+		}
+	}
+	if errors.Is(err, solver.ErrNoSolution) && !p.Proved {
+		return exitStopped
+	}
+	return exitCode(err)
+}
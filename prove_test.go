@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNewProofSummary(t *testing.T) {
+	g := grid.Grid{Size: 6}
+	stats := &solver.Stats{}
+	builder := solver.NewBuilder().Placer(placer.OrderedNoAllocStonePlacerName).Solver(solver.SingleThreadedSolverName).Stats(stats)
+	s, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, err := s.Solve(g)
+	p := newProofSummary(builder, solver.SingleOctantStartingPointsName, g, solution, err, time.Second, stats, nil)
+	if p.Proved || p.Outcome != "solved" {
+		t.Errorf("newProofSummary() of a solved search has Proved %t and outcome %s, want false and solved", p.Proved, p.Outcome)
+	}
+	var rejected int64
+	for _, n := range p.Rejections {
+		rejected += n
+	}
+	if p.Tried != p.Nodes+rejected {
+		t.Errorf("newProofSummary() tried %d stones, want the %d placed and %d rejected", p.Tried, p.Nodes, rejected)
+	}
+	// The single threaded solver searches the starting points in order, so those before the solved one are exhausted
+	solvedAt := len(p.StartingPointResults)
+	for i, sp := range stats.StartingPoints() {
+		if sp.Solved {
+			solvedAt = min(solvedAt, i)
+		}
+	}
+	for i, sp := range p.StartingPointResults {
+		if want := i < solvedAt; sp.Exhausted != want {
+			t.Errorf("newProofSummary() starting point %v has Exhausted %t, want %t", sp.StartingPoint, sp.Exhausted, want)
+		}
+	}
+}
+
+func TestWriteProofSummary(t *testing.T) {
+	p := proofSummary{
+		Size: 8, Placer: "ordered_noalloc", Solver: "async", StartingPoints: "single_octant", Outcome: "no_solution", Proved: true,
+		DurationNanos: int64(2 * time.Second), Nodes: 400, Tried: 1000,
+		Rejections: map[placer.RejectionReason]int64{placer.RejectedConstraintViolated: 550, placer.RejectedCannotComplete: 50},
+		Pruned:     map[string]int64{"isoceles": 700, "circles": 900},
+		StartingPointResults: []proofStartingPoint{
+			{StartingPoint: grid.Placements{{Row: 0, Col: 0}}, Nodes: 300, DurationNanos: int64(3 * time.Millisecond), Subtrees: 1, Finished: 1, Exhausted: true},
+			{StartingPoint: grid.Placements{{Row: 0, Col: 1}}, Nodes: 100, DurationNanos: int64(time.Millisecond), Subtrees: 3, Finished: 3, Exhausted: true},
+		},
+	}
+	want := `Proved that no solution exists for 8x8: searched below all 2 starting points exhaustively, making 400 placements in 2s
+Strategy: ordered_noalloc placer, async solver, single_octant starting points
+Stones tried: 1000, placed: 400, rejected by the separation constraint: 550, by the completion bound: 50
+Candidates pruned by isoceles triangles: 700, by circles: 900
+  starting point  nodes  time  subtrees  exhausted
+            [A0]    300   3ms       1/1        yes
+            [A1]    100   1ms       3/3        yes
+`
+	var b strings.Builder
+	writeProofSummary(&b, p)
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("writeProofSummary() output mismatch (-want +got):\n%s", diff)
+	}
+}
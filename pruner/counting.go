@@ -0,0 +1,59 @@
+package pruner
+
+import (
+	"math/bits"
+	"sync/atomic"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/sets"
+)
+
+// Counts are the numbers of points that counting pruners added to sets that didn't already have them, by the rule that pruned them.
+// Points that both rules would prune are counted for the rule that the placer applied first.
+type Counts struct {
+	Isoceles atomic.Int64
+	Circles  atomic.Int64
+}
+
+// countingPruner prunes with another Pruner, counting the points it adds
+type countingPruner struct {
+	pruner Pruner
+	counts *Counts
+}
+
+// Counting returns a constructor of pruners which prune like the pruners that newPruner constructs, and add the points they prune to
+// counts. Counting costs a few operations per call, and an atomic add when points are pruned, so is meant for runs which report it.
+func Counting(newPruner func(grid.Grid) Pruner, counts *Counts) func(grid.Grid) Pruner {
+	return func(g grid.Grid) Pruner {
+		return countingPruner{pruner: newPruner(g), counts: counts}
+	}
+}
+
+func (p countingPruner) PruneIsoceles(ps sets.PointSet, p1, p2 grid.Point) {
+	before := pointCount(ps)
+	p.pruner.PruneIsoceles(ps, p1, p2)
+	if n := pointCount(ps) - before; n > 0 {
+		p.counts.Isoceles.Add(int64(n))
+	}
+}
+
+func (p countingPruner) PruneCircles(ps sets.PointSet, p1 grid.Point, sep uint16) {
+	before := pointCount(ps)
+	p.pruner.PruneCircles(ps, p1, sep)
+	if n := pointCount(ps) - before; n > 0 {
+		p.counts.Circles.Add(int64(n))
+	}
+}
+
+// pointCount returns the number of points in the set, without allocating for the bit array sets that the placers use
+func pointCount(ps sets.PointSet) int {
+	bs, ok := ps.(*sets.BitArrayPointSet)
+	if !ok {
+		return len(ps.Elements())
+	}
+	n := 0
+	for _, row := range bs {
+		n += bits.OnesCount16(row)
+	}
+	return n
+}
@@ -1,10 +1,20 @@
 package pruner
 
 import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
 
 	"github.com/WillMorrison/pegboard-blog/grid"
 	"github.com/WillMorrison/pegboard-blog/sets"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Pruner interface {
@@ -46,23 +56,119 @@ func (p runtimePruner) PruneCircles(ps sets.PointSet, p1 grid.Point, sep uint16)
 
 type precomputedPruner struct {
 	isoceles [grid.MaxGridSize][grid.MaxGridSize][grid.MaxGridSize][grid.MaxGridSize]sets.BitArrayPointSet
-	circles  [grid.MaxGridSize][grid.MaxGridSize][grid.MaxSeparation + 1]sets.BitArrayPointSet
+	// Circles centered on the same column differ only by a vertical translation, so they are stamped onto the grid from one mask per column and separation
+	circles *[grid.MaxGridSize][grid.MaxSeparation + 1]circleMask
+	// gridMask is the points of the grid, as words. It removes the parts of stamped circles that fall off the grid
+	gridMask [4]uint64
 }
 
-// Global singleton instances of precomputedPruner by grid size
+// circleMaskCenter is the row of the center of a circleMask
+const circleMaskCenter = grid.MaxGridSize - 1
+
+// circleMask is the set of points on a circle centered at row circleMaskCenter, as the rows of a sets.BitArrayPointSet stored as bytes in
+// native order. It is padded with empty rows so that any 16 consecutive rows starting from the first circleMaskCenter+1 can be read as a whole
+// BitArrayPointSet, which translates the circle vertically onto any row of a grid.
+type circleMask [2 * (circleMaskCenter + 16)]byte
+
+// stamp adds the points of the circle, translated to be centered on row, to ps. Points off the grid are removed with gridMask.
+// This is four word loads, ands, and ors, like sets.BitArrayPointSet.Union.
+func (c *circleMask) stamp(ps *sets.BitArrayPointSet, gridMask *[4]uint64, row uint8) {
+	window := c[2*(circleMaskCenter-int(row)):]
+	v := (*[4]uint64)(unsafe.Pointer(ps))
+	v[0] |= binary.NativeEndian.Uint64(window[0:]) & gridMask[0]
+	v[1] |= binary.NativeEndian.Uint64(window[8:]) & gridMask[1]
+	v[2] |= binary.NativeEndian.Uint64(window[16:]) & gridMask[2]
+	v[3] |= binary.NativeEndian.Uint64(window[24:]) & gridMask[3]
+}
+
+var (
+	circlesOnce sync.Once
+	// circles are indexed by the column of their center and their separation.
+	// Rows are only 16 bits wide, so translating circles horizontally with shifts would lose the columns to the left of the center.
+	circles *[grid.MaxGridSize][grid.MaxSeparation + 1]circleMask
+)
+
+// canonicalCircles returns the circle masks for every column and separation. They don't depend on the grid size, so are shared by all grids.
+func canonicalCircles() *[grid.MaxGridSize][grid.MaxSeparation + 1]circleMask {
+	circlesOnce.Do(func() {
+		circles = new([grid.MaxGridSize][grid.MaxSeparation + 1]circleMask)
+		for col := 0; col < grid.MaxGridSize; col++ {
+			for r := 0; r < circleMaskCenter+16; r++ {
+				var rows [grid.MaxSeparation + 1]uint16
+				for c := 0; c < 16; c++ {
+					dr, dc := r-circleMaskCenter, c-col
+					if sep := dr*dr + dc*dc; sep <= grid.MaxSeparation {
+						rows[sep] |= 0x8000 >> c
+					}
+				}
+				for sep, bits := range rows {
+					binary.NativeEndian.PutUint16(circles[col][sep][2*r:], bits)
+				}
+			}
+		}
+	})
+	return circles
+}
+
+var tracer = otel.Tracer("github.com/WillMorrison/pegboard-blog/pruner")
+
+// logger receives diagnostics about the precomputed tables, or is nil to discard them.
+// The tables are shared by every pruner of a grid size, so the logger is set for the package rather than per pruner.
+var logger atomic.Pointer[slog.Logger]
+
+// SetLogger sets the logger that diagnostics about precomputing pruner tables are written to, or discards them if l is nil.
+func SetLogger(l *slog.Logger) {
+	logger.Store(l)
+}
+
+func logDebug(msg string, args ...any) {
+	if l := logger.Load(); l != nil {
+		l.Debug(msg, args...)
+	}
+}
+
+// Global singleton instances of precomputedPruner, indexed by grid size
 var (
 	mu                       sync.Mutex
-	cachedPrecomputedPruners []*precomputedPruner = make([]*precomputedPruner, grid.MaxGridSize)
+	cachedPrecomputedPruners []*precomputedPruner = make([]*precomputedPruner, grid.MaxGridSize+1)
 )
 
+// NewPrecomputedPruner returns the precomputed Pruner for the grid. Pruners are cached, and when a larger grid's pruner is already cached
+// the tables are derived from it by masking, which is much quicker than computing them, so searches of several sizes should start with the largest.
+// The pruner of a grid of size 0 prunes nothing, as the grid has no points.
 func NewPrecomputedPruner(g grid.Grid) Pruner {
 	mu.Lock()
 	defer mu.Unlock()
-	if pruner := cachedPrecomputedPruners[g.Size-1]; pruner != nil {
+	if pruner := cachedPrecomputedPruners[g.Size]; pruner != nil {
 		return pruner
 	}
+	start := time.Now()
+	var p *precomputedPruner
+	if larger := largerCached(cachedPrecomputedPruners, g); larger != nil {
+		p = derivePrecomputedPruner(g, larger)
+		logDebug("derived precomputed pruner tables from a larger grid's", "size", g.Size, "duration", time.Since(start))
+	} else {
+		p = computePrecomputedPruner(g)
+		logDebug("computed precomputed pruner tables", "size", g.Size, "duration", time.Since(start))
+	}
+	cachedPrecomputedPruners[g.Size] = p
+	return p
+}
+
+// largerCached returns the cached pruner for the smallest grid larger than g, or nil if there isn't one. mu must be held.
+func largerCached[P any](cached []*P, g grid.Grid) *P {
+	for _, p := range cached[g.Size+1:] {
+		if p != nil {
+			return p
+		}
+	}
+	return nil
+}
+
+// computePrecomputedPruner computes the tables of a precomputedPruner for the grid with a runtimePruner.
+func computePrecomputedPruner(g grid.Grid) *precomputedPruner {
 	rp := runtimePruner{g}
-	p := new(precomputedPruner)
+	p := &precomputedPruner{circles: canonicalCircles(), gridMask: gridMask(g)}
 	it1 := g.Iter()
 	for p1, ok1 := it1.Next(); ok1; p1, ok1 = it1.Next() {
 		it2 := g.Iter()
@@ -70,19 +176,230 @@ func NewPrecomputedPruner(g grid.Grid) Pruner {
 			if p1 == p2 {
 				continue
 			}
-			sep := grid.Separation(p1, p2)
-			rp.PruneCircles(&(p.circles[p1.Row][p1.Col][sep]), p1, sep)
 			rp.PruneIsoceles(&(p.isoceles[p1.Row][p1.Col][p2.Row][p2.Col]), p1, p2)
 		}
 	}
-	cachedPrecomputedPruners[g.Size-1] = p
 	return p
 }
 
+// derivePrecomputedPruner returns the precomputedPruner for the grid from that of a larger grid. Whether points are pruned only depends
+// on their separations, so the smaller grid's sets are the larger grid's sets without the points off the smaller grid.
+func derivePrecomputedPruner(g grid.Grid, larger *precomputedPruner) *precomputedPruner {
+	p := &precomputedPruner{circles: larger.circles, gridMask: gridMask(g)}
+	for r1 := uint8(0); r1 < g.Size; r1++ {
+		for c1 := uint8(0); c1 < g.Size; c1++ {
+			for r2 := uint8(0); r2 < g.Size; r2++ {
+				for c2 := uint8(0); c2 < g.Size; c2++ {
+					ps := &p.isoceles[r1][c1][r2][c2]
+					*ps = larger.isoceles[r1][c1][r2][c2]
+					v := (*[4]uint64)(unsafe.Pointer(ps))
+					for i := range v {
+						v[i] &= p.gridMask[i]
+					}
+				}
+			}
+		}
+	}
+	return p
+}
+
+// gridMask returns the points of the grid as the words of a sets.BitArrayPointSet.
+func gridMask(g grid.Grid) [4]uint64 {
+	var mask sets.BitArrayPointSet
+	it := g.Iter()
+	for pt, ok := it.Next(); ok; pt, ok = it.Next() {
+		mask.Add(pt)
+	}
+	return *(*[4]uint64)(unsafe.Pointer(&mask))
+}
+
+// NewPrecomputedPrunerContext is like NewPrecomputedPruner, but records the precomputation as a span in the context's trace.
+// Calling it before a search makes the cost of building the tables visible separately from the search itself.
+func NewPrecomputedPrunerContext(ctx context.Context, g grid.Grid) Pruner {
+	_, span := tracer.Start(ctx, "PrecomputePruner", trace.WithAttributes(attribute.Int("pegboard.grid.size", int(g.Size))))
+	defer span.End()
+	mu.Lock()
+	cached := cachedPrecomputedPruners[g.Size] != nil
+	mu.Unlock()
+	span.SetAttributes(attribute.Bool("pegboard.cached", cached))
+	return NewPrecomputedPruner(g)
+}
+
 func (p *precomputedPruner) PruneIsoceles(ps sets.PointSet, p1, p2 grid.Point) {
 	ps.Union(&p.isoceles[p1.Row][p1.Col][p2.Row][p2.Col])
 }
 
 func (p *precomputedPruner) PruneCircles(ps sets.PointSet, p1 grid.Point, sep uint16) {
-	ps.Union(&p.circles[p1.Row][p1.Col][sep])
+	if bs, ok := ps.(*sets.BitArrayPointSet); ok {
+		p.circles[p1.Col][sep].stamp(bs, &p.gridMask, p1.Row)
+		return
+	}
+	var circle sets.BitArrayPointSet
+	p.circles[p1.Col][sep].stamp(&circle, &p.gridMask, p1.Row)
+	ps.Union(&circle)
+}
+
+// BitboardPruner is a precomputed pruner for grids no larger than sets.BitboardMaxGridSize.
+// Every mask fits in a single word, so pruning is a single bitwise or.
+type BitboardPruner struct {
+	isoceles [sets.BitboardMaxGridSize * sets.BitboardMaxGridSize][sets.BitboardMaxGridSize * sets.BitboardMaxGridSize]sets.BitboardPointSet
+	circles  [sets.BitboardMaxGridSize * sets.BitboardMaxGridSize][bitboardMaxSeparation + 1]sets.BitboardPointSet
+}
+
+// The largest squared distance between points on a maximum sized bitboard grid
+const bitboardMaxSeparation = (sets.BitboardMaxGridSize - 1) * (sets.BitboardMaxGridSize - 1) * 2
+
+// Global singleton instances of BitboardPruner, indexed by grid size
+var cachedBitboardPruners []*BitboardPruner = make([]*BitboardPruner, sets.BitboardMaxGridSize+1)
+
+// NewBitboardPruner returns a BitboardPruner for the given grid, or an error if the grid is larger than sets.BitboardMaxGridSize. The
+// pruner of a grid of size 0 prunes nothing, as the grid has no points.
+func NewBitboardPruner(g grid.Grid) (*BitboardPruner, error) {
+	if g.Size > sets.BitboardMaxGridSize {
+		return nil, fmt.Errorf("the bitboard pruner only supports grids up to %dx%d, got %dx%d", sets.BitboardMaxGridSize, sets.BitboardMaxGridSize, g.Size, g.Size)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if pruner := cachedBitboardPruners[g.Size]; pruner != nil {
+		return pruner, nil
+	}
+	start := time.Now()
+	var p *BitboardPruner
+	if larger := largerCached(cachedBitboardPruners, g); larger != nil {
+		p = deriveBitboardPruner(g, larger)
+		logDebug("derived bitboard pruner tables from a larger grid's", "size", g.Size, "duration", time.Since(start))
+	} else {
+		p = computeBitboardPruner(g)
+		logDebug("computed bitboard pruner tables", "size", g.Size, "duration", time.Since(start))
+	}
+	cachedBitboardPruners[g.Size] = p
+	return p, nil
+}
+
+// computeBitboardPruner computes the tables of a BitboardPruner for the grid with a runtimePruner.
+func computeBitboardPruner(g grid.Grid) *BitboardPruner {
+	rp := runtimePruner{g}
+	p := new(BitboardPruner)
+	it1 := g.Iter()
+	for p1, ok1 := it1.Next(); ok1; p1, ok1 = it1.Next() {
+		it2 := g.Iter()
+		for p2, ok2 := it2.Next(); ok2; p2, ok2 = it2.Next() {
+			if p1 == p2 {
+				continue
+			}
+			i1, i2 := sets.BitboardIndex(p1), sets.BitboardIndex(p2)
+			sep := grid.Separation(p1, p2)
+			rp.PruneCircles(&(p.circles[i1][sep]), p1, sep)
+			rp.PruneIsoceles(&(p.isoceles[i1][i2]), p1, p2)
+		}
+	}
+	return p
+}
+
+// deriveBitboardPruner returns the BitboardPruner for the grid from that of a larger grid by masking, like derivePrecomputedPruner.
+// A circle is only stored for separations between two points of the grid, so circles which no longer fit are left empty.
+func deriveBitboardPruner(g grid.Grid, larger *BitboardPruner) *BitboardPruner {
+	mask := sets.BitboardGridMask(g)
+	p := new(BitboardPruner)
+	it1 := g.Iter()
+	for p1, ok1 := it1.Next(); ok1; p1, ok1 = it1.Next() {
+		it2 := g.Iter()
+		for p2, ok2 := it2.Next(); ok2; p2, ok2 = it2.Next() {
+			i1, i2 := sets.BitboardIndex(p1), sets.BitboardIndex(p2)
+			sep := grid.Separation(p1, p2)
+			p.circles[i1][sep] = larger.circles[i1][sep] & mask
+			p.isoceles[i1][i2] = larger.isoceles[i1][i2] & mask
+		}
+	}
+	return p
+}
+
+// Isoceles returns the set of points that form an isoceles triangle with the two given points
+func (p *BitboardPruner) Isoceles(p1, p2 grid.Point) sets.BitboardPointSet {
+	return p.isoceles[sets.BitboardIndex(p1)][sets.BitboardIndex(p2)]
+}
+
+// Circle returns the set of points that fall on the circle with the given radius (squared) around the given point
+func (p *BitboardPruner) Circle(p1 grid.Point, sep uint16) sets.BitboardPointSet {
+	if sep > bitboardMaxSeparation {
+		return 0
+	}
+	return p.circles[sets.BitboardIndex(p1)][sep]
+}
+
+func (p *BitboardPruner) PruneIsoceles(ps sets.PointSet, p1, p2 grid.Point) {
+	mask := p.Isoceles(p1, p2)
+	ps.Union(&mask)
+}
+
+func (p *BitboardPruner) PruneCircles(ps sets.PointSet, p1 grid.Point, sep uint16) {
+	mask := p.Circle(p1, sep)
+	ps.Union(&mask)
+}
+
+// maxStonesPerLine is the most stones that can share a single row or column of a grid of the given size.
+// All separations between stones on a line must be unique, so their positions form a Golomb ruler no longer than the line.
+// The shortest Golomb rulers with 1 to 6 marks have lengths 0, 1, 3, 6, 11 and 17.
+var maxStonesPerLine = [grid.MaxGridSize + 1]int{0, 1, 2, 2, 3, 3, 3, 4, 4, 4, 4, 4, 5, 5, 5}
+
+// CompletionBound returns an upper bound on the number of stones that can be added to the given placements using only the candidate points.
+// Each row and column can hold only a limited number of stones, so the bound is the smaller of the row-wise and column-wise sums of
+// min(candidates on the line, stones the line still has room for).
+// If the bound is less than the number of stones still to be placed, the placements cannot be completed.
+func CompletionBound(g grid.Grid, stones grid.Placements, candidates grid.PointIterator) int {
+	var lc lineCounts
+	lc.count(g, stones, candidates)
+	rowBound, colBound := lc.bounds(g)
+	return min(rowBound, colBound)
+}
+
+// ForcedPoint returns the first candidate point which every completion of the placements must contain, and whether there is one.
+// If the row-wise CompletionBound equals the number of stones still to be placed, every row must be filled to its bound, so every
+// candidate on a row with no more candidates than room is needed. The same holds for columns.
+// The candidates are iterated over twice, using Reset.
+func ForcedPoint(g grid.Grid, stones grid.Placements, candidates grid.PointIterator) (grid.Point, bool) {
+	var lc lineCounts
+	lc.count(g, stones, candidates)
+	rowBound, colBound := lc.bounds(g)
+	needed := int(g.Size) - len(stones)
+	rowTight, colTight := rowBound == needed, colBound == needed
+	if needed == 0 || !rowTight && !colTight {
+		return grid.Point{}, false
+	}
+	candidates.Reset()
+	for p, ok := candidates.Next(); ok; p, ok = candidates.Next() {
+		if rowTight && lc.rowCandidates[p.Row] <= lc.rowRoom[p.Row] || colTight && lc.colCandidates[p.Col] <= lc.colRoom[p.Col] {
+			return p, true
+		}
+	}
+	return grid.Point{}, false
+}
+
+// lineCounts holds the number of candidates on each row and column, and the number of stones each still has room for
+type lineCounts struct {
+	rowCandidates, colCandidates, rowRoom, colRoom [grid.MaxGridSize]int
+}
+
+func (lc *lineCounts) count(g grid.Grid, stones grid.Placements, candidates grid.PointIterator) {
+	for i := uint8(0); i < g.Size; i++ {
+		lc.rowRoom[i] = maxStonesPerLine[g.Size]
+		lc.colRoom[i] = maxStonesPerLine[g.Size]
+	}
+	for _, p := range stones {
+		lc.rowRoom[p.Row]--
+		lc.colRoom[p.Col]--
+	}
+	for p, ok := candidates.Next(); ok; p, ok = candidates.Next() {
+		lc.rowCandidates[p.Row]++
+		lc.colCandidates[p.Col]++
+	}
+}
+
+// bounds returns the row-wise and column-wise sums of min(candidates on the line, stones the line still has room for)
+func (lc *lineCounts) bounds(g grid.Grid) (rowBound, colBound int) {
+	for i := uint8(0); i < g.Size; i++ {
+		rowBound += max(0, min(lc.rowCandidates[i], lc.rowRoom[i]))
+		colBound += max(0, min(lc.colCandidates[i], lc.colRoom[i]))
+	}
+	return rowBound, colBound
 }
@@ -1,11 +1,13 @@
 package pruner
 
 import (
+	"context"
 	"reflect"
 	"testing"
 
 	"github.com/WillMorrison/pegboard-blog/grid"
 	"github.com/WillMorrison/pegboard-blog/sets"
+	"github.com/google/go-cmp/cmp"
 )
 
 func Test_Pruner_PruneIsoceles(t *testing.T) {
@@ -58,6 +60,13 @@ func Test_Pruner_PruneIsoceles(t *testing.T) {
 	}{
 		{name: "runtime", new: NewRuntimePruner},
 		{name: "precomputed", new: NewPrecomputedPruner},
+		{name: "bitboard", new: func(g grid.Grid) Pruner {
+			p, err := NewBitboardPruner(g)
+			if err != nil {
+				t.Fatalf("NewBitboardPruner(%+v) = %v", g, err)
+			}
+			return p
+		}},
 	}
 	for _, impl := range impls {
 		for _, tt := range tests {
@@ -116,6 +125,13 @@ func Test_Pruner_PruneCircles(t *testing.T) {
 	}{
 		{name: "runtime", new: NewRuntimePruner},
 		{name: "precomputed", new: NewPrecomputedPruner},
+		{name: "bitboard", new: func(g grid.Grid) Pruner {
+			p, err := NewBitboardPruner(g)
+			if err != nil {
+				t.Fatalf("NewBitboardPruner(%+v) = %v", g, err)
+			}
+			return p
+		}},
 	}
 	for _, impl := range impls {
 		for _, tt := range tests {
@@ -153,3 +169,188 @@ func Benchmark_PrecomputedPruner(b *testing.B) {
 		}
 	}
 }
+
+func Test_CompletionBound(t *testing.T) {
+	tests := []struct {
+		name       string
+		grid       grid.Grid
+		stones     grid.Placements
+		candidates grid.Placements
+		want       int
+	}{
+		{
+			name:       "no candidates",
+			grid:       grid.Grid{Size: 5},
+			stones:     grid.Placements{grid.Point{Row: 0, Col: 0}},
+			candidates: grid.Placements{},
+			want:       0,
+		},
+		{
+			name:       "single row limited by golomb ruler",
+			grid:       grid.Grid{Size: 7},
+			candidates: grid.Placements{grid.Point{Row: 2, Col: 0}, grid.Point{Row: 2, Col: 1}, grid.Point{Row: 2, Col: 2}, grid.Point{Row: 2, Col: 3}, grid.Point{Row: 2, Col: 4}, grid.Point{Row: 2, Col: 5}},
+			want:       4,
+		},
+		{
+			name:       "row already partially filled",
+			grid:       grid.Grid{Size: 7},
+			stones:     grid.Placements{grid.Point{Row: 2, Col: 0}, grid.Point{Row: 2, Col: 1}, grid.Point{Row: 2, Col: 4}},
+			candidates: grid.Placements{grid.Point{Row: 2, Col: 5}, grid.Point{Row: 2, Col: 6}},
+			want:       1,
+		},
+		{
+			name:       "limited by columns",
+			grid:       grid.Grid{Size: 7},
+			candidates: grid.Placements{grid.Point{Row: 1, Col: 2}, grid.Point{Row: 2, Col: 2}, grid.Point{Row: 3, Col: 2}, grid.Point{Row: 4, Col: 2}, grid.Point{Row: 5, Col: 2}, grid.Point{Row: 6, Col: 2}},
+			want:       4,
+		},
+		{
+			name:       "one per row and column",
+			grid:       grid.Grid{Size: 4},
+			candidates: grid.Placements{grid.Point{Row: 0, Col: 3}, grid.Point{Row: 1, Col: 2}, grid.Point{Row: 2, Col: 1}},
+			want:       3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := sets.NewMapPointSet(tt.candidates)
+			if got := CompletionBound(tt.grid, tt.stones, ps.Iter()); got != tt.want {
+				t.Errorf("CompletionBound(%v, %v, %v) = %d, want %d", tt.grid, tt.stones, tt.candidates, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ForcedPoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		grid       grid.Grid
+		stones     grid.Placements
+		candidates grid.Placements
+		want       grid.Point
+		wantOk     bool
+	}{
+		{
+			name:       "bound is tight",
+			grid:       grid.Grid{Size: 4},
+			stones:     grid.Placements{{Row: 0, Col: 0}},
+			candidates: grid.Placements{{Row: 1, Col: 2}, {Row: 2, Col: 1}, {Row: 3, Col: 3}},
+			want:       grid.Point{Row: 1, Col: 2},
+			wantOk:     true,
+		},
+		{
+			name:       "bound is not tight",
+			grid:       grid.Grid{Size: 4},
+			stones:     grid.Placements{{Row: 0, Col: 0}},
+			candidates: grid.Placements{{Row: 1, Col: 2}, {Row: 2, Col: 1}, {Row: 3, Col: 0}, {Row: 3, Col: 3}},
+		},
+		{
+			name:       "row with more candidates than room is not forced",
+			grid:       grid.Grid{Size: 4},
+			candidates: grid.Placements{{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 0, Col: 2}, {Row: 0, Col: 3}, {Row: 1, Col: 0}},
+			want:       grid.Point{Row: 1, Col: 0},
+			wantOk:     true,
+		},
+		{
+			name:       "complete",
+			grid:       grid.Grid{Size: 2},
+			stones:     grid.Placements{{Row: 0, Col: 0}, {Row: 0, Col: 1}},
+			candidates: grid.Placements{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := sets.NewSortedMapPointSet(tt.candidates)
+			got, ok := ForcedPoint(tt.grid, tt.stones, ps.Iter())
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("ForcedPoint(%v, %v, %v) = %v, %t, want %v, %t", tt.grid, tt.stones, tt.candidates, got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func Test_PrecomputedPruner_PruneCircles_MatchesRuntime(t *testing.T) {
+	for _, g := range []grid.Grid{{Size: 1}, {Size: 7}, {Size: 8}, {Size: grid.MaxGridSize}} {
+		precomputed, runtime := NewPrecomputedPruner(g), NewRuntimePruner(g)
+		it := g.Iter()
+		for p, ok := it.Next(); ok; p, ok = it.Next() {
+			for sep := uint16(1); sep <= grid.MaxSeparation; sep++ {
+				var got, want sets.BitArrayPointSet
+				precomputed.PruneCircles(&got, p, sep)
+				runtime.PruneCircles(&want, p, sep)
+				if got != want {
+					t.Fatalf("%+v: PruneCircles(%s, %d) = %v, want %v", g, p, sep, got.Elements(), want.Elements())
+				}
+				// Other set implementations take the slower path
+				gotMap := sets.NewSortedMapPointSet(nil)
+				precomputed.PruneCircles(gotMap, p, sep)
+				if diff := cmp.Diff(want.Elements(), gotMap.Elements()); diff != "" {
+					t.Fatalf("%+v: PruneCircles(%s, %d) on a map set mismatch (-want +got):\n%s", g, p, sep, diff)
+				}
+			}
+		}
+	}
+}
+
+func Test_DerivedPruners_MatchComputed(t *testing.T) {
+	largest := computePrecomputedPruner(grid.Grid{Size: grid.MaxGridSize})
+	for size := uint8(1); size < grid.MaxGridSize; size++ {
+		g := grid.Grid{Size: size}
+		if got, want := derivePrecomputedPruner(g, largest), computePrecomputedPruner(g); *got != *want {
+			t.Errorf("%+v: derived precomputed pruner differs from the computed one", g)
+		}
+	}
+	largestBitboard := computeBitboardPruner(grid.Grid{Size: sets.BitboardMaxGridSize})
+	for size := uint8(1); size < sets.BitboardMaxGridSize; size++ {
+		g := grid.Grid{Size: size}
+		if got, want := deriveBitboardPruner(g, largestBitboard), computeBitboardPruner(g); *got != *want {
+			t.Errorf("%+v: derived bitboard pruner differs from the computed one", g)
+		}
+	}
+}
+
+func Test_Pruners_EmptyGrid(t *testing.T) {
+	g := grid.Grid{Size: 0}
+	if p, err := NewBitboardPruner(g); err != nil {
+		t.Errorf("NewBitboardPruner(%+v) = %v", g, err)
+	} else if *p != (BitboardPruner{}) {
+		t.Errorf("NewBitboardPruner(%+v) prunes points, want an empty pruner", g)
+	}
+	if p := NewPrecomputedPrunerContext(context.Background(), g).(*precomputedPruner); p.gridMask != [4]uint64{} {
+		t.Errorf("NewPrecomputedPruner(%+v) has grid mask %v, want none", g, p.gridMask)
+	}
+	// The empty grid's pruners aren't used for larger grids
+	one := grid.Grid{Size: 1}
+	if got, want := NewPrecomputedPruner(one).(*precomputedPruner), computePrecomputedPruner(one); *got != *want {
+		t.Errorf("NewPrecomputedPruner(%+v) after the empty grid's differs from the computed one", one)
+	}
+}
+
+func Test_BitboardPruner_GridTooLarge(t *testing.T) {
+	g := grid.Grid{Size: sets.BitboardMaxGridSize + 1}
+	if _, err := NewBitboardPruner(g); err == nil {
+		t.Errorf("NewBitboardPruner(%+v) succeeded, want an error", g)
+	}
+}
+
+func Test_Counting(t *testing.T) {
+	g := grid.Grid{5}
+	counts := &Counts{}
+	p := Counting(NewPrecomputedPruner, counts)(g)
+	ps := sets.BitArrayPointSet{}
+	ps.Add(grid.Point{0, 0})
+	// The diagonal through the already pruned corner
+	p.PruneIsoceles(&ps, grid.Point{0, 1}, grid.Point{1, 0})
+	// The points next to the corner, neither already pruned
+	p.PruneCircles(&ps, grid.Point{0, 0}, 1)
+	if got, want := counts.Isoceles.Load(), int64(4); got != want {
+		t.Errorf("Counts.Isoceles = %d, want %d", got, want)
+	}
+	if got, want := counts.Circles.Load(), int64(2); got != want {
+		t.Errorf("Counts.Circles = %d, want %d", got, want)
+	}
+	want := grid.Placements{grid.Point{0, 0}, grid.Point{0, 1}, grid.Point{1, 0}, grid.Point{1, 1}, grid.Point{2, 2}, grid.Point{3, 3}, grid.Point{4, 4}}
+	if diff := cmp.Diff(want, ps.Elements()); diff != "" {
+		t.Errorf("pruned points mismatch (-want +got):\n%s", diff)
+	}
+}
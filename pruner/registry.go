@@ -0,0 +1,42 @@
+package pruner
+
+import (
+	"slices"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+// Names of the Pruner implementations in Pruners, as used on the command line.
+const (
+	RuntimePrunerName     = "runtime"
+	PrecomputedPrunerName = "precomputed"
+)
+
+// Pruners maps the name of each Pruner implementation to its constructor, so that programs and config files can choose one by name.
+var Pruners = map[string]func(grid.Grid) Pruner{
+	RuntimePrunerName:     NewRuntimePruner,
+	PrecomputedPrunerName: NewPrecomputedPruner,
+}
+
+// Register adds a Pruner implementation to Pruners, so that it can be chosen by name like the built in ones. Call it from an init
+// function, as the names are read when flags are defined. It panics if the name is empty or already registered, or the constructor
+// is nil.
+func Register(name string, newPruner func(grid.Grid) Pruner) {
+	if name == "" || newPruner == nil {
+		panic("pruner: Register called without a name or constructor")
+	}
+	if _, dup := Pruners[name]; dup {
+		panic("pruner: Register called twice for " + name)
+	}
+	Pruners[name] = newPruner
+}
+
+// Names returns the names in Pruners, sorted.
+func Names() []string {
+	names := make([]string, 0, len(Pruners))
+	for name := range Pruners {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// resultLogRecord is a line of the -log_results file: the result of one search, when it finished, and the run's metadata, which
+// includes its configuration.
+type resultLogRecord struct {
+	Time time.Time `json:"time"`
+	jsonResult
+	// DepthNodes is the number of nodes at each depth of the search, from the root
+	DepthNodes []int64 `json:"depth_nodes,omitempty"`
+}
+
+// newResultLogRecord returns the record of a search that finished at the given time.
+func newResultLogRecord(r batchResult, startingPoints string, metadata *runMetadata, finished time.Time) resultLogRecord {
+	j := newJSONResult(r, startingPoints)
+	j.Metadata = metadata
+	return resultLogRecord{Time: finished.UTC(), jsonResult: j, DepthNodes: r.DepthNodes}
+}
+
+// appendResultLog appends the records to the named file as JSON lines, creating it if needed. Each line is written with one call, so
+// that runs logging to the same file at once don't interleave their records.
+func appendResultLog(filename string, records []resultLogRecord) error {
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	for _, r := range records {
+		line, err := json.Marshal(r)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
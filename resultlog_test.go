@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAppendResultLog(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "results.jsonl")
+	metadata := &runMetadata{Version: "v1.2.0", Hostname: "host", GOMAXPROCS: 4, Config: map[string]string{"size": "8"}}
+	finished := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	none := batchResult{Size: 8, Placer: "ordered", Solver: "async", Err: solver.ErrNoSolution, Duration: time.Second, Nodes: 9, DepthNodes: []int64{1, 3, 5}}
+	canceled := batchResult{Size: 9, Placer: "ordered", Solver: "async", Err: solver.ErrCanceled, Duration: time.Minute, Nodes: 90}
+	// Runs append to the records of earlier runs
+	for _, r := range []batchResult{none, canceled} {
+		if err := appendResultLog(filename, []resultLogRecord{newResultLogRecord(r, "first_octant", metadata, finished)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var got []map[string]any
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %d isn't JSON: %v", len(got)+1, err)
+		}
+		got = append(got, map[string]any{"time": record["time"], "size": record["size"], "outcome": record["outcome"], "nodes": record["nodes"], "depth_nodes": record["depth_nodes"], "hostname": record["metadata"].(map[string]any)["hostname"]})
+	}
+	want := []map[string]any{
+		{"time": "2024-05-01T12:00:00Z", "size": 8.0, "outcome": "no_solution", "nodes": 9.0, "depth_nodes": []any{1.0, 3.0, 5.0}, "hostname": "host"},
+		{"time": "2024-05-01T12:00:00Z", "size": 9.0, "outcome": "canceled", "nodes": 90.0, "depth_nodes": nil, "hostname": "host"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("results log mismatch (-want +got):\n%s", diff)
+	}
+}
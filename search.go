@@ -0,0 +1,487 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/affinity"
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/pruner"
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"github.com/hashicorp/packer/command/enumflag"
+	"go.opentelemetry.io/otel"
+)
+
+// searchFlags are the flags of the subcommands that run one search with a solver and report on it: solve, watch, warm-start and shard.
+// They include the strategy flags.
+type searchFlags struct {
+	strategy                          *strategyFlags
+	cpuprofile, memprofile, tracefile *string
+	traceExporter                     string
+	gomaxprocs                        *int
+	pinWorkers                        *bool
+	weightsFile, deadCacheFile        *string
+	deadCacheDepth                    *int
+	maxNodes                          *int64
+	progress                          *time.Duration
+	tui                               bool
+	depthStats, startingPointStats    *bool
+	depthCSV, logResults              *string
+	markdownFile, bundlePath          *string
+	markdownBoard                     string
+	verbose                           *bool
+	eventsFile, dumpFile              *string
+	expvarAddr, pprofAddr             *string
+	cpuStats, memoryStats, heatmap    *bool
+	heatmapFirst, heatmapSVG          *string
+}
+
+// addSearchFlags adds the strategy flags and the search flags to the flag set. With tui, the search is shown in the TUI while it runs,
+// so there is no -progress flag to report it at an interval.
+func addSearchFlags(fs *flag.FlagSet, tui bool) *searchFlags {
+	sf := &searchFlags{
+		strategy:           addStrategyFlags(fs, true),
+		cpuprofile:         fs.String("cpuprofile", "", "write cpu profile to file"),
+		memprofile:         fs.String("memprofile", "", "write memory profile to this file"),
+		tracefile:          fs.String("trace", "", "write trace to this file"),
+		traceExporter:      NoTraceExporter,
+		gomaxprocs:         fs.Int("gomaxprocs", 0, "maximum number of CPUs to run on, which also sets the number of workers for parallel solvers unless -workers does. Defaults to $GOMAXPROCS if set, otherwise one per physical core"),
+		pinWorkers:         fs.Bool("pin_workers", false, "pin each parallel solver worker to its own physical core, where the OS allows it"),
+		weightsFile:        fs.String("weights", "", "file of expected search tree sizes per starting point, in the format printed by the estimate subcommand, used to allocate workers across starting points"),
+		deadCacheFile:      fs.String("dead_cache", "", "file of prefixes already searched without finding a solution, for this grid size and placer. They are skipped, and the prefixes this search finishes without a solution are added"),
+		deadCacheDepth:     fs.Int("dead_cache_depth", 0, "split the starting points into the valid placements with this many stones, so the dead prefix cache records smaller subtrees (with -dead_cache)"),
+		maxNodes:           fs.Int64("max_nodes", 0, "stop the search after this many placements, and report the deepest partial placement reached and the statistics so far, or 0 for no limit"),
+		tui:                tui,
+		depthStats:         fs.Bool("depth_stats", false, "print the number of candidates tried and the fraction placed at each depth after the search"),
+		depthCSV:           fs.String("depth_csv", "", "write the nodes, candidates tried and stones placed at each depth below each starting point to this CSV file after the search"),
+		startingPointStats: fs.Bool("starting_point_stats", false, "print the nodes and time spent below each starting point, and which found the solution, after the search"),
+		logResults:         fs.String("log_results", "", "append a JSON line to this file for each search that finishes, with its configuration, duration, outcome and nodes, to collect the results of many runs"),
+		markdownFile:       fs.String("markdown", "", "also write the result as a Markdown fragment for a blog post, with the board, separations and strategies, to this file, or - for stdout"),
+		markdownBoard:      ASCIIBoardFormat,
+		bundlePath:         fs.String("bundle", "", "write the effective config, build info, log, final stats and solution of the run to this directory, or gzipped tarball if it ends in .tar.gz, so the result can be reproduced"),
+		verbose:            fs.Bool("v", false, "log diagnostics from the solver and pruner, such as when the search and precomputation start and finish, to stderr"),
+		eventsFile:         fs.String("events", "", "write the search's events, such as solutions found, subtrees finished and work split between workers, to this file as JSON lines"),
+		expvarAddr:         fs.String("expvar_addr", "", "serve the search's counters, such as nodes, rejected stones, work splits and idle workers, as expvars at /debug/vars on this address, e.g. localhost:6060"),
+		pprofAddr:          fs.String("pprof_addr", "", "serve the runtime's CPU, heap, goroutine and other profiles at /debug/pprof/ on this address during the run, for go tool pprof, e.g. localhost:6061. It must differ from -expvar_addr"),
+		dumpFile:           fs.String("dump_file", "", "append the state of each worker to this file instead of stderr when the process receives SIGUSR1"),
+		cpuStats:           fs.Bool("cpu_stats", false, "print the physical cores, logical CPUs and GOMAXPROCS the search ran with, and the stones placed per second, after the search"),
+		memoryStats:        fs.Bool("memory_stats", false, "print the peak heap, allocations and garbage collections during the search after it"),
+		heatmap:            fs.Bool("heatmap", false, "print a heatmap of the nodes searched by the cell of their first stone after the search"),
+		heatmapFirst:       fs.String("heatmap_first", "", "instead of the first stone, show the heatmap by the cell of the second stone for nodes with this first stone, e.g. A1"),
+		heatmapSVG:         fs.String("heatmap_svg", "", "also write the heatmap as an SVG image to this file"),
+	}
+	fs.Var(enumflag.New(&sf.traceExporter, NoTraceExporter, StdoutTraceExporter, OTLPHTTPTraceExporter), "otel_exporter", "where to send OpenTelemetry spans for the solver's phases. otlp_http is configured with the standard OTEL_EXPORTER_OTLP_* environment variables")
+	fs.Var(enumflag.New(&sf.markdownBoard, ASCIIBoardFormat, SVGBoardFormat), "markdown_board", "how to draw the board in the -markdown export: as text in a code block, or as an inline SVG image")
+	if !tui {
+		sf.progress = fs.Duration("progress", 0, "print the nodes placed, nodes/s, nodes at each depth, tasks done and estimated completion to stderr at this interval during the search, or 0 for none")
+	}
+	return sf
+}
+
+// searchRun is a search set up with the search flags. Subcommands can change its starting points before it searches.
+type searchRun struct {
+	flags        *searchFlags
+	ctx          context.Context
+	g            grid.Grid
+	builder      *solver.Builder
+	stats        *solver.Stats
+	events       *solver.EventBus
+	bundle       *runBundle
+	physicalCPUs []int
+	spc          placer.StonePlacerConstructor
+	spp          solver.StartingPointsProvider
+	// deferred are the functions that close calls, in reverse order
+	deferred []func()
+}
+
+// start sets up the search of the flags: its tracing, the CPUs it runs on, its statistics and diagnostics, and its placer and starting
+// points. Call it once the flags are parsed, and close the run when the subcommand is done. Errors are fatal.
+func (sf *searchFlags) start() *searchRun {
+	r := &searchRun{flags: sf}
+	if *sf.bundlePath != "" {
+		r.bundle = newRunBundle(*sf.bundlePath)
+	}
+
+	var logger *slog.Logger
+	if *sf.verbose {
+		logger = slog.New(slog.NewTextHandler(log.Writer(), &slog.HandlerOptions{Level: slog.LevelDebug}))
+		pruner.SetLogger(logger)
+	}
+
+	ctx, shutdownTracing, err := setupTracing(context.Background(), sf.traceExporter)
+	if err != nil {
+		fatal(err)
+	}
+	r.atClose(func() { shutdownTracing(context.Background()) })
+	ctx, span := otel.Tracer("github.com/WillMorrison/pegboard-blog").Start(ctx, "pegboard")
+	r.atClose(func() { span.End() })
+	r.ctx = ctx
+
+	// This workload is compute bound, so hyperthread siblings mostly contend with each other for the same execution units
+	r.physicalCPUs = affinity.PhysicalCPUs()
+	if *sf.gomaxprocs > 0 {
+		runtime.GOMAXPROCS(*sf.gomaxprocs)
+	} else if os.Getenv("GOMAXPROCS") == "" {
+		runtime.GOMAXPROCS(len(r.physicalCPUs))
+	}
+	var workerInit func(int)
+	if *sf.pinWorkers {
+		workerInit = func(worker int) {
+			if err := affinity.Pin(r.physicalCPUs[worker%len(r.physicalCPUs)]); err != nil {
+				log.Printf("Could not pin worker %d: %v", worker, err)
+			}
+		}
+	}
+
+	if r.g, err = sf.strategy.grid(); err != nil {
+		fatal(err)
+	}
+
+	if *sf.eventsFile != "" {
+		r.events = solver.NewEventBus()
+	}
+	r.stats = &solver.Stats{}
+	if *sf.expvarAddr != "" {
+		serveExpvars(*sf.expvarAddr, r.stats)
+	}
+	if *sf.pprofAddr != "" {
+		if *sf.pprofAddr == *sf.expvarAddr {
+			fatal("-pprof_addr and -expvar_addr can't serve on the same address")
+		}
+		servePprof(*sf.pprofAddr)
+	}
+	if *sf.heatmap || *sf.heatmapSVG != "" {
+		r.stats.EnableHeatmap(*sf.heatmapFirst != "")
+	}
+	r.builder = sf.strategy.builder(r.g).
+		Stats(r.stats).
+		WorkerInit(workerInit).
+		Logger(logger).
+		Events(r.events)
+
+	if r.spc, err = r.builder.BuildPlacer(); err != nil {
+		fatal(err)
+	}
+	if r.spp, err = r.builder.BuildStartingPoints(); err != nil {
+		fatal(err)
+	}
+	precomputePruner(ctx, r.g, r.builder)
+	return r
+}
+
+// atClose adds a function for close to call.
+func (r *searchRun) atClose(f func()) {
+	r.deferred = append(r.deferred, f)
+}
+
+// close writes the reports that the search deferred, so that they follow the result that the subcommand prints, then stops the
+// profiling and tracing.
+func (r *searchRun) close() {
+	for i := len(r.deferred) - 1; i >= 0; i-- {
+		r.deferred[i]()
+	}
+}
+
+// search builds the solver for the starting points and searches with it until it finishes, is interrupted, or exceeds -max_nodes. It
+// writes the reports of the flags, or defers them to close.
+func (r *searchRun) search() (solution grid.Placements, duration time.Duration, err error) {
+	sf, g, stats, builder := r.flags, r.g, r.stats, r.builder
+	if *sf.weightsFile != "" {
+		weights, err := readWeights(*sf.weightsFile)
+		if err != nil {
+			fatal(err)
+		}
+		r.spp = solver.WeightedStartingPoints(r.spp, r.spc, weights, runtime.GOMAXPROCS(0))
+	}
+
+	var deadCache *solver.DeadPrefixCache
+	if *sf.deadCacheFile != "" {
+		// The auto placer always uses the bound
+		strategy := fmt.Sprintf("%s bound=%t forced=%t", builder.PlacerName(), *sf.strategy.bound || sf.strategy.placer == solver.AutoName, *sf.strategy.forced)
+		if deadCache, err = loadDeadPrefixCache(*sf.deadCacheFile, g, strategy); err != nil {
+			fatal(err)
+		}
+		if *sf.deadCacheDepth > 0 {
+			r.spp = solver.PrefixStartingPoints(r.spp, r.spc, *sf.deadCacheDepth)
+		}
+		r.spp = deadCache.StartingPoints(r.spp)
+		if r.events == nil {
+			r.events = solver.NewEventBus()
+			builder.Events(r.events)
+		}
+	}
+
+	s, err := builder.StartingPointsProvider(r.spp).Build()
+	if err != nil {
+		fatal(err)
+	}
+
+	if *sf.cpuprofile != "" {
+		f, err := os.Create(*sf.cpuprofile)
+		if err != nil {
+			fatal(err)
+		}
+		pprof.StartCPUProfile(f)
+		r.atClose(pprof.StopCPUProfile)
+	}
+
+	if *sf.tracefile != "" {
+		f, err := os.Create(*sf.tracefile)
+		if err != nil {
+			fatal(err)
+		}
+		trace.Start(f)
+		r.atClose(trace.Stop)
+	}
+
+	// Stop the search gracefully on the first interrupt. A second interrupt kills the process as usual.
+	ctx, stop := signal.NotifyContext(r.ctx, os.Interrupt)
+
+	var memorySampler *solver.MemorySampler
+	if *sf.memoryStats || r.bundle != nil {
+		memorySampler = solver.StartMemorySampler(10 * time.Millisecond)
+	}
+	stopDumping := dumpWorkerStatesOnSignal(stats, *sf.dumpFile)
+	stopEvents := func() error { return nil }
+	if *sf.eventsFile != "" {
+		if stopEvents, err = writeEvents(r.events, *sf.eventsFile); err != nil {
+			fatal(err)
+		}
+	}
+	stopRecording := func() {}
+	if deadCache != nil {
+		stopRecording = deadCache.Record(r.events)
+	}
+	stopProgress := func() {}
+	if sf.tui || *sf.progress > 0 {
+		var estimatedNodes float64
+		for _, e := range solver.EstimateTreeSizeParallel(g, r.spp, r.spc, progressProbes, solver.RandStreams{Seed: time.Now().UnixNano()}, 0) {
+			estimatedNodes += e.Nodes
+		}
+		if sf.tui {
+			title := fmt.Sprintf("%dx%d grid, %s placer, %s solver", g.Size, g.Size, builder.PlacerName(), builder.SolverName())
+			stopProgress = showTUI(ctx, os.Stderr, stats, g, title, estimatedNodes)
+		} else {
+			stopProgress = reportProgressEvery(ctx, os.Stderr, stats, *sf.progress, estimatedNodes)
+		}
+	}
+	startTime := time.Now()
+	solveCtx, stopBudget := solver.WithNodeBudget(ctx, stats, *sf.maxNodes)
+	solution, err = s.SolveContext(solveCtx, g)
+	duration = time.Since(startTime)
+	stopBudget()
+	stopProgress()
+	stop()
+	stopDumping()
+	if err := stopEvents(); err != nil {
+		log.Print(err)
+	}
+	if deadCache != nil {
+		stopRecording()
+		if err := saveDeadPrefixCache(*sf.deadCacheFile, deadCache); err != nil {
+			log.Print(err)
+		}
+		r.atClose(func() { writeDeadPrefixCacheStats(os.Stdout, deadCache.Stats()) })
+	}
+	var memory *solver.MemoryStats
+	if memorySampler != nil {
+		m := memorySampler.Stop()
+		memory = &m
+	}
+	if *sf.memoryStats {
+		r.atClose(func() { writeMemoryStats(os.Stdout, *memory) })
+	}
+	if *sf.cpuStats {
+		nodes := stats.Nodes.Load()
+		r.atClose(func() {
+			writeCPUStats(os.Stdout, len(r.physicalCPUs), runtime.NumCPU(), runtime.GOMAXPROCS(0), nodes, duration)
+		})
+	}
+	if r.bundle != nil {
+		if err := r.bundle.record(sf.strategy.fs, builder, g, solution, err, duration, stats, memory); err != nil {
+			fatal(err)
+		}
+		r.atClose(func() {
+			if err := r.bundle.write(); err != nil {
+				log.Print(err)
+			}
+		})
+	}
+	if *sf.markdownFile != "" {
+		sorted := append(grid.Placements(nil), solution...)
+		sorted.Sort()
+		m := markdownResult{
+			Grid: g, Solution: sorted, Err: err, Duration: duration, Nodes: stats.Nodes.Load(), Board: sf.markdownBoard,
+			Strategy: [][2]string{
+				{"Placer", builder.PlacerName()},
+				{"Solver", builder.SolverName()},
+				{"Starting points", sf.strategy.startingPoints},
+				{"Bound", fmt.Sprint(*sf.strategy.bound || sf.strategy.placer == solver.AutoName)},
+				{"Forced", fmt.Sprint(*sf.strategy.forced)},
+				{"GOMAXPROCS", fmt.Sprint(runtime.GOMAXPROCS(0))},
+				{"Commit", shortCommit(buildCommit())},
+			},
+		}
+		r.atClose(func() {
+			if err := writeMarkdownFile(*sf.markdownFile, m); err != nil {
+				log.Print(err)
+			}
+		})
+	}
+	if *sf.depthStats {
+		r.atClose(func() { writeDepthStats(os.Stdout, stats.Depths()) })
+	}
+	if *sf.depthCSV != "" {
+		if err := writeDepthCSVFile(*sf.depthCSV, g.Size, builder.PlacerName(), builder.SolverName(), stats.StartingPoints()); err != nil {
+			log.Print(err)
+		}
+	}
+	if *sf.logResults != "" {
+		sorted := append(grid.Placements(nil), solution...)
+		sorted.Sort()
+		b := batchResult{
+			Size: g.Size, Placer: builder.PlacerName(), Solver: builder.SolverName(), Solution: sorted, Err: err, Duration: duration,
+			Nodes: stats.Nodes.Load(), DepthNodes: depthNodes(stats.Depths()),
+		}
+		if err := appendResultLog(*sf.logResults, []resultLogRecord{newResultLogRecord(b, sf.strategy.startingPoints, newRunMetadata(sf.strategy.fs), startTime.Add(duration))}); err != nil {
+			log.Print(err)
+		}
+	}
+	if *sf.startingPointStats {
+		r.atClose(func() { writeStartingPointStats(os.Stdout, stats.StartingPoints()) })
+	}
+	if *sf.heatmap || *sf.heatmapSVG != "" {
+		r.atClose(func() {
+			if err := writeHeatmaps(g, stats, *sf.heatmapFirst, *sf.heatmapSVG); err != nil {
+				log.Print(err)
+			}
+		})
+	}
+
+	if *sf.memprofile != "" {
+		f, err := os.Create(*sf.memprofile)
+		if err != nil {
+			fatal(err)
+		}
+		defer f.Close()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			fatal(err)
+		}
+	}
+	return solution, duration, err
+}
+
+// outputFlags are the flags that choose where and in which format the results of a subcommand are written, which solve, watch,
+// warm-start and batch share.
+type outputFlags struct {
+	quiet, json *bool
+	file        *string
+	format      string
+}
+
+// addOutputFlags adds the output flags to the flag set.
+func addOutputFlags(fs *flag.FlagSet) *outputFlags {
+	of := &outputFlags{
+		quiet:  fs.Bool("quiet", false, "print nothing to stdout, for scripts which only need the exit code: 0 if a solution was found, 1 if none exists, 2 if the search was stopped, and 3 for invalid input. Errors are still logged to stderr"),
+		json:   fs.Bool("json", false, "print the result as JSON, with the grid size, placer, solver, solution, duration and error, and the build, host and flags of the run, instead of as text. The same as -format json"),
+		file:   fs.String("output", "", "write the result to this file in the -format, instead of the text report to stdout"),
+		format: TextOutputFormat,
+	}
+	fs.Var(enumflag.New(&of.format, TextOutputFormat, JSONOutputFormat, CSVOutputFormat, SVGOutputFormat), "format", "format to write the result to -output or stdout in: a summary table, JSON, a CSV row per grid size, or the board as an SVG image")
+	return of
+}
+
+// setup silences stdout for -quiet, and makes -json choose the JSON format. Call it once the flags are parsed.
+func (of *outputFlags) setup() {
+	if *of.quiet {
+		silenceStdout()
+	}
+	if *of.json {
+		of.format = JSONOutputFormat
+	}
+}
+
+// formatted returns whether the result is written in the -format rather than as the text report. The text report has more detail than
+// the text format, so is kept unless the result is written elsewhere.
+func (of *outputFlags) formatted() bool {
+	return of.format != TextOutputFormat || *of.file != ""
+}
+
+// resultFlags are the output flags, and the flags that choose how the text report of a search shows its solution, which solve, watch and
+// warm-start share.
+type resultFlags struct {
+	output              *outputFlags
+	notation, showBoard string
+	separations         *bool
+}
+
+// addResultFlags adds the output flags and the result flags to the flag set.
+func addResultFlags(fs *flag.FlagSet) *resultFlags {
+	rf := &resultFlags{
+		output:      addOutputFlags(fs),
+		notation:    AlgebraicNotation,
+		showBoard:   NoBoardFormat,
+		separations: fs.Bool("separations", false, "also print which of the grid's separations the solution uses and which it leaves unused"),
+	}
+	fs.Var(enumflag.New(&rf.notation, AlgebraicNotation, TupleNotation, MatrixNotation, CompactNotation), "notation", "notation to print the solution in: a list of stones such as [A0 B3], (row,col) tuples, a 0/1 matrix, or the columns of each row's stones such as 0/3/-")
+	fs.Var(enumflag.New(&rf.showBoard, NoBoardFormat, ASCIIBoardFormat, ColorBoardFormat), "show_board", "also draw the solution, or the deepest partial placement of an interrupted search, as a board. color shows the cells pruned for every stone and the candidates left for the next")
+	return rf
+}
+
+// report writes the result of the run's search, in the -format or as the text report, and returns the exit code for its outcome.
+func (rf *resultFlags) report(r *searchRun, solution grid.Placements, duration time.Duration, err error) int {
+	g, stats, builder := r.g, r.stats, r.builder
+	if rf.output.formatted() {
+		solution.Sort()
+		if err == nil {
+			err = grid.CheckValidSolution(g, solution)
+		}
+		b := batchResult{Size: g.Size, Placer: builder.PlacerName(), Solver: builder.SolverName(), Solution: solution, Err: err, Duration: duration, Nodes: stats.Nodes.Load()}
+		if err := writeResults(*rf.output.file, rf.output.format, r.flags.strategy.startingPoints, newRunMetadata(r.flags.strategy.fs), false, []batchResult{b}); err != nil {
+			fatal(err)
+		}
+		return exitCode(err)
+	}
+
+	if errors.Is(err, solver.ErrCanceled) {
+		stopped := "interrupted"
+		if errors.Is(err, solver.ErrNodeBudget) {
+			stopped = "stopped at the -max_nodes budget"
+		}
+		fmt.Printf("Search %s for %+v after %v and %d placements. Deepest partial placement reached: %v\n", stopped, g, duration, stats.Nodes.Load(), stats.Deepest())
+		writeBoard(os.Stdout, rf.showBoard, g, stats.Deepest())
+		if total := stats.TasksTotal.Load(); total > 0 {
+			fmt.Printf("%d of %d tasks were completed\n", stats.TasksDone.Load(), total)
+		}
+		return exitStopped
+	}
+	if errors.Is(err, solver.ErrNoSolution) {
+		fmt.Printf("Search ended with no solution found for %+v in %v\n", g, duration)
+		return exitNoSolution
+	}
+	if err != nil {
+		fatal(err)
+	}
+	solution.Sort()
+	if err := grid.CheckValidSolution(g, solution); err != nil {
+		fmt.Printf("We found a solution %v for %+v in %v but it was invalid! %s\n", solution, g, duration, err)
+		return exitInvalid
+	}
+	fmt.Printf("Solution found for %+v in %v:%s\n", g, duration, solutionSuffix(rf.notation, g, solution))
+	writeBoard(os.Stdout, rf.showBoard, g, solution)
+	if *rf.separations {
+		writeSeparationUsage(os.Stdout, g, solution)
+	}
+	return exitSolved
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/pruner"
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"github.com/WillMorrison/pegboard-blog/solver/crosscheck"
+)
+
+// selftestStartingPoints are the starting points that placers other than the ordered ones need to cover every solution.
+var selftestStartingPoints = map[string]string{
+	placer.BidirectionalStonePlacerName: solver.BidirectionalStartingPointsName,
+}
+
+// selftestConfigs returns a configuration for every combination of the registered placers, solvers and the pruners of the placers
+// that use one, each with the starting points that suit its placer.
+func selftestConfigs() []crosscheck.Config {
+	var configs []crosscheck.Config
+	for _, c := range benchMatrix(grid.Grid{}, placer.Names(), solver.Names(), pruner.Names()) {
+		c := c
+		name := c.Placer + "/" + c.Solver
+		if c.UsesPruner {
+			name += "/" + c.Pruner
+		}
+		configs = append(configs, crosscheck.Config{Name: name, Builder: func() *solver.Builder {
+			b := solver.NewBuilder().Placer(c.Placer).Solver(c.Solver)
+			if c.UsesPruner {
+				b.Pruner(c.Pruner)
+			}
+			if sp, ok := selftestStartingPoints[c.Placer]; ok {
+				b.StartingPoints(sp)
+			}
+			return b
+		}})
+	}
+	return configs
+}
+
+// writeSelftestSummary writes a line for each grid size with how many configurations found a solution, and the slowest of them.
+func writeSelftestSummary(w io.Writer, results []crosscheck.Result) {
+	for i := 0; i < len(results); {
+		g := results[i].Grid
+		solvable, n := 0, 0
+		var slowest crosscheck.Result
+		for ; i < len(results) && results[i].Grid == g; i++ {
+			r := results[i]
+			n++
+			if r.Solvable {
+				solvable++
+			}
+			if r.Duration > slowest.Duration {
+				slowest = r
+			}
+		}
+		fmt.Fprintf(w, "%dx%d: %d of %d configurations found a valid solution, the slowest %s in %v\n", g.Size, g.Size, solvable, n, slowest.Config, slowest.Duration.Round(time.Microsecond))
+	}
+}
+
+// selftest implements the selftest subcommand, which solves every small grid with every combination of the registered placers,
+// solvers and pruners, checks each solution, and checks that the combinations agree on which grids have solutions. It is a quick
+// check that experimental changes haven't broken a strategy, and exits 1 if any combination fails or they disagree.
+func selftest(args []string) {
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	maxSize := fs.Uint("max_size", 7, "the largest grid size to solve, from 1")
+	count := fs.Bool("count", false, "also enumerate every solution with each combination, and check that they agree on the number up to rotation and reflection. The unordered placer makes this slow beyond 5x5")
+	parseFlags(fs, fs.Name(), args)
+	if *maxSize < 1 || *maxSize > grid.MaxGridSize {
+		fatalf("The largest size must be between 1 and %d, got %d", grid.MaxGridSize, *maxSize)
+	}
+	usePhysicalCores()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	configs := selftestConfigs()
+	fmt.Printf("Solving grids up to %dx%d with %d combinations of placer, solver and pruner\n", *maxSize, *maxSize, len(configs))
+	startTime := time.Now()
+	results, err := crosscheck.Run(ctx, configs, crosscheck.Options{MaxSize: uint8(*maxSize), Count: *count})
+	writeSelftestSummary(os.Stdout, results)
+	if err != nil {
+		fmt.Printf("FAIL: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("PASS: every combination agreed on every grid, in %v\n", time.Since(startTime).Round(time.Millisecond))
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+func TestSelftestConfigs(t *testing.T) {
+	configs := selftestConfigs()
+	placers := make(map[string]bool)
+	for _, c := range configs {
+		placers[strings.Split(c.Name, "/")[0]] = true
+		// Every combination must build, or the self-test would fail on the first grid
+		if _, err := c.Builder().Build(); err != nil {
+			t.Errorf("configuration %s: Build() error = %v", c.Name, err)
+		}
+	}
+	for _, name := range placer.Names() {
+		if !placers[name] {
+			t.Errorf("selftestConfigs() has no configuration with placer %s", name)
+		}
+	}
+	if want := len(placer.Names()) * len(solver.Names()); len(configs) < want {
+		t.Errorf("selftestConfigs() has %d configurations, want at least one for each of the %d placer and solver pairs", len(configs), want)
+	}
+}
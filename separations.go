@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+// separationUsage splits the separations achievable on the grid into those used by the placements and those left unused, each in
+// increasing order.
+func separationUsage(g grid.Grid, p grid.Placements) (used, unused []uint32) {
+	inUse := make(map[uint32]bool)
+	for _, s := range p.Separations() {
+		inUse[uint32(s)] = true
+	}
+	for _, s := range grid.AllSeparations(g) {
+		if inUse[s] {
+			used = append(used, s)
+		} else {
+			unused = append(unused, s)
+		}
+	}
+	return used, unused
+}
+
+// writeSeparationUsage writes which of the grid's separations the solution uses, and which it leaves unused.
+func writeSeparationUsage(w io.Writer, g grid.Grid, solution grid.Placements) {
+	used, unused := separationUsage(g, solution)
+	fmt.Fprintf(w, "Separations used (%d of %d): %v\n", len(used), len(used)+len(unused), used)
+	fmt.Fprintf(w, "Separations unused (%d): %v\n", len(unused), unused)
+}
+
+// separationHistogram counts the solutions that use each of the grid's separations.
+type separationHistogram struct {
+	separations []uint32
+	counts      map[uint32]int
+	solutions   int
+}
+
+func newSeparationHistogram(g grid.Grid) *separationHistogram {
+	return &separationHistogram{separations: grid.AllSeparations(g), counts: make(map[uint32]int)}
+}
+
+// add counts the separations of a solution.
+func (h *separationHistogram) add(solution grid.Placements) {
+	h.solutions++
+	for _, s := range solution.Separations() {
+		h.counts[uint32(s)]++
+	}
+}
+
+// write writes a table of how many solutions use each separation, with a bar for its share of the solutions. Separations used by every
+// solution, or by none, are the structural constants of the grid's solutions.
+func (h *separationHistogram) write(w io.Writer) {
+	const barWidth = 40
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "separation\tsolutions\tshare\t\t")
+	for _, s := range h.separations {
+		share := 0.0
+		if h.solutions > 0 {
+			share = float64(h.counts[s]) / float64(h.solutions)
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%.1f%%\t%-*s\t\n", s, h.counts[s], 100*share, barWidth, strings.Repeat("#", int(share*barWidth+0.5)))
+	}
+	tw.Flush()
+	always, never := 0, 0
+	for _, s := range h.separations {
+		switch h.counts[s] {
+		case h.solutions:
+			always++
+		case 0:
+			never++
+		}
+	}
+	fmt.Fprintf(w, "Across %d solutions, %d separations are used by every solution and %d by none\n", h.solutions, always, never)
+}
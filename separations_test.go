@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSeparationUsage(t *testing.T) {
+	// The 3x3 grid has separations 1, 2, 4, 5 and 8
+	g := grid.Grid{Size: 3}
+	p := grid.Placements{{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 2, Col: 2}}
+	used, unused := separationUsage(g, p)
+	if diff := cmp.Diff([]uint32{1, 5, 8}, used); diff != "" {
+		t.Errorf("separationUsage(%v) used mismatch (-want +got):\n%s", p, diff)
+	}
+	if diff := cmp.Diff([]uint32{2, 4}, unused); diff != "" {
+		t.Errorf("separationUsage(%v) unused mismatch (-want +got):\n%s", p, diff)
+	}
+}
+
+func TestSeparationHistogram(t *testing.T) {
+	g := grid.Grid{Size: 3}
+	h := newSeparationHistogram(g)
+	h.add(grid.Placements{{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 2, Col: 2}})
+	h.add(grid.Placements{{Row: 0, Col: 0}, {Row: 1, Col: 0}, {Row: 2, Col: 2}})
+	want := map[uint32]int{1: 2, 5: 2, 8: 2}
+	if diff := cmp.Diff(want, h.counts); diff != "" {
+		t.Errorf("separationHistogram counts mismatch (-want +got):\n%s", diff)
+	}
+}
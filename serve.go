@@ -0,0 +1,158 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// Errors returned by solveService.solve for requests that weren't searched.
+var (
+	errInvalidRequest = errors.New("invalid request")
+	errBusy           = errors.New("timed out waiting for other searches to finish")
+)
+
+// solveService searches within requests, to /solve or the gRPC API. At most MaxSolves searches run at once, and the others wait for
+// a free slot.
+type solveService struct {
+	cache   *solver.ResultCache
+	slots   chan struct{}
+	timeout time.Duration
+}
+
+func newSolveService(cfg serverConfig) *solveService {
+	return &solveService{cache: cfg.Cache, slots: make(chan struct{}, max(cfg.MaxSolves, 1)), timeout: cfg.SolveTimeout}
+}
+
+// solve searches for a solution for the request, recording statistics to stats, and returns the result. A search that doesn't finish
+// before the context is done or the timeout is stopped, and the result has the error. It returns errInvalidRequest or errBusy if the
+// request wasn't searched.
+func (ss *solveService) solve(ctx context.Context, req jobRequest, stats *solver.Stats) (batchResult, error) {
+	g, s, err := req.build(ss.cache, stats)
+	if err != nil {
+		return batchResult{}, fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+	if ss.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ss.timeout)
+		defer cancel()
+	}
+	select {
+	case ss.slots <- struct{}{}:
+		defer func() { <-ss.slots }()
+	case <-ctx.Done():
+		return batchResult{}, errBusy
+	}
+
+	startTime := time.Now()
+	solution, err := s.SolveContext(ctx, g)
+	solution.Sort()
+	return batchResult{Size: g.Size, Placer: req.Placer, Solver: req.Solver, Solution: solution, Err: err, Duration: time.Since(startTime), Nodes: stats.Nodes.Load()}, nil
+}
+
+// enumerate searches the whole tree for the request with its placer, calling yield with each solution found until it returns false,
+// and returns the result with the first solution and the number found. The request's solver is unused, so the result has none. Like
+// solve, it waits for a slot and stops at the timeout, and returns errInvalidRequest or errBusy if the request wasn't searched.
+func (ss *solveService) enumerate(ctx context.Context, req jobRequest, stats *solver.Stats, yield func(grid.Placements) bool) (batchResult, int, error) {
+	g, builder, err := req.builder(stats)
+	if err != nil {
+		return batchResult{}, 0, fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+	spc, err := builder.BuildPlacer()
+	if err != nil {
+		return batchResult{}, 0, fmt.Errorf("%w: %w", errInvalidRequest, err)
+	}
+	if ss.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ss.timeout)
+		defer cancel()
+	}
+	select {
+	case ss.slots <- struct{}{}:
+		defer func() { <-ss.slots }()
+	case <-ctx.Done():
+		return batchResult{}, 0, errBusy
+	}
+
+	var first grid.Placements
+	n := 0
+	startTime := time.Now()
+	// Every other starting point breaks the symmetry, so only finds some of each solution's rotations and reflections
+	err = solver.Enumerate(ctx, g, solver.EmptyStartingPoint, spc, stats, func(p grid.Placements) bool {
+		p.Sort()
+		if n == 0 {
+			first = p
+		}
+		n++
+		return yield(p)
+	})
+	if err == nil && n == 0 {
+		err = solver.ErrNoSolution
+	}
+	return batchResult{Size: g.Size, Placer: builder.PlacerName(), Solution: first, Err: err, Duration: time.Since(startTime), Nodes: stats.Nodes.Load()}, n, nil
+}
+
+// ServeHTTP serves POST /solve, which searches for a solution for a JSON jobRequest, e.g. {"size": 7}, and responds with the result in
+// the format of -json. A search that doesn't finish before the client gives up or the timeout is stopped, and responds with the
+// canceled or timeout outcome, or a 503 status if it never got a slot.
+func (ss *solveService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req jobRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid JSON request: %v", err), http.StatusBadRequest)
+		return
+	}
+	result, err := ss.solve(r.Context(), req, &solver.Stats{})
+	switch {
+	case errors.Is(err, errBusy):
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		writeJSON(w, http.StatusOK, newJSONResult(result, req.startingPoints()))
+	}
+}
+
+// addServerFlags adds the flags that configure the searches of the servers to the flag set, and returns a function that returns the
+// configuration they set once the flags are parsed.
+func addServerFlags(fs *flag.FlagSet) func() serverConfig {
+	maxSolves := fs.Int("max_solves", 2, "number of solve requests searched at once. Others wait for a free slot")
+	solveTimeout := fs.Duration("solve_timeout", time.Minute, "how long a solve request waits and searches for before it is stopped, or 0 for as long as the client waits")
+	maxJobs := fs.Int("max_jobs", defaultMaxJobs, "number of jobs searched at once. Requests for more are refused until one finishes")
+	keepJobs := fs.Int("keep_jobs", defaultKeepJobs, "number of finished jobs whose results are kept. Older ones are evicted")
+	jobTTL := fs.Duration("job_ttl", 0, "how long the result of each finished job is kept, or 0 until it is evicted to make room")
+	resultCache := fs.Int("result_cache", 256, "number of search results kept to answer identical requests, or 0 to search for every request")
+	resultCacheTTL := fs.Duration("result_cache_ttl", 0, "how long each search result is kept, or 0 until it is evicted to make room")
+	return func() serverConfig {
+		cfg := serverConfig{MaxSolves: *maxSolves, SolveTimeout: *solveTimeout, MaxJobs: *maxJobs, KeepJobs: *keepJobs, JobTTL: *jobTTL}
+		if *resultCache > 0 {
+			cfg.Cache = solver.NewResultCache(solver.ResultCacheOptions{MaxEntries: *resultCache, TTL: *resultCacheTTL})
+		}
+		return cfg
+	}
+}
+
+// serve implements the serve subcommand, which serves the HTTP API: POST /solve for searches that finish within a request, /jobs for
+// longer ones, and /validate to check placements.
+func serve(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", "localhost:8080", "address to serve the HTTP API on")
+	config := addServerFlags(fs)
+	parseFlags(fs, fs.Name(), args)
+	usePhysicalCores()
+	log.Printf("Serving on %s", *addr)
+	fatal(http.ListenAndServe(*addr, newServeMux(config())))
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// maxRequestBytes limits the size of request bodies. Valid requests are far smaller.
+const maxRequestBytes = 1 << 16
+
+// serverConfig configures the servers of the serve, grpc-serve and pipeline subcommands.
+type serverConfig struct {
+	// Cache, if not nil, holds the results of earlier searches, which jobs and solve requests share
+	Cache *solver.ResultCache
+	// MaxSolves is the number of solve requests searched at once, at least 1. Others wait for one of them to finish.
+	MaxSolves int
+	// SolveTimeout limits how long a solve request waits and searches for, or 0 for as long as the client waits.
+	SolveTimeout time.Duration
+	// MaxJobs is the number of jobs searched at once, or 0 for defaultMaxJobs. Requests for more jobs are refused until one finishes.
+	MaxJobs int
+	// KeepJobs is the number of finished jobs whose results are kept, or 0 for defaultKeepJobs. Older finished jobs are evicted.
+	KeepJobs int
+	// JobTTL is how long the result of a finished job is kept, or 0 until it is evicted to make room.
+	JobTTL time.Duration
+}
+
+// newServeMux returns the handler of the serve subcommand. Small grids are searched within a request to /solve, but searches of large
+// grids take far longer than an HTTP request should, so they are run as jobs which are polled for their result, or watched live from
+// the web UI at /.
+func newServeMux(cfg serverConfig) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handleIndex)
+	mux.HandleFunc("/validate", handleValidate)
+	mux.Handle("/solve", newSolveService(cfg))
+	jobs := newJobQueue(cfg)
+	mux.HandleFunc("/jobs", jobs.handleJobs)
+	mux.HandleFunc("/jobs/", jobs.handleJob)
+	mux.HandleFunc("/cache", cacheHandler(cfg.Cache))
+	return mux
+}
+
+// cacheStatsResponse is the response to requests about the result cache.
+type cacheStatsResponse struct {
+	Enabled   bool  `json:"enabled"`
+	Entries   int   `json:"entries"`
+	Hits      int64 `json:"hits"`
+	Shared    int64 `json:"shared"`
+	Misses    int64 `json:"misses"`
+	Evictions int64 `json:"evictions"`
+}
+
+// cacheHandler serves the result cache. GET returns its statistics, and DELETE clears it and returns them.
+func cacheHandler(cache *solver.ResultCache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodDelete:
+			if cache != nil {
+				cache.Clear()
+			}
+		default:
+			w.Header().Set("Allow", "GET, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		resp := cacheStatsResponse{Enabled: cache != nil}
+		if cache != nil {
+			st := cache.Stats()
+			resp.Entries, resp.Hits, resp.Shared, resp.Misses, resp.Evictions = st.Entries, st.Hits, st.Shared, st.Misses, st.Evictions
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+// validateRequest is the JSON body of a POST /validate request.
+type validateRequest struct {
+	Size       uint8           `json:"size"`
+	Placements grid.Placements `json:"placements"`
+}
+
+// validateResponse is the result of a POST /validate request. If the placements are invalid, the first constraint that failed and the
+// stones that violate it are included, with the same meaning as the fields of grid.ValidationError, followed by every violation, the
+// fewest stones to remove to fix them, and the cells those stones could be moved to, as found by grid.Diagnose.
+type validateResponse struct {
+	Size         uint8              `json:"size"`
+	Placements   grid.Placements    `json:"placements"`
+	Valid        bool               `json:"valid"`
+	Error        string             `json:"error,omitempty"`
+	Constraint   grid.Constraint    `json:"constraint,omitempty"`
+	Point        *grid.Point        `json:"point,omitempty"`
+	Separation   uint16             `json:"separation,omitempty"`
+	Pairs        []grid.Placements  `json:"pairs,omitempty"`
+	Violations   []violation        `json:"violations,omitempty"`
+	Remove       grid.Placements    `json:"remove,omitempty"`
+	Alternatives []grid.Alternative `json:"alternatives,omitempty"`
+}
+
+// violation is one violated constraint in a validateResponse.
+type violation struct {
+	Error      string            `json:"error"`
+	Constraint grid.Constraint   `json:"constraint"`
+	Point      *grid.Point       `json:"point,omitempty"`
+	Separation uint16            `json:"separation,omitempty"`
+	Pairs      []grid.Placements `json:"pairs,omitempty"`
+}
+
+func newViolation(err *grid.ValidationError) violation {
+	v := violation{Error: err.Error(), Constraint: err.Constraint}
+	switch err.Constraint {
+	case grid.InBoundsConstraint, grid.DistinctPointsConstraint:
+		v.Point = &err.Point
+	case grid.UniqueSeparationsConstraint:
+		v.Separation = err.Separation
+		v.Pairs = err.Pairs[:]
+	}
+	return v
+}
+
+// handleValidate checks whether placements are a valid solution for a grid size.
+// The request body is either JSON, e.g. {"size": 3, "placements": ["A0", "B1", "B2"]}, or text in the format "A0 B1 B2" with the size
+// given by the size query parameter.
+func handleValidate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req, err := parseValidateRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Size == 0 || req.Size > grid.MaxGridSize {
+		http.Error(w, fmt.Sprintf("size must be between 1 and %d", grid.MaxGridSize), http.StatusBadRequest)
+		return
+	}
+
+	resp := validateResponse{Size: req.Size, Placements: req.Placements, Valid: true}
+	g := grid.Grid{Size: req.Size}
+	if err := grid.CheckValidSolution(g, req.Placements); err != nil {
+		resp.Valid = false
+		var verr *grid.ValidationError
+		if !errors.As(err, &verr) {
+			resp.Error = err.Error()
+		} else {
+			first := newViolation(verr)
+			resp.Error, resp.Constraint, resp.Point, resp.Separation, resp.Pairs = first.Error, first.Constraint, first.Point, first.Separation, first.Pairs
+		}
+		// Listing every violation takes time quadratic in the number of stones, which is only worth spending on plausible placements
+		if len(req.Placements) <= grid.MaxDiagnosedStones {
+			d := grid.Diagnose(g, req.Placements)
+			for _, v := range d.Violations {
+				resp.Violations = append(resp.Violations, newViolation(v))
+			}
+			resp.Remove, resp.Alternatives = d.Remove, d.Alternatives
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func parseValidateRequest(r *http.Request) (validateRequest, error) {
+	var req validateRequest
+	body := http.MaxBytesReader(nil, r.Body, maxRequestBytes)
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == "application/json" {
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			return req, fmt.Errorf("invalid JSON request: %w", err)
+		}
+		return req, nil
+	}
+
+	size, err := strconv.ParseUint(r.URL.Query().Get("size"), 10, 8)
+	if err != nil {
+		return req, fmt.Errorf("invalid size query parameter: %w", err)
+	}
+	text, err := io.ReadAll(body)
+	if err != nil {
+		return req, err
+	}
+	req.Size = uint8(size)
+	req.Placements, err = grid.ParsePlacements(string(text))
+	return req, err
+}
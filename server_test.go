@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/net/websocket"
+)
+
+func TestHandleValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		contentType string
+		body        string
+		wantStatus  int
+		want        validateResponse
+	}{
+		{"valid json",
+			"/validate", "application/json", `{"size": 3, "placements": ["A0", "B1", "B2"]}`,
+			http.StatusOK,
+			validateResponse{Size: 3, Placements: grid.Placements{{Row: 0, Col: 0}, {Row: 1, Col: 1}, {Row: 1, Col: 2}}, Valid: true},
+		},
+		{"valid text",
+			"/validate?size=3", "text/plain", "[A0 B1 B2]",
+			http.StatusOK,
+			validateResponse{Size: 3, Placements: grid.Placements{{Row: 0, Col: 0}, {Row: 1, Col: 1}, {Row: 1, Col: 2}}, Valid: true},
+		},
+		{"duplicate separation",
+			"/validate?size=3", "text/plain", "A0 B1 A2",
+			http.StatusOK,
+			validateResponse{
+				Size:       3,
+				Placements: grid.Placements{{Row: 0, Col: 0}, {Row: 1, Col: 1}, {Row: 0, Col: 2}},
+				Error:      "Duplicated separation with squared distance 2 between both [A0 B1] and [B1 A2]",
+				Constraint: grid.UniqueSeparationsConstraint,
+				Separation: 2,
+				Pairs:      []grid.Placements{{{Row: 0, Col: 0}, {Row: 1, Col: 1}}, {{Row: 1, Col: 1}, {Row: 0, Col: 2}}},
+				Violations: []violation{{
+					Error:      "Duplicated separation with squared distance 2 between both [A0 B1] and [B1 A2]",
+					Constraint: grid.UniqueSeparationsConstraint,
+					Separation: 2,
+					Pairs:      []grid.Placements{{{Row: 0, Col: 0}, {Row: 1, Col: 1}}, {{Row: 1, Col: 1}, {Row: 0, Col: 2}}},
+				}},
+				Remove:       grid.Placements{{Row: 0, Col: 0}},
+				Alternatives: []grid.Alternative{{Stone: grid.Point{Row: 0, Col: 0}, Cells: grid.Placements{{Row: 1, Col: 0}, {Row: 2, Col: 1}}}},
+			},
+		},
+		{"out of bounds",
+			"/validate", "application/json", `{"size": 2, "placements": ["A0", "C0"]}`,
+			http.StatusOK,
+			validateResponse{
+				Size:         2,
+				Placements:   grid.Placements{{Row: 0, Col: 0}, {Row: 2, Col: 0}},
+				Error:        "C0 is out of bounds",
+				Constraint:   grid.InBoundsConstraint,
+				Point:        &grid.Point{Row: 2, Col: 0},
+				Violations:   []violation{{Error: "C0 is out of bounds", Constraint: grid.InBoundsConstraint, Point: &grid.Point{Row: 2, Col: 0}}},
+				Remove:       grid.Placements{{Row: 2, Col: 0}},
+				Alternatives: []grid.Alternative{{Stone: grid.Point{Row: 2, Col: 0}, Cells: grid.Placements{{Row: 0, Col: 1}, {Row: 1, Col: 0}, {Row: 1, Col: 1}}}},
+			},
+		},
+		{"invalid point", "/validate?size=3", "text/plain", "A0 B", http.StatusBadRequest, validateResponse{}},
+		{"missing size", "/validate", "text/plain", "A0", http.StatusBadRequest, validateResponse{}},
+		{"too large", "/validate", "application/json", `{"size": 15, "placements": []}`, http.StatusBadRequest, validateResponse{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			newServeMux(serverConfig{}).ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("POST %s returned status %d, want %d: %s", tt.url, rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got validateResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("could not decode response %s: %v", rec.Body, err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("POST %s response mismatch (-want +got):\n%s", tt.url, diff)
+			}
+		})
+	}
+}
+
+func TestHandleValidate_Method(t *testing.T) {
+	rec := httptest.NewRecorder()
+	newServeMux(serverConfig{}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/validate", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /validate returned status %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+// serveJSON sends a request to the handler and decodes its JSON response into v, returning the status code.
+func serveJSON(t *testing.T, h http.Handler, method, url, body string, v any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, url, strings.NewReader(body)))
+	if rec.Code < 300 {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("%s %s: could not decode response %s: %v", method, url, rec.Body, err)
+		}
+	}
+	return rec.Code
+}
+
+func TestSolve(t *testing.T) {
+	mux := newServeMux(serverConfig{SolveTimeout: 50 * time.Millisecond})
+
+	var result jsonResult
+	if code := serveJSON(t, mux, http.MethodPost, "/solve", `{"size": 6}`, &result); code != http.StatusOK {
+		t.Fatalf("POST /solve returned status %d, want %d", code, http.StatusOK)
+	}
+	if result.Outcome != "solved" {
+		t.Fatalf("POST /solve outcome = %q, want solved", result.Outcome)
+	}
+	if err := grid.CheckValidSolution(grid.Grid{Size: 6}, result.Solution); err != nil {
+		t.Errorf("POST /solve solution %v is invalid: %v", result.Solution, err)
+	}
+
+	// 11x11 grids take far longer than the timeout to search
+	var long jsonResult
+	serveJSON(t, mux, http.MethodPost, "/solve", `{"size": 11, "solver": "single_thread"}`, &long)
+	if long.Outcome != "timeout" || long.Solution != nil {
+		t.Errorf("POST /solve for 11x11 outcome = %q with solution %v, want timeout and none", long.Outcome, long.Solution)
+	}
+
+	if code := serveJSON(t, mux, http.MethodPost, "/solve", `{"size": 15}`, nil); code != http.StatusBadRequest {
+		t.Errorf("POST /solve with size 15 returned status %d, want %d", code, http.StatusBadRequest)
+	}
+	if code := serveJSON(t, mux, http.MethodGet, "/solve", "", nil); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /solve returned status %d, want %d", code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestSolve_Busy(t *testing.T) {
+	h := newSolveService(serverConfig{MaxSolves: 1, SolveTimeout: 10 * time.Millisecond})
+	// Hold the only slot, as a long search would
+	h.slots <- struct{}{}
+	if code := serveJSON(t, h, http.MethodPost, "/solve", `{"size": 6}`, nil); code != http.StatusServiceUnavailable {
+		t.Errorf("POST /solve while busy returned status %d, want %d", code, http.StatusServiceUnavailable)
+	}
+	<-h.slots
+	var result jsonResult
+	if code := serveJSON(t, h, http.MethodPost, "/solve", `{"size": 6}`, &result); code != http.StatusOK || result.Outcome != "solved" {
+		t.Errorf("POST /solve once free returned status %d and outcome %q, want %d and solved", code, result.Outcome, http.StatusOK)
+	}
+}
+
+func TestJobs(t *testing.T) {
+	mux := newServeMux(serverConfig{})
+
+	var job jobStatus
+	if code := serveJSON(t, mux, http.MethodPost, "/jobs", `{"size": 6}`, &job); code != http.StatusAccepted {
+		t.Fatalf("POST /jobs returned status %d, want %d", code, http.StatusAccepted)
+	}
+	for job.State == JobRunning {
+		time.Sleep(time.Millisecond)
+		serveJSON(t, mux, http.MethodGet, "/jobs/"+job.ID, "", &job)
+	}
+	if job.State != JobSolved {
+		t.Fatalf("GET /jobs/%s state = %q, want %q", job.ID, job.State, JobSolved)
+	}
+	if err := grid.CheckValidSolution(grid.Grid{Size: 6}, job.Solution); err != nil {
+		t.Errorf("GET /jobs/%s solution %v is invalid: %v", job.ID, job.Solution, err)
+	}
+
+	// 11x11 grids take far longer than the test to search
+	var long jobStatus
+	serveJSON(t, mux, http.MethodPost, "/jobs", `{"size": 11, "solver": "single_thread"}`, &long)
+	if code := serveJSON(t, mux, http.MethodDelete, "/jobs/"+long.ID, "", &long); code != http.StatusOK || long.State != JobCanceled {
+		t.Errorf("DELETE /jobs/%s returned status %d and state %q, want %d and %q", long.ID, code, long.State, http.StatusOK, JobCanceled)
+	}
+
+	var jobs []jobStatus
+	serveJSON(t, mux, http.MethodGet, "/jobs", "", &jobs)
+	if len(jobs) != 2 || jobs[0].ID != job.ID || jobs[1].ID != long.ID {
+		t.Errorf("GET /jobs = %+v, want jobs %s and %s", jobs, job.ID, long.ID)
+	}
+
+	if code := serveJSON(t, mux, http.MethodGet, "/jobs/missing", "", nil); code != http.StatusNotFound {
+		t.Errorf("GET /jobs/missing returned status %d, want %d", code, http.StatusNotFound)
+	}
+	if code := serveJSON(t, mux, http.MethodPost, "/jobs", `{"size": 15}`, nil); code != http.StatusBadRequest {
+		t.Errorf("POST /jobs with size 15 returned status %d, want %d", code, http.StatusBadRequest)
+	}
+	if code := serveJSON(t, mux, http.MethodPost, "/jobs", `{"size": 6, "placer": "random"}`, nil); code != http.StatusBadRequest {
+		t.Errorf("POST /jobs with an unknown placer returned status %d, want %d", code, http.StatusBadRequest)
+	}
+}
+
+func TestJobs_Limits(t *testing.T) {
+	mux := newServeMux(serverConfig{MaxJobs: 1, KeepJobs: 1})
+
+	// 11x11 grids take far longer than the test to search, so the first job holds the only slot
+	var long jobStatus
+	if code := serveJSON(t, mux, http.MethodPost, "/jobs", `{"size": 11, "solver": "single_thread"}`, &long); code != http.StatusAccepted {
+		t.Fatalf("POST /jobs returned status %d, want %d", code, http.StatusAccepted)
+	}
+	if code := serveJSON(t, mux, http.MethodPost, "/jobs", `{"size": 6}`, nil); code != http.StatusServiceUnavailable {
+		t.Errorf("POST /jobs while another job runs returned status %d, want %d", code, http.StatusServiceUnavailable)
+	}
+	serveJSON(t, mux, http.MethodDelete, "/jobs/"+long.ID, "", &long)
+
+	var job jobStatus
+	if code := serveJSON(t, mux, http.MethodPost, "/jobs", `{"size": 6}`, &job); code != http.StatusAccepted {
+		t.Fatalf("POST /jobs once the first was canceled returned status %d, want %d", code, http.StatusAccepted)
+	}
+	for job.State == JobRunning {
+		time.Sleep(time.Millisecond)
+		serveJSON(t, mux, http.MethodGet, "/jobs/"+job.ID, "", &job)
+	}
+	// Only the most recent finished job is kept
+	var jobs []jobStatus
+	serveJSON(t, mux, http.MethodGet, "/jobs", "", &jobs)
+	if len(jobs) != 1 || jobs[0].ID != job.ID {
+		t.Errorf("GET /jobs = %+v, want only job %s", jobs, job.ID)
+	}
+	if code := serveJSON(t, mux, http.MethodGet, "/jobs/"+long.ID, "", nil); code != http.StatusNotFound {
+		t.Errorf("GET /jobs/%s for an evicted job returned status %d, want %d", long.ID, code, http.StatusNotFound)
+	}
+}
+
+func TestJobs_TTL(t *testing.T) {
+	const ttl = 50 * time.Millisecond
+	mux := newServeMux(serverConfig{JobTTL: ttl})
+	var job jobStatus
+	serveJSON(t, mux, http.MethodPost, "/jobs", `{"size": 6}`, &job)
+	// The job may already be evicted by the time it is seen to finish
+	for job.State == JobRunning && serveJSON(t, mux, http.MethodGet, "/jobs/"+job.ID, "", &job) == http.StatusOK {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(2 * ttl)
+	var jobs []jobStatus
+	serveJSON(t, mux, http.MethodGet, "/jobs", "", &jobs)
+	if len(jobs) != 0 {
+		t.Errorf("GET /jobs after the TTL = %+v, want no jobs", jobs)
+	}
+}
+
+func TestJobs_Cache(t *testing.T) {
+	mux := newServeMux(serverConfig{Cache: solver.NewResultCache(solver.ResultCacheOptions{MaxEntries: 10})})
+
+	// The second job with the same strategies reuses the first's result, and the third searches again
+	var solutions []grid.Placements
+	for _, body := range []string{`{"size": 6}`, `{"size": 6}`, `{"size": 6, "no_cache": true}`} {
+		var job jobStatus
+		serveJSON(t, mux, http.MethodPost, "/jobs", body, &job)
+		for job.State == JobRunning {
+			time.Sleep(time.Millisecond)
+			serveJSON(t, mux, http.MethodGet, "/jobs/"+job.ID, "", &job)
+		}
+		if job.State != JobSolved {
+			t.Fatalf("job %s for %s state = %q, want %q", job.ID, body, job.State, JobSolved)
+		}
+		solutions = append(solutions, job.Solution)
+	}
+	if diff := cmp.Diff(solutions[0], solutions[1]); diff != "" {
+		t.Errorf("cached job's solution differs from the first job's (-first +cached):\n%s", diff)
+	}
+
+	var stats cacheStatsResponse
+	serveJSON(t, mux, http.MethodGet, "/cache", "", &stats)
+	if want := (cacheStatsResponse{Enabled: true, Entries: 1, Hits: 1, Misses: 1}); stats != want {
+		t.Errorf("GET /cache = %+v, want %+v", stats, want)
+	}
+	serveJSON(t, mux, http.MethodDelete, "/cache", "", &stats)
+	if stats.Entries != 0 {
+		t.Errorf("DELETE /cache left %d entries, want 0", stats.Entries)
+	}
+}
+
+func TestJobs_Live(t *testing.T) {
+	server := httptest.NewServer(newServeMux(serverConfig{}))
+	defer server.Close()
+
+	var job jobStatus
+	serveJSON(t, server.Config.Handler, http.MethodPost, "/jobs", `{"size": 11, "placer": "ordered_noalloc", "solver": "single_thread"}`, &job)
+	ws, err := websocket.Dial(strings.Replace(server.URL, "http", "ws", 1)+"/jobs/"+job.ID+"/live", "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	var u liveUpdate
+	if err := websocket.JSON.Receive(ws, &u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Job.ID != job.ID || u.Job.State != JobRunning {
+		t.Errorf("first live update is of job %s in state %q, want running job %s", u.Job.ID, u.Job.State, job.ID)
+	}
+	if len(u.Workers) == 0 {
+		t.Errorf("first live update has no workers, want the solver's")
+	}
+
+	// The last update is the canceled job, after which the server closes the connection
+	serveJSON(t, server.Config.Handler, http.MethodDelete, "/jobs/"+job.ID, "", &job)
+	for err == nil && u.Job.State == JobRunning {
+		err = websocket.JSON.Receive(ws, &u)
+	}
+	if err != nil || u.Job.State != JobCanceled {
+		t.Errorf("last live update state = %q with error %v, want %q", u.Job.State, err, JobCanceled)
+	}
+	if err := websocket.JSON.Receive(ws, &u); err == nil {
+		t.Errorf("live updates continued after the job finished")
+	}
+}
+
+func TestHandleIndex(t *testing.T) {
+	mux := newServeMux(serverConfig{})
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "/live") {
+		t.Errorf("GET / returned status %d, want %d and the web UI", rec.Code, http.StatusOK)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /missing returned status %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
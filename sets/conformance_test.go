@@ -0,0 +1,46 @@
+package sets_test
+
+import (
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/sets"
+	"github.com/WillMorrison/pegboard-blog/sets/settest"
+)
+
+func Test_SeparationSet(t *testing.T) {
+	// the largest possible separation on a max sized grid .
+	// Some implementations have an upper bound on separation that is not 2^16
+	maxSep := grid.Separation(grid.Point{Row: 0, Col: 0}, grid.Point{Row: grid.MaxGridSize - 1, Col: grid.MaxGridSize - 1})
+
+	tests := []struct {
+		name string
+		ssc  sets.SeparationSetConstructor
+	}{
+		{"mapSeparationSet", sets.NewMapSeparationSet},
+		{"sortedMapSeparationSet", sets.NewSortedMapSeparationSet},
+		{"bitSeparationSet", sets.NewBitArraySeparationSet},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settest.SeparationSet(t, tt.ssc, maxSep)
+		})
+	}
+}
+
+func Test_PointSet(t *testing.T) {
+	tests := []struct {
+		name string
+		psc  sets.PointSetConstructor
+	}{
+		{"mapPointSet", sets.NewMapPointSet},
+		{"sortedMapPointSet", sets.NewSortedMapPointSet},
+		{"bitArrayPointSet", sets.NewBitArrayPointSet},
+		{"bitboardPointSet", sets.NewBitboardPointSet},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settest.PointSet(t, tt.psc)
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package sets
+
+import "slices"
+
+// Names of the SeparationSet implementations in SeparationSets, as used on the command line.
+const (
+	MapSeparationSetName      = "map"
+	BitArraySeparationSetName = "array"
+)
+
+// SeparationSets maps the name of each SeparationSet implementation to its constructor, so that programs and config files can choose
+// one by name.
+var SeparationSets = map[string]SeparationSetConstructor{
+	MapSeparationSetName:      NewMapSeparationSet,
+	BitArraySeparationSetName: NewBitArraySeparationSet,
+}
+
+// RegisterSeparationSet adds a SeparationSet implementation to SeparationSets, so that it can be chosen by name like the built in
+// ones. Call it from an init function, as the names are read when flags are defined. It panics if the name is empty or already
+// registered, or the constructor is nil.
+func RegisterSeparationSet(name string, c SeparationSetConstructor) {
+	if name == "" || c == nil {
+		panic("sets: RegisterSeparationSet called without a name or constructor")
+	}
+	if _, dup := SeparationSets[name]; dup {
+		panic("sets: RegisterSeparationSet called twice for " + name)
+	}
+	SeparationSets[name] = c
+}
+
+// SeparationSetNames returns the names in SeparationSets, sorted.
+func SeparationSetNames() []string {
+	names := make([]string, 0, len(SeparationSets))
+	for name := range SeparationSets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -1,6 +1,8 @@
 package sets
 
 import (
+	"math/bits"
+	"slices"
 	"unsafe"
 
 	"github.com/WillMorrison/pegboard-blog/grid"
@@ -14,6 +16,10 @@ type SeparationSet interface {
 	Copy() SeparationSet
 	Clone(SeparationSet)
 	Elements() []uint16
+	// AppendElements appends the separations in the set to the given slice and returns the extended slice, like the builtin append
+	AppendElements([]uint16) []uint16
+	// NextAbove returns the smallest separation in the set which is greater than sep, and whether there is one
+	NextAbove(sep uint16) (uint16, bool)
 }
 
 type SeparationSetConstructor func(grid.Placements) SeparationSet
@@ -66,11 +72,50 @@ func (ss mapSeparationSet) Clone(ss2 SeparationSet) {
 }
 
 func (ss mapSeparationSet) Elements() []uint16 {
-	keys := make([]uint16, 0, len(ss))
+	return ss.AppendElements(make([]uint16, 0, len(ss)))
+}
+
+func (ss mapSeparationSet) AppendElements(buf []uint16) []uint16 {
 	for k := range ss {
-		keys = append(keys, k)
+		buf = append(buf, k)
 	}
-	return keys
+	return buf
+}
+
+func (ss mapSeparationSet) NextAbove(sep uint16) (uint16, bool) {
+	next, found := uint16(0), false
+	for k := range ss {
+		if k > sep && (!found || k < next) {
+			next, found = k, true
+		}
+	}
+	return next, found
+}
+
+// sortedMapSeparationSet is a mapSeparationSet whose Elements are in ascending order, so that its output is deterministic
+type sortedMapSeparationSet struct {
+	mapSeparationSet
+}
+
+// NewSortedMapSeparationSet returns a map-based set whose Elements and AppendElements return separations in ascending order, like the
+// bit array sets do. It is slower than NewMapSeparationSet, but its output can be compared directly with other implementations and gold files.
+func NewSortedMapSeparationSet(p grid.Placements) SeparationSet {
+	return sortedMapSeparationSet{NewMapSeparationSet(p).(mapSeparationSet)}
+}
+
+func (ss sortedMapSeparationSet) Copy() SeparationSet {
+	return sortedMapSeparationSet{ss.mapSeparationSet.Copy().(mapSeparationSet)}
+}
+
+func (ss sortedMapSeparationSet) Elements() []uint16 {
+	return ss.AppendElements(make([]uint16, 0, len(ss.mapSeparationSet)))
+}
+
+func (ss sortedMapSeparationSet) AppendElements(buf []uint16) []uint16 {
+	n := len(buf)
+	buf = ss.mapSeparationSet.AppendElements(buf)
+	slices.Sort(buf[n:])
+	return buf
 }
 
 // A set representing membership as bits. Has up to 2*13^2 = 338 members, which is sufficient for separations on a max sized grid.
@@ -136,13 +181,34 @@ func (ss *BitArraySeparationSet) Clone(ss2 SeparationSet) {
 }
 
 func (ss BitArraySeparationSet) Elements() []uint16 {
-	keys := make([]uint16, 0, len(ss))
+	return ss.AppendElements(make([]uint16, 0, len(ss)))
+}
+
+func (ss BitArraySeparationSet) AppendElements(buf []uint16) []uint16 {
 	for sep := uint16(0); sep < uint16(grid.MaxSeparation+1); sep++ {
 		if ss.Has(sep) {
-			keys = append(keys, sep)
+			buf = append(buf, sep)
 		}
 	}
-	return keys
+	return buf
+}
+
+func (ss *BitArraySeparationSet) NextAbove(sep uint16) (uint16, bool) {
+	if sep >= grid.MaxSeparation {
+		return 0, false
+	}
+	next := sep + 1
+	// Mask off the bits below next in its word, then find the first set bit in it or the following words
+	i := next >> 6
+	word := ss[i] &^ (1<<(next&0x3f) - 1)
+	for word == 0 {
+		i++
+		if int(i) == len(ss) {
+			return 0, false
+		}
+		word = ss[i]
+	}
+	return i<<6 | uint16(bits.TrailingZeros64(word)), true
 }
 
 type SeparationSetIterator struct {
@@ -211,12 +277,51 @@ type PointSet interface {
 	Clone(PointSet)
 	// Elements returns a slice of points in the set
 	Elements() grid.Placements
+	// AppendElements appends the points in the set to the given slice and returns the extended slice, like the builtin append
+	AppendElements(grid.Placements) grid.Placements
 	// Iter returns an iterator over the points in the set
 	Iter() grid.PointIterator
+	// IterOrder returns an iterator over the points in the set which visits them in the given order
+	IterOrder(Order) grid.PointIterator
 }
 
 type PointSetConstructor func(grid.Placements) PointSet
 
+// Order is an order in which to iterate over the points in a set
+type Order uint8
+
+const (
+	// Forward visits points left to right, top to bottom, the order of grid.AdvanceStone
+	Forward Order = iota
+	// Reverse visits points right to left, bottom to top, the order of grid.RetreatStone
+	Reverse
+)
+
+// NewOrderedPointSetIterator returns an iterator over the points in a set in a custom order. It visits the points returned by order
+// which are in the set, so order must return each point at most once. Iterating over a line of the grid, for example, visits just
+// the set's points on that line.
+func NewOrderedPointSetIterator(ps PointSet, order grid.PointIterator) grid.PointIterator {
+	return &orderedPointSetIterator{ps: ps, order: order}
+}
+
+type orderedPointSetIterator struct {
+	ps    PointSet
+	order grid.PointIterator
+}
+
+func (pi *orderedPointSetIterator) Next() (grid.Point, bool) {
+	for p, ok := pi.order.Next(); ok; p, ok = pi.order.Next() {
+		if pi.ps.Has(p) {
+			return p, true
+		}
+	}
+	return grid.Point{}, false
+}
+
+func (pi *orderedPointSetIterator) Reset() {
+	pi.order.Reset()
+}
+
 func genericPointSetUnion(ps1, ps2 PointSet) {
 	it := ps2.Iter()
 	for p, ok := it.Next(); ok; p, ok = it.Next() {
@@ -242,6 +347,10 @@ func (pi *placementsIterator) Next() (grid.Point, bool) {
 	return next, true
 }
 
+func (pi *placementsIterator) Reset() {
+	pi.i = 0
+}
+
 type mapPointSet map[grid.Point]bool
 
 func NewMapPointSet(points grid.Placements) PointSet {
@@ -283,17 +392,59 @@ func (ps mapPointSet) Clone(ps2 PointSet) {
 }
 
 func (ps mapPointSet) Elements() grid.Placements {
-	points := make(grid.Placements, 0, len(ps))
+	return ps.AppendElements(make(grid.Placements, 0, len(ps)))
+}
+
+func (ps mapPointSet) AppendElements(buf grid.Placements) grid.Placements {
 	for p := range ps {
-		points = append(points, p)
+		buf = append(buf, p)
 	}
-	return points
+	return buf
 }
 
 func (ps mapPointSet) Iter() grid.PointIterator {
 	return &placementsIterator{i: 0, elements: ps.Elements()}
 }
 
+func (ps mapPointSet) IterOrder(order Order) grid.PointIterator {
+	elements := ps.Elements()
+	elements.Sort()
+	if order == Reverse {
+		slices.Reverse(elements)
+	}
+	return &placementsIterator{i: 0, elements: elements}
+}
+
+// sortedMapPointSet is a mapPointSet whose Elements are in left to right, top to bottom order, so that its output is deterministic
+type sortedMapPointSet struct {
+	mapPointSet
+}
+
+// NewSortedMapPointSet returns a map-based set whose Elements, AppendElements and Iter return points in left to right, top to bottom order,
+// like the bit sets do. It is slower than NewMapPointSet, but its output can be compared directly with other implementations and gold files.
+func NewSortedMapPointSet(points grid.Placements) PointSet {
+	return sortedMapPointSet{NewMapPointSet(points).(mapPointSet)}
+}
+
+func (ps sortedMapPointSet) Copy() PointSet {
+	return sortedMapPointSet{ps.mapPointSet.Copy().(mapPointSet)}
+}
+
+func (ps sortedMapPointSet) Elements() grid.Placements {
+	return ps.AppendElements(make(grid.Placements, 0, len(ps.mapPointSet)))
+}
+
+func (ps sortedMapPointSet) AppendElements(buf grid.Placements) grid.Placements {
+	n := len(buf)
+	buf = ps.mapPointSet.AppendElements(buf)
+	buf[n:].Sort()
+	return buf
+}
+
+func (ps sortedMapPointSet) Iter() grid.PointIterator {
+	return ps.IterOrder(Forward)
+}
+
 // A set representing membership as bits. Has up to 16^2 = 256 members, which is sufficient for all points on a max sized grid.
 // Each uint16 represents memberships for one row.
 type BitArrayPointSet [16]uint16
@@ -329,6 +480,39 @@ func (pi *bitArrayPointSetIterator) Next() (grid.Point, bool) {
 	return next, true
 }
 
+func (pi *bitArrayPointSetIterator) Reset() {
+	pi.next = grid.Point{}
+	if !pi.ps.Has(pi.next) {
+		pi.Next()
+	}
+}
+
+type bitArrayPointSetReverseIterator struct {
+	ps *BitArrayPointSet
+	// row is the row that remaining holds the unvisited points of
+	row       int
+	remaining uint16
+}
+
+func (pi *bitArrayPointSetReverseIterator) Next() (grid.Point, bool) {
+	for pi.remaining == 0 {
+		if pi.row == 0 {
+			return grid.Point{}, false
+		}
+		pi.row--
+		pi.remaining = pi.ps[pi.row]
+	}
+	// The lowest set bit is the rightmost point in the row
+	col := 15 - bits.TrailingZeros16(pi.remaining)
+	pi.remaining &= pi.remaining - 1
+	return grid.Point{Row: uint8(pi.row), Col: uint8(col)}, true
+}
+
+func (pi *bitArrayPointSetReverseIterator) Reset() {
+	pi.row = len(pi.ps)
+	pi.remaining = 0
+}
+
 func (ps BitArrayPointSet) Has(p grid.Point) bool {
 	return ps[p.Row]&(0x8000>>p.Col) != 0
 }
@@ -376,18 +560,161 @@ func (ps *BitArrayPointSet) Clone(ps2 PointSet) {
 }
 
 func (ps BitArrayPointSet) Elements() grid.Placements {
-	keys := make(grid.Placements, 0, len(ps))
-	it := ps.Iter()
+	return ps.AppendElements(make(grid.Placements, 0, len(ps)))
+}
+
+func (ps BitArrayPointSet) AppendElements(buf grid.Placements) grid.Placements {
+	it := bitArrayPointSetIterator{ps: &ps}
+	it.Reset()
 	for p, ok := it.Next(); ok; p, ok = it.Next() {
-		keys = append(keys, p)
+		buf = append(buf, p)
 	}
-	return keys
+	return buf
 }
 
 func (ps *BitArrayPointSet) Iter() grid.PointIterator {
-	it := bitArrayPointSetIterator{ps: ps, next: grid.Point{}}
-	if !ps.Has(it.next) {
-		it.Next()
-	}
+	it := bitArrayPointSetIterator{ps: ps}
+	it.Reset()
 	return &it
 }
+
+func (ps *BitArrayPointSet) IterOrder(order Order) grid.PointIterator {
+	if order == Reverse {
+		it := bitArrayPointSetReverseIterator{ps: ps}
+		it.Reset()
+		return &it
+	}
+	return ps.Iter()
+}
+
+// The largest grid whose points all fit in a BitboardPointSet
+const BitboardMaxGridSize = 8
+
+// A set representing membership as bits in a single word. Has up to 8^2 = 64 members, which is sufficient for all points on grids up to 8x8.
+// Each byte represents memberships for one row, and point ordering is little endian so that iterating from the lowest set bit
+// visits points from top to bottom, left to right.
+type BitboardPointSet uint64
+
+func NewBitboardPointSet(points grid.Placements) PointSet {
+	var ps BitboardPointSet
+	for _, p := range points {
+		ps.Add(p)
+	}
+	return &ps
+}
+
+// BitboardIndex returns the bit index of a point in a BitboardPointSet
+func BitboardIndex(p grid.Point) uint8 {
+	return p.Row<<3 | p.Col
+}
+
+// BitboardPoint returns the point corresponding to a bit index of a BitboardPointSet
+func BitboardPoint(i uint8) grid.Point {
+	return grid.Point{Row: i >> 3, Col: i & 0x7}
+}
+
+// BitboardGridMask returns a set containing every point on the given grid, which must be no larger than BitboardMaxGridSize.
+func BitboardGridMask(g grid.Grid) BitboardPointSet {
+	var row uint64 = (1 << g.Size) - 1
+	var mask uint64
+	for r := uint8(0); r < g.Size; r++ {
+		mask |= row << (r << 3)
+	}
+	return BitboardPointSet(mask)
+}
+
+type bitboardPointSetIterator struct {
+	set       uint64
+	remaining uint64
+}
+
+func (pi *bitboardPointSetIterator) Next() (grid.Point, bool) {
+	if pi.remaining == 0 {
+		return grid.Point{}, false
+	}
+	i := bits.TrailingZeros64(pi.remaining)
+	pi.remaining &= pi.remaining - 1 // clear lowest set bit
+	return BitboardPoint(uint8(i)), true
+}
+
+func (pi *bitboardPointSetIterator) Reset() {
+	pi.remaining = pi.set
+}
+
+type bitboardPointSetReverseIterator struct {
+	set       uint64
+	remaining uint64
+}
+
+func (pi *bitboardPointSetReverseIterator) Next() (grid.Point, bool) {
+	if pi.remaining == 0 {
+		return grid.Point{}, false
+	}
+	i := 63 - bits.LeadingZeros64(pi.remaining)
+	pi.remaining &^= 1 << i // clear highest set bit
+	return BitboardPoint(uint8(i)), true
+}
+
+func (pi *bitboardPointSetReverseIterator) Reset() {
+	pi.remaining = pi.set
+}
+
+func (ps BitboardPointSet) Has(p grid.Point) bool {
+	return ps&(1<<BitboardIndex(p)) != 0
+}
+
+func (ps *BitboardPointSet) Add(p grid.Point) {
+	*ps |= 1 << BitboardIndex(p)
+}
+
+func (ps *BitboardPointSet) Union(ps2 PointSet) {
+	switch t := ps2.(type) {
+	// If the second set is also a bitboard, the union is a single bitwise or
+	case *BitboardPointSet:
+		*ps |= *t
+	default:
+		genericPointSetUnion(ps, ps2)
+	}
+}
+
+func (ps *BitboardPointSet) Clear() {
+	*ps = 0
+}
+
+func (ps *BitboardPointSet) Copy() PointSet {
+	var newSet BitboardPointSet = *ps
+	return &newSet
+}
+
+func (ps *BitboardPointSet) Clone(ps2 PointSet) {
+	switch t := ps2.(type) {
+	// If the second set is also a bitboard, just copy the word
+	case *BitboardPointSet:
+		*ps = *t
+	default:
+		genericPointSetClone(ps, ps2)
+	}
+}
+
+func (ps BitboardPointSet) Elements() grid.Placements {
+	return ps.AppendElements(make(grid.Placements, 0, bits.OnesCount64(uint64(ps))))
+}
+
+func (ps BitboardPointSet) AppendElements(buf grid.Placements) grid.Placements {
+	it := bitboardPointSetIterator{set: uint64(ps), remaining: uint64(ps)}
+	for p, ok := it.Next(); ok; p, ok = it.Next() {
+		buf = append(buf, p)
+	}
+	return buf
+}
+
+func (ps BitboardPointSet) Iter() grid.PointIterator {
+	return &bitboardPointSetIterator{set: uint64(ps), remaining: uint64(ps)}
+}
+
+func (ps BitboardPointSet) IterOrder(order Order) grid.PointIterator {
+	if order == Reverse {
+		return &bitboardPointSetReverseIterator{set: uint64(ps), remaining: uint64(ps)}
+	}
+	return ps.Iter()
+}
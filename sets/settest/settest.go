@@ -0,0 +1,392 @@
+// Package settest provides conformance suites for sets.SeparationSet and sets.PointSet implementations, so that new sets can check
+// their behavior against the same tests as the sets in package sets by running a single function from a test.
+package settest
+
+import (
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/sets"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// SeparationSet runs the conformance suite against the SeparationSets that ssc constructs, as subtests of t. maxSep is the largest
+// separation the implementation supports, which should be at least the largest separation on a grid of grid.MaxGridSize.
+func SeparationSet(t *testing.T, ssc sets.SeparationSetConstructor, maxSep uint16) {
+	t.Run("Empty_Has_Elements", func(t *testing.T) {
+		ss := ssc(nil)
+		if ss.Has(0) {
+			t.Errorf("Has(0)=true, want false")
+		}
+		if got := len(ss.Elements()); got != 0 {
+			t.Errorf("len(Elements())=%d, want 0", got)
+		}
+	})
+
+	t.Run("Add_Has_Elements", func(t *testing.T) {
+		for sep := uint16(0); sep <= maxSep; sep++ {
+			ss := ssc(nil)
+			ss.Add(sep)
+			if !ss.Has(sep) {
+				t.Errorf("Has(%d)=false, want true", sep)
+			}
+			if got := len(ss.Elements()); got != 1 {
+				t.Errorf("len(Elements())=%d, want 1", got)
+			}
+		}
+	})
+
+	t.Run("Add_Has_Other", func(t *testing.T) {
+		for sep := uint16(0); sep < maxSep; sep++ {
+			ss := ssc(nil)
+			ss.Add(sep)
+			if ss.Has(sep + 1) {
+				t.Errorf("Has(%d)=true, want false", sep+1)
+			}
+		}
+	})
+
+	t.Run("NextAbove", func(t *testing.T) {
+		ss := ssc(nil)
+		elements := []uint16{0, 5, 63, 64, 200, maxSep}
+		for _, sep := range elements {
+			ss.Add(sep)
+		}
+		for sep := uint16(0); sep <= maxSep; sep++ {
+			want, wantOk := uint16(0), false
+			for _, e := range elements {
+				if e > sep {
+					want, wantOk = e, true
+					break
+				}
+			}
+			if got, ok := ss.NextAbove(sep); got != want || ok != wantOk {
+				t.Errorf("NextAbove(%d)=%d, %v, want %d, %v", sep, got, ok, want, wantOk)
+			}
+		}
+	})
+
+	t.Run("Add_Copy_Has_Elements", func(t *testing.T) {
+		sep := uint16(4)
+		ss1 := ssc(nil)
+		ss1.Add(sep)
+		ss2 := ss1.Copy()
+		if !ss2.Has(sep) {
+			t.Errorf("Has(%d)=false, want true", sep)
+		}
+		if got := len(ss2.Elements()); got != 1 {
+			t.Errorf("len(Elements())=%d, want 1", got)
+		}
+	})
+
+	t.Run("Copy_Add_Has_Elements", func(t *testing.T) {
+		sep := uint16(4)
+		ss1 := ssc(nil)
+		ss2 := ss1.Copy()
+		ss2.Add(sep)
+		if got := len(ss1.Elements()); got != 0 {
+			t.Errorf("len(Elements())=%d, want 0", got)
+		}
+	})
+
+	t.Run("Constructor", func(t *testing.T) {
+		ss := ssc(grid.Placements{ // Separation matrix for points noted in comments
+			grid.Point{Row: 0, Col: 0}, //  0  25  25   5
+			grid.Point{Row: 3, Col: 4}, // 25   0  10   8
+			grid.Point{Row: 0, Col: 5}, // 25  10   0  10
+			grid.Point{Row: 1, Col: 2}, //  5   8  10   0
+		})
+		want := []uint16{5, 8, 10, 25}
+		if got := ss.Elements(); !cmp.Equal(got, want, cmpopts.SortSlices(func(a, b uint16) bool { return a < b })) {
+			t.Errorf("Elements()=%v, want %v", got, want)
+		}
+	})
+
+	t.Run("Add_Clone_Elements", func(t *testing.T) {
+		// Add two different separations to each set, then make the second set a clone of the first
+		sep1 := uint16(4)
+		sep2 := uint16(6)
+		ss1 := ssc(nil)
+		ss1.Add(sep1)
+		ss2 := ssc(nil)
+		ss2.Add(sep2)
+		ss2.Clone(ss1)
+		if diff := cmp.Diff(ss1.Elements(), ss2.Elements()); diff != "" {
+			t.Errorf("Clone().Elements() had diff %s", diff)
+		}
+	})
+
+	t.Run("Clone_Add_Has", func(t *testing.T) {
+		// Make the second set a clone of the first, then add a value to it
+		sep := uint16(4)
+		ss1 := ssc(nil)
+		ss2 := ssc(nil)
+		ss2.Clone(ss1)
+		ss2.Add(sep)
+		if ss1.Has(sep) {
+			t.Errorf("Has(%d)=true, want false", sep)
+		}
+	})
+
+	t.Run("Add_Clear_Has", func(t *testing.T) {
+		ss := ssc(nil)
+		ss.Add(maxSep)
+		ss.Clear()
+		if got := len(ss.Elements()); got != 0 {
+			t.Errorf("len(Clear().Elements())=%d, want 0", got)
+		}
+	})
+
+	t.Run("Union_Elements", func(t *testing.T) {
+		ss1 := ssc(nil)
+		ss1.Add(1)
+		ss1.Add(4)
+		ss2 := ssc(nil)
+		ss2.Add(4)
+		ss2.Add(9)
+		ss2.Union(ss1)
+		want := []uint16{1, 4, 9}
+		if diff := cmp.Diff(ss2.Elements(), want, cmpopts.SortSlices(func(a, b uint16) bool { return a < b })); diff != "" {
+			t.Errorf("Union().Elements() had diff %s", diff)
+		}
+	})
+
+	t.Run("AppendElements", func(t *testing.T) {
+		ss := ssc(grid.Placements{grid.Point{Row: 0, Col: 0}, grid.Point{Row: 0, Col: 1}, grid.Point{Row: 0, Col: 3}})
+		buf := make([]uint16, 1, 8)
+		got := ss.AppendElements(buf)
+		want := []uint16{0, 1, 4, 9}
+		if diff := cmp.Diff(got, want, cmpopts.SortSlices(func(a, b uint16) bool { return a < b })); diff != "" {
+			t.Errorf("AppendElements() had diff %s", diff)
+		}
+		if &got[0] != &buf[0] {
+			t.Errorf("AppendElements() did not reuse buffer with sufficient capacity")
+		}
+	})
+
+	t.Run("Iter_Empty", func(t *testing.T) {
+		ss := ssc(nil)
+		got := make([]uint16, 0)
+		it := sets.NewSeparationSetIterator(ss)
+		for sep, ok := it.Next(); ok; sep, ok = it.Next() {
+			got = append(got, sep)
+		}
+		want := []uint16{}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("Iter() had diff: %s", diff)
+		}
+	})
+
+	t.Run("Iter_Nonempty", func(t *testing.T) {
+		ss := ssc(grid.Placements{grid.Point{Row: 0, Col: 0}, grid.Point{Row: 0, Col: 1}, grid.Point{Row: 0, Col: 3}})
+		got := make([]uint16, 0)
+		it := sets.NewSeparationSetIterator(ss)
+		for sep, ok := it.Next(); ok; sep, ok = it.Next() {
+			got = append(got, sep)
+		}
+		want := []uint16{1, 4, 9}
+		if diff := cmp.Diff(got, want, cmpopts.SortSlices(func(a, b uint16) bool { return a < b })); diff != "" {
+			t.Errorf("Iter() had diff: %s", diff)
+		}
+	})
+
+	t.Run("IterForGrid_Nonempty", func(t *testing.T) {
+		ss := ssc(grid.Placements{grid.Point{Row: 0, Col: 0}, grid.Point{Row: 2, Col: 2}, grid.Point{Row: 3, Col: 3}})
+		got := make([]uint16, 0)
+		it := sets.NewSeparationSetIteratorForGrid(ss, grid.Grid{Size: 3})
+		for sep, ok := it.Next(); ok; sep, ok = it.Next() {
+			got = append(got, sep)
+		}
+		want := []uint16{2, 8} // 18 is an invalid separation on a size 3 grid
+		if diff := cmp.Diff(got, want, cmpopts.SortSlices(func(a, b uint16) bool { return a < b })); diff != "" {
+			t.Errorf("Iter() had diff: %s", diff)
+		}
+	})
+}
+
+// PointSet runs the conformance suite against the PointSets that psc constructs, as subtests of t. The points used fit in an 8x8 grid.
+func PointSet(t *testing.T, psc sets.PointSetConstructor) {
+	// Arbitrary grid point values.
+	point1 := grid.Point{Row: 1, Col: 2}
+	point2 := grid.Point{Row: 3, Col: 4}
+	point3 := grid.Point{Row: 5, Col: 6}
+
+	t.Run("Empty Has", func(t *testing.T) {
+		ps := psc(nil)
+		if ps.Has(point1) {
+			t.Errorf("Has(%s)=true, want false", point1)
+		}
+	})
+
+	t.Run("Add Has", func(t *testing.T) {
+		ps := psc(nil)
+		ps.Add(point1)
+		if !ps.Has(point1) {
+			t.Errorf("Has(%s)=false, want true", point1)
+		}
+	})
+
+	t.Run("Add Has Other", func(t *testing.T) {
+		ps := psc(nil)
+		ps.Add(point1)
+		if ps.Has(point2) {
+			t.Errorf("Has(%s)=true, want false", point2)
+		}
+	})
+
+	t.Run("Add Copy Has", func(t *testing.T) {
+		ps1 := psc(nil)
+		ps1.Add(point1)
+		ps2 := ps1.Copy()
+		if !ps2.Has(point1) {
+			t.Errorf("Has(%s)=false, want true", point1)
+		}
+	})
+
+	t.Run("Add Copy Has Other", func(t *testing.T) {
+		ps1 := psc(nil)
+		ps1.Add(point1)
+		ps2 := ps1.Copy()
+		if ps2.Has(point2) {
+			t.Errorf("Has(%s)=true, want false", point2)
+		}
+	})
+
+	t.Run("Copy Add Has", func(t *testing.T) {
+		ps1 := psc(nil)
+		ps2 := ps1.Copy()
+		ps2.Add(point1)
+		if ps1.Has(point1) {
+			t.Errorf("Has(%s)=true, want false", point1)
+		}
+	})
+
+	t.Run("Elements", func(t *testing.T) {
+		tests := []struct {
+			name string
+			arg  grid.Placements
+			want grid.Placements
+		}{
+			{
+				name: "nil",
+				arg:  nil,
+				want: grid.Placements{},
+			},
+			{
+				name: "empty",
+				arg:  grid.Placements{},
+				want: grid.Placements{},
+			},
+			{
+				name: "nonempty",
+				arg:  grid.Placements{point1, point2},
+				want: grid.Placements{point1, point2},
+			},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				ps := psc(tt.arg)
+				if got := ps.Elements(); !cmp.Equal(got, tt.want, cmpopts.SortSlices(grid.LessThan)) {
+					t.Errorf("New(%v).Elements() = %v, want %v", tt.arg, got, tt.want)
+				}
+			})
+		}
+	})
+	t.Run("Add_Clone_Elements", func(t *testing.T) {
+		// Add two different points to each set, then make the second set a clone of the first
+		ps1 := psc(nil)
+		ps1.Add(point1)
+		ps2 := psc(nil)
+		ps2.Add(point2)
+		ps2.Clone(ps1)
+		if diff := cmp.Diff(ps1.Elements(), ps2.Elements()); diff != "" {
+			t.Errorf("Clone().Elements() had diff %s", diff)
+		}
+	})
+
+	t.Run("Clone_Add_Has", func(t *testing.T) {
+		// Make the second set a clone of the first, then add a value to it
+		ps1 := psc(nil)
+		ps2 := psc(nil)
+		ps2.Clone(ps1)
+		ps2.Add(point1)
+		if ps1.Has(point1) {
+			t.Errorf("Has(%s)=true, want false", point1)
+		}
+	})
+
+	t.Run("Union_Elements", func(t *testing.T) {
+		// Add two different points to each set, then make the second set a clone of the first
+		ps1 := psc(grid.Placements{point1, point2})
+		ps2 := psc(grid.Placements{point1, point3})
+		ps2.Union(ps1)
+		want := grid.Placements{point1, point2, point3}
+		if diff := cmp.Diff(ps2.Elements(), want, cmpopts.SortSlices(grid.LessThan)); diff != "" {
+			t.Errorf("Union().Elements() had diff %s", diff)
+		}
+	})
+
+	t.Run("AppendElements", func(t *testing.T) {
+		ps := psc(grid.Placements{point2, point3})
+		buf := append(make(grid.Placements, 0, 8), point1)
+		got := ps.AppendElements(buf)
+		want := grid.Placements{point1, point2, point3}
+		if diff := cmp.Diff(got, want, cmpopts.SortSlices(grid.LessThan)); diff != "" {
+			t.Errorf("AppendElements() had diff %s", diff)
+		}
+		if &got[0] != &buf[0] {
+			t.Errorf("AppendElements() did not reuse buffer with sufficient capacity")
+		}
+	})
+
+	t.Run("Iter_Reset", func(t *testing.T) {
+		ps := psc(grid.Placements{point1, point2, point3})
+		it := ps.Iter()
+		var first grid.Placements
+		for p, ok := it.Next(); ok; p, ok = it.Next() {
+			first = append(first, p)
+		}
+		it.Reset()
+		var second grid.Placements
+		for p, ok := it.Next(); ok; p, ok = it.Next() {
+			second = append(second, p)
+		}
+		if diff := cmp.Diff(first, grid.Placements{point1, point2, point3}, cmpopts.SortSlices(grid.LessThan)); diff != "" {
+			t.Errorf("Iter() had diff %s", diff)
+		}
+		if diff := cmp.Diff(second, first); diff != "" {
+			t.Errorf("Iter() after Reset() had diff %s", diff)
+		}
+	})
+
+	t.Run("IterOrder", func(t *testing.T) {
+		ps := psc(grid.Placements{point3, point1, point2, {Row: 1, Col: 0}, {Row: 7, Col: 7}})
+		forward := grid.Placements{{Row: 1, Col: 0}, point1, point2, point3, {Row: 7, Col: 7}}
+		reverse := grid.Placements{{Row: 7, Col: 7}, point3, point2, point1, {Row: 1, Col: 0}}
+		for _, tt := range []struct {
+			order sets.Order
+			want  grid.Placements
+		}{{sets.Forward, forward}, {sets.Reverse, reverse}} {
+			it := ps.IterOrder(tt.order)
+			for i := 0; i < 2; i++ {
+				var got grid.Placements
+				for p, ok := it.Next(); ok; p, ok = it.Next() {
+					got = append(got, p)
+				}
+				if diff := cmp.Diff(got, tt.want); diff != "" {
+					t.Errorf("IterOrder(%d) had diff %s", tt.order, diff)
+				}
+				it.Reset()
+			}
+		}
+	})
+
+	t.Run("Clear_Elements", func(t *testing.T) {
+		ps := psc(grid.Placements{point1, point2})
+		ps.Clear()
+		if got := len(ps.Elements()); got != 0 {
+			t.Errorf("len(Clear().Elements())=%d, want 0", got)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// shard implements the shard subcommand, which searches one of the shards that the starting points are split into, and writes its result
+// as JSON for merge. It returns the exit code for the outcome of the search.
+func shard(args []string) int {
+	fs := flag.NewFlagSet("shard", flag.ExitOnError)
+	sf := addSearchFlags(fs, false)
+	shards := fs.Int("shards", 1, "number of shards to split the search into")
+	index := fs.Int("index", 0, "index of the shard to search, from 0 to shards-1")
+	depth := fs.Int("shard_depth", 0, "shard the valid placements with this many stones instead of the starting points")
+	outFile := fs.String("out", "", "file to write the shard result to, instead of stdout")
+	parseFlags(fs, fs.Name(), args)
+	if *index < 0 || *index >= *shards {
+		fatalf("Shard index %d is out of range for %d shards.", *index, *shards)
+	}
+
+	r := sf.start()
+	defer r.close()
+	if *depth > 0 {
+		r.spp = solver.PrefixStartingPoints(r.spp, r.spc, *depth)
+	}
+	r.spp = solver.Shard(r.spp, *shards, *index)
+	solution, duration, err := r.search()
+	result := solver.ShardResult{
+		Size:           r.g.Size,
+		Shards:         *shards,
+		Index:          *index,
+		Depth:          *depth,
+		StartingPoints: len(r.spp(r.g)),
+		Exhausted:      errors.Is(err, solver.ErrNoSolution),
+		Nodes:          r.stats.Nodes.Load(),
+		Duration:       duration.String(),
+	}
+	if err == nil {
+		solution.Sort()
+		result.Solution = solution
+	}
+	if err := writeShardResult(*outFile, result); err != nil {
+		fatal(err)
+	}
+	return exitCode(err)
+}
+
+// writeShardResult writes a shard result as JSON to the named file, or stdout if filename is empty.
+func writeShardResult(filename string, result solver.ShardResult) error {
+	out := os.Stdout
+	if filename != "" {
+		f, err := os.Create(filename)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+// mergeShards implements the merge subcommand, which reads the shard result files named in args and prints the combined verdict.
+// It exits with exitNoSolution if every shard was exhausted, and exitStopped if the verdict is incomplete.
+func mergeShards(args []string) {
+	if len(args) == 0 {
+		fatal("Usage: pegboard merge shard_result.json...")
+	}
+	var results []solver.ShardResult
+	for _, filename := range args {
+		b, err := os.ReadFile(filename)
+		if err != nil {
+			fatal(err)
+		}
+		var r solver.ShardResult
+		if err := json.Unmarshal(b, &r); err != nil {
+			fatalf("%s: %v", filename, err)
+		}
+		results = append(results, r)
+	}
+
+	merged, err := solver.MergeShards(results)
+	if err != nil {
+		fatal(err)
+	}
+	size := results[0].Size
+	switch {
+	case merged.Solution != nil:
+		fmt.Printf("Solution found for %dx%d grid: %v\n", size, size, merged.Solution)
+	case merged.NoSolution:
+		fmt.Printf("All %d shards were exhausted: no solution exists for %dx%d grid\n", results[0].Shards, size, size)
+		os.Exit(exitNoSolution)
+	default:
+		fmt.Printf("No solution found yet for %dx%d grid. Shards without a finished result: %v\n", size, size, merged.Missing)
+		os.Exit(exitStopped)
+	}
+}
@@ -0,0 +1,387 @@
+package solver
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/pruner"
+	"github.com/WillMorrison/pegboard-blog/sets"
+)
+
+// Builder constructs a Solver from the names of its strategies in the registries, such as placer.Placers and Solvers, and checks that
+// the strategies and options can be used together.
+// The zero value is not ready to use; start with NewBuilder, whose defaults are the same as the command line's.
+// Setters return the builder so that calls can be chained, and errors are reported by the Build methods.
+type Builder struct {
+	grid           *grid.Grid
+	placer         string
+	pruner         string
+	separationSet  string
+	startingPoints string
+	spp            StartingPointsProvider
+	prefix         grid.Placements
+	solver         string
+	bound, forced  bool
+	splitDepth     int
+	timeSlice      time.Duration
+	workers        int
+	seed           int64
+	stats          *Stats
+	pruneCounts    *pruner.Counts
+	workerInit     func(worker int)
+	logger         *slog.Logger
+	events         *EventBus
+	pool           *Pool
+
+	// set records the options that were set explicitly, which must be used by the chosen strategies
+	set map[string]bool
+}
+
+// NewBuilder returns a Builder with the default strategies.
+func NewBuilder() *Builder {
+	return &Builder{
+		placer:         placer.OrderedNoAllocStonePlacerName,
+		pruner:         pruner.PrecomputedPrunerName,
+		separationSet:  sets.BitArraySeparationSetName,
+		startingPoints: SingleOctantStartingPointsName,
+		solver:         AsyncSolverName,
+		splitDepth:     3,
+		set:            make(map[string]bool),
+	}
+}
+
+// Grid sets the grid that the solver will be used on, so that strategies that only support some grid sizes can be checked.
+func (b *Builder) Grid(g grid.Grid) *Builder {
+	b.grid = &g
+	return b
+}
+
+// Placer sets the name of the StonePlacer implementation, or AutoName to choose the fastest one for the grid with the bound enabled.
+func (b *Builder) Placer(name string) *Builder {
+	b.placer = name
+	return b
+}
+
+// Pruner sets the name of the Pruner implementation, which only the pruning placers use.
+func (b *Builder) Pruner(name string) *Builder {
+	b.pruner = name
+	b.set["pruner"] = true
+	return b
+}
+
+// SeparationSet sets the name of the SeparationSet implementation, which only the unordered and ordered placers use.
+func (b *Builder) SeparationSet(name string) *Builder {
+	b.separationSet = name
+	b.set["separation set"] = true
+	return b
+}
+
+// StartingPoints sets the name of the starting points to search from.
+func (b *Builder) StartingPoints(name string) *Builder {
+	b.startingPoints = name
+	b.spp = nil
+	return b
+}
+
+// StartingPointsProvider sets the starting points to search from, overriding StartingPoints.
+// Use it to search from starting points derived from the named ones, such as a shard of them.
+func (b *Builder) StartingPointsProvider(spp StartingPointsProvider) *Builder {
+	b.spp = spp
+	return b
+}
+
+// StartFrom sets a partial placement to search the completions of, overriding the starting points. Ordered placers only place stones
+// after the prefix's last one, so with them the completions searched are those whose smallest stones are the prefix. Build returns an
+// error if the prefix breaks a constraint or the placer would prune it.
+func (b *Builder) StartFrom(prefix grid.Placements) *Builder {
+	b.prefix = slices.Clone(prefix)
+	b.prefix.Sort()
+	return b
+}
+
+// Solver sets the name of the Solver implementation, or AutoName to choose the fastest one for the grid and the number of workers.
+func (b *Builder) Solver(name string) *Builder {
+	b.solver = name
+	return b
+}
+
+// Bound enables cutting branches which the row and column bound shows cannot be completed. Only the pruning placers support it.
+func (b *Builder) Bound(bound bool) *Builder {
+	b.bound = bound
+	b.set["bound"] = bound
+	return b
+}
+
+// Forced enables placing stones which the row and column bound shows every completion needs.
+// Only the ordered_noalloc_pruning and ordered_noalloc_opportunistic_pruning placers support it.
+func (b *Builder) Forced(forced bool) *Builder {
+	b.forced = forced
+	b.set["forced"] = forced
+	return b
+}
+
+// SplitDepth sets the number of stones in each task's prefix for the fixed_depth solver.
+func (b *Builder) SplitDepth(depth int) *Builder {
+	b.splitDepth = depth
+	b.set["split depth"] = true
+	return b
+}
+
+// TimeSlice sets the time the time_sliced solver spends below each starting point in turn.
+func (b *Builder) TimeSlice(slice time.Duration) *Builder {
+	b.timeSlice = slice
+	b.set["time slice"] = true
+	return b
+}
+
+// Workers sets the number of worker goroutines for the async_splitting and fixed_depth solvers. The default is one per CPU that Go can use.
+func (b *Builder) Workers(n int) *Builder {
+	b.workers = n
+	b.set["workers"] = true
+	return b
+}
+
+// Seed sets the seed that shuffles the order the named starting points are searched in, and the order that the ordered_shuffled placer
+// tries candidates in. Only that placer supports it.
+func (b *Builder) Seed(seed int64) *Builder {
+	b.seed = seed
+	b.set["seed"] = true
+	return b
+}
+
+// ShuffleSeed returns the seed set with Seed, and whether one was set, so that a run that shuffled can be reproduced.
+func (b *Builder) ShuffleSeed() (seed int64, ok bool) {
+	return b.seed, b.set["seed"]
+}
+
+// Stats sets the statistics that the solver collects while it searches.
+func (b *Builder) Stats(stats *Stats) *Builder {
+	b.stats = stats
+	return b
+}
+
+// PruneCounts sets where the pruning placers count the points their pruner prunes by each rule. Placers that don't use a pruner
+// ignore it.
+func (b *Builder) PruneCounts(counts *pruner.Counts) *Builder {
+	b.pruneCounts = counts
+	return b
+}
+
+// WorkerInit sets a function called at the start of each worker goroutine of the parallel solvers.
+func (b *Builder) WorkerInit(f func(worker int)) *Builder {
+	b.workerInit = f
+	return b
+}
+
+// Logger sets the logger that the solver writes diagnostics to. By default they are discarded.
+func (b *Builder) Logger(l *slog.Logger) *Builder {
+	b.logger = l
+	return b
+}
+
+// Events sets the bus that the solver publishes its events to.
+func (b *Builder) Events(bus *EventBus) *Builder {
+	b.events = bus
+	return b
+}
+
+// Pool sets the pool of workers that the solver shares with other searches. By default it uses as many as it starts.
+func (b *Builder) Pool(p *Pool) *Builder {
+	b.pool = p
+	return b
+}
+
+// UsesPruner returns whether the placer uses the pruner.
+func (b *Builder) UsesPruner() bool {
+	return placer.Placers[b.PlacerName()].UsesPruner
+}
+
+// PrunerName returns the name of the pruner that the pruning placers use.
+func (b *Builder) PrunerName() string {
+	return b.pruner
+}
+
+// PlacerName returns the name of the placer to build, resolving AutoName to the fastest placer for the grid.
+// Ordered placers place every set of stones once, and the bitboard placer is fastest on the grids it supports.
+func (b *Builder) PlacerName() string {
+	if b.placer != AutoName || b.grid == nil {
+		return b.placer
+	}
+	if b.grid.Size <= sets.BitboardMaxGridSize {
+		return placer.OrderedBitboardStonePlacerName
+	}
+	return placer.OrderedNoAllocPruningStonePlacerName
+}
+
+// SolverName returns the name of the solver to build, resolving AutoName to the fastest solver for the grid and the workers
+// available. Small grids are searched in less time than it takes to start workers, and one worker gains nothing from splitting.
+func (b *Builder) SolverName() string {
+	if b.solver != AutoName || b.grid == nil {
+		return b.solver
+	}
+	workers := b.workers
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if b.grid.Size <= sets.BitboardMaxGridSize || workers == 1 {
+		return SingleThreadedSolverName
+	}
+	return AsyncSplittingSolverName
+}
+
+// unused returns an error if an option was set explicitly but the chosen strategy doesn't use it, which is likely a mistake.
+func (b *Builder) unused(option, strategy string) error {
+	if b.set[option] {
+		return fmt.Errorf("the %s does not support setting the %s", strategy, option)
+	}
+	return nil
+}
+
+// BuildPlacer returns the StonePlacerConstructor, or an error if the options are invalid or can't be used with the placer.
+func (b *Builder) BuildPlacer() (placer.StonePlacerConstructor, error) {
+	separationSetConstructor, ok := sets.SeparationSets[b.separationSet]
+	if !ok {
+		return nil, fmt.Errorf("unknown separation set %q", b.separationSet)
+	}
+	prunerConstructor, ok := pruner.Pruners[b.pruner]
+	if !ok {
+		return nil, fmt.Errorf("unknown pruner %q", b.pruner)
+	}
+	if b.placer == AutoName && b.grid == nil {
+		return nil, errors.New("the auto placer needs the grid size")
+	}
+	name := b.PlacerName()
+	reg, ok := placer.Placers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown placer %q", name)
+	}
+
+	placerName := name + " placer"
+	var errs []error
+	if !reg.UsesSeparationSet {
+		errs = append(errs, b.unused("separation set", placerName))
+	}
+	if !reg.UsesPruner {
+		errs = append(errs, b.unused("pruner", placerName))
+	}
+	if !reg.SupportsBound {
+		errs = append(errs, b.unused("bound", placerName))
+	}
+	if !reg.SupportsForced {
+		errs = append(errs, b.unused("forced", placerName))
+	}
+	if !reg.SupportsSeed {
+		errs = append(errs, b.unused("seed", placerName))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if reg.MaxGridSize > 0 && b.grid != nil && b.grid.Size > reg.MaxGridSize {
+		return nil, fmt.Errorf("the %s only supports grids up to %dx%d", placerName, reg.MaxGridSize, reg.MaxGridSize)
+	}
+
+	if b.pruneCounts != nil {
+		prunerConstructor = pruner.Counting(prunerConstructor, b.pruneCounts)
+	}
+	return reg.New(placer.Options{
+		SeparationSetConstructor: separationSetConstructor,
+		PrunerConstructor:        prunerConstructor,
+		// Both of the automatically chosen placers are faster with the bound
+		Bound:  b.bound || b.placer == AutoName,
+		Forced: b.forced,
+		Seed:   b.seed,
+	}), nil
+}
+
+// BuildStartingPoints returns the StartingPointsProvider, or an error if it is unknown. The named starting points are shuffled if a seed
+// was set.
+func (b *Builder) BuildStartingPoints() (StartingPointsProvider, error) {
+	if b.prefix != nil {
+		prefix := b.prefix
+		return func(grid.Grid) []grid.Placements { return []grid.Placements{slices.Clone(prefix)} }, nil
+	}
+	if b.spp != nil {
+		return b.spp, nil
+	}
+	spp, ok := StartingPoints[b.startingPoints]
+	if !ok {
+		return nil, fmt.Errorf("unknown starting points %q", b.startingPoints)
+	}
+	if b.set["seed"] {
+		spp = ShuffledStartingPoints(spp, b.seed)
+	}
+	return spp, nil
+}
+
+// Build returns the Solver, or an error if the options are invalid or can't be used together.
+func (b *Builder) Build() (Solver, error) {
+	spc, err := b.BuildPlacer()
+	if err != nil {
+		return nil, err
+	}
+	spp, err := b.BuildStartingPoints()
+	if err != nil {
+		return nil, err
+	}
+	if b.prefix != nil {
+		if b.grid == nil {
+			return nil, errors.New("starting from a partial placement needs the grid size")
+		}
+		// A prefix that the placer prunes is valid, and the solver searches the placer's empty subtree below it without finding a solution
+		if _, err := placer.NewFromPrefix(spc, *b.grid, b.prefix); err != nil && !errors.Is(err, placer.ErrCannotComplete) {
+			return nil, err
+		}
+	}
+	if b.solver == AutoName && b.grid == nil {
+		return nil, errors.New("the auto solver needs the grid size")
+	}
+	name := b.SolverName()
+	reg, ok := Solvers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown solver %q", name)
+	}
+
+	solverName := name + " solver"
+	var errs []error
+	// The auto solver uses the number of workers to choose a solver, so it isn't a mistake if the chosen one doesn't support them
+	if !reg.SupportsWorkers && b.solver != AutoName {
+		errs = append(errs, b.unused("workers", solverName))
+	}
+	if !reg.SupportsSplitDepth {
+		errs = append(errs, b.unused("split depth", solverName))
+	}
+	if !reg.SupportsTimeSlice {
+		errs = append(errs, b.unused("time slice", solverName))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	if b.workers < 0 {
+		return nil, fmt.Errorf("the number of workers must not be negative, got %d", b.workers)
+	}
+	if b.splitDepth < 0 {
+		return nil, fmt.Errorf("the split depth must not be negative, got %d", b.splitDepth)
+	}
+	if b.timeSlice < 0 {
+		return nil, fmt.Errorf("the time slice must not be negative, got %v", b.timeSlice)
+	}
+
+	return reg.New(Options{
+		StartingPointsProvider: spp,
+		StonePlacerConstructor: spc,
+		Stats:                  b.stats,
+		WorkerInit:             b.workerInit,
+		Workers:                b.workers,
+		SplitDepth:             b.splitDepth,
+		TimeSlice:              b.timeSlice,
+		Logger:                 b.logger,
+		Events:                 b.events,
+		Pool:                   b.pool,
+	}), nil
+}
@@ -0,0 +1,241 @@
+package solver
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/pruner"
+	"github.com/WillMorrison/pegboard-blog/sets"
+)
+
+func TestBuilder_Build(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *Builder
+		wantErr bool
+	}{
+		{name: "defaults", builder: NewBuilder()},
+		{name: "pruning placer with options", builder: NewBuilder().Placer(placer.OrderedNoAllocPruningStonePlacerName).Pruner(pruner.RuntimePrunerName).Bound(true).Forced(true)},
+		{name: "ordered placer with separation set", builder: NewBuilder().Placer(placer.OrderedStonePlacerName).SeparationSet(sets.MapSeparationSetName)},
+		{name: "bitboard placer with bound", builder: NewBuilder().Grid(grid.Grid{Size: 8}).Placer(placer.OrderedBitboardStonePlacerName).Bound(true)},
+		{name: "fixed depth solver with workers", builder: NewBuilder().Solver(FixedDepthSolverName).SplitDepth(2).Workers(2)},
+		{name: "bitboard placer on large grid", builder: NewBuilder().Grid(grid.Grid{Size: 9}).Placer(placer.OrderedBitboardStonePlacerName), wantErr: true},
+		{name: "bound without pruning", builder: NewBuilder().Placer(placer.OrderedNoAllocStonePlacerName).Bound(true), wantErr: true},
+		{name: "forced with impact placer", builder: NewBuilder().Placer(placer.ImpactOrderedPruningStonePlacerName).Forced(true), wantErr: true},
+		{name: "pruner without pruning", builder: NewBuilder().Pruner(pruner.RuntimePrunerName), wantErr: true},
+		{name: "separation set with noalloc placer", builder: NewBuilder().SeparationSet(sets.MapSeparationSetName), wantErr: true},
+		{name: "workers with single thread solver", builder: NewBuilder().Solver(SingleThreadedSolverName).Workers(2), wantErr: true},
+		{name: "split depth with async splitting solver", builder: NewBuilder().Solver(AsyncSplittingSolverName).SplitDepth(2), wantErr: true},
+		{name: "negative workers", builder: NewBuilder().Solver(AsyncSplittingSolverName).Workers(-1), wantErr: true},
+		{name: "start from prefix", builder: NewBuilder().Grid(grid.Grid{Size: 7}).StartFrom(grid.Placements{{Row: 0, Col: 2}, {Row: 0, Col: 0}})},
+		{name: "start from invalid prefix", builder: NewBuilder().Grid(grid.Grid{Size: 7}).StartFrom(grid.Placements{{Row: 0, Col: 0}, {Row: 0, Col: 1}, {Row: 0, Col: 2}}), wantErr: true},
+		{name: "start from without grid", builder: NewBuilder().StartFrom(grid.Placements{{Row: 0, Col: 0}}), wantErr: true},
+		{name: "shuffled placer with seed", builder: NewBuilder().Placer(placer.ShuffledStonePlacerName).Seed(42)},
+		{name: "seed with unshuffled placer", builder: NewBuilder().Seed(42), wantErr: true},
+		{name: "unknown placer", builder: NewBuilder().Placer("random"), wantErr: true},
+		{name: "unknown pruner", builder: NewBuilder().Pruner("random"), wantErr: true},
+		{name: "unknown starting points", builder: NewBuilder().StartingPoints("random"), wantErr: true},
+		{name: "unknown solver", builder: NewBuilder().Solver("random"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := tt.builder.Build()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && s == nil {
+				t.Errorf("Build() returned a nil Solver")
+			}
+		})
+	}
+}
+
+func TestBuilder_BuildFixedDepth(t *testing.T) {
+	stats := &Stats{}
+	s, err := NewBuilder().Placer(placer.OrderedNoAllocPruningStonePlacerName).Solver(FixedDepthSolverName).SplitDepth(2).Workers(3).Stats(stats).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	fd, ok := s.(FixedDepthSplittingSolver)
+	if !ok {
+		t.Fatalf("Build() = %T, want FixedDepthSplittingSolver", s)
+	}
+	if fd.SplitDepth != 2 || fd.Workers != 3 || fd.Stats != stats {
+		t.Errorf("Build() = %+v, want SplitDepth 2, Workers 3 and the given Stats", fd)
+	}
+	if _, ok := fd.StonePlacerConstructor.(placer.OrderedPruningNoAllocStonePlacerProvider); !ok {
+		t.Errorf("Build() placer = %T, want OrderedPruningNoAllocStonePlacerProvider", fd.StonePlacerConstructor)
+	}
+	g := grid.Grid{Size: 6}
+	solution, err := s.Solve(g)
+	if err != nil {
+		t.Fatalf("Solve() error = %v", err)
+	}
+	if err := grid.CheckValidSolution(g, solution); err != nil {
+		t.Errorf("Solve() = %v, which is invalid: %v", solution, err)
+	}
+}
+
+func TestBuilder_StartFrom(t *testing.T) {
+	g := grid.Grid{Size: 7}
+	prefix := grid.Placements{{Row: 0, Col: 2}, {Row: 0, Col: 0}}
+	s, err := NewBuilder().Grid(g).StartFrom(prefix).Build()
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	solution, err := s.Solve(g)
+	if err != nil {
+		t.Fatalf("Solve() error = %v", err)
+	}
+	if err := grid.CheckValidSolution(g, solution); err != nil {
+		t.Errorf("Solve() = %v, which is invalid: %v", solution, err)
+	}
+	solution.Sort()
+	if !slices.Equal(solution[:2], grid.Placements{{Row: 0, Col: 0}, {Row: 0, Col: 2}}) {
+		t.Errorf("Solve() = %v, want a completion of %v", solution, prefix)
+	}
+}
+
+func TestBuilder_ShuffleSeed(t *testing.T) {
+	if _, ok := NewBuilder().ShuffleSeed(); ok {
+		t.Errorf("ShuffleSeed() without a seed set ok = true, want false")
+	}
+	// Zero is a seed like any other once set
+	if seed, ok := NewBuilder().Seed(0).ShuffleSeed(); !ok || seed != 0 {
+		t.Errorf("ShuffleSeed() after Seed(0) = %d, %t, want 0, true", seed, ok)
+	}
+}
+
+func TestBuilder_StartFromPrunedPrefix(t *testing.T) {
+	g := grid.Grid{Size: 7}
+	// A valid prefix, whose stones the pruning placer prunes with the completion bound
+	prefix := grid.Placements{{Row: 0, Col: 0}, {Row: 6, Col: 6}}
+	spc, err := NewBuilder().Placer(placer.OrderedNoAllocPruningStonePlacerName).Bound(true).BuildPlacer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := placer.NewFromPrefix(spc, g, prefix); !errors.Is(err, placer.ErrCannotComplete) {
+		t.Fatalf("NewFromPrefix(%v) error = %v, want the prefix pruned", prefix, err)
+	}
+	for _, solverName := range Names() {
+		t.Run(solverName, func(t *testing.T) {
+			s, err := NewBuilder().Grid(g).Placer(placer.OrderedNoAllocPruningStonePlacerName).Bound(true).Solver(solverName).StartFrom(prefix).Build()
+			if err != nil {
+				t.Fatalf("Build() error = %v", err)
+			}
+			if solution, err := s.Solve(g); !errors.Is(err, ErrNoSolution) {
+				t.Errorf("Solve() = %v, %v, want %v", solution, err, ErrNoSolution)
+			}
+		})
+	}
+}
+
+func TestBuilder_Registries(t *testing.T) {
+	g := grid.Grid{Size: 6}
+	for _, placerName := range placer.Names() {
+		for _, solverName := range Names() {
+			t.Run(placerName+"/"+solverName, func(t *testing.T) {
+				s, err := NewBuilder().Grid(g).Placer(placerName).Solver(solverName).Build()
+				if err != nil {
+					t.Fatalf("Build() error = %v", err)
+				}
+				solution, err := s.Solve(g)
+				if err != nil {
+					t.Fatalf("Solve() error = %v", err)
+				}
+				if err := grid.CheckValidSolution(g, solution); err != nil {
+					t.Errorf("Solve() = %v, which is invalid: %v", solution, err)
+				}
+			})
+		}
+	}
+}
+
+func TestRegister(t *testing.T) {
+	const name = "registered_for_test"
+	Register(name, Solvers[SingleThreadedSolverName])
+	RegisterStartingPoints(name, EmptyStartingPoint)
+	t.Cleanup(func() {
+		delete(Solvers, name)
+		delete(StartingPoints, name)
+	})
+
+	g := grid.Grid{Size: 5}
+	s, err := NewBuilder().Grid(g).Solver(name).StartingPoints(name).Build()
+	if err != nil {
+		t.Fatalf("Build() with registered solver and starting points error = %v", err)
+	}
+	solution, err := s.Solve(g)
+	if err != nil {
+		t.Fatalf("Solve() error = %v", err)
+	}
+	if err := grid.CheckValidSolution(g, solution); err != nil {
+		t.Errorf("Solve() = %v, which is invalid: %v", solution, err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		register func()
+	}{
+		{"duplicate solver", func() { Register(name, Solvers[SingleThreadedSolverName]) }},
+		{"auto solver", func() { Register(AutoName, Solvers[SingleThreadedSolverName]) }},
+		{"solver without New", func() { Register("other", Registration{}) }},
+		{"duplicate starting points", func() { RegisterStartingPoints(name, EmptyStartingPoint) }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("registering didn't panic")
+				}
+			}()
+			tt.register()
+		})
+	}
+}
+
+func TestBuilder_Auto(t *testing.T) {
+	tests := []struct {
+		name       string
+		builder    *Builder
+		wantPlacer string
+		wantSolver string
+		wantErr    bool
+	}{
+		{name: "small grid", builder: NewBuilder().Grid(grid.Grid{Size: 8}).Workers(4), wantPlacer: placer.OrderedBitboardStonePlacerName, wantSolver: SingleThreadedSolverName},
+		{name: "large grid", builder: NewBuilder().Grid(grid.Grid{Size: 11}).Workers(4), wantPlacer: placer.OrderedNoAllocPruningStonePlacerName, wantSolver: AsyncSplittingSolverName},
+		{name: "large grid with one worker", builder: NewBuilder().Grid(grid.Grid{Size: 11}).Workers(1), wantPlacer: placer.OrderedNoAllocPruningStonePlacerName, wantSolver: SingleThreadedSolverName},
+		{name: "no grid", builder: NewBuilder(), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := tt.builder.Placer(AutoName).Solver(AutoName)
+			_, err := b.Build()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := b.PlacerName(); got != tt.wantPlacer {
+				t.Errorf("PlacerName() = %q, want %q", got, tt.wantPlacer)
+			}
+			if got := b.SolverName(); got != tt.wantSolver {
+				t.Errorf("SolverName() = %q, want %q", got, tt.wantSolver)
+			}
+			spc, _ := b.BuildPlacer()
+			var bound bool
+			switch p := spc.(type) {
+			case placer.OrderedBitboardStonePlacerProvider:
+				bound = p.Bound
+			case placer.OrderedPruningNoAllocStonePlacerProvider:
+				bound = p.Bound
+			}
+			if !bound {
+				t.Errorf("BuildPlacer() = %+v, want the bound enabled", spc)
+			}
+		})
+	}
+}
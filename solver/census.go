@@ -0,0 +1,66 @@
+package solver
+
+import (
+	"context"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+// CensusEntry counts the solutions for one grid size.
+type CensusEntry struct {
+	Size      uint8 `json:"size"`
+	Solutions int   `json:"solutions"`
+	Classes   int   `json:"classes"`
+	// SymmetryTypes is the number of equivalence classes of each symmetry type that has any
+	SymmetryTypes map[grid.SymmetryType]int `json:"symmetry_types"`
+	// Canonical is the canonical solution of each equivalence class, in order
+	Canonical []grid.Placements `json:"canonical"`
+	// Complete is false if the enumeration was interrupted, in which case the counts are only lower bounds.
+	Complete bool   `json:"complete"`
+	Duration string `json:"duration"`
+}
+
+// Census is the number of solutions for each grid size from 1 up to some maximum.
+type Census struct {
+	Sizes          []CensusEntry `json:"sizes"`
+	TotalSolutions int           `json:"total_solutions"`
+	TotalClasses   int           `json:"total_classes"`
+}
+
+// TakeCensus enumerates and classifies every solution for each grid size from 1 to maxSize in turn.
+// spc must be an ordered placer, which places each set of stones once, and must support every size up to maxSize.
+// If the context is done, the census is returned up to and including the interrupted size, which is marked incomplete, with the context's error.
+func TakeCensus(ctx context.Context, maxSize uint8, spc placer.StonePlacerConstructor) (Census, error) {
+	var census Census
+	for size := uint8(1); size <= maxSize; size++ {
+		g := grid.Grid{Size: size}
+		var solutions []grid.Placements
+		startTime := time.Now()
+		err := Enumerate(ctx, g, EmptyStartingPoint, spc, nil, func(p grid.Placements) bool {
+			solutions = append(solutions, p)
+			return true
+		})
+		entry := CensusEntry{
+			Size:          size,
+			Solutions:     len(solutions),
+			SymmetryTypes: make(map[grid.SymmetryType]int),
+			Canonical:     []grid.Placements{},
+			Complete:      err == nil,
+			Duration:      time.Since(startTime).String(),
+		}
+		for _, c := range ClassifySolutions(g, solutions) {
+			entry.Classes++
+			entry.SymmetryTypes[c.Symmetry]++
+			entry.Canonical = append(entry.Canonical, c.Canonical)
+		}
+		census.Sizes = append(census.Sizes, entry)
+		census.TotalSolutions += entry.Solutions
+		census.TotalClasses += entry.Classes
+		if err != nil {
+			return census, err
+		}
+	}
+	return census, nil
+}
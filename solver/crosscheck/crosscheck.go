@@ -0,0 +1,149 @@
+// Package crosscheck runs several solver configurations on the same grids and reports where they disagree. Pruning rules, bounds and
+// symmetry breaking can silently skip solutions, so a new strategy should agree with a trusted one on every small grid before it is
+// trusted with a long search.
+package crosscheck
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// Config is a solver configuration to check.
+type Config struct {
+	Name string
+	// Builder returns a new Builder with the configuration's strategies. The grid is set by Run.
+	Builder func() *solver.Builder
+}
+
+// Options controls which grids Run checks, and how.
+type Options struct {
+	// MinSize and MaxSize are the smallest and largest grid sizes to check. MinSize defaults to 1.
+	MinSize, MaxSize uint8
+	// Count enumerates every solution with each configuration's placer and starting points, and compares the number of solutions up
+	// to rotation and reflection, as well as whether a solution exists.
+	Count bool
+}
+
+// Result is the outcome of one configuration on one grid.
+type Result struct {
+	Config   string
+	Grid     grid.Grid
+	Solvable bool
+	// Solution is the solution found, if any
+	Solution grid.Placements
+	// Classes is the number of solutions up to rotation and reflection, if Options.Count was set
+	Classes  int
+	Duration time.Duration
+}
+
+// Disagreement is an error reporting that configurations disagreed on a grid.
+type Disagreement struct {
+	Grid    grid.Grid
+	Results []Result
+	counted bool
+}
+
+func (d *Disagreement) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "configurations disagree on a %dx%d grid:", d.Grid.Size, d.Grid.Size)
+	for _, r := range d.Results {
+		fmt.Fprintf(&b, " %s solvable=%t", r.Config, r.Solvable)
+		if d.counted {
+			fmt.Fprintf(&b, " classes=%d", r.Classes)
+		}
+		b.WriteString(";")
+	}
+	return strings.TrimSuffix(b.String(), ";")
+}
+
+// Run runs every configuration on every grid size in the options, and returns the results in order of grid size then configuration.
+// The error joins a *Disagreement for each grid the configurations disagree on, or reports the first configuration that failed to
+// build, returned an invalid solution or was stopped by the context.
+func Run(ctx context.Context, configs []Config, opts Options) ([]Result, error) {
+	if len(configs) < 2 {
+		return nil, errors.New("crosscheck needs at least two configurations")
+	}
+	minSize := max(opts.MinSize, 1)
+	var results []Result
+	var disagreements []error
+	for size := minSize; size <= opts.MaxSize; size++ {
+		g := grid.Grid{Size: size}
+		sizeResults := make([]Result, len(configs))
+		for i, c := range configs {
+			r, err := run(ctx, c, g, opts.Count)
+			if err != nil {
+				return results, fmt.Errorf("%s on a %dx%d grid: %w", c.Name, size, size, err)
+			}
+			sizeResults[i] = r
+		}
+		for _, r := range sizeResults[1:] {
+			if r.Solvable != sizeResults[0].Solvable || r.Classes != sizeResults[0].Classes {
+				disagreements = append(disagreements, &Disagreement{Grid: g, Results: sizeResults, counted: opts.Count})
+				break
+			}
+		}
+		results = append(results, sizeResults...)
+	}
+	return results, errors.Join(disagreements...)
+}
+
+// run runs one configuration on one grid.
+func run(ctx context.Context, c Config, g grid.Grid, count bool) (r Result, err error) {
+	r = Result{Config: c.Name, Grid: g}
+	start := time.Now()
+	defer func() { r.Duration = time.Since(start) }()
+
+	b := c.Builder().Grid(g)
+	s, err := b.Build()
+	if err != nil {
+		return r, err
+	}
+	solution, err := s.SolveContext(ctx, g)
+	switch {
+	case err == nil:
+		if err := grid.CheckValidSolution(g, solution); err != nil {
+			return r, fmt.Errorf("invalid solution %v: %w", solution, err)
+		}
+		solution.Sort()
+		r.Solvable, r.Solution = true, solution
+	case errors.Is(err, solver.ErrNoSolution):
+	default:
+		return r, err
+	}
+	if !count {
+		return r, nil
+	}
+
+	spc, err := b.BuildPlacer()
+	if err != nil {
+		return r, err
+	}
+	spp, err := b.BuildStartingPoints()
+	if err != nil {
+		return r, err
+	}
+	var solutions []grid.Placements
+	var invalid error
+	err = solver.Enumerate(ctx, g, spp, spc, nil, func(p grid.Placements) bool {
+		if invalid = grid.CheckValidSolution(g, p); invalid != nil {
+			invalid = fmt.Errorf("invalid solution %v: %w", p, invalid)
+			return false
+		}
+		solutions = append(solutions, p)
+		return true
+	})
+	if err := errors.Join(invalid, err); err != nil {
+		return r, err
+	}
+	r.Classes = len(solver.ClassifySolutions(g, solutions))
+	if (r.Classes > 0) != r.Solvable {
+		return r, fmt.Errorf("the solver found a solution: %t, but enumerating found %d", r.Solvable, len(solutions))
+	}
+	return r, nil
+}
@@ -0,0 +1,72 @@
+package crosscheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+func TestRun_Agree(t *testing.T) {
+	configs := []Config{
+		{"ordered", func() *solver.Builder {
+			return solver.NewBuilder().Placer(placer.OrderedNoAllocStonePlacerName).Solver(solver.SingleThreadedSolverName)
+		}},
+		{"pruning_bound_forced", func() *solver.Builder {
+			return solver.NewBuilder().Placer(placer.OrderedNoAllocPruningStonePlacerName).Bound(true).Forced(true).Solver(solver.SingleThreadedSolverName)
+		}},
+		{"bidirectional", func() *solver.Builder {
+			return solver.NewBuilder().Placer(placer.BidirectionalStonePlacerName).StartingPoints(solver.BidirectionalStartingPointsName).Solver(solver.SingleThreadedSolverName)
+		}},
+		{"auto", func() *solver.Builder { return solver.NewBuilder().Placer(solver.AutoName).Solver(solver.AutoName) }},
+	}
+	for _, count := range []bool{false, true} {
+		results, err := Run(context.Background(), configs, Options{MaxSize: 7, Count: count})
+		if err != nil {
+			t.Fatalf("Run(Count: %t) error = %v", count, err)
+		}
+		if want := 7 * len(configs); len(results) != want {
+			t.Errorf("Run(Count: %t) returned %d results, want %d", count, len(results), want)
+		}
+		for _, r := range results {
+			// Every grid up to 7x7 has a solution
+			if !r.Solvable {
+				t.Errorf("%s on %+v: Solvable = false, want true", r.Config, r.Grid)
+			}
+			if count && r.Classes == 0 {
+				t.Errorf("%s on %+v: Classes = 0 with Count set", r.Config, r.Grid)
+			}
+		}
+	}
+}
+
+func TestRun_Disagree(t *testing.T) {
+	// A configuration without starting points never finds a solution
+	configs := []Config{
+		{"ordered", func() *solver.Builder { return solver.NewBuilder().Solver(solver.SingleThreadedSolverName) }},
+		{"broken", func() *solver.Builder {
+			return solver.NewBuilder().Solver(solver.SingleThreadedSolverName).StartingPointsProvider(func(grid.Grid) []grid.Placements { return nil })
+		}},
+	}
+	_, err := Run(context.Background(), configs, Options{MinSize: 3, MaxSize: 4, Count: true})
+	var d *Disagreement
+	if !errors.As(err, &d) {
+		t.Fatalf("Run() error = %v, want a *Disagreement", err)
+	}
+	if d.Grid.Size != 3 || len(d.Results) != 2 || !d.Results[0].Solvable || d.Results[1].Solvable {
+		t.Errorf("Run() first disagreement = %+v, want the broken configuration unsolvable on a 3x3 grid", d)
+	}
+}
+
+func TestRun_InvalidConfig(t *testing.T) {
+	configs := []Config{
+		{"ordered", func() *solver.Builder { return solver.NewBuilder() }},
+		{"unknown", func() *solver.Builder { return solver.NewBuilder().Placer("unknown") }},
+	}
+	if _, err := Run(context.Background(), configs, Options{MaxSize: 2}); err == nil {
+		t.Error("Run() with an unknown placer succeeded, want an error")
+	}
+}
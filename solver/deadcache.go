@@ -0,0 +1,156 @@
+package solver
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+// deadPrefixCacheVersion is the version of the dead prefix cache file format. Caches of other versions are not loaded.
+const deadPrefixCacheVersion = 1
+
+// DeadPrefixCache records the prefixes whose subtrees were searched completely without finding a solution, so that later searches
+// of the same grid can skip them. Which placements lie below a prefix depends on the placer, so a cache is only valid for the
+// strategy it was made with, which is recorded with it. It is safe for concurrent use.
+type DeadPrefixCache struct {
+	size     uint8
+	strategy string
+
+	mu   sync.Mutex
+	dead map[string]grid.Placements
+
+	hits, misses, added atomic.Int64
+}
+
+// DeadPrefixCacheStats counts how a DeadPrefixCache was used.
+type DeadPrefixCacheStats struct {
+	// Entries is the number of dead prefixes in the cache, including those added
+	Entries int
+	// Hits and Misses count the prefixes looked up that were and weren't dead, and Added the dead prefixes added
+	Hits, Misses, Added int64
+}
+
+// deadPrefixCacheFile is the JSON encoding of a DeadPrefixCache.
+type deadPrefixCacheFile struct {
+	Version  int               `json:"version"`
+	Size     uint8             `json:"size"`
+	Strategy string            `json:"strategy"`
+	Dead     []grid.Placements `json:"dead"`
+}
+
+// NewDeadPrefixCache returns an empty cache for the grid and strategy, which names the placer and anything else that changes the
+// placements below a prefix.
+func NewDeadPrefixCache(g grid.Grid, strategy string) *DeadPrefixCache {
+	return &DeadPrefixCache{size: g.Size, strategy: strategy, dead: make(map[string]grid.Placements)}
+}
+
+// ReadDeadPrefixCache reads a cache written by WriteTo. It returns an empty cache, and says it is stale, if the cache was written
+// for another grid size, strategy or version of the file format, since its prefixes can't be trusted for this search.
+func ReadDeadPrefixCache(r io.Reader, g grid.Grid, strategy string) (c *DeadPrefixCache, stale bool, err error) {
+	var f deadPrefixCacheFile
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return nil, false, fmt.Errorf("invalid dead prefix cache: %w", err)
+	}
+	c = NewDeadPrefixCache(g, strategy)
+	if f.Version != deadPrefixCacheVersion || f.Size != g.Size || f.Strategy != strategy {
+		return c, true, nil
+	}
+	for _, p := range f.Dead {
+		c.dead[deadPrefixKey(p)] = p
+	}
+	return c, false, nil
+}
+
+// WriteTo writes the cache as JSON, with its prefixes in order.
+func (c *DeadPrefixCache) WriteTo(w io.Writer) (int64, error) {
+	f := deadPrefixCacheFile{Version: deadPrefixCacheVersion, Size: c.size, Strategy: c.strategy}
+	c.mu.Lock()
+	for _, p := range c.dead {
+		f.Dead = append(f.Dead, p)
+	}
+	c.mu.Unlock()
+	sort.Slice(f.Dead, func(i, j int) bool { return f.Dead[i].Compare(f.Dead[j]) < 0 })
+	b, err := json.Marshal(f)
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(append(b, '\n'))
+	return int64(n), err
+}
+
+func deadPrefixKey(p grid.Placements) string {
+	sorted := make(grid.Placements, len(p))
+	copy(sorted, p)
+	sorted.Sort()
+	return fmt.Sprint(sorted)
+}
+
+// Dead returns whether the subtree below the prefix is known to have no solution.
+func (c *DeadPrefixCache) Dead(p grid.Placements) bool {
+	c.mu.Lock()
+	_, dead := c.dead[deadPrefixKey(p)]
+	c.mu.Unlock()
+	if dead {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return dead
+}
+
+// Add records that the subtree below the prefix has no solution.
+func (c *DeadPrefixCache) Add(p grid.Placements) {
+	key := deadPrefixKey(p)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.dead[key]; !ok {
+		c.dead[key] = append(grid.Placements(nil), p...)
+		c.added.Add(1)
+	}
+}
+
+// Stats returns how the cache has been used.
+func (c *DeadPrefixCache) Stats() DeadPrefixCacheStats {
+	c.mu.Lock()
+	entries := len(c.dead)
+	c.mu.Unlock()
+	return DeadPrefixCacheStats{Entries: entries, Hits: c.hits.Load(), Misses: c.misses.Load(), Added: c.added.Load()}
+}
+
+// StartingPoints returns a StartingPointsProvider which leaves out the starting points of spp that are known to be dead.
+func (c *DeadPrefixCache) StartingPoints(spp StartingPointsProvider) StartingPointsProvider {
+	return func(g grid.Grid) []grid.Placements {
+		var startingPoints []grid.Placements
+		for _, sp := range spp(g) {
+			if !c.Dead(sp) {
+				startingPoints = append(startingPoints, sp)
+			}
+		}
+		return startingPoints
+	}
+}
+
+// Record subscribes to the bus and adds the root of each subtree that a solver finishes without a solution, until the returned
+// function is called, which waits for the events published so far to be recorded. Subtrees that were split between workers are
+// skipped, as each worker only searched part of them.
+func (c *DeadPrefixCache) Record(bus *EventBus) (stop func()) {
+	events, unsubscribe := bus.Subscribe(1024)
+	recorded := make(chan struct{})
+	go func() {
+		defer close(recorded)
+		for e := range events {
+			if e.Kind == EventSubtreeDone && !e.Solved && !e.Split {
+				c.Add(e.Placements)
+			}
+		}
+	}()
+	return func() {
+		unsubscribe()
+		<-recorded
+	}
+}
@@ -0,0 +1,62 @@
+package solver
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/pruner"
+)
+
+func TestDeadPrefixCache(t *testing.T) {
+	// There are no solutions for an 8x8 grid, so every starting point is dead
+	g := grid.Grid{Size: 8}
+	startingPoints := SingleOctantStartingPoints(g)
+	const strategy = "ordered_noalloc_pruning"
+	bus := NewEventBus()
+	cache := NewDeadPrefixCache(g, strategy)
+	stop := cache.Record(bus)
+	stats := &Stats{}
+	s := SingleThreadedSolver{
+		StartingPointsProvider: cache.StartingPoints(SingleOctantStartingPoints),
+		StonePlacerConstructor: placer.OrderedPruningNoAllocStonePlacerProvider{PrunerConstructor: pruner.NewRuntimePruner, Bound: true},
+		Stats:                  stats,
+		Events:                 bus,
+	}
+	if _, err := s.Solve(g); !errors.Is(err, ErrNoSolution) {
+		t.Fatalf("Solve() error = %v, want ErrNoSolution", err)
+	}
+	stop()
+	want := DeadPrefixCacheStats{Entries: len(startingPoints), Misses: int64(len(startingPoints)), Added: int64(len(startingPoints))}
+	if got := cache.Stats(); got != want {
+		t.Errorf("Stats() after the first search = %+v, want %+v", got, want)
+	}
+
+	var buf bytes.Buffer
+	if _, err := cache.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+	if _, stale, err := ReadDeadPrefixCache(bytes.NewReader(buf.Bytes()), g, "other"); err != nil || !stale {
+		t.Errorf("ReadDeadPrefixCache() with another strategy = %t, %v, want stale", stale, err)
+	}
+	loaded, stale, err := ReadDeadPrefixCache(bytes.NewReader(buf.Bytes()), g, strategy)
+	if err != nil || stale {
+		t.Fatalf("ReadDeadPrefixCache() = %t, %v, want a fresh cache", stale, err)
+	}
+
+	// A search with the loaded cache skips every starting point
+	s.StartingPointsProvider = loaded.StartingPoints(SingleOctantStartingPoints)
+	s.Stats, s.Events = &Stats{}, nil
+	if _, err := s.Solve(g); !errors.Is(err, ErrNoSolution) {
+		t.Errorf("Solve() with the loaded cache error = %v, want ErrNoSolution", err)
+	}
+	if nodes := s.Stats.Nodes.Load(); nodes != 0 {
+		t.Errorf("Solve() with the loaded cache placed %d stones, want 0", nodes)
+	}
+	want = DeadPrefixCacheStats{Entries: len(startingPoints), Hits: int64(len(startingPoints))}
+	if got := loaded.Stats(); got != want {
+		t.Errorf("Stats() of the loaded cache = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,108 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+// Enumerate searches the whole tree below each starting point, and calls yield with every solution found until yield returns false.
+// The solutions passed to yield are copies that the caller may keep.
+// To find every solution, use EmptyStartingPoint: other starting points break symmetry, so only find some of each solution's rotations and reflections.
+// If the context is done, the search is aborted and the context's error is returned.
+// If stats is not nil, it collects statistics during the search, with each starting point counted as a task.
+func Enumerate(ctx context.Context, g grid.Grid, spp StartingPointsProvider, spc placer.StonePlacerConstructor, stats *Stats, yield func(grid.Placements) bool) error {
+	var dfs func(sp placer.StonePlacerV2) bool
+	dfs = func(sp placer.StonePlacerV2) bool {
+		if sp.Remaining() == 0 {
+			return yield(sp.AppendPlacements(make(grid.Placements, 0, g.Size)))
+		}
+		for !sp.Done() {
+			nextState, err := sp.Place(ctx)
+			// If the context is done, abort search
+			if placer.IsCanceled(err) {
+				return false
+			}
+			if stats != nil {
+				stats.recordPlace(sp, nextState, err)
+			}
+			if err != nil {
+				continue
+			}
+			if !dfs(nextState) {
+				return false
+			}
+		}
+		return true
+	}
+
+	startingPoints := spp(g)
+	if stats != nil {
+		stats.TasksTotal.Add(int64(len(startingPoints)))
+	}
+	spcV2 := placer.AdaptV2Constructor(spc)
+	for _, sp := range startingPoints {
+		start := spcV2.New(g, sp)
+		if stats != nil {
+			stats.recordStart(start)
+		}
+		if !dfs(start) {
+			break
+		}
+		if stats != nil {
+			stats.TasksDone.Add(1)
+		}
+	}
+	return contextError(ctx)
+}
+
+// UpToSymmetry wraps yield for Enumerate so that it is called once for each equivalence class of solutions under rotation and
+// reflection, with the class's canonical solution, when the first solution of the class is found.
+func UpToSymmetry(g grid.Grid, yield func(grid.Placements) bool) func(grid.Placements) bool {
+	seen := make(map[string]bool)
+	return func(p grid.Placements) bool {
+		canonical := grid.Canonical(g, p)
+		key := fmt.Sprint(canonical)
+		if seen[key] {
+			return true
+		}
+		seen[key] = true
+		return yield(canonical)
+	}
+}
+
+// EquivalenceClass is a set of solutions that are rotations or reflections of each other
+type EquivalenceClass struct {
+	// Canonical is the lexicographically smallest solution in the class
+	Canonical grid.Placements
+	// OrbitSize is the number of distinct solutions in the class. It is 8 unless the solutions are symmetric.
+	OrbitSize int
+	// Symmetry is the type of the rotations and reflections that map the solutions onto themselves
+	Symmetry grid.SymmetryType
+	// Found is the number of solutions in the class that were classified
+	Found int
+}
+
+// ClassifySolutions groups solutions into equivalence classes under rotation and reflection, ordered by their canonical solutions.
+func ClassifySolutions(g grid.Grid, solutions []grid.Placements) []EquivalenceClass {
+	classes := make(map[string]*EquivalenceClass)
+	for _, s := range solutions {
+		canonical := grid.Canonical(g, s)
+		key := fmt.Sprint(canonical)
+		if c, ok := classes[key]; ok {
+			c.Found++
+			continue
+		}
+		classes[key] = &EquivalenceClass{Canonical: canonical, OrbitSize: grid.OrbitSize(g, canonical), Symmetry: grid.ClassifySymmetry(g, canonical), Found: 1}
+	}
+
+	sorted := make([]EquivalenceClass, 0, len(classes))
+	for _, c := range classes {
+		sorted = append(sorted, *c)
+	}
+	slices.SortFunc(sorted, func(c1, c2 EquivalenceClass) int { return c1.Canonical.Compare(c2.Canonical) })
+	return sorted
+}
@@ -0,0 +1,257 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/pruner"
+	"github.com/WillMorrison/pegboard-blog/sets"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEnumerate(t *testing.T) {
+	for size := uint8(1); size <= 6; size++ {
+		g := grid.Grid{Size: size}
+		var solutions []grid.Placements
+		err := Enumerate(context.Background(), g, EmptyStartingPoint, placer.OrderedNoAllocStonePlacerProvider{}, nil, func(p grid.Placements) bool {
+			solutions = append(solutions, p)
+			return true
+		})
+		if err != nil {
+			t.Fatalf("Enumerate(%v) error = %v", g, err)
+		}
+		if len(solutions) == 0 {
+			t.Errorf("Enumerate(%v) found no solutions", g)
+		}
+		for _, s := range solutions {
+			if err := grid.CheckValidSolution(g, s); err != nil {
+				t.Errorf("Enumerate(%v) found invalid solution %v: %v", g, s, err)
+			}
+		}
+
+		// Enumerating every solution finds every member of each equivalence class
+		classes := ClassifySolutions(g, solutions)
+		total := 0
+		for _, c := range classes {
+			if c.Found != c.OrbitSize {
+				t.Errorf("Enumerate(%v) found %d solutions equivalent to %v, want %d", g, c.Found, c.Canonical, c.OrbitSize)
+			}
+			total += c.Found
+		}
+		if total != len(solutions) {
+			t.Errorf("ClassifySolutions(%v) classified %d solutions, want %d", g, total, len(solutions))
+		}
+	}
+}
+
+func TestUpToSymmetry(t *testing.T) {
+	g := grid.Grid{Size: 5}
+	var solutions, distinct []grid.Placements
+	err := Enumerate(context.Background(), g, EmptyStartingPoint, placer.OrderedNoAllocStonePlacerProvider{}, nil, func(p grid.Placements) bool {
+		solutions = append(solutions, p)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Enumerate(%v) error = %v", g, err)
+	}
+	err = Enumerate(context.Background(), g, EmptyStartingPoint, placer.OrderedNoAllocStonePlacerProvider{}, nil, UpToSymmetry(g, func(p grid.Placements) bool {
+		distinct = append(distinct, p)
+		return true
+	}))
+	if err != nil {
+		t.Fatalf("Enumerate(%v) up to symmetry error = %v", g, err)
+	}
+	var want []grid.Placements
+	for _, c := range ClassifySolutions(g, solutions) {
+		want = append(want, c.Canonical)
+	}
+	slices.SortFunc(distinct, grid.Placements.Compare)
+	if diff := cmp.Diff(want, distinct); diff != "" {
+		t.Errorf("Enumerate(%v) up to symmetry mismatch with the canonical solutions of each class (-want +got):\n%s", g, diff)
+	}
+}
+
+func TestEnumerate_MatchesOrderedPlacer(t *testing.T) {
+	// Placers that order or skip children differently find the same solutions as the grid ordered placer
+	tests := []struct {
+		name string
+		spc  placer.StonePlacerConstructor
+	}{
+		{"ImpactOrderedPruning", placer.ImpactOrderedPruningStonePlacerProvider{PrunerConstructor: pruner.NewRuntimePruner}},
+		{"Pruning/Forced", placer.OrderedPruningNoAllocStonePlacerProvider{PrunerConstructor: pruner.NewRuntimePruner, Forced: true}},
+		{"Pruning/Bound/Forced", placer.OrderedPruningNoAllocStonePlacerProvider{PrunerConstructor: pruner.NewRuntimePruner, Bound: true, Forced: true}},
+		{"OpportunisticPruning/Bound/Forced", placer.OrderedOpportunisticPruningNoAllocStonePlacerProvider{PrunerConstructor: pruner.NewRuntimePruner, Bound: true, Forced: true}},
+		{"Bidirectional", placer.BidirectionalStonePlacerProvider{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for size := uint8(1); size <= 7; size++ {
+				g := grid.Grid{Size: size}
+				var want, got []grid.Placements
+				Enumerate(context.Background(), g, EmptyStartingPoint, placer.OrderedNoAllocStonePlacerProvider{}, nil, func(p grid.Placements) bool {
+					want = append(want, p)
+					return true
+				})
+				Enumerate(context.Background(), g, EmptyStartingPoint, tt.spc, nil, func(p grid.Placements) bool {
+					p.Sort()
+					got = append(got, p)
+					return true
+				})
+				slices.SortFunc(got, grid.Placements.Compare)
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("Enumerate(%v) mismatch (-want +got):\n%s", g, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestEnumerate_BidirectionalStartingPoints(t *testing.T) {
+	// Bidirectional starting points cover the same placements for a bidirectional placer as single octant starting points do for an ordered one
+	for size := uint8(1); size <= 6; size++ {
+		g := grid.Grid{Size: size}
+		var want, got []grid.Placements
+		Enumerate(context.Background(), g, SingleOctantStartingPoints, placer.OrderedNoAllocStonePlacerProvider{}, nil, func(p grid.Placements) bool {
+			want = append(want, p)
+			return true
+		})
+		Enumerate(context.Background(), g, BidirectionalStartingPoints, placer.BidirectionalStonePlacerProvider{}, nil, func(p grid.Placements) bool {
+			p.Sort()
+			got = append(got, p)
+			return true
+		})
+		slices.SortFunc(want, grid.Placements.Compare)
+		slices.SortFunc(got, grid.Placements.Compare)
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Enumerate(%v) mismatch (-want +got):\n%s", g, diff)
+		}
+	}
+}
+
+func TestEnumerate_Stop(t *testing.T) {
+	calls := 0
+	err := Enumerate(context.Background(), grid.Grid{Size: 5}, EmptyStartingPoint, placer.OrderedNoAllocStonePlacerProvider{}, nil, func(p grid.Placements) bool {
+		calls++
+		return false
+	})
+	if err != nil {
+		t.Fatalf("Enumerate() error = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Enumerate() called yield %d times after it returned false, want 1", calls)
+	}
+}
+
+func TestStonePlacer_DepthRemaining(t *testing.T) {
+	g := grid.Grid{Size: 5}
+	for _, name := range placer.Names() {
+		t.Run(name, func(t *testing.T) {
+			spc := placer.Placers[name].New(placer.Options{SeparationSetConstructor: sets.NewBitArraySeparationSet, PrunerConstructor: pruner.NewRuntimePruner})
+			var check func(sp placer.StonePlacer)
+			check = func(sp placer.StonePlacer) {
+				n := len(sp.Placements())
+				if sp.Depth() != n || sp.Remaining() != int(g.Size)-n {
+					t.Fatalf("placer with placements %v has Depth() = %d and Remaining() = %d, want %d and %d", sp.Placements(), sp.Depth(), sp.Remaining(), n, int(g.Size)-n)
+				}
+				for sp.Remaining() > 0 && !sp.Done() {
+					if child, err := sp.Place(); err == nil {
+						check(child)
+					}
+				}
+			}
+			check(spc.New(g, grid.Placements{}))
+			check(spc.New(g, grid.Placements{{Row: 1, Col: 1}, {Row: 0, Col: 2}}))
+		})
+	}
+}
+
+func TestAdaptV2(t *testing.T) {
+	g := grid.Grid{Size: 6}
+	spc := placer.OrderedPruningNoAllocStonePlacerProvider{PrunerConstructor: pruner.NewRuntimePruner, Bound: true}
+	var want []grid.Placements
+	Enumerate(context.Background(), g, EmptyStartingPoint, spc, nil, func(p grid.Placements) bool {
+		want = append(want, p)
+		return true
+	})
+
+	var got []grid.Placements
+	reasons := make(map[placer.RejectionReason]int)
+	var dfs func(sp placer.StonePlacerV2)
+	dfs = func(sp placer.StonePlacerV2) {
+		if sp.Remaining() == 0 {
+			got = append(got, sp.AppendPlacements(nil))
+			return
+		}
+		for !sp.Done() {
+			next, err := sp.Place(context.Background())
+			var rerr *placer.RejectionError
+			if errors.As(err, &rerr) {
+				reasons[rerr.Reason]++
+				continue
+			}
+			dfs(next)
+		}
+	}
+	dfs(placer.AdaptV2Constructor(spc).New(g, grid.Placements{}))
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("search with adapted placer mismatch (-want +got):\n%s", diff)
+	}
+	// The pruning placer never tries stones that would violate the constraint, so the bound rejects them all
+	if len(reasons) != 1 || reasons[placer.RejectedCannotComplete] == 0 {
+		t.Errorf("search with adapted placer had rejection reasons %v, want %s only", reasons, placer.RejectedCannotComplete)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := placer.AdaptV2Constructor(spc).New(g, grid.Placements{}).Place(ctx)
+	if !placer.IsCanceled(err) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Place() with canceled context error = %v, want %s rejection wrapping %v", err, placer.RejectedCanceled, context.Canceled)
+	}
+}
+
+func TestTakeCensus(t *testing.T) {
+	census, err := TakeCensus(context.Background(), 5, placer.OrderedNoAllocStonePlacerProvider{})
+	if err != nil {
+		t.Fatalf("TakeCensus() error = %v", err)
+	}
+	if len(census.Sizes) != 5 {
+		t.Fatalf("TakeCensus() has %d sizes, want 5", len(census.Sizes))
+	}
+	solutions, classes := 0, 0
+	for _, e := range census.Sizes {
+		g := grid.Grid{Size: e.Size}
+		orbits, symmetryTypes := 0, 0
+		for _, c := range e.Canonical {
+			if err := grid.CheckValidSolution(g, c); err != nil {
+				t.Errorf("TakeCensus() has invalid solution %v for %v: %v", c, g, err)
+			}
+			orbits += grid.OrbitSize(g, c)
+		}
+		for _, n := range e.SymmetryTypes {
+			symmetryTypes += n
+		}
+		if !e.Complete || e.Classes != len(e.Canonical) || symmetryTypes != e.Classes || orbits != e.Solutions {
+			t.Errorf("TakeCensus() entry for %v = %+v, which is inconsistent", g, e)
+		}
+		solutions += e.Solutions
+		classes += e.Classes
+	}
+	// The single stone on a 1x1 grid is fully symmetric
+	if got := census.Sizes[0].SymmetryTypes[grid.FullSymmetry]; got != 1 {
+		t.Errorf("TakeCensus() found %d fully symmetric solutions for 1x1, want 1", got)
+	}
+	if census.TotalSolutions != solutions || census.TotalClasses != classes {
+		t.Errorf("TakeCensus() totals = %d solutions and %d classes, want %d and %d", census.TotalSolutions, census.TotalClasses, solutions, classes)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	census, err = TakeCensus(ctx, 5, placer.OrderedNoAllocStonePlacerProvider{})
+	if !errors.Is(err, ErrCanceled) || len(census.Sizes) != 1 || census.Sizes[0].Complete {
+		t.Errorf("TakeCensus() with canceled context = %+v, %v, want one incomplete size and %v", census, err, ErrCanceled)
+	}
+}
@@ -0,0 +1,85 @@
+package solver
+
+import (
+	"math/rand"
+	"sync"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+// TreeSizeEstimate is an estimate of the number of nodes in the search tree below a starting point.
+type TreeSizeEstimate struct {
+	StartingPoint grid.Placements
+	// Nodes is the mean of the per-probe estimates, counting the starting point itself as a node.
+	Nodes float64
+	// Probes is the number of random probes the estimate was made from.
+	Probes int
+}
+
+// probe walks a single random path from the starting point to a leaf of the search tree, and returns Knuth's unbiased estimate of the tree size:
+// 1 + d1 + d1*d2 + ... where di is the number of children of the node at depth i along the path.
+//
+// Placers may reuse memory between the children they create, so the placements of every child are copied before picking one and
+// building a fresh placer from it.
+func probe(g grid.Grid, spc placer.StonePlacerConstructor, start grid.Placements, rng *rand.Rand) float64 {
+	estimate, weight := 1.0, 1.0
+	children := make([]grid.Placements, 0, int(g.Size)*int(g.Size))
+	for current := start; len(current) < int(g.Size); {
+		children = children[:0]
+		for sp := spc.New(g, current); !sp.Done(); {
+			child, err := sp.Place()
+			if err != nil {
+				continue
+			}
+			children = append(children, child.AppendPlacements(make(grid.Placements, 0, g.Size)))
+		}
+		if len(children) == 0 {
+			break
+		}
+		weight *= float64(len(children))
+		estimate += weight
+		current = children[rng.Intn(len(children))]
+	}
+	return estimate
+}
+
+// EstimateTreeSize estimates the size of the search tree below each starting point using Knuth's method of random probes,
+// without searching the whole tree. The estimates are unbiased, but their variance can be large for irregular trees, so more probes give better estimates.
+func EstimateTreeSize(g grid.Grid, spp StartingPointsProvider, spc placer.StonePlacerConstructor, probes int, rng *rand.Rand) []TreeSizeEstimate {
+	var estimates []TreeSizeEstimate
+	for _, sp := range spp(g) {
+		total := 0.0
+		for i := 0; i < probes; i++ {
+			total += probe(g, spc, sp, rng)
+		}
+		estimates = append(estimates, TreeSizeEstimate{StartingPoint: sp, Nodes: total / float64(probes), Probes: probes})
+	}
+	return estimates
+}
+
+// EstimateTreeSizeParallel is like EstimateTreeSize, but probes the starting points with the given number of workers, or one per CPU
+// that Go can use if it is 0. Starting point i is probed by worker i modulo the number of workers, using that worker's stream, so the
+// estimates only depend on the seed and the number of workers.
+func EstimateTreeSizeParallel(g grid.Grid, spp StartingPointsProvider, spc placer.StonePlacerConstructor, probes int, streams RandStreams, workers int) []TreeSizeEstimate {
+	startingPoints := spp(g)
+	estimates := make([]TreeSizeEstimate, len(startingPoints))
+	workers = numWorkers(workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			rng := streams.Worker(worker)
+			for i := worker; i < len(startingPoints); i += workers {
+				total := 0.0
+				for p := 0; p < probes; p++ {
+					total += probe(g, spc, startingPoints[i], rng)
+				}
+				estimates[i] = TreeSizeEstimate{StartingPoint: startingPoints[i], Nodes: total / float64(probes), Probes: probes}
+			}
+		}(w)
+	}
+	wg.Wait()
+	return estimates
+}
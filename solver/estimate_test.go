@@ -0,0 +1,78 @@
+package solver
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+// countNodes exhaustively counts the nodes in the search tree below a placer, including the placer itself
+func countNodes(sp placer.StonePlacer) int {
+	nodes := 1
+	if len(sp.Placements()) == int(sp.Grid().Size) {
+		return nodes
+	}
+	for !sp.Done() {
+		child, err := sp.Place()
+		if err != nil {
+			continue
+		}
+		nodes += countNodes(child)
+	}
+	return nodes
+}
+
+func TestEstimateTreeSize(t *testing.T) {
+	g := grid.Grid{Size: 5}
+	spc := placer.OrderedNoAllocStonePlacerProvider{}
+	estimates := EstimateTreeSize(g, SingleOctantStartingPoints, spc, 5000, rand.New(rand.NewSource(1)))
+
+	startingPoints := SingleOctantStartingPoints(g)
+	if len(estimates) != len(startingPoints) {
+		t.Fatalf("EstimateTreeSize() returned %d estimates, want %d", len(estimates), len(startingPoints))
+	}
+	for i, estimate := range estimates {
+		want := countNodes(spc.New(g, startingPoints[i]))
+		if math.Abs(estimate.Nodes-float64(want)) > 0.1*float64(want) {
+			t.Errorf("EstimateTreeSize() for %v estimated %f nodes, want %d", estimate.StartingPoint, estimate.Nodes, want)
+		}
+	}
+}
+
+func TestEstimateTreeSizeParallel(t *testing.T) {
+	g := grid.Grid{Size: 6}
+	spc := placer.OrderedNoAllocStonePlacerProvider{}
+	want := EstimateTreeSizeParallel(g, SingleOctantStartingPoints, spc, 50, RandStreams{Seed: 7}, 3)
+	// The estimates depend only on the seed and the number of workers, not on how the workers were scheduled
+	for i := 0; i < 5; i++ {
+		got := EstimateTreeSizeParallel(g, SingleOctantStartingPoints, spc, 50, RandStreams{Seed: 7}, 3)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("EstimateTreeSizeParallel() = %v, then %v with the same seed and workers", want, got)
+		}
+	}
+	if got := EstimateTreeSizeParallel(g, SingleOctantStartingPoints, spc, 50, RandStreams{Seed: 8}, 3); reflect.DeepEqual(got, want) {
+		t.Errorf("EstimateTreeSizeParallel() = %v with seeds 7 and 8, want different estimates", got)
+	}
+}
+
+func TestRandStreams(t *testing.T) {
+	streams := RandStreams{Seed: 42}
+	first := make(map[int64]int)
+	for worker := 0; worker < 8; worker++ {
+		a, b := streams.Worker(worker), streams.Worker(worker)
+		for i := 0; i < 100; i++ {
+			if x, y := a.Int63(), b.Int63(); x != y {
+				t.Fatalf("worker %d's streams differ at %d: %d and %d", worker, i, x, y)
+			}
+		}
+		v := streams.Worker(worker).Int63()
+		if other, ok := first[v]; ok {
+			t.Errorf("workers %d and %d have streams starting with %d", other, worker, v)
+		}
+		first[v] = worker
+	}
+}
@@ -0,0 +1,128 @@
+package solver
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+// EventKind is the kind of an Event.
+type EventKind uint8
+
+const (
+	// EventSolution is published when a worker finds a solution. Placements is the solution.
+	EventSolution EventKind = iota
+	// EventSubtreeDone is published when a worker finishes a task: a starting point, a split off subtree or a fixed depth prefix.
+	// Placements is the root of the subtree, and Nodes the number of placements made below it. Solved is whether a solution was found
+	// below it, and Split whether parts of it were handed to other workers, which report them as their own subtrees.
+	EventSubtreeDone
+	// EventSplit is published when a worker of the splitting solver hands part of its subtree to an idle worker. Placements is the root
+	// of the part handed over.
+	EventSplit
+	// EventCheckpoint is published by programs when they write the search's progress to a file. Path is the file.
+	EventCheckpoint
+)
+
+var eventKindNames = []string{"solution", "subtree_done", "split", "checkpoint"}
+
+func (k EventKind) String() string {
+	if int(k) < len(eventKindNames) {
+		return eventKindNames[k]
+	}
+	return fmt.Sprintf("EventKind(%d)", k)
+}
+
+func (k EventKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Event is something notable that happened during a search. Events are coarse, like spans and log records: there are none per
+// placement.
+type Event struct {
+	Kind EventKind `json:"kind"`
+	Time time.Time `json:"time"`
+	// Worker is the index of the worker that the event happened in, and StartingPoint the index of the starting point it is below
+	Worker        int             `json:"worker"`
+	StartingPoint int             `json:"starting_point"`
+	Placements    grid.Placements `json:"placements,omitempty"`
+	Nodes         int64           `json:"nodes,omitempty"`
+	Solved        bool            `json:"solved,omitempty"`
+	Split         bool            `json:"split,omitempty"`
+	Path          string          `json:"path,omitempty"`
+}
+
+// EventBus passes the events of a search to any number of subscribers, such as a logger, a UI and a file writer, each of which reads
+// them from its own channel at its own pace. Publishing never blocks the search: a subscriber that falls behind by more than its buffer
+// misses events, which are counted by Dropped.
+// A nil *EventBus discards events, so solvers publish unconditionally.
+type EventBus struct {
+	mu   sync.RWMutex
+	subs map[*subscription]bool
+}
+
+type subscription struct {
+	c       chan Event
+	dropped atomic.Int64
+}
+
+// NewEventBus returns an EventBus with no subscribers.
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[*subscription]bool)}
+}
+
+// Subscribe returns a channel receiving the events published from now on, which buffers up to buffer events, and a function which
+// unsubscribes and closes the channel. The function returns the number of events dropped because the buffer was full.
+func (b *EventBus) Subscribe(buffer int) (<-chan Event, func() (dropped int64)) {
+	sub := &subscription{c: make(chan Event, buffer)}
+	b.mu.Lock()
+	b.subs[sub] = true
+	b.mu.Unlock()
+	var once sync.Once
+	return sub.c, func() int64 {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, sub)
+			b.mu.Unlock()
+			close(sub.c)
+		})
+		return sub.dropped.Load()
+	}
+}
+
+// Publish sends the event to every subscriber with room in its buffer, setting its Time if it is zero.
+func (b *EventBus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subs {
+		select {
+		case sub.c <- e:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// publishSolution publishes an EventSolution for the solution, which must not be modified afterwards.
+func (b *EventBus) publishSolution(tc *taskCounter, solution grid.Placements) {
+	if b == nil {
+		return
+	}
+	b.Publish(Event{Kind: EventSolution, Worker: tc.workerIndex, StartingPoint: tc.startingPoint, Placements: solution})
+}
+
+// publishSubtreeDone publishes an EventSubtreeDone for the task rooted at root. Nodes are only counted if the solver has Stats.
+func (b *EventBus) publishSubtreeDone(tc *taskCounter, root grid.Placements) {
+	if b == nil {
+		return
+	}
+	b.Publish(Event{Kind: EventSubtreeDone, Worker: tc.workerIndex, StartingPoint: tc.startingPoint, Placements: root, Nodes: tc.nodes, Solved: tc.solved, Split: tc.split})
+}
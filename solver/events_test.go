@@ -0,0 +1,53 @@
+package solver
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+func TestEventBus(t *testing.T) {
+	bus := NewEventBus()
+	first, unsubscribeFirst := bus.Subscribe(100)
+	second, unsubscribeSecond := bus.Subscribe(1)
+	s := SingleThreadedSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, Stats: &Stats{}, Events: bus}
+	solution, err := s.Solve(grid.Grid{Size: 5})
+	if err != nil {
+		t.Fatalf("Solve() error = %v", err)
+	}
+	if dropped := unsubscribeFirst(); dropped != 0 {
+		t.Errorf("first subscriber dropped %d events, want 0", dropped)
+	}
+	var kinds []EventKind
+	for e := range first {
+		kinds = append(kinds, e.Kind)
+		switch e.Kind {
+		case EventSolution:
+			if !slices.Equal(e.Placements, solution) {
+				t.Errorf("solution event has placements %v, want %v", e.Placements, solution)
+			}
+		case EventSubtreeDone:
+			if e.Nodes == 0 || len(e.Placements) != 1 {
+				t.Errorf("subtree done event = %+v, want a starting point with nodes", e)
+			}
+		}
+	}
+	if len(kinds) < 2 || kinds[len(kinds)-2] != EventSolution || kinds[len(kinds)-1] != EventSubtreeDone {
+		t.Errorf("events had kinds %v, want them to end with a solution and its subtree done", kinds)
+	}
+
+	// The second subscriber's buffer only had room for the first event
+	if dropped := unsubscribeSecond(); dropped != int64(len(kinds)-1) {
+		t.Errorf("second subscriber dropped %d events, want %d", dropped, len(kinds)-1)
+	}
+	if e, ok := <-second; !ok || e.Kind != kinds[0] {
+		t.Errorf("second subscriber received %+v, %t, want the first event", e, ok)
+	}
+
+	// Publishing after everyone unsubscribed, or to a nil bus, does nothing
+	bus.Publish(Event{Kind: EventCheckpoint})
+	var nilBus *EventBus
+	nilBus.Publish(Event{Kind: EventCheckpoint})
+}
@@ -0,0 +1,69 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+// FrontierEntry is a canonical partial placement on the search frontier.
+type FrontierEntry struct {
+	Prefix grid.Placements
+	// Children is the number of valid placements with one more stone below the prefix.
+	Children int
+}
+
+// Frontier enumerates every valid partial placement with depth stones which is canonical under rotation and reflection, calling yield with each
+// until yield returns false. Every valid placement is equivalent to exactly one canonical one, so the frontier covers the whole search without repeats.
+// The placements passed to yield are copies that the caller may keep.
+//
+// It returns the size of the canonical frontier at every depth from 0 to depth, which shows how quickly the constraints cut down the search.
+// spc must be an ordered placer, which places each set of stones once. If the context is done, the enumeration is aborted and the context's error is returned.
+// It returns an error if depth is negative.
+func Frontier(ctx context.Context, g grid.Grid, spc placer.StonePlacerConstructor, depth int, yield func(FrontierEntry) bool) ([]int, error) {
+	if depth < 0 {
+		return nil, fmt.Errorf("the frontier depth must not be negative, got %d", depth)
+	}
+	sizes := make([]int, depth+1)
+	done := ctx.Done()
+	var dfs func(sp placer.StonePlacer) bool
+	dfs = func(sp placer.StonePlacer) bool {
+		p := sp.Placements()
+		if grid.Canonical(g, p).Compare(p) == 0 {
+			sizes[len(p)]++
+			if len(p) == depth {
+				entry := FrontierEntry{Prefix: sp.AppendPlacements(make(grid.Placements, 0, g.Size))}
+				// Complete solutions have no children, and placers can't place more stones than the grid size
+				for len(p) < int(g.Size) && !sp.Done() {
+					if _, err := sp.Place(); err == nil {
+						entry.Children++
+					}
+				}
+				return yield(entry)
+			}
+		}
+		if len(p) == depth || len(p) == int(g.Size) {
+			return true
+		}
+		for !sp.Done() {
+			select {
+			// If done channel is closed, abort search
+			case <-done:
+				return false
+			default:
+			}
+			nextState, err := sp.Place()
+			if err != nil {
+				continue
+			}
+			if !dfs(nextState) {
+				return false
+			}
+		}
+		return true
+	}
+	dfs(spc.New(g, grid.Placements{}))
+	return sizes, contextError(ctx)
+}
@@ -0,0 +1,65 @@
+package solver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+func TestFrontier(t *testing.T) {
+	g := grid.Grid{Size: 6}
+	spc := placer.OrderedNoAllocStonePlacerProvider{}
+	var entries []FrontierEntry
+	sizes, err := Frontier(context.Background(), g, spc, 3, func(e FrontierEntry) bool {
+		entries = append(entries, e)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Frontier() error = %v", err)
+	}
+	if sizes[0] != 1 {
+		t.Errorf("Frontier() has %d canonical placements of 0 stones, want 1", sizes[0])
+	}
+	if want := len(SingleOctantStartingPoints(g)); sizes[1] != want {
+		t.Errorf("Frontier() has %d canonical placements of 1 stone, want %d", sizes[1], want)
+	}
+	if sizes[3] != len(entries) {
+		t.Errorf("Frontier() reported %d canonical placements of 3 stones, but yielded %d", sizes[3], len(entries))
+	}
+
+	// Every valid placement should be the image of exactly one frontier entry
+	orbits := 0
+	for _, e := range entries {
+		if got := grid.Canonical(g, e.Prefix); got.Compare(e.Prefix) != 0 {
+			t.Errorf("Frontier() yielded %v, which is not canonical", e.Prefix)
+		}
+		orbits += grid.OrbitSize(g, e.Prefix)
+	}
+	all := len(FixedDepthSplittingSolver{StartingPointsProvider: EmptyStartingPoint, StonePlacerConstructor: spc, SplitDepth: 3}.Prefixes(g))
+	if orbits != all {
+		t.Errorf("Frontier() entries have %d images in total, want all %d valid placements", orbits, all)
+	}
+
+	e, children := entries[0], 0
+	for sp := spc.New(g, e.Prefix); !sp.Done(); {
+		if _, err := sp.Place(); err == nil {
+			children++
+		}
+	}
+	if e.Children != children {
+		t.Errorf("Frontier() reported %d children of %v, want %d", e.Children, e.Prefix, children)
+	}
+}
+
+func TestFrontier_NegativeDepth(t *testing.T) {
+	yielded := false
+	sizes, err := Frontier(context.Background(), grid.Grid{Size: 6}, placer.OrderedNoAllocStonePlacerProvider{}, -1, func(FrontierEntry) bool {
+		yielded = true
+		return true
+	})
+	if err == nil || sizes != nil || yielded {
+		t.Errorf("Frontier() with depth -1 = %v, %v and yielded %t, want an error and nothing yielded", sizes, err, yielded)
+	}
+}
@@ -0,0 +1,46 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+// discardHandler is a slog.Handler which discards every record, for solvers that weren't given a Logger.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+var discardLogger = slog.New(discardHandler{})
+
+// loggerOrDiscard returns l, or a logger which discards everything if l is nil.
+func loggerOrDiscard(l *slog.Logger) *slog.Logger {
+	if l == nil {
+		return discardLogger
+	}
+	return l
+}
+
+// logSolve logs the start of a solve by the named solver, and returns a function which logs its outcome.
+// Like spans, records are only logged for coarse units of work, never per placement.
+func logSolve(ctx context.Context, l *slog.Logger, name string, g grid.Grid) func(solution grid.Placements, err error) {
+	l = loggerOrDiscard(l)
+	l.DebugContext(ctx, "search started", "solver", name, "size", g.Size)
+	start := time.Now()
+	return func(solution grid.Placements, err error) {
+		switch {
+		case err == nil:
+			l.InfoContext(ctx, "solution found", "solver", name, "size", g.Size, "duration", time.Since(start), "solution", solution)
+		case errors.Is(err, ErrNoSolution):
+			l.InfoContext(ctx, "no solution exists", "solver", name, "size", g.Size, "duration", time.Since(start))
+		default:
+			l.WarnContext(ctx, "search stopped", "solver", name, "size", g.Size, "duration", time.Since(start), "error", err)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package solver
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// MemoryStats describes how a search used memory, from runtime.MemStats snapshots taken while it ran.
+type MemoryStats struct {
+	// PeakHeap is the most bytes of live heap objects seen in any snapshot. Snapshots are periodic, so short spikes may be missed.
+	PeakHeap uint64
+	// TotalAlloc and Mallocs are the bytes and number of heap objects allocated during the search, including those since freed.
+	TotalAlloc uint64
+	Mallocs    uint64
+	// GCCycles is the number of garbage collections completed during the search, and GCPause the time the world was stopped for them.
+	GCCycles uint32
+	GCPause  time.Duration
+}
+
+// MemorySampler snapshots runtime.MemStats periodically until it is stopped. Reading MemStats briefly stops the world, so the interval
+// should be long compared to a GC pause.
+type MemorySampler struct {
+	start    runtime.MemStats
+	peakHeap uint64
+	stop     chan struct{}
+	done     sync.WaitGroup
+}
+
+// StartMemorySampler takes the first snapshot and starts sampling the heap every interval.
+func StartMemorySampler(interval time.Duration) *MemorySampler {
+	m := &MemorySampler{stop: make(chan struct{})}
+	runtime.ReadMemStats(&m.start)
+	m.peakHeap = m.start.HeapAlloc
+	m.done.Add(1)
+	go func() {
+		defer m.done.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var ms runtime.MemStats
+		for {
+			select {
+			case <-m.stop:
+				return
+			case <-ticker.C:
+				runtime.ReadMemStats(&ms)
+				m.peakHeap = max(m.peakHeap, ms.HeapAlloc)
+			}
+		}
+	}()
+	return m
+}
+
+// Stop takes the last snapshot and returns the memory used since the sampler was started. It must be called once.
+func (m *MemorySampler) Stop() MemoryStats {
+	close(m.stop)
+	m.done.Wait()
+	var end runtime.MemStats
+	runtime.ReadMemStats(&end)
+	return MemoryStats{
+		PeakHeap:   max(m.peakHeap, end.HeapAlloc),
+		TotalAlloc: end.TotalAlloc - m.start.TotalAlloc,
+		Mallocs:    end.Mallocs - m.start.Mallocs,
+		GCCycles:   end.NumGC - m.start.NumGC,
+		GCPause:    time.Duration(end.PauseTotalNs - m.start.PauseTotalNs),
+	}
+}
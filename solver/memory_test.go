@@ -0,0 +1,31 @@
+package solver
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+var sink [][]byte
+
+func TestMemorySampler(t *testing.T) {
+	m := StartMemorySampler(time.Millisecond)
+	for i := 0; i < 100; i++ {
+		sink = append(sink, make([]byte, 1<<16))
+	}
+	runtime.GC()
+	got := m.Stop()
+	sink = nil
+	if got.TotalAlloc < 100<<16 {
+		t.Errorf("TotalAlloc = %d, want at least %d", got.TotalAlloc, 100<<16)
+	}
+	if got.Mallocs < 100 {
+		t.Errorf("Mallocs = %d, want at least 100", got.Mallocs)
+	}
+	if got.PeakHeap < 100<<16 {
+		t.Errorf("PeakHeap = %d, want at least %d", got.PeakHeap, 100<<16)
+	}
+	if got.GCCycles < 1 {
+		t.Errorf("GCCycles = %d, want at least 1", got.GCCycles)
+	}
+}
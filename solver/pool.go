@@ -0,0 +1,137 @@
+package solver
+
+import (
+	"context"
+	"sync"
+)
+
+// Pool shares a fixed budget of workers between the searches that use it, such as the concurrent requests of a server or the grids of
+// a range of sizes, which would otherwise each start as many workers as there are CPUs. A solver with a Pool takes a slot from it for
+// each task it searches, and gives it back when the task is finished, so its workers wait while the budget is used up.
+// Slots are shared fairly: a freed slot goes to the search holding the fewest slots, and between searches holding the same number, to
+// the one that has waited longest. A search started while another holds the whole budget gets a slot as soon as one task finishes.
+// A nil *Pool places no limit, so solvers use their Pool unconditionally.
+type Pool struct {
+	size int
+
+	mu   sync.Mutex
+	busy int
+	// waiting holds the jobs with tasks waiting for a slot
+	waiting map[*PoolJob]bool
+	// ticket numbers the waiting tasks in the order they started waiting
+	ticket uint64
+}
+
+// PoolJob is one search's share of a Pool, which counts the slots the search holds.
+type PoolJob struct {
+	pool    *Pool
+	held    int
+	waiters []poolWaiter
+}
+
+type poolWaiter struct {
+	ticket uint64
+	ready  chan struct{}
+}
+
+// NewPool returns a Pool of the given number of workers, or one per CPU that Go can use if workers is not positive.
+func NewPool(workers int) *Pool {
+	return &Pool{size: numWorkers(workers), waiting: make(map[*PoolJob]bool)}
+}
+
+// Size returns the number of workers in the pool.
+func (p *Pool) Size() int {
+	return p.size
+}
+
+// Busy returns the number of slots in use.
+func (p *Pool) Busy() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.busy
+}
+
+// Job returns a new share of the pool for a search, or nil if the pool is nil.
+func (p *Pool) Job() *PoolJob {
+	if p == nil {
+		return nil
+	}
+	return &PoolJob{pool: p}
+}
+
+// Acquire waits for a slot for one of the job's tasks, or until the context is done, when it returns the context's error.
+// Each successful Acquire must be followed by a Release. A nil *PoolJob returns immediately.
+func (j *PoolJob) Acquire(ctx context.Context) error {
+	if j == nil {
+		return nil
+	}
+	p := j.pool
+	p.mu.Lock()
+	// Tasks only take a free slot straight away if nobody is queued for it, or they would overtake the jobs with fewer slots
+	if p.busy < p.size && len(p.waiting) == 0 {
+		p.busy++
+		j.held++
+		p.mu.Unlock()
+		return nil
+	}
+	p.ticket++
+	w := poolWaiter{ticket: p.ticket, ready: make(chan struct{})}
+	j.waiters = append(j.waiters, w)
+	p.waiting[j] = true
+	p.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for i := range j.waiters {
+		if j.waiters[i].ticket == w.ticket {
+			j.waiters = append(j.waiters[:i], j.waiters[i+1:]...)
+			if len(j.waiters) == 0 {
+				delete(p.waiting, j)
+			}
+			return ctx.Err()
+		}
+	}
+	// The slot was granted as the context was done, so pass it on
+	j.held--
+	p.busy--
+	p.dispatch()
+	return ctx.Err()
+}
+
+// Release gives back a slot taken by Acquire. A nil *PoolJob does nothing.
+func (j *PoolJob) Release() {
+	if j == nil {
+		return
+	}
+	p := j.pool
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	j.held--
+	p.busy--
+	p.dispatch()
+}
+
+// dispatch hands the free slots to the waiting tasks, fairest first. p.mu must be held.
+func (p *Pool) dispatch() {
+	for p.busy < p.size && len(p.waiting) > 0 {
+		var next *PoolJob
+		for j := range p.waiting {
+			if next == nil || j.held < next.held || (j.held == next.held && j.waiters[0].ticket < next.waiters[0].ticket) {
+				next = j
+			}
+		}
+		w := next.waiters[0]
+		next.waiters = next.waiters[1:]
+		if len(next.waiters) == 0 {
+			delete(p.waiting, next)
+		}
+		next.held++
+		p.busy++
+		close(w.ready)
+	}
+}
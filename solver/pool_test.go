@@ -0,0 +1,140 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+// acquired starts acquiring a slot for the job, returning a channel that receives the result.
+func acquired(ctx context.Context, job *PoolJob) <-chan error {
+	c := make(chan error, 1)
+	go func() { c <- job.Acquire(ctx) }()
+	return c
+}
+
+// waitForWaiters waits until the pool has n tasks waiting for slots.
+func waitForWaiters(t *testing.T, p *Pool, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		p.mu.Lock()
+		waiting := 0
+		for j := range p.waiting {
+			waiting += len(j.waiters)
+		}
+		p.mu.Unlock()
+		if waiting == n {
+			return
+		}
+	}
+	t.Fatalf("pool never had %d waiting tasks", n)
+}
+
+func TestPool_Fair(t *testing.T) {
+	ctx := context.Background()
+	p := NewPool(2)
+	greedy, fresh := p.Job(), p.Job()
+	for i := 0; i < 2; i++ {
+		if err := greedy.Acquire(ctx); err != nil {
+			t.Fatalf("Acquire() error = %v", err)
+		}
+	}
+	// The greedy job queues first, but the fresh job holds fewer slots, so it gets the next one
+	greedyNext := acquired(ctx, greedy)
+	waitForWaiters(t, p, 1)
+	freshNext := acquired(ctx, fresh)
+	waitForWaiters(t, p, 2)
+
+	greedy.Release()
+	if err := <-freshNext; err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	select {
+	case <-greedyNext:
+		t.Fatal("the job holding more slots was given the freed slot")
+	default:
+	}
+	if got := p.Busy(); got != 2 {
+		t.Errorf("Busy() = %d, want 2", got)
+	}
+
+	fresh.Release()
+	if err := <-greedyNext; err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+}
+
+func TestPool_Canceled(t *testing.T) {
+	p := NewPool(1)
+	job := p.Job()
+	if err := job.Acquire(context.Background()); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	waiting := acquired(ctx, p.Job())
+	waitForWaiters(t, p, 1)
+	cancel()
+	if err := <-waiting; !errors.Is(err, context.Canceled) {
+		t.Errorf("Acquire() error = %v, want %v", err, context.Canceled)
+	}
+	// The canceled task gave up its place, so the slot goes back to the pool
+	job.Release()
+	if got := p.Busy(); got != 0 {
+		t.Errorf("Busy() = %d after every slot was released, want 0", got)
+	}
+}
+
+func TestPool_Nil(t *testing.T) {
+	var p *Pool
+	job := p.Job()
+	if err := job.Acquire(context.Background()); err != nil {
+		t.Errorf("Acquire() on a nil job error = %v", err)
+	}
+	job.Release()
+}
+
+func TestPool_ConcurrentSolves(t *testing.T) {
+	p := NewPool(2)
+	solvers := []Solver{
+		AsyncSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, Pool: p},
+		AsyncSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, Workers: 4, Pool: p},
+		FixedDepthSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, SplitDepth: 2, Workers: 4, Pool: p},
+		SingleThreadedSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, Pool: p},
+	}
+	var wg sync.WaitGroup
+	for _, s := range solvers {
+		wg.Add(1)
+		go func(s Solver) {
+			defer wg.Done()
+			g := grid.Grid{Size: 7}
+			got, err := s.Solve(g)
+			if err != nil {
+				t.Errorf("%T.Solve() error = %v", s, err)
+				return
+			}
+			if err := grid.CheckValidSolution(g, got); err != nil {
+				t.Errorf("%T.Solve() = %v, want valid solution", s, got)
+			}
+		}(s)
+	}
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	for {
+		if busy := p.Busy(); busy > p.Size() {
+			t.Fatalf("Busy() = %d, more than the pool's %d workers", busy, p.Size())
+		}
+		select {
+		case <-done:
+			if got := p.Busy(); got != 0 {
+				t.Errorf("Busy() = %d after the searches finished, want 0", got)
+			}
+			return
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
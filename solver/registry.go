@@ -0,0 +1,135 @@
+package solver
+
+import (
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+// Names of the starting points in StartingPoints and the Solver implementations in Solvers, as used on the command line.
+const (
+	EmptyStartingPointName          = "empty_grid"
+	SingleOctantStartingPointsName  = "first_octant"
+	BidirectionalStartingPointsName = "bidirectional"
+
+	SingleThreadedSolverName = "single_thread"
+	AsyncSolverName          = "async"
+	AsyncSplittingSolverName = "async_splitting"
+	FixedDepthSolverName     = "fixed_depth"
+	TimeSlicedSolverName     = "time_sliced"
+)
+
+// AutoName can be given to Builder.Placer and Builder.Solver instead of a registered name, to choose the best known strategy for the
+// grid size and the CPUs available.
+const AutoName = "auto"
+
+// StartingPoints maps names to StartingPointsProviders, so that programs and config files can choose one by name.
+var StartingPoints = map[string]StartingPointsProvider{
+	EmptyStartingPointName:          EmptyStartingPoint,
+	SingleOctantStartingPointsName:  SingleOctantStartingPoints,
+	BidirectionalStartingPointsName: BidirectionalStartingPoints,
+}
+
+// Options holds the settings of the solvers in Solvers. Each solver ignores the options it doesn't support.
+type Options struct {
+	StartingPointsProvider StartingPointsProvider
+	StonePlacerConstructor placer.StonePlacerConstructor
+	Stats                  *Stats
+	WorkerInit             func(worker int)
+	Workers                int
+	SplitDepth             int
+	TimeSlice              time.Duration
+	Logger                 *slog.Logger
+	Events                 *EventBus
+	Pool                   *Pool
+}
+
+// Registration describes a Solver implementation: how to construct it, and which Options it supports.
+type Registration struct {
+	New func(Options) Solver
+
+	SupportsWorkers    bool
+	SupportsSplitDepth bool
+	SupportsTimeSlice  bool
+}
+
+// Solvers maps the name of each Solver implementation to its Registration, so that programs and config files can choose one by name.
+var Solvers = map[string]Registration{
+	SingleThreadedSolverName: {
+		New: func(o Options) Solver {
+			return SingleThreadedSolver{StartingPointsProvider: o.StartingPointsProvider, StonePlacerConstructor: o.StonePlacerConstructor, Stats: o.Stats, Logger: o.Logger, Events: o.Events, Pool: o.Pool}
+		},
+	},
+	// There is one worker per starting point
+	AsyncSolverName: {
+		New: func(o Options) Solver {
+			return AsyncSolver{StartingPointsProvider: o.StartingPointsProvider, StonePlacerConstructor: o.StonePlacerConstructor, Stats: o.Stats, WorkerInit: o.WorkerInit, Logger: o.Logger, Events: o.Events, Pool: o.Pool}
+		},
+	},
+	AsyncSplittingSolverName: {
+		New: func(o Options) Solver {
+			return AsyncSplittingSolver{StartingPointsProvider: o.StartingPointsProvider, StonePlacerConstructor: o.StonePlacerConstructor, Stats: o.Stats, WorkerInit: o.WorkerInit, Workers: o.Workers, Logger: o.Logger, Events: o.Events, Pool: o.Pool}
+		},
+		SupportsWorkers: true,
+	},
+	FixedDepthSolverName: {
+		New: func(o Options) Solver {
+			return FixedDepthSplittingSolver{StartingPointsProvider: o.StartingPointsProvider, StonePlacerConstructor: o.StonePlacerConstructor, SplitDepth: o.SplitDepth, Stats: o.Stats, WorkerInit: o.WorkerInit, Workers: o.Workers, Logger: o.Logger, Events: o.Events, Pool: o.Pool}
+		},
+		SupportsWorkers:    true,
+		SupportsSplitDepth: true,
+	},
+	TimeSlicedSolverName: {
+		New: func(o Options) Solver {
+			return TimeSlicedSolver{StartingPointsProvider: o.StartingPointsProvider, StonePlacerConstructor: o.StonePlacerConstructor, Slice: o.TimeSlice, Stats: o.Stats, Logger: o.Logger, Events: o.Events, Pool: o.Pool}
+		},
+		SupportsTimeSlice: true,
+	},
+}
+
+// Register adds a Solver implementation to Solvers, so that it can be chosen by name like the built in ones, without changes to the
+// programs that choose them. Call it from an init function, as the names are read when flags are defined. It panics if the name is
+// empty, AutoName or already registered, or the Registration has no New function.
+func Register(name string, reg Registration) {
+	if name == "" || name == AutoName || reg.New == nil {
+		panic("solver: Register called without a name or New function, or with " + AutoName)
+	}
+	if _, dup := Solvers[name]; dup {
+		panic("solver: Register called twice for " + name)
+	}
+	Solvers[name] = reg
+}
+
+// RegisterStartingPoints adds a StartingPointsProvider to StartingPoints, so that it can be chosen by name like the built in ones.
+// Call it from an init function, as the names are read when flags are defined. It panics if the name is empty or already registered,
+// or the provider is nil.
+func RegisterStartingPoints(name string, spp StartingPointsProvider) {
+	if name == "" || spp == nil {
+		panic("solver: RegisterStartingPoints called without a name or provider")
+	}
+	if _, dup := StartingPoints[name]; dup {
+		panic("solver: RegisterStartingPoints called twice for " + name)
+	}
+	StartingPoints[name] = spp
+}
+
+// StartingPointsNames returns the names in StartingPoints, sorted.
+func StartingPointsNames() []string {
+	return sortedKeys(StartingPoints)
+}
+
+// Names returns the names in Solvers, sorted.
+func Names() []string {
+	return sortedKeys(Solvers)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
@@ -0,0 +1,89 @@
+package solver
+
+import (
+	"context"
+	"slices"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+// StepKind is the kind of a Step of a replayed search.
+type StepKind uint8
+
+const (
+	// StepPlace is a stone being placed, which Placements ends with
+	StepPlace StepKind = iota
+	// StepBacktrack is the search leaving a placement whose subtree it has finished, returning to Placements
+	StepBacktrack
+	// StepSolution is a solution being found, which Placements is
+	StepSolution
+)
+
+var stepKindNames = []string{"place", "backtrack", "solution"}
+
+func (k StepKind) String() string {
+	return stepKindNames[k]
+}
+
+// Step is one step of a replayed search.
+type Step struct {
+	Kind StepKind
+	// Placements is the placement the search reached, in the order the stones were placed. It is only valid during the call it is
+	// passed to.
+	Placements grid.Placements
+	// Removed is the stones removed by a StepBacktrack, which may be more than one for placers that place several stones at once
+	Removed grid.Placements
+}
+
+// Replay searches the grid in the same order as SingleThreadedSolver, calling f with every step of the search, such as each stone
+// placed and each backtrack, for visualizations of the search. It stops at the first solution, when f returns false, or when the
+// context is done, returning the context's error. Replaying records far more than a search does, so it is only practical for small
+// grids or the start of a search.
+func Replay(ctx context.Context, g grid.Grid, spp StartingPointsProvider, spc placer.StonePlacerConstructor, f func(Step) bool) error {
+	done := ctx.Done()
+	stopped := false
+	var visit func(sp placer.StonePlacer, parent grid.Placements) bool
+	// visit returns whether the replay should stop, because a solution was found or f or the context said so
+	visit = func(sp placer.StonePlacer, parent grid.Placements) bool {
+		p := sp.AppendPlacements(make(grid.Placements, 0, g.Size))
+		if !f(Step{Kind: StepPlace, Placements: p}) {
+			return true
+		}
+		if sp.Remaining() == 0 {
+			f(Step{Kind: StepSolution, Placements: p})
+			return true
+		}
+		for !sp.Done() {
+			select {
+			case <-done:
+				stopped = true
+				return true
+			default:
+			}
+			child, err := sp.Place()
+			if err != nil {
+				continue
+			}
+			if visit(child, p) {
+				return true
+			}
+		}
+		var removed grid.Placements
+		for _, stone := range p {
+			if !slices.Contains(parent, stone) {
+				removed = append(removed, stone)
+			}
+		}
+		return !f(Step{Kind: StepBacktrack, Placements: parent, Removed: removed})
+	}
+	for _, start := range spp(g) {
+		if visit(spc.New(g, start), nil) {
+			break
+		}
+	}
+	if stopped {
+		return contextError(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+func TestReplay(t *testing.T) {
+	g := grid.Grid{Size: 6}
+	spc := placer.OrderedNoAllocStonePlacerProvider{}
+	var steps []Step
+	depth := 0
+	err := Replay(context.Background(), g, SingleOctantStartingPoints, spc, func(s Step) bool {
+		switch s.Kind {
+		case StepPlace:
+			depth++
+		case StepBacktrack:
+			depth--
+		}
+		if s.Kind != StepSolution && len(s.Placements) != depth {
+			t.Fatalf("step %d is a %v to %v, but the search is %d stones deep", len(steps), s.Kind, s.Placements, depth)
+		}
+		steps = append(steps, Step{Kind: s.Kind, Placements: append(grid.Placements(nil), s.Placements...)})
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	last := steps[len(steps)-1]
+	if last.Kind != StepSolution {
+		t.Fatalf("Replay() ended with a %v step, want a solution", last.Kind)
+	}
+	// The replay finds the solution the single threaded solver finds
+	want, _ := SingleThreadedSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: spc}.Solve(g)
+	if last.Placements.Compare(want) != 0 {
+		t.Errorf("Replay() found %v, want %v", last.Placements, want)
+	}
+
+	stopped := 0
+	Replay(context.Background(), g, SingleOctantStartingPoints, spc, func(s Step) bool {
+		stopped++
+		return stopped < 10
+	})
+	if stopped != 10 {
+		t.Errorf("Replay() took %d steps, want it to stop at the 10th", stopped)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := Replay(ctx, grid.Grid{Size: 8}, SingleOctantStartingPoints, spc, func(Step) bool { return true }); !errors.Is(err, ErrCanceled) {
+		t.Errorf("Replay() error = %v, want %v", err, ErrCanceled)
+	}
+}
@@ -0,0 +1,211 @@
+package solver
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+// ResultKey identifies a search whose result can be reused. A solution always has as many stones as the grid has rows, so the grid
+// fixes the number of stones, and the strategy and prefix fix the rest of what can change the result.
+type ResultKey struct {
+	Size uint8
+	// Strategy names the placer, solver and anything else that changes which solution is found, as for DeadPrefixCache
+	Strategy string
+	// Prefix is the stones that every solution must contain, sorted and formatted, or "" for the whole grid
+	Prefix string
+}
+
+// NewResultKey returns the key of a search of the grid with the strategy, for solutions containing the prefix.
+func NewResultKey(g grid.Grid, strategy string, prefix grid.Placements) ResultKey {
+	key := ResultKey{Size: g.Size, Strategy: strategy}
+	if len(prefix) > 0 {
+		key.Prefix = deadPrefixKey(prefix)
+	}
+	return key
+}
+
+// ResultCacheOptions controls what a ResultCache keeps, and for how long.
+type ResultCacheOptions struct {
+	// MaxEntries is the number of results kept, the least recently used being evicted first, or 0 for no limit
+	MaxEntries int
+	// TTL is how long a result is kept after the search that found it, or 0 to keep results until they are evicted
+	TTL time.Duration
+	// OnlySolutions is whether to keep only the solutions found, and search again for grids that had none
+	OnlySolutions bool
+}
+
+// ResultCacheStats counts how a ResultCache was used.
+type ResultCacheStats struct {
+	// Entries is the number of results in the cache
+	Entries int
+	// Hits counts the searches answered from the cache, Shared those that waited for an identical search already running, and Misses
+	// those that were run
+	Hits, Shared, Misses int64
+	// Evictions counts the results removed to make room or because they expired
+	Evictions int64
+}
+
+// ResultCache remembers the results of searches, so that programs serving many requests don't repeat identical searches. Solutions
+// and ErrNoSolution are kept, but not the errors of searches that were stopped, which are run again when next asked for. Identical
+// searches asked for while one is running wait for its result instead of running alongside it.
+// It is safe for concurrent use. A nil *ResultCache keeps nothing, so every search is run.
+type ResultCache struct {
+	opts ResultCacheOptions
+
+	mu      sync.Mutex
+	entries map[ResultKey]*resultEntry
+	// lru holds the finished entries, most recently used first
+	lru   *list.List
+	stats ResultCacheStats
+}
+
+type resultEntry struct {
+	key ResultKey
+	// done is closed when the search has finished, after which the fields below are set
+	done     chan struct{}
+	solution grid.Placements
+	err      error
+	found    time.Time
+	// kept is whether the result was kept, rather than the search being stopped
+	kept bool
+	elem *list.Element
+}
+
+// NewResultCache returns an empty cache.
+func NewResultCache(opts ResultCacheOptions) *ResultCache {
+	return &ResultCache{opts: opts, entries: make(map[ResultKey]*resultEntry), lru: list.New()}
+}
+
+// Stats returns how the cache has been used.
+func (c *ResultCache) Stats() ResultCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	return stats
+}
+
+// Clear removes every finished result.
+func (c *ResultCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.remove(c.lru.Front().Value.(*resultEntry))
+	}
+}
+
+// remove removes a finished entry. c.mu must be held.
+func (c *ResultCache) remove(e *resultEntry) {
+	c.lru.Remove(e.elem)
+	delete(c.entries, e.key)
+}
+
+// keep returns whether the result of a search should be kept.
+func (c *ResultCache) keep(err error) bool {
+	return err == nil || (errors.Is(err, ErrNoSolution) && !c.opts.OnlySolutions)
+}
+
+// Do returns the result of the search with the key, calling search to find it unless it is cached or already running. The solution
+// returned is a copy, which the caller may modify.
+func (c *ResultCache) Do(ctx context.Context, key ResultKey, search func(context.Context) (grid.Placements, error)) (grid.Placements, error) {
+	if c == nil {
+		return search(ctx)
+	}
+	for {
+		c.mu.Lock()
+		e := c.entries[key]
+		if e != nil {
+			select {
+			case <-e.done:
+				if c.opts.TTL > 0 && time.Since(e.found) > c.opts.TTL {
+					c.remove(e)
+					c.stats.Evictions++
+					e = nil
+					break
+				}
+				c.lru.MoveToFront(e.elem)
+				c.stats.Hits++
+				c.mu.Unlock()
+				return slices.Clone(e.solution), e.err
+			default:
+				c.stats.Shared++
+				c.mu.Unlock()
+				select {
+				case <-e.done:
+				case <-ctx.Done():
+					return nil, contextError(ctx)
+				}
+				if e.kept {
+					return slices.Clone(e.solution), e.err
+				}
+				// The search was stopped before it finished, so run it again
+				continue
+			}
+		}
+
+		e = &resultEntry{key: key, done: make(chan struct{})}
+		c.entries[key] = e
+		c.stats.Misses++
+		c.mu.Unlock()
+		return c.run(ctx, e, search)
+	}
+}
+
+// run runs the search for a new entry, and keeps or discards its result.
+func (c *ResultCache) run(ctx context.Context, e *resultEntry, search func(context.Context) (grid.Placements, error)) (grid.Placements, error) {
+	// If the search panics, the searches waiting for it are woken to run it again
+	finished := false
+	defer func() {
+		if !finished {
+			c.finish(e, nil, errors.New("search panicked"))
+		}
+	}()
+	solution, err := search(ctx)
+	finished = true
+	c.finish(e, solution, err)
+	return solution, err
+}
+
+// finish records the result of an entry's search, and wakes the searches waiting for it.
+func (c *ResultCache) finish(e *resultEntry, solution grid.Placements, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.keep(err) {
+		e.solution, e.err, e.found, e.kept = slices.Clone(solution), err, time.Now(), true
+		e.elem = c.lru.PushFront(e)
+		for c.opts.MaxEntries > 0 && c.lru.Len() > c.opts.MaxEntries {
+			c.remove(c.lru.Back().Value.(*resultEntry))
+			c.stats.Evictions++
+		}
+	} else {
+		delete(c.entries, e.key)
+	}
+	close(e.done)
+}
+
+// CachingSolver is a Solver which remembers the results of another Solver in a ResultCache, which may be shared between solvers with
+// different strategies.
+type CachingSolver struct {
+	Solver Solver
+	Cache  *ResultCache
+	// Strategy names the placer, solver and options of Solver, so that results of other strategies aren't reused
+	Strategy string
+	// Prefix is the stones that Solver's solutions contain, e.g. because its starting points do, or nil if they are unrestricted
+	Prefix grid.Placements
+}
+
+func (s CachingSolver) Solve(g grid.Grid) (grid.Placements, error) {
+	return s.SolveContext(context.Background(), g)
+}
+
+func (s CachingSolver) SolveContext(ctx context.Context, g grid.Grid) (grid.Placements, error) {
+	return s.Cache.Do(ctx, NewResultKey(g, s.Strategy, s.Prefix), func(ctx context.Context) (grid.Placements, error) {
+		return s.Solver.SolveContext(ctx, g)
+	})
+}
@@ -0,0 +1,133 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+func TestResultCache(t *testing.T) {
+	ctx := context.Background()
+	solution := grid.Placements{{Row: 0, Col: 0}, {Row: 1, Col: 1}, {Row: 1, Col: 2}}
+	var searches int
+	search := func(result grid.Placements, err error) func(context.Context) (grid.Placements, error) {
+		return func(context.Context) (grid.Placements, error) {
+			searches++
+			return append(grid.Placements(nil), result...), err
+		}
+	}
+
+	c := NewResultCache(ResultCacheOptions{MaxEntries: 2})
+	three := NewResultKey(grid.Grid{Size: 3}, "test", nil)
+	for i := 0; i < 2; i++ {
+		got, err := c.Do(ctx, three, search(solution, nil))
+		if err != nil || !slices.Equal(got, solution) {
+			t.Fatalf("Do() = %v, %v, want %v", got, err, solution)
+		}
+		// Callers may modify the solutions they are given
+		got[0] = grid.Point{Row: 9, Col: 9}
+	}
+	eight := NewResultKey(grid.Grid{Size: 8}, "test", nil)
+	for i := 0; i < 2; i++ {
+		if _, err := c.Do(ctx, eight, search(nil, ErrNoSolution)); !errors.Is(err, ErrNoSolution) {
+			t.Fatalf("Do() error = %v, want %v", err, ErrNoSolution)
+		}
+	}
+	// Stopped searches aren't kept
+	canceled := NewResultKey(grid.Grid{Size: 9}, "test", nil)
+	for i := 0; i < 2; i++ {
+		c.Do(ctx, canceled, search(nil, ErrCanceled))
+	}
+	if searches != 4 {
+		t.Errorf("Do() searched %d times, want 4", searches)
+	}
+	if got, want := c.Stats(), (ResultCacheStats{Entries: 2, Hits: 2, Misses: 4}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+
+	// The third result evicts the least recently used, which was the 3x3 grid's
+	c.Do(ctx, NewResultKey(grid.Grid{Size: 3}, "other", nil), search(solution, nil))
+	c.Do(ctx, eight, search(nil, ErrNoSolution))
+	if got, want := c.Stats(), (ResultCacheStats{Entries: 2, Hits: 3, Misses: 5, Evictions: 1}); got != want {
+		t.Errorf("Stats() after eviction = %+v, want %+v", got, want)
+	}
+}
+
+func TestResultCache_Options(t *testing.T) {
+	ctx := context.Background()
+	var searches int
+	search := func(context.Context) (grid.Placements, error) {
+		searches++
+		return nil, ErrNoSolution
+	}
+	key := NewResultKey(grid.Grid{Size: 8}, "test", grid.Placements{{Row: 1, Col: 0}, {Row: 0, Col: 0}})
+
+	c := NewResultCache(ResultCacheOptions{OnlySolutions: true})
+	c.Do(ctx, key, search)
+	c.Do(ctx, key, search)
+	if searches != 2 {
+		t.Errorf("with OnlySolutions, Do() searched %d times, want 2", searches)
+	}
+
+	searches = 0
+	c = NewResultCache(ResultCacheOptions{TTL: time.Millisecond})
+	c.Do(ctx, key, search)
+	time.Sleep(2 * time.Millisecond)
+	c.Do(ctx, key, search)
+	if searches != 2 || c.Stats().Evictions != 1 {
+		t.Errorf("with a TTL, Do() searched %d times and evicted %d results, want 2 and 1", searches, c.Stats().Evictions)
+	}
+}
+
+func TestResultCache_Shared(t *testing.T) {
+	c := NewResultCache(ResultCacheOptions{})
+	key := NewResultKey(grid.Grid{Size: 7}, "test", nil)
+	var searches atomic.Int64
+	release := make(chan struct{})
+	s := CachingSolver{
+		Solver:   SingleThreadedSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}},
+		Cache:    c,
+		Strategy: "test",
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			got, err := c.Do(context.Background(), key, func(ctx context.Context) (grid.Placements, error) {
+				searches.Add(1)
+				<-release
+				return s.Solver.SolveContext(ctx, grid.Grid{Size: 7})
+			})
+			if err != nil {
+				t.Errorf("Do() error = %v", err)
+			}
+			if err := grid.CheckValidSolution(grid.Grid{Size: 7}, got); err != nil {
+				t.Errorf("Do() = %v, want valid solution", got)
+			}
+		}()
+	}
+	for c.Stats().Shared != 3 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if got := searches.Load(); got != 1 {
+		t.Errorf("identical concurrent searches ran %d times, want 1", got)
+	}
+
+	// CachingSolver uses the same key, so it is answered from the cache
+	if _, err := s.Solve(grid.Grid{Size: 7}); err != nil {
+		t.Errorf("CachingSolver.Solve() error = %v", err)
+	}
+	if got := c.Stats().Hits; got != 1 {
+		t.Errorf("CachingSolver.Solve() made %d hits, want 1", got)
+	}
+}
@@ -0,0 +1,58 @@
+package solver
+
+import (
+	"math/rand"
+	"slices"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+// splitMix64 is the SplitMix64 generator. It is tiny, fast and passes BigCrush, and streams seeded from distinct values through its
+// mixing function are independent for practical purposes, which makes it a good way to derive one stream per worker from one seed.
+type splitMix64 struct {
+	state uint64
+}
+
+// mix64 is SplitMix64's output function, a bijection which scrambles the bits of its input.
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func (s *splitMix64) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	return mix64(s.state)
+}
+
+func (s *splitMix64) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
+
+func (s *splitMix64) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// RandStreams derives an independent random number stream for each worker of a parallel search from a single seed, so that randomized
+// searches are reproducible bit for bit given the seed and the number of workers, however the workers are scheduled. Each worker must
+// only use its own stream, and the work given to each worker must not depend on timing.
+type RandStreams struct {
+	Seed int64
+}
+
+// Worker returns the stream of the worker with the given index. Calls with the same seed and index return generators that produce the
+// same sequence.
+func (r RandStreams) Worker(worker int) *rand.Rand {
+	return rand.New(&splitMix64{state: mix64(uint64(r.Seed) ^ mix64(uint64(worker)+1))})
+}
+
+// ShuffledStartingPoints returns the starting points of spp in an order shuffled by the seed, so that solvers which search them in turn,
+// or start a worker on each in turn, begin in a different part of the tree for each seed, and in the same part for the same seed.
+func ShuffledStartingPoints(spp StartingPointsProvider, seed int64) StartingPointsProvider {
+	return func(g grid.Grid) []grid.Placements {
+		startingPoints := slices.Clone(spp(g))
+		r := rand.New(&splitMix64{state: mix64(uint64(seed))})
+		r.Shuffle(len(startingPoints), func(i, j int) { startingPoints[i], startingPoints[j] = startingPoints[j], startingPoints[i] })
+		return startingPoints
+	}
+}
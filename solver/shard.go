@@ -0,0 +1,93 @@
+package solver
+
+import (
+	"fmt"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+// PrefixStartingPoints returns a StartingPointsProvider which splits the starting points of spp into every valid placement with depth stones,
+// the same tasks that FixedDepthSplittingSolver searches.
+func PrefixStartingPoints(spp StartingPointsProvider, spc placer.StonePlacerConstructor, depth int) StartingPointsProvider {
+	return func(g grid.Grid) []grid.Placements {
+		return FixedDepthSplittingSolver{StartingPointsProvider: spp, StonePlacerConstructor: spc, SplitDepth: depth}.Prefixes(g)
+	}
+}
+
+// Shard returns a StartingPointsProvider which selects one of shards disjoint subsets of the starting points of spp.
+// The selection is deterministic, so independent machines which each search a different index between them search every starting point.
+// Starting points are dealt out in turn, so that the expensive starting points near the corner are spread across shards.
+func Shard(spp StartingPointsProvider, shards, index int) StartingPointsProvider {
+	return func(g grid.Grid) []grid.Placements {
+		var startingPoints []grid.Placements
+		for i, sp := range spp(g) {
+			if i%shards == index {
+				startingPoints = append(startingPoints, sp)
+			}
+		}
+		return startingPoints
+	}
+}
+
+// ShardResult is the outcome of searching one shard.
+type ShardResult struct {
+	Size   uint8 `json:"size"`
+	Shards int   `json:"shards"`
+	Index  int   `json:"index"`
+	// Depth is the number of stones in each of the shard's starting points, or 0 if the shard was made from the starting points themselves.
+	Depth int `json:"depth"`
+	// StartingPoints is the number of starting points in the shard
+	StartingPoints int `json:"starting_points"`
+	// Solution is the solution found, if any.
+	Solution grid.Placements `json:"solution,omitempty"`
+	// Exhausted is true if every starting point in the shard was searched completely without finding a solution.
+	Exhausted bool   `json:"exhausted"`
+	Nodes     int64  `json:"nodes"`
+	Duration  string `json:"duration"`
+}
+
+// MergedShards is the combined verdict of a set of shard results.
+type MergedShards struct {
+	// Solution is a solution found by any shard, or nil.
+	Solution grid.Placements
+	// NoSolution is true if every shard was exhausted without finding a solution, which proves there are none.
+	NoSolution bool
+	// Missing lists the indexes of shards that have no result, or whose search was not finished.
+	Missing []int
+}
+
+// MergeShards combines the results of searching the shards of a grid. All results must be for the same grid and sharding.
+func MergeShards(results []ShardResult) (MergedShards, error) {
+	var merged MergedShards
+	if len(results) == 0 {
+		return merged, fmt.Errorf("no shard results to merge")
+	}
+	first := results[0]
+	finished := make(map[int]bool)
+	for _, r := range results {
+		if r.Size != first.Size || r.Shards != first.Shards || r.Depth != first.Depth {
+			return merged, fmt.Errorf("shard %d of %d for size %d at depth %d can't be merged with shard %d of %d for size %d at depth %d",
+				r.Index, r.Shards, r.Size, r.Depth, first.Index, first.Shards, first.Size, first.Depth)
+		}
+		if r.Index < 0 || r.Index >= r.Shards {
+			return merged, fmt.Errorf("shard index %d is out of range for %d shards", r.Index, r.Shards)
+		}
+		if r.Solution != nil {
+			if err := grid.CheckValidSolution(grid.Grid{Size: r.Size}, r.Solution); err != nil {
+				return merged, fmt.Errorf("shard %d reported an invalid solution %v: %w", r.Index, r.Solution, err)
+			}
+			merged.Solution = r.Solution
+		}
+		if r.Solution != nil || r.Exhausted {
+			finished[r.Index] = true
+		}
+	}
+	for i := 0; i < first.Shards; i++ {
+		if !finished[i] {
+			merged.Missing = append(merged.Missing, i)
+		}
+	}
+	merged.NoSolution = merged.Solution == nil && len(merged.Missing) == 0
+	return merged, nil
+}
@@ -0,0 +1,81 @@
+package solver
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+func TestShard(t *testing.T) {
+	g := grid.Grid{Size: 7}
+	spp := PrefixStartingPoints(SingleOctantStartingPoints, placer.OrderedNoAllocStonePlacerProvider{}, 2)
+	all := spp(g)
+	var union []grid.Placements
+	for i := 0; i < 3; i++ {
+		shard := Shard(spp, 3, i)(g)
+		if !reflect.DeepEqual(shard, Shard(spp, 3, i)(g)) {
+			t.Errorf("Shard(%d) is not deterministic", i)
+		}
+		union = append(union, shard...)
+	}
+	if len(union) != len(all) {
+		t.Errorf("shards have %d starting points in total, want %d", len(union), len(all))
+	}
+	seen := make(map[string]bool)
+	for _, sp := range union {
+		if seen[WeightKey(sp)] {
+			t.Errorf("starting point %v is in more than one shard", sp)
+		}
+		seen[WeightKey(sp)] = true
+	}
+}
+
+func TestMergeShards(t *testing.T) {
+	solution := grid.Placements{{Row: 0, Col: 0}, {Row: 1, Col: 1}, {Row: 1, Col: 2}}
+	tests := []struct {
+		name    string
+		results []ShardResult
+		want    MergedShards
+		wantErr bool
+	}{
+		{"all exhausted",
+			[]ShardResult{{Size: 8, Shards: 2, Index: 0, Exhausted: true}, {Size: 8, Shards: 2, Index: 1, Exhausted: true}},
+			MergedShards{NoSolution: true},
+			false,
+		},
+		{"missing shard",
+			[]ShardResult{{Size: 8, Shards: 3, Index: 1, Exhausted: true}, {Size: 8, Shards: 3, Index: 2}},
+			MergedShards{Missing: []int{0, 2}},
+			false,
+		},
+		{"solution",
+			[]ShardResult{{Size: 3, Shards: 2, Index: 1, Solution: solution}},
+			MergedShards{Solution: solution, Missing: []int{0}},
+			false,
+		},
+		{"invalid solution",
+			[]ShardResult{{Size: 3, Shards: 1, Index: 0, Solution: grid.Placements{{Row: 0, Col: 0}}}},
+			MergedShards{},
+			true,
+		},
+		{"mismatched sharding",
+			[]ShardResult{{Size: 8, Shards: 2, Index: 0, Exhausted: true}, {Size: 8, Shards: 3, Index: 1, Exhausted: true}},
+			MergedShards{},
+			true,
+		},
+		{"no results", nil, MergedShards{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeShards(tt.results)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("MergeShards() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeShards() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,21 +1,55 @@
 package solver
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"runtime"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/WillMorrison/pegboard-blog/grid"
 	"github.com/WillMorrison/pegboard-blog/placer"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
-	errNoSolutions = fmt.Errorf("no solutions exist")
+	// ErrNoSolution is returned by solvers that searched every placement without finding a solution.
+	ErrNoSolution = errors.New("no solutions exist")
+	// ErrCanceled is returned, wrapping context.Canceled, when a search is stopped because its context was canceled.
+	ErrCanceled = errors.New("search canceled")
+	// ErrTimeout is returned, wrapping context.DeadlineExceeded, when a search is stopped because its context's deadline passed.
+	ErrTimeout = errors.New("search timed out")
+	// ErrNodeBudget is the cause of the cancellation of a context returned by WithNodeBudget, and is wrapped with ErrCanceled when it
+	// stops a search.
+	ErrNodeBudget = errors.New("node budget exhausted")
 )
 
+// contextError returns the context's error wrapped with ErrCanceled or ErrTimeout, and the cause of its cancellation if it has one, or
+// nil if the context is not done.
+func contextError(ctx context.Context) error {
+	switch err := ctx.Err(); {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.DeadlineExceeded):
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	default:
+		if cause := context.Cause(ctx); cause != err {
+			return fmt.Errorf("%w: %w: %w", ErrCanceled, cause, err)
+		}
+		return fmt.Errorf("%w: %w", ErrCanceled, err)
+	}
+}
+
 type Solver interface {
 	// Solve returns either Placements such that IsValidSolution(grid, placements) == true, or an error
 	Solve(grid.Grid) (grid.Placements, error)
+
+	// SolveContext is like Solve, but stops searching when the context is done and returns the context's error.
+	SolveContext(context.Context, grid.Grid) (grid.Placements, error)
 }
 
 type StartingPointsProvider func(grid.Grid) []grid.Placements
@@ -44,81 +78,230 @@ func SingleOctantStartingPoints(g grid.Grid) []grid.Placements {
 	return startingPoints
 }
 
+// BidirectionalStartingPoints returns Placements for placers that grow placements from both ends of the grid, such as
+// placer.BidirectionalStonePlacerProvider. Each has a first stone in the same octant as SingleOctantStartingPoints, and a last stone
+// anywhere after it, so that they cover the same placements that SingleOctantStartingPoints does with a placer that places stones in order.
+// Ordered placers take both stones as coming first, so these starting points don't suit them.
+func BidirectionalStartingPoints(g grid.Grid) []grid.Placements {
+	if g.Size < 2 {
+		return SingleOctantStartingPoints(g)
+	}
+	var startingPoints []grid.Placements
+	for _, first := range SingleOctantStartingPoints(g) {
+		for last := grid.AdvanceStone(g, first[0]); grid.IsInBounds(g, last); last = grid.AdvanceStone(g, last) {
+			startingPoints = append(startingPoints, grid.Placements{first[0], last})
+		}
+	}
+	return startingPoints
+}
+
 type SingleThreadedSolver struct {
 	StartingPointsProvider StartingPointsProvider
 	StonePlacerConstructor placer.StonePlacerConstructor
+	// Stats, if not nil, collects statistics during the search
+	Stats *Stats
+	// Logger, if not nil, receives diagnostics about the search, such as when it starts and finishes
+	Logger *slog.Logger
+	// Events, if not nil, receives the search's events, such as solutions found and subtrees finished
+	Events *EventBus
+	// Pool, if not nil, limits the tasks searched at once, sharing the workers with the other searches that use it
+	Pool *Pool
 }
 
-func (s SingleThreadedSolver) dfs(sp placer.StonePlacer) (placer.StonePlacer, error) {
-	if len(sp.Placements()) == int(sp.Grid().Size) {
+// dfs implements depth first search. If the context is done, the search is aborted
+func (s SingleThreadedSolver) dfs(ctx context.Context, sp placer.StonePlacerV2, tc *taskCounter) (placer.StonePlacerV2, error) {
+	if sp.Remaining() == 0 {
 		return sp, nil
 	}
 
 	for !sp.Done() {
-		nextState, err := sp.Place()
+		nextState, err := sp.Place(ctx)
+		if placer.IsCanceled(err) {
+			return sp, ErrNoSolution
+		}
+		if s.Stats != nil {
+			s.Stats.recordPlace(sp, nextState, err)
+			tc.record(sp, nextState, err)
+		}
 		if err != nil {
 			continue
 		}
-		final, err := s.dfs(nextState)
+		final, err := s.dfs(ctx, nextState, tc)
 		if err != nil {
 			continue
 		}
 		return final, nil
 	}
-	return sp, errNoSolutions
+	return sp, ErrNoSolution
 }
 
 func (s SingleThreadedSolver) Solve(g grid.Grid) (grid.Placements, error) {
-	for _, sp := range s.StartingPointsProvider(g) {
-		start := s.StonePlacerConstructor.New(g, sp)
-		solution, err := s.dfs(start)
+	return s.SolveContext(context.Background(), g)
+}
+
+func (s SingleThreadedSolver) SolveContext(ctx context.Context, g grid.Grid) (solution grid.Placements, err error) {
+	ctx, span := startSolveSpan(ctx, "SingleThreadedSolver", g)
+	defer func() { endSolveSpan(span, solution, err) }()
+	logEnd := logSolve(ctx, s.Logger, "SingleThreadedSolver", g)
+	defer func() { logEnd(solution, err) }()
+
+	startingPoints := s.StartingPointsProvider(g)
+	if s.Stats != nil {
+		s.Stats.TasksTotal.Add(int64(len(startingPoints)))
+		s.Stats.setStartingPoints(startingPoints)
+	}
+	spc := placer.AdaptV2Constructor(s.StonePlacerConstructor)
+	job := s.Pool.Job()
+	for i, sp := range startingPoints {
+		if err := job.Acquire(ctx); err != nil {
+			return nil, contextError(ctx)
+		}
+		start := spc.New(g, sp)
+		if s.Stats != nil {
+			s.Stats.recordStart(start)
+		}
+		_, spSpan := startSubtreeSpan(ctx, "StartingPoint", sp)
+		tc := newTaskCounter(s.Stats, 0, i)
+		solution, err := s.dfs(ctx, start, &tc)
+		spSpan.End()
+		job.Release()
+		loggerOrDiscard(s.Logger).DebugContext(ctx, "starting point searched", "starting_point", sp, "solved", err == nil, "duration", time.Since(tc.start))
+		tc.solved = err == nil
+		if s.Stats != nil {
+			s.Stats.recordTask(&tc)
+		}
+		if ctx.Err() != nil {
+			return nil, contextError(ctx)
+		}
 		if err != nil {
+			// The starting point was exhausted. Like the parallel solvers, the one the solution is found below isn't counted as done
+			if s.Stats != nil {
+				s.Stats.TasksDone.Add(1)
+				s.Stats.recordSubtreeDone(i)
+			}
+			s.Events.publishSubtreeDone(&tc, sp)
 			continue
 		}
+		s.Events.publishSolution(&tc, solution.Placements())
+		s.Events.publishSubtreeDone(&tc, sp)
 		return solution.Placements(), nil
 	}
-	return nil, errNoSolutions
+	return nil, ErrNoSolution
 }
 
 type AsyncSolver struct {
 	StartingPointsProvider StartingPointsProvider
 	StonePlacerConstructor placer.StonePlacerConstructor
+	// Stats, if not nil, collects statistics during the search
+	Stats *Stats
+	// WorkerInit, if not nil, is called at the start of each worker goroutine with the worker's index, e.g. to pin it to a CPU
+	WorkerInit func(worker int)
+	// Logger, if not nil, receives diagnostics about the search, such as when it starts and finishes
+	Logger *slog.Logger
+	// Events, if not nil, receives the search's events, such as solutions found and subtrees finished
+	Events *EventBus
+	// Pool, if not nil, limits the tasks searched at once, sharing the workers with the other searches that use it
+	Pool *Pool
 }
 
 // dfs implements depth first search, and returns any found solutions on the solution channel.
-// If the done channel is closed, the search is aborted
-func (s AsyncSolver) dfs(sp placer.StonePlacer, solution chan<- grid.Placements, done <-chan struct{}) {
+// If the context is done, the search is aborted
+func (s AsyncSolver) dfs(ctx context.Context, sp placer.StonePlacerV2, solution chan<- grid.Placements, tc *taskCounter) {
+	if sp.Remaining() == 0 {
+		// Only a starting point can be complete already, as complete placements below it are sent as soon as they are placed
+		sendSolution(s.Events, sp, solution, ctx.Done(), tc)
+		return
+	}
 	for !sp.Done() {
-		select {
-		// If done channel is closed, abort search
-		case <-done:
+		nextState, err := sp.Place(ctx)
+		if placer.IsCanceled(err) {
 			return
-		default:
 		}
-		nextState, err := sp.Place()
+		if s.Stats != nil {
+			s.Stats.recordPlace(sp, nextState, err)
+			tc.record(sp, nextState, err)
+		}
 		if err != nil {
 			continue
 		}
-		if len(nextState.Placements()) == int(nextState.Grid().Size) {
-			solution <- nextState.Placements()
+		if nextState.Remaining() == 0 {
+			sendSolution(s.Events, nextState, solution, ctx.Done(), tc)
 			return
 		}
-		s.dfs(nextState, solution, done)
+		s.dfs(ctx, nextState, solution, tc)
+	}
+}
+
+// sendSolution sends the complete placements of sp on the solution channel, unless the search is aborted first.
+func sendSolution(events *EventBus, sp placer.PlacementState, solution chan<- grid.Placements, done <-chan struct{}, tc *taskCounter) {
+	tc.solved = true
+	// Send a copy, as the placer's memory may be reused by the rest of the search before it is aborted.
+	// Another worker may have already sent a solution and stopped the search.
+	found := sp.AppendPlacements(make(grid.Placements, 0, sp.Grid().Size))
+	events.publishSolution(tc, found)
+	select {
+	case solution <- found:
+	case <-done:
 	}
 }
 
 func (s AsyncSolver) Solve(g grid.Grid) (grid.Placements, error) {
+	return s.SolveContext(context.Background(), g)
+}
+
+func (s AsyncSolver) SolveContext(ctx context.Context, g grid.Grid) (solution grid.Placements, err error) {
+	ctx, span := startSolveSpan(ctx, "AsyncSolver", g)
+	defer func() { endSolveSpan(span, solution, err) }()
+	logEnd := logSolve(ctx, s.Logger, "AsyncSolver", g)
+	defer func() { logEnd(solution, err) }()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	wg := sync.WaitGroup{}
-	done := make(chan struct{})
+	// Stop the workers and wait for them before returning, so that their statistics are complete
+	defer func() { cancel(); wg.Wait() }()
+	done := ctx.Done()
 	solutions := make(chan grid.Placements, 1)
-	for _, sp := range s.StartingPointsProvider(g) {
-		start := s.StonePlacerConstructor.New(g, sp)
+	startingPoints := s.StartingPointsProvider(g)
+	if s.Stats != nil {
+		s.Stats.TasksTotal.Add(int64(len(startingPoints)))
+		s.Stats.setStartingPoints(startingPoints)
+	}
+	spc := placer.AdaptV2Constructor(s.StonePlacerConstructor)
+	job := s.Pool.Job()
+	for i, sp := range startingPoints {
+		start := spc.New(g, sp)
+		if s.Stats != nil {
+			s.Stats.recordStart(start)
+		}
+		_, spSpan := startSubtreeSpan(ctx, "StartingPoint", sp)
 		wg.Add(1)
-		go func() {
+		go func(worker int) {
 			defer wg.Done()
-			s.dfs(start, solutions, done)
-
-		}()
+			defer spSpan.End()
+			if s.WorkerInit != nil {
+				s.WorkerInit(worker)
+			}
+			if err := job.Acquire(ctx); err != nil {
+				return
+			}
+			tc := newTaskCounter(s.Stats, worker, worker)
+			s.dfs(ctx, start, solutions, &tc)
+			job.Release()
+			if s.Stats != nil {
+				s.Stats.recordTask(&tc)
+			}
+			select {
+			case <-done: // The starting point was abandoned, not finished
+			default:
+				loggerOrDiscard(s.Logger).DebugContext(ctx, "starting point searched", "starting_point", startingPoints[worker], "solved", tc.solved, "duration", time.Since(tc.start))
+				s.Events.publishSubtreeDone(&tc, startingPoints[worker])
+				if s.Stats != nil {
+					s.Stats.TasksDone.Add(1)
+					s.Stats.recordSubtreeDone(worker)
+				}
+			}
+		}(i)
 	}
 	go func() {
 		// If wg.Wait returns, all dfs searches should have completed.
@@ -128,35 +311,77 @@ func (s AsyncSolver) Solve(g grid.Grid) (grid.Placements, error) {
 		case <-done:
 			return
 		// Or none might have found a solution, in which case send a nil to the solutions channel to unblock Solve's receiver
-		// Keep in mind we might have returned from Wait before Solve closed done, so send nil in a nonblocking manner.
+		// Keep in mind we might have returned from Wait before Solve cancelled the search, so send nil in a nonblocking manner.
 		case solutions <- nil:
 		default:
 		}
 	}()
 
-	solution := <-solutions
-	close(done)
+	select {
+	case solution = <-solutions:
+	case <-done:
+		// The parent context is done, since nothing else cancels ctx until we return
+		return nil, contextError(ctx)
+	}
+	cancel()
 	if solution != nil {
 		return solution, nil
 	}
-	return nil, errNoSolutions
+	return nil, ErrNoSolution
 }
 
 type AsyncSplittingSolver struct {
 	StartingPointsProvider StartingPointsProvider
 	StonePlacerConstructor placer.StonePlacerConstructor
+	// Stats, if not nil, collects statistics during the search
+	Stats *Stats
+	// WorkerInit, if not nil, is called at the start of each worker goroutine with the worker's index, e.g. to pin it to a CPU
+	WorkerInit func(worker int)
+	// Workers is the number of worker goroutines, or 0 for one per CPU that Go can use, GOMAXPROCS.
+	Workers int
+	// MinSplitRemaining is the number of stones that must be left to place below a node for a worker to hand it to an idle worker
+	// while every other worker is busy, or 0 for half the grid size. Deep nodes have small subtrees, which cost more to hand over than
+	// to search. As more workers wait for work the limit falls, until any node is handed over when all the others are waiting.
+	MinSplitRemaining int
+	// Logger, if not nil, receives diagnostics about the search, such as when it starts and finishes
+	Logger *slog.Logger
+	// Events, if not nil, receives the search's events, such as solutions found and subtrees finished
+	Events *EventBus
+	// Pool, if not nil, limits the tasks searched at once, sharing the workers with the other searches that use it
+	Pool *Pool
+}
+
+// splitThreshold returns the number of stones that must be left to place below a node for it to be handed over, when idle of the
+// workers are waiting for work. It falls linearly from minRemaining when one worker is waiting to 1 when all the others are.
+func splitThreshold(idle, workers, minRemaining int) int {
+	if workers <= 2 || idle >= workers-1 {
+		return 1
+	}
+	busy := workers - 1 - idle
+	return 1 + (minRemaining-1)*busy/(workers-2)
+}
+
+// numWorkers returns the number of workers to start for a configured number, 0 meaning one per CPU that Go can use.
+func numWorkers(workers int) int {
+	if workers > 0 {
+		return workers
+	}
+	return runtime.GOMAXPROCS(0)
 }
 
 type workRequest struct {
 	// The sender of the request owns the memory for the response placements, so provide that memory to the sender
 	Placements grid.Placements
+	// StartingPoint is the index of the starting point that the response placements are below
+	StartingPoint int
 	// The channel that the requester will wait on for a response.
 	Response   chan grid.Placements
 }
 
 // Send will reply to the request for work. It does not transfer ownership of the memory associated with the Placements slice.
 // Returns when either the response is sent, or the done channel is closed.
-func (wr *workRequest) Send(p grid.Placements, done <-chan struct{}) {
+func (wr *workRequest) Send(p grid.Placements, startingPoint int, done <-chan struct{}) {
+	wr.StartingPoint = startingPoint
 	wr.Placements = wr.Placements[:len(p)]
 	copy(wr.Placements, p)
 	select {
@@ -166,37 +391,56 @@ func (wr *workRequest) Send(p grid.Placements, done <-chan struct{}) {
 }
 
 // dfs implements depth first search, and returns any found solutions on the solution channel.
-// If the done channel is closed, the search is aborted
+// If the context is done, the search is aborted
 // Work is split as requests are available in the work channel
-func (s AsyncSplittingSolver) dfs(sp placer.StonePlacer, solution chan<- grid.Placements, done <-chan struct{}, work chan *workRequest) {
+func (s AsyncSplittingSolver) dfs(ctx context.Context, sp placer.StonePlacerV2, solution chan<- grid.Placements, work chan *workRequest, tc *taskCounter) {
+	if sp.Remaining() == 0 {
+		// Only a starting point can be complete already, as complete placements below it are sent as soon as they are placed
+		sendSolution(s.Events, sp, solution, ctx.Done(), tc)
+		return
+	}
 	for !sp.Done() {
-		select {
-		// If done channel is closed, abort search
-		case <-done:
+		nextState, err := sp.Place(ctx)
+		if placer.IsCanceled(err) {
 			return
-		default:
 		}
-		nextState, err := sp.Place()
+		if s.Stats != nil {
+			s.Stats.recordPlace(sp, nextState, err)
+			tc.record(sp, nextState, err)
+		}
 		if err != nil {
 			continue
 		}
-		if len(nextState.Placements()) == int(nextState.Grid().Size) {
-			solution <- nextState.Placements()
+		if nextState.Remaining() == 0 {
+			sendSolution(s.Events, nextState, solution, ctx.Done(), tc)
 			return
 		}
 
-		select {
-		// Split work if there is a request in the work channel. The requesting worker will eventually pick up this part of the search and we can move on.
-		case request := <-work:
-			request.Send(nextState.Placements(), done)
-		default:
-			s.dfs(nextState, solution, done, work)
+		// Split work if there is a request in the work channel and the subtree is worth handing over while that many workers wait.
+		// The requesting worker will eventually pick up this part of the search and we can move on.
+		if idle := len(work); idle > 0 && nextState.Remaining() >= splitThreshold(idle, s.Workers, s.MinSplitRemaining) {
+			select {
+			case request := <-work:
+				// Count the subtree before handing it over, so that it can't finish before its starting point has it
+				s.Stats.recordSplit(tc.startingPoint)
+				request.Send(nextState.Placements(), tc.startingPoint, ctx.Done())
+				tc.split = true
+				if s.Events != nil {
+					s.Events.Publish(Event{Kind: EventSplit, Worker: tc.workerIndex, StartingPoint: tc.startingPoint, Placements: nextState.AppendPlacements(nil)})
+				}
+				continue
+			default:
+			}
 		}
+		s.dfs(ctx, nextState, solution, work, tc)
 	}
 }
 
 // worker adds requests to the work channel when idle, and listens for tasks to come back or the done channel to be closed.
-func (s AsyncSplittingSolver) worker(g grid.Grid, solutions chan<- grid.Placements, done <-chan struct{}, work chan *workRequest) {
+// Each task a worker receives, whether a starting point or a split off subtree, is recorded as a span.
+func (s AsyncSplittingSolver) worker(ctx context.Context, worker int, g grid.Grid, solutions chan<- grid.Placements, work chan *workRequest, job *PoolJob) {
+	done := ctx.Done()
+	spc := placer.AdaptV2Constructor(s.StonePlacerConstructor)
 	request := workRequest{
 		Placements: make(grid.Placements, 0, g.Size),
 		Response:   make(chan grid.Placements),
@@ -204,11 +448,32 @@ func (s AsyncSplittingSolver) worker(g grid.Grid, solutions chan<- grid.Placemen
 	for {
 		select {
 		case work <- &request: // Request some work to do
+			s.Stats.recordIdle(1)
 			select {
 			case p := <-request.Response:
-				sp := s.StonePlacerConstructor.New(g, p)
-				s.dfs(sp, solutions, done, work)
+				s.Stats.recordIdle(-1)
+				if err := job.Acquire(ctx); err != nil {
+					return
+				}
+				_, span := startSubtreeSpan(ctx, "Subtree", p)
+				sp := spc.New(g, p)
+				tc := newTaskCounter(s.Stats, worker, request.StartingPoint)
+				s.dfs(ctx, sp, solutions, work, &tc)
+				job.Release()
+				if s.Stats != nil {
+					s.Stats.recordTask(&tc)
+				}
+				select {
+				case <-done: // The subtree was abandoned, not finished
+				default:
+					if s.Stats != nil {
+						s.Stats.recordSubtreeDone(tc.startingPoint)
+					}
+					s.Events.publishSubtreeDone(&tc, slices.Clone(p))
+				}
+				span.End()
 			case <-done:
+				s.Stats.recordIdle(-1)
 				return
 			}
 		case <-done: // Exit if a solution was found by some worker
@@ -218,21 +483,44 @@ func (s AsyncSplittingSolver) worker(g grid.Grid, solutions chan<- grid.Placemen
 }
 
 func (s AsyncSplittingSolver) Solve(g grid.Grid) (grid.Placements, error) {
-	numWorkers := runtime.NumCPU()
+	return s.SolveContext(context.Background(), g)
+}
+
+func (s AsyncSplittingSolver) SolveContext(ctx context.Context, g grid.Grid) (solution grid.Placements, err error) {
+	ctx, span := startSolveSpan(ctx, "AsyncSplittingSolver", g)
+	defer func() { endSolveSpan(span, solution, err) }()
+	logEnd := logSolve(ctx, s.Logger, "AsyncSplittingSolver", g)
+	defer func() { logEnd(solution, err) }()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	numWorkers := numWorkers(s.Workers)
+	// The workers read these from their copy of the solver
+	s.Workers = numWorkers
+	if s.MinSplitRemaining <= 0 {
+		s.MinSplitRemaining = int(g.Size) / 2
+	}
 
 	wg := sync.WaitGroup{}
+	// Stop the workers and wait for them before returning, so that their statistics are complete
+	workers := sync.WaitGroup{}
+	defer func() { cancel(); workers.Wait() }()
 	work := make(chan *workRequest, numWorkers)
-	done := make(chan struct{})
+	done := ctx.Done()
 	solutions := make(chan grid.Placements, 1)
 
 	// Add starting points to work queue
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		for _, sp := range s.StartingPointsProvider(g) {
+		startingPoints := s.StartingPointsProvider(g)
+		if s.Stats != nil {
+			s.Stats.setStartingPoints(startingPoints)
+		}
+		for i, sp := range startingPoints {
 			select {
 			case request := <-work:
-				request.Send(sp, done) // Queue some work to do
+				request.Send(sp, i, done) // Queue some work to do
 			case <-done: // Exit if a solution was found by some worker
 				return
 			}
@@ -240,10 +528,16 @@ func (s AsyncSplittingSolver) Solve(g grid.Grid) (grid.Placements, error) {
 	}()
 
 	// Start workers
+	job := s.Pool.Job()
 	for i := 0; i < numWorkers; i++ {
-		go func() {
-			s.worker(g, solutions, done, work)
-		}()
+		workers.Add(1)
+		go func(worker int) {
+			defer workers.Done()
+			if s.WorkerInit != nil {
+				s.WorkerInit(worker)
+			}
+			s.worker(ctx, worker, g, solutions, work, job)
+		}(i)
 	}
 
 	go func() {
@@ -263,16 +557,211 @@ func (s AsyncSplittingSolver) Solve(g grid.Grid) (grid.Placements, error) {
 		case <-done:
 			return
 		// Or none might have found a solution, in which case send a nil to the solutions channel to unblock Solve's receiver
-		// Keep in mind we might have returned from Wait before Solve closed done, so send nil in a nonblocking manner.
+		// Keep in mind we might have returned from Wait before Solve cancelled the search, so send nil in a nonblocking manner.
 		case solutions <- nil:
 		default:
 		}
 	}()
 
-	solution := <-solutions
-	close(done)
+	select {
+	case solution = <-solutions:
+	case <-done:
+		// The parent context is done, since nothing else cancels ctx until we return
+		return nil, contextError(ctx)
+	}
+	cancel()
+	if solution != nil {
+		return solution, nil
+	}
+	return nil, ErrNoSolution
+}
+
+// FixedDepthSplittingSolver splits the search into tasks at a fixed depth: every valid placement of SplitDepth stones is enumerated up front,
+// and workers search below each of them. This gives predictable work granularity and simple progress accounting (tasks done / total tasks),
+// at the cost of enumerating the prefixes before the search starts.
+type FixedDepthSplittingSolver struct {
+	StartingPointsProvider StartingPointsProvider
+	StonePlacerConstructor placer.StonePlacerConstructor
+	// SplitDepth is the number of stones placed in each task's prefix. Starting points with at least this many stones are tasks themselves.
+	SplitDepth int
+	// Stats, if not nil, collects statistics during the search
+	Stats *Stats
+	// WorkerInit, if not nil, is called at the start of each worker goroutine with the worker's index, e.g. to pin it to a CPU
+	WorkerInit func(worker int)
+	// Workers is the number of worker goroutines, or 0 for one per CPU that Go can use, GOMAXPROCS.
+	Workers int
+	// Logger, if not nil, receives diagnostics about the search, such as when it starts and finishes
+	Logger *slog.Logger
+	// Events, if not nil, receives the search's events, such as solutions found and subtrees finished
+	Events *EventBus
+	// Pool, if not nil, limits the tasks searched at once, sharing the workers with the other searches that use it
+	Pool *Pool
+}
+
+// prefixes appends copies of all valid placements below sp with SplitDepth stones (or complete solutions, if the grid is small) to out.
+// If the context is done, the enumeration is aborted and the prefixes found so far are returned.
+func (s FixedDepthSplittingSolver) prefixes(ctx context.Context, sp placer.StonePlacerV2, out []grid.Placements) []grid.Placements {
+	if sp.Depth() >= s.SplitDepth || sp.Remaining() == 0 {
+		return append(out, sp.AppendPlacements(make(grid.Placements, 0, sp.Grid().Size)))
+	}
+	for !sp.Done() {
+		nextState, err := sp.Place(ctx)
+		if placer.IsCanceled(err) {
+			return out
+		}
+		if err != nil {
+			continue
+		}
+		out = s.prefixes(ctx, nextState, out)
+	}
+	return out
+}
+
+// Prefixes returns the tasks that the search of g is split into.
+func (s FixedDepthSplittingSolver) Prefixes(g grid.Grid) []grid.Placements {
+	tasks, _, _, _ := s.tasks(context.Background(), g)
+	return tasks
+}
+
+// tasks returns the starting points, the tasks they are split into, and the index of the starting point that each task is below.
+// Enumerating the tasks can take a long time for deep splits, so it stops if the context is done, and returns the context's error.
+func (s FixedDepthSplittingSolver) tasks(ctx context.Context, g grid.Grid) (tasks []grid.Placements, origins []int, startingPoints []grid.Placements, err error) {
+	spc := placer.AdaptV2Constructor(s.StonePlacerConstructor)
+	startingPoints = s.StartingPointsProvider(g)
+	for i, sp := range startingPoints {
+		tasks = s.prefixes(ctx, spc.New(g, sp), tasks)
+		if err := contextError(ctx); err != nil {
+			return nil, nil, nil, err
+		}
+		for len(origins) < len(tasks) {
+			origins = append(origins, i)
+		}
+	}
+	return tasks, origins, startingPoints, nil
+}
+
+func (s FixedDepthSplittingSolver) Solve(g grid.Grid) (grid.Placements, error) {
+	return s.SolveContext(context.Background(), g)
+}
+
+func (s FixedDepthSplittingSolver) SolveContext(ctx context.Context, g grid.Grid) (solution grid.Placements, err error) {
+	ctx, span := startSolveSpan(ctx, "FixedDepthSplittingSolver", g)
+	defer func() { endSolveSpan(span, solution, err) }()
+	logEnd := logSolve(ctx, s.Logger, "FixedDepthSplittingSolver", g)
+	defer func() { logEnd(solution, err) }()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	_, prefixesSpan := tracer().Start(ctx, "Prefixes", trace.WithAttributes(attribute.Int("pegboard.split_depth", s.SplitDepth)))
+	tasks, origins, startingPoints, err := s.tasks(ctx, g)
+	prefixesSpan.SetAttributes(attribute.Int("pegboard.tasks", len(tasks)))
+	prefixesSpan.End()
+	if err != nil {
+		return nil, err
+	}
+	if s.Stats != nil {
+		s.Stats.TasksTotal.Add(int64(len(tasks)))
+		s.Stats.setStartingPoints(startingPoints)
+		s.Stats.setSubtrees(origins)
+	}
+
+	wg := sync.WaitGroup{}
+	// Stop the workers and wait for them before returning, so that their statistics are complete
+	defer func() { cancel(); wg.Wait() }()
+	done := ctx.Done()
+	queue := make(chan int)
+	solutions := make(chan grid.Placements, 1)
+
+	// Add the indexes of the tasks to the queue
+	go func() {
+		defer close(queue)
+		for i := range tasks {
+			select {
+			case queue <- i:
+			case <-done: // Exit if a solution was found by some worker
+				return
+			}
+		}
+	}()
+
+	// Start workers. Below the split depth the search is the same as AsyncSolver's.
+	searcher := AsyncSolver{Stats: s.Stats, Events: s.Events}
+	spc := placer.AdaptV2Constructor(s.StonePlacerConstructor)
+	job := s.Pool.Job()
+	for i := 0; i < numWorkers(s.Workers); i++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			if s.WorkerInit != nil {
+				s.WorkerInit(worker)
+			}
+			for i := range queue {
+				task := tasks[i]
+				if len(task) == int(g.Size) {
+					// Small grids may have complete solutions as tasks
+					tc := taskCounter{workerIndex: worker, startingPoint: origins[i], start: time.Now(), solved: true}
+					if s.Stats != nil {
+						s.Stats.recordTask(&tc)
+					}
+					s.Events.publishSolution(&tc, task)
+					select {
+					case solutions <- task:
+					case <-done:
+					}
+					return
+				}
+				if err := job.Acquire(ctx); err != nil {
+					return
+				}
+				_, taskSpan := startSubtreeSpan(ctx, "Task", task)
+				start := spc.New(g, task)
+				if s.Stats != nil {
+					s.Stats.recordStart(start)
+				}
+				tc := newTaskCounter(s.Stats, worker, origins[i])
+				searcher.dfs(ctx, start, solutions, &tc)
+				job.Release()
+				if s.Stats != nil {
+					s.Stats.recordTask(&tc)
+				}
+				taskSpan.End()
+				select {
+				case <-done: // The task was abandoned, not finished
+					return
+				default:
+				}
+				s.Events.publishSubtreeDone(&tc, task)
+				if s.Stats != nil {
+					s.Stats.TasksDone.Add(1)
+					s.Stats.recordSubtreeDone(origins[i])
+				}
+			}
+		}(i)
+	}
+
+	go func() {
+		// If wg.Wait returns, all tasks have been searched or the search was aborted.
+		wg.Wait()
+		select {
+		// They might have completed if one found a solution, in which case just abort
+		case <-done:
+			return
+		// Or none might have found a solution, in which case send a nil to the solutions channel to unblock Solve's receiver
+		// Keep in mind we might have returned from Wait before Solve cancelled the search, so send nil in a nonblocking manner.
+		case solutions <- nil:
+		default:
+		}
+	}()
+
+	select {
+	case solution = <-solutions:
+	case <-done:
+		// The parent context is done, since nothing else cancels ctx until we return
+		return nil, contextError(ctx)
+	}
+	cancel()
 	if solution != nil {
 		return solution, nil
 	}
-	return nil, errNoSolutions
+	return nil, ErrNoSolution
 }
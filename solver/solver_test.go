@@ -1,11 +1,20 @@
 package solver
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
 	"reflect"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/WillMorrison/pegboard-blog/grid"
 	"github.com/WillMorrison/pegboard-blog/placer"
+	"github.com/WillMorrison/pegboard-blog/pruner"
+	"github.com/WillMorrison/pegboard-blog/sets"
 )
 
 func TestSingleOctantStartingPoints(t *testing.T) {
@@ -50,13 +59,52 @@ func TestSolver_Solve(t *testing.T) {
 		solver Solver
 	}{
 		{"SingleThreadedSolver",
-			SingleThreadedSolver{SingleOctantStartingPoints, placer.OrderedNoAllocStonePlacerProvider{}},
+			SingleThreadedSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}},
 		},
 		{"AsyncSolver",
-			AsyncSolver{SingleOctantStartingPoints, placer.OrderedNoAllocStonePlacerProvider{}},
+			AsyncSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}},
 		},
 		{"AsyncSplittingSolver",
-			AsyncSplittingSolver{SingleOctantStartingPoints, placer.OrderedNoAllocStonePlacerProvider{}},
+			AsyncSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}},
+		},
+		{"AsyncSplittingSolver/Workers",
+			AsyncSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, Workers: 4, MinSplitRemaining: 3},
+		},
+		{"AsyncSplittingSolver/Pool",
+			AsyncSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, Workers: 4, Pool: NewPool(2)},
+		},
+		{"AsyncSolver/Pool",
+			AsyncSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, Pool: NewPool(1)},
+		},
+		{"FixedDepthSplittingSolver",
+			FixedDepthSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, SplitDepth: 3},
+		},
+		{"AsyncSplittingSolver/Bitboard",
+			AsyncSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedBitboardStonePlacerProvider{}},
+		},
+		{"AsyncSplittingSolver/Bitboard/Bound",
+			AsyncSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedBitboardStonePlacerProvider{Bound: true}},
+		},
+		{"AsyncSplittingSolver/OpportunisticPruning/Bound",
+			AsyncSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedOpportunisticPruningNoAllocStonePlacerProvider{PrunerConstructor: pruner.NewPrecomputedPruner, Bound: true}},
+		},
+		{"AsyncSplittingSolver/Pruning/Bound/Forced",
+			AsyncSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedPruningNoAllocStonePlacerProvider{PrunerConstructor: pruner.NewPrecomputedPruner, Bound: true, Forced: true}},
+		},
+		{"FixedDepthSplittingSolver/Pruning/Bound/Forced",
+			FixedDepthSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedPruningNoAllocStonePlacerProvider{PrunerConstructor: pruner.NewPrecomputedPruner, Bound: true, Forced: true}, SplitDepth: 3},
+		},
+		{"TimeSlicedSolver",
+			TimeSlicedSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}},
+		},
+		{"TimeSlicedSolver/Pruning/Bound/Forced",
+			TimeSlicedSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedPruningNoAllocStonePlacerProvider{PrunerConstructor: pruner.NewPrecomputedPruner, Bound: true, Forced: true}, Slice: time.Millisecond},
+		},
+		{"AsyncSplittingSolver/Bidirectional",
+			AsyncSplittingSolver{StartingPointsProvider: BidirectionalStartingPoints, StonePlacerConstructor: placer.BidirectionalStonePlacerProvider{}},
+		},
+		{"AsyncSplittingSolver/ImpactOrderedPruning",
+			AsyncSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.ImpactOrderedPruningStonePlacerProvider{PrunerConstructor: pruner.NewPrecomputedPruner}},
 		},
 	}
 	for _, tt := range tests {
@@ -74,16 +122,455 @@ func TestSolver_Solve(t *testing.T) {
 				}
 			})
 
+			t.Run("Cancelled", func(t *testing.T) {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				_, err := tt.solver.SolveContext(ctx, grid.Grid{Size: 8})
+				if !errors.Is(err, context.Canceled) || !errors.Is(err, ErrCanceled) {
+					t.Errorf("%+v.SolveContext() error = %v, want %v and %v", tt.solver, err, ErrCanceled, context.Canceled)
+				}
+			})
+
+			t.Run("TimedOut", func(t *testing.T) {
+				ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+				defer cancel()
+				_, err := tt.solver.SolveContext(ctx, grid.Grid{Size: 8})
+				if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, ErrTimeout) {
+					t.Errorf("%+v.SolveContext() error = %v, want %v and %v", tt.solver, err, ErrTimeout, context.DeadlineExceeded)
+				}
+			})
+
 			t.Run("NoSolution", func(t *testing.T) {
 				if testing.Short() {
 					t.Skip("skipping test in short mode.")
 				}
 				g := grid.Grid{Size: 8}
 				_, err := tt.solver.Solve(g)
-				if err == nil {
-					t.Errorf("%+v.Solve() error = %v: want err", tt.solver, err)
+				if !errors.Is(err, ErrNoSolution) {
+					t.Errorf("%+v.Solve() error = %v: want %v", tt.solver, err, ErrNoSolution)
 				}
 			})
 		})
 	}
 }
+
+func TestSolver_Stats(t *testing.T) {
+	g := grid.Grid{Size: 6}
+	stats := &Stats{}
+	s := SingleThreadedSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, Stats: stats}
+	solution, err := s.Solve(g)
+	if err != nil {
+		t.Fatalf("Solve() error = %v", err)
+	}
+	if got := stats.Nodes.Load(); got < int64(g.Size) {
+		t.Errorf("Stats.Nodes = %d, want at least %d", got, g.Size)
+	}
+	if got := stats.Deepest(); !reflect.DeepEqual(got, solution) {
+		t.Errorf("Stats.Deepest() = %v, want solution %v", got, solution)
+	}
+	if total := stats.TasksTotal.Load(); total != int64(len(SingleOctantStartingPoints(g))) {
+		t.Errorf("Stats.TasksTotal = %d, want one per starting point", total)
+	}
+	// A solved task is not counted as done, as for the parallel solvers, so only the starting points before the solution's are
+	var exhausted int64
+	for _, sp := range stats.StartingPoints() {
+		if sp.Exhausted() {
+			exhausted++
+		}
+	}
+	done := stats.TasksDone.Load()
+	if done != exhausted || done >= stats.TasksTotal.Load() {
+		t.Fatalf("Stats.TasksDone = %d, want the %d exhausted starting points, fewer than TasksTotal since the solution's starting point was not exhausted", done, exhausted)
+	}
+	for _, p := range stats.StartingPoints()[done].StartingPoint {
+		if !slices.Contains(solution, p) {
+			t.Errorf("the first starting point not done is %v, want the solution %v's", stats.StartingPoints()[done].StartingPoint, solution)
+		}
+	}
+}
+
+func TestWithNodeBudget(t *testing.T) {
+	// 8x8 has no solution, so the search only stops early because of the budget
+	g := grid.Grid{Size: 8}
+	stats := &Stats{}
+	s := SingleThreadedSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, Stats: stats}
+	ctx, cancel := WithNodeBudget(context.Background(), stats, 1000)
+	defer cancel()
+	_, err := s.SolveContext(ctx, g)
+	if !errors.Is(err, ErrNodeBudget) || !errors.Is(err, ErrCanceled) {
+		t.Fatalf("SolveContext() error = %v, want %v wrapped with %v", err, ErrNodeBudget, ErrCanceled)
+	}
+	if got := stats.Nodes.Load(); got < 1000 || got > 1010 {
+		t.Errorf("Stats.Nodes = %d, want the search to stop at the budget of 1000", got)
+	}
+	if len(stats.Deepest()) == 0 {
+		t.Errorf("Stats.Deepest() is empty, want the deepest placement reached within the budget")
+	}
+}
+
+func TestStats_Splits(t *testing.T) {
+	g := grid.Grid{Size: 7}
+	stats := &Stats{}
+	// With a single starting point, every other worker is idle until work is split off for it
+	s := AsyncSplittingSolver{StartingPointsProvider: EmptyStartingPoint, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, Stats: stats, Workers: 4}
+	if _, err := s.Solve(g); err != nil {
+		t.Fatalf("Solve() error = %v", err)
+	}
+	if got := stats.Splits.Load(); got == 0 {
+		t.Errorf("Stats.Splits = 0, want work split off for the idle workers")
+	}
+	if got := stats.IdleWorkers.Load(); got != 0 {
+		t.Errorf("Stats.IdleWorkers = %d after the search, want 0", got)
+	}
+}
+
+func TestStats_Depths(t *testing.T) {
+	g := grid.Grid{Size: 6}
+	stats := &Stats{}
+	if err := Enumerate(context.Background(), g, SingleOctantStartingPoints, placer.OrderedNoAllocStonePlacerProvider{}, stats, func(grid.Placements) bool { return true }); err != nil {
+		t.Fatalf("Enumerate() error = %v", err)
+	}
+	depths := stats.Depths()
+	if len(depths) != int(g.Size)+1 {
+		t.Fatalf("Stats.Depths() has %d depths, want %d", len(depths), g.Size+1)
+	}
+	if got, want := depths[1].Nodes, int64(len(SingleOctantStartingPoints(g))); got != want {
+		t.Errorf("Stats.Depths()[1].Nodes = %d, want one per starting point %d", got, want)
+	}
+	var placed int64
+	for i, d := range depths {
+		if d.Depth != i || d.Placed > d.Tried {
+			t.Errorf("Stats.Depths()[%d] = %+v, want Depth %d and no more placed than tried", i, d, i)
+		}
+		if i > 1 && d.Nodes != depths[i-1].Placed {
+			t.Errorf("Stats.Depths()[%d].Nodes = %d, want the %d placed from depth %d", i, d.Nodes, depths[i-1].Placed, i-1)
+		}
+		placed += d.Placed
+	}
+	if nodes := stats.Nodes.Load(); placed != nodes {
+		t.Errorf("Stats.Depths() placed %d stones in total, want Stats.Nodes %d", placed, nodes)
+	}
+}
+
+func TestStats_Heatmap(t *testing.T) {
+	g := grid.Grid{Size: 6}
+	stats := &Stats{}
+	if stats.Heatmap(g) != nil {
+		t.Errorf("Stats.Heatmap() = %v before EnableHeatmap, want nil", stats.Heatmap(g))
+	}
+	stats.EnableHeatmap(true)
+	if err := Enumerate(context.Background(), g, SingleOctantStartingPoints, placer.OrderedNoAllocStonePlacerProvider{}, stats, func(grid.Placements) bool { return true }); err != nil {
+		t.Fatalf("Enumerate() error = %v", err)
+	}
+	depths := stats.Depths()
+	var wantFirst, wantSecond int64
+	for _, d := range depths[1:] {
+		wantFirst += d.Nodes
+	}
+	wantSecond = wantFirst - depths[1].Nodes
+
+	var first, second int64
+	h := stats.Heatmap(g)
+	for r, row := range h {
+		for c, n := range row {
+			p := grid.Point{Row: uint8(r), Col: uint8(c)}
+			// Only the starting points in the first octant are searched
+			if isStart := slices.ContainsFunc(SingleOctantStartingPoints(g), func(sp grid.Placements) bool { return sp[0] == p }); (n > 0) != isStart {
+				t.Errorf("Stats.Heatmap() has %d nodes for first stone %v", n, p)
+			}
+			first += n
+			for _, row := range stats.SecondStoneHeatmap(g, p) {
+				for _, n := range row {
+					second += n
+				}
+			}
+		}
+	}
+	if first != wantFirst || second != wantSecond {
+		t.Errorf("Stats heatmaps count %d and %d nodes, want %d with at least one stone and %d with at least two", first, second, wantFirst, wantSecond)
+	}
+	if h.Max() != h[0][0] {
+		t.Errorf("Heatmap.Max() = %d, want the corner's %d nodes", h.Max(), h[0][0])
+	}
+}
+
+func TestStats_StartingPoints(t *testing.T) {
+	spc := placer.OrderedNoAllocStonePlacerProvider{}
+	tests := []struct {
+		name   string
+		solver func(*Stats) Solver
+	}{
+		{name: "SingleThreadedSolver", solver: func(st *Stats) Solver {
+			return SingleThreadedSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: spc, Stats: st}
+		}},
+		{name: "AsyncSolver", solver: func(st *Stats) Solver {
+			return AsyncSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: spc, Stats: st}
+		}},
+		{name: "AsyncSplittingSolver", solver: func(st *Stats) Solver {
+			return AsyncSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: spc, Stats: st, Workers: 3}
+		}},
+		{name: "FixedDepthSplittingSolver", solver: func(st *Stats) Solver {
+			return FixedDepthSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: spc, SplitDepth: 3, Stats: st, Workers: 3}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, g := range []grid.Grid{{Size: 7}, {Size: 8}} {
+				stats := &Stats{}
+				_, err := tt.solver(stats).Solve(g)
+				startingPoints := stats.StartingPoints()
+				if len(startingPoints) != len(SingleOctantStartingPoints(g)) {
+					t.Fatalf("Stats.StartingPoints() has %d starting points for %v, want %d", len(startingPoints), g, len(SingleOctantStartingPoints(g)))
+				}
+				var nodes int64
+				solved := 0
+				tried := make([]int64, len(stats.Depths()))
+				for _, sp := range startingPoints {
+					nodes += sp.Nodes
+					if sp.Solved {
+						solved++
+					}
+					for _, d := range sp.Depths {
+						tried[d.Depth] += d.Tried
+					}
+				}
+				for _, d := range stats.Depths() {
+					if tried[d.Depth] != d.Tried {
+						t.Errorf("Stats.StartingPoints() for %v tried %d stones at depth %d in total, want Stats.Depths() %d", g, tried[d.Depth], d.Depth, d.Tried)
+					}
+				}
+				if nodes != stats.Nodes.Load() {
+					t.Errorf("Stats.StartingPoints() for %v have %d nodes in total, want Stats.Nodes %d", g, nodes, stats.Nodes.Load())
+				}
+				// The parallel solvers may find several solutions before the search is stopped
+				if (err == nil) != (solved > 0) {
+					t.Errorf("Stats.StartingPoints() for %v have %d solved, but Solve() error = %v", g, solved, err)
+				}
+				for _, sp := range startingPoints {
+					if errors.Is(err, ErrNoSolution) && !sp.Exhausted() {
+						t.Errorf("Stats.StartingPoints() for %v has starting point %v with %d of %d subtrees finished, want it exhausted", g, sp.StartingPoint, sp.Finished, sp.Subtrees)
+					}
+				}
+				var rejected, placedTried int64
+				for _, n := range stats.Rejections() {
+					rejected += n
+				}
+				for _, d := range stats.Depths() {
+					placedTried += d.Tried - d.Placed
+				}
+				if rejected != placedTried {
+					t.Errorf("Stats.Rejections() for %v add up to %d, want the %d stones tried but not placed", g, rejected, placedTried)
+				}
+			}
+		})
+	}
+}
+
+func TestStats_WorkerStates(t *testing.T) {
+	spc := placer.OrderedNoAllocStonePlacerProvider{}
+	tests := []struct {
+		name   string
+		solver func(*Stats) Solver
+	}{
+		{name: "SingleThreadedSolver", solver: func(st *Stats) Solver {
+			return SingleThreadedSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: spc, Stats: st}
+		}},
+		{name: "AsyncSolver", solver: func(st *Stats) Solver {
+			return AsyncSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: spc, Stats: st}
+		}},
+		{name: "AsyncSplittingSolver", solver: func(st *Stats) Solver {
+			return AsyncSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: spc, Stats: st, Workers: 3}
+		}},
+		{name: "FixedDepthSplittingSolver", solver: func(st *Stats) Solver {
+			return FixedDepthSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: spc, SplitDepth: 3, Stats: st, Workers: 3}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 10x10 grids take far longer than the test to search, so the workers are busy when the states are taken
+			g := grid.Grid{Size: 10}
+			stats := &Stats{}
+			stats.EnableWorkerStates()
+			ctx, cancel := context.WithCancel(context.Background())
+			finished := make(chan struct{})
+			go func() {
+				defer close(finished)
+				tt.solver(stats).SolveContext(ctx, g)
+			}()
+			// Poll until a worker has picked up a task rather than guessing how long that takes
+			var states []WorkerState
+			busy := func() bool {
+				return slices.ContainsFunc(states, func(s WorkerState) bool { return !s.Idle })
+			}
+			for deadline := time.Now().Add(5 * time.Second); !busy() && time.Now().Before(deadline); {
+				time.Sleep(time.Millisecond)
+				states = stats.WorkerStates(time.Second)
+			}
+			cancel()
+			<-finished
+
+			if !busy() {
+				t.Fatalf("Stats.WorkerStates() = %+v, want a busy worker", states)
+			}
+			for _, s := range states {
+				if s.Idle {
+					continue
+				}
+				if s.Stale {
+					t.Errorf("Stats.WorkerStates() worker %d is stale", s.Worker)
+				}
+				if !slices.Equal(s.Placements[:len(s.StartingPoint)], s.StartingPoint) {
+					t.Errorf("Stats.WorkerStates() worker %d is at %v, which is not below its starting point %v", s.Worker, s.Placements, s.StartingPoint)
+				}
+			}
+
+			// After the search, every worker is idle and has counted all of its nodes
+			var nodes int64
+			for _, s := range stats.WorkerStates(time.Second) {
+				if !s.Idle {
+					t.Errorf("Stats.WorkerStates() worker %d is busy after the search", s.Worker)
+				}
+				nodes += s.Nodes
+			}
+			if nodes != stats.Nodes.Load() {
+				t.Errorf("Stats.WorkerStates() have %d nodes in total, want Stats.Nodes %d", nodes, stats.Nodes.Load())
+			}
+		})
+	}
+}
+
+func TestReportProgress(t *testing.T) {
+	stats := &Stats{}
+	stats.Nodes.Add(42)
+	stats.TasksTotal.Add(3)
+	ctx, cancel := context.WithCancel(context.Background())
+	progress := ReportProgress(ctx, stats, time.Millisecond)
+	if got := <-progress; got.Nodes != 42 || got.TasksTotal != 3 {
+		t.Errorf("ReportProgress() sent %+v, want Nodes 42 and TasksTotal 3", got)
+	}
+	cancel()
+	for range progress {
+		// Drain until closed
+	}
+}
+
+func TestFixedDepthSplittingSolver_Prefixes(t *testing.T) {
+	tests := []struct {
+		name       string
+		g          grid.Grid
+		splitDepth int
+		wantDepth  int
+	}{
+		{"shallow", grid.Grid{Size: 6}, 3, 3},
+		{"starting point depth", grid.Grid{Size: 6}, 0, 1},
+		{"deeper than grid", grid.Grid{Size: 3}, 5, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := FixedDepthSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, SplitDepth: tt.splitDepth}
+			prefixes := s.Prefixes(tt.g)
+			if len(prefixes) == 0 {
+				t.Fatalf("Prefixes() returned no prefixes")
+			}
+			for _, p := range prefixes {
+				if len(p) != tt.wantDepth {
+					t.Errorf("Prefixes() returned %v with %d stones, want %d", p, len(p), tt.wantDepth)
+				}
+				if got := len(sets.NewMapSeparationSet(p).Elements()); got != len(p)*(len(p)-1)/2 {
+					t.Errorf("Prefixes() returned %v with duplicate separations", p)
+				}
+			}
+		})
+	}
+}
+
+func TestFixedDepthSplittingSolver_PrefixesCanceled(t *testing.T) {
+	// Enumerating every placement of 6 stones on 14x14 takes far longer than the timeout
+	g := grid.Grid{Size: 14}
+	s := FixedDepthSplittingSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, SplitDepth: 6}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := s.SolveContext(ctx, g); !errors.Is(err, ErrTimeout) {
+		t.Errorf("SolveContext() error = %v, want %v", err, ErrTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("SolveContext() returned after %v, want it to stop enumerating prefixes soon after the timeout", elapsed)
+	}
+}
+
+func TestSolver_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	s := SingleThreadedSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, Logger: logger}
+	if _, err := s.Solve(grid.Grid{Size: 5}); err != nil {
+		t.Fatalf("Solve() error = %v", err)
+	}
+	for _, want := range []string{`msg="search started" solver=SingleThreadedSolver size=5`, `msg="starting point searched" starting_point=[A0]`, `msg="solution found"`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("Solve() logged %q, want it to contain %q", buf.String(), want)
+		}
+	}
+}
+
+func TestTimeSlicedSolver_SmallGrids(t *testing.T) {
+	// A tiny slice suspends and resumes every search many times
+	for size := uint8(1); size <= 7; size++ {
+		g := grid.Grid{Size: size}
+		stats := &Stats{}
+		s := TimeSlicedSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, Slice: time.Nanosecond, Stats: stats}
+		got, err := s.Solve(g)
+		if err != nil {
+			t.Fatalf("Solve(%+v) error = %v", g, err)
+		}
+		if err := grid.CheckValidSolution(g, got); err != nil {
+			t.Errorf("Solve(%+v) = %v, want valid solution: %v", g, got, err)
+		}
+		var nodes int64
+		for _, sp := range stats.StartingPoints() {
+			nodes += sp.Nodes
+		}
+		if nodes != stats.Nodes.Load() {
+			t.Errorf("Solve(%+v) counted %d nodes below the starting points, want Stats.Nodes %d", g, nodes, stats.Nodes.Load())
+		}
+	}
+}
+
+func TestSolver_CompleteStartingPoints(t *testing.T) {
+	// On a 2x2 grid every bidirectional starting point already has both stones, so the solvers must check it before placing below it
+	g := grid.Grid{Size: 2}
+	solvers := []Solver{
+		SingleThreadedSolver{StartingPointsProvider: BidirectionalStartingPoints, StonePlacerConstructor: placer.BidirectionalStonePlacerProvider{}},
+		AsyncSolver{StartingPointsProvider: BidirectionalStartingPoints, StonePlacerConstructor: placer.BidirectionalStonePlacerProvider{}},
+		AsyncSplittingSolver{StartingPointsProvider: BidirectionalStartingPoints, StonePlacerConstructor: placer.BidirectionalStonePlacerProvider{}},
+		FixedDepthSplittingSolver{StartingPointsProvider: BidirectionalStartingPoints, StonePlacerConstructor: placer.BidirectionalStonePlacerProvider{}, SplitDepth: 3},
+		TimeSlicedSolver{StartingPointsProvider: BidirectionalStartingPoints, StonePlacerConstructor: placer.BidirectionalStonePlacerProvider{}},
+	}
+	for _, s := range solvers {
+		got, err := s.Solve(g)
+		if err != nil {
+			t.Fatalf("%T.Solve(%+v) error = %v", s, g, err)
+		}
+		if err := grid.CheckValidSolution(g, got); err != nil {
+			t.Errorf("%T.Solve(%+v) = %v, want valid solution: %v", s, g, got, err)
+		}
+	}
+}
+
+func Test_splitThreshold(t *testing.T) {
+	tests := []struct {
+		idle, workers, minRemaining int
+		want                        int
+	}{
+		{idle: 1, workers: 1, minRemaining: 6, want: 1},
+		{idle: 1, workers: 2, minRemaining: 6, want: 1},
+		{idle: 1, workers: 8, minRemaining: 6, want: 6},
+		{idle: 4, workers: 8, minRemaining: 6, want: 3},
+		{idle: 7, workers: 8, minRemaining: 6, want: 1},
+	}
+	for _, tt := range tests {
+		if got := splitThreshold(tt.idle, tt.workers, tt.minRemaining); got != tt.want {
+			t.Errorf("splitThreshold(%d, %d, %d) = %d, want %d", tt.idle, tt.workers, tt.minRemaining, got, tt.want)
+		}
+	}
+}
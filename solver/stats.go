@@ -0,0 +1,478 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+// Stats collects statistics about a search while it runs. It is safe to read from other goroutines while the search is in progress.
+// Solvers only record statistics if they are given a non-nil *Stats, so there is no cost when nobody is listening.
+type Stats struct {
+	// Nodes is the number of successful stone placements made.
+	Nodes atomic.Int64
+	// TasksTotal and TasksDone count the tasks the search is split into up front: the starting points, or the prefixes for FixedDepthSplittingSolver.
+	// AsyncSplittingSolver splits its tasks as it goes, so does not count them.
+	TasksTotal atomic.Int64
+	TasksDone  atomic.Int64
+	// Splits counts the subtrees handed to idle workers, and IdleWorkers the workers waiting for work, by AsyncSplittingSolver.
+	Splits      atomic.Int64
+	IdleWorkers atomic.Int64
+
+	// depths holds the counters for placers with each number of stones placed
+	depths [grid.MaxGridSize + 1]depthCounters
+
+	// rejections counts the stones that placers rejected, by the reasons in rejectionReasons
+	rejections [len(rejectionReasons)]atomic.Int64
+
+	// firstCells and secondCells count the nodes by the cells of their first stone, and first two stones, if EnableHeatmap was called.
+	firstCells, secondCells []atomic.Int64
+
+	// startingPoints are the starting points of the latest search, and spCounters their statistics. Both are guarded by mu.
+	startingPoints []grid.Placements
+	spCounters     []startingPointCounters
+
+	// workers are the states of the workers by index if workersEnabled, guarded by mu, and workerRequest counts requests for snapshots
+	workersEnabled bool
+	workers        []*workerState
+	workerRequest  atomic.Uint64
+
+	// budget is the value of Nodes at which exhausted is called, or 0 for no budget, as set by WithNodeBudget
+	budget    atomic.Int64
+	exhausted func()
+
+	// deepestLen allows checking whether a placement is the deepest without taking the lock.
+	deepestLen atomic.Int32
+	mu         sync.Mutex
+	deepest    grid.Placements
+}
+
+type depthCounters struct {
+	nodes, tried, placed atomic.Int64
+}
+
+type startingPointCounters struct {
+	nodes, nanos atomic.Int64
+	solved       atomic.Bool
+	depths       [grid.MaxGridSize + 1]depthCounters
+	// subtrees counts the subtrees the search below the starting point is made of, and finished those searched to the end
+	subtrees, finished atomic.Int64
+}
+
+// taskCounter counts the nodes of one task, which a single goroutine searches, for the starting point it is below.
+type taskCounter struct {
+	workerIndex   int
+	startingPoint int
+	start         time.Time
+	nodes         int64
+	solved        bool
+	// split is whether part of the task was handed to another worker
+	split bool
+	// depths counts the task's placers, and the stones they tried and placed, by depth. The task's root is counted when it first tries
+	// to place a stone, which rooted records.
+	depths [grid.MaxGridSize + 1]struct{ nodes, tried, placed int64 }
+	rooted bool
+	// stats and worker are set if worker states are enabled, and answered is the last snapshot request the task has answered
+	stats    *Stats
+	worker   *workerState
+	answered uint64
+}
+
+// record counts an attempt by sp to place a stone, which returned next or err
+func (tc *taskCounter) record(sp, next placer.PlacementState, err error) {
+	d := &tc.depths[sp.Depth()]
+	if !tc.rooted {
+		d.nodes++
+		tc.rooted = true
+	}
+	d.tried++
+	if err == nil {
+		d.placed++
+		tc.depths[next.Depth()].nodes++
+		tc.nodes++
+		if tc.worker != nil {
+			tc.snapshot(next)
+		}
+	}
+}
+
+// setStartingPoints resets the per starting point statistics for a search from the given starting points
+func (st *Stats) setStartingPoints(startingPoints []grid.Placements) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.startingPoints = startingPoints
+	st.spCounters = make([]startingPointCounters, len(startingPoints))
+	for i := range st.spCounters {
+		st.spCounters[i].subtrees.Store(1)
+	}
+}
+
+// setSubtrees sets the number of subtrees below each starting point from the index of the starting point that each subtree is below,
+// for solvers which split the starting points up front
+func (st *Stats) setSubtrees(origins []int) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for i := range st.spCounters {
+		st.spCounters[i].subtrees.Store(0)
+	}
+	for _, i := range origins {
+		st.spCounters[i].subtrees.Add(1)
+	}
+}
+
+// startingPointCounters returns the counters of a starting point of the latest search, or nil if it has none
+func (st *Stats) startingPointCounters(startingPoint int) *startingPointCounters {
+	st.mu.Lock()
+	counters := st.spCounters
+	st.mu.Unlock()
+	if startingPoint >= len(counters) {
+		return nil
+	}
+	return &counters[startingPoint]
+}
+
+// recordTask adds a finished or abandoned task's statistics to its starting point's
+func (st *Stats) recordTask(tc *taskCounter) {
+	tc.finish()
+	c := st.startingPointCounters(tc.startingPoint)
+	if c == nil {
+		return
+	}
+	c.nodes.Add(tc.nodes)
+	c.nanos.Add(int64(time.Since(tc.start)))
+	if tc.solved {
+		c.solved.Store(true)
+	}
+	for i := range tc.depths {
+		if d := &tc.depths[i]; d.nodes > 0 || d.tried > 0 {
+			c.depths[i].nodes.Add(d.nodes)
+			c.depths[i].tried.Add(d.tried)
+			c.depths[i].placed.Add(d.placed)
+		}
+	}
+}
+
+// recordSplit counts a subtree below the starting point handed to an idle worker, if st is not nil
+func (st *Stats) recordSplit(startingPoint int) {
+	if st == nil {
+		return
+	}
+	st.Splits.Add(1)
+	if c := st.startingPointCounters(startingPoint); c != nil {
+		c.subtrees.Add(1)
+	}
+}
+
+// recordSubtreeDone counts a subtree below the starting point that was searched to the end, rather than abandoned
+func (st *Stats) recordSubtreeDone(startingPoint int) {
+	if c := st.startingPointCounters(startingPoint); c != nil {
+		c.finished.Add(1)
+	}
+}
+
+// recordIdle adds n to the workers waiting for work, if st is not nil
+func (st *Stats) recordIdle(n int64) {
+	if st != nil {
+		st.IdleWorkers.Add(n)
+	}
+}
+
+// recordStart updates the statistics for a placer that a task starts searching from
+func (st *Stats) recordStart(sp placer.PlacementState) {
+	st.depths[sp.Depth()].nodes.Add(1)
+	if st.firstCells != nil {
+		st.recordCells(sp.Placements())
+	}
+}
+
+// recordPlace updates the statistics for an attempt by sp to place a stone, which made next unless there was an error
+func (st *Stats) recordPlace(sp, next placer.PlacementState, err error) {
+	d := &st.depths[sp.Depth()]
+	d.tried.Add(1)
+	if err != nil {
+		st.rejections[rejectionIndex(err)].Add(1)
+		return
+	}
+	d.placed.Add(1)
+	st.record(next)
+}
+
+// record updates the statistics for a successful placement
+func (st *Stats) record(sp placer.PlacementState) {
+	if n := st.Nodes.Add(1); n == st.budget.Load() {
+		st.exhausted()
+	}
+	p := sp.Placements()
+	st.depths[len(p)].nodes.Add(1)
+	if st.firstCells != nil {
+		st.recordCells(p)
+	}
+	if int32(len(p)) <= st.deepestLen.Load() {
+		return
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if len(p) > len(st.deepest) {
+		// Placers may reuse their memory, so take a copy
+		st.deepest = append(st.deepest[:0], p...)
+		st.deepestLen.Store(int32(len(p)))
+	}
+}
+
+// cellIndex returns the index of a point's counter in firstCells, and of a row of counters in secondCells
+func cellIndex(p grid.Point) int {
+	return int(p.Row)*grid.MaxGridSize + int(p.Col)
+}
+
+// recordCells updates the heatmap counters for a node
+func (st *Stats) recordCells(p grid.Placements) {
+	if len(p) == 0 {
+		return
+	}
+	st.firstCells[cellIndex(p[0])].Add(1)
+	if len(p) > 1 && st.secondCells != nil {
+		st.secondCells[cellIndex(p[0])*grid.MaxGridSize*grid.MaxGridSize+cellIndex(p[1])].Add(1)
+	}
+}
+
+// EnableHeatmap makes the statistics count the nodes by the cell of their first stone, and of their second stone too if second is true,
+// for Heatmap. It must be called before the search starts. Counting costs a little time per node, so it is off by default.
+func (st *Stats) EnableHeatmap(second bool) {
+	const cells = grid.MaxGridSize * grid.MaxGridSize
+	st.firstCells = make([]atomic.Int64, cells)
+	if second {
+		st.secondCells = make([]atomic.Int64, cells*cells)
+	}
+}
+
+// Heatmap is the number of nodes of a search whose stones were on each cell of a grid, indexed by row then column.
+type Heatmap [][]int64
+
+// Max returns the largest count in the heatmap.
+func (h Heatmap) Max() int64 {
+	var max int64
+	for _, row := range h {
+		for _, n := range row {
+			if n > max {
+				max = n
+			}
+		}
+	}
+	return max
+}
+
+// Heatmap returns the number of nodes whose first stone was on each cell of the grid, which shows where the search effort was spent.
+// Nodes without stones are not counted. It returns nil if EnableHeatmap wasn't called.
+func (st *Stats) Heatmap(g grid.Grid) Heatmap {
+	if st.firstCells == nil {
+		return nil
+	}
+	return st.heatmap(g, st.firstCells)
+}
+
+// SecondStoneHeatmap returns the number of nodes whose first stone was on first, by the cell of their second stone.
+// It returns nil if EnableHeatmap wasn't called with second set.
+func (st *Stats) SecondStoneHeatmap(g grid.Grid, first grid.Point) Heatmap {
+	if st.secondCells == nil {
+		return nil
+	}
+	const cells = grid.MaxGridSize * grid.MaxGridSize
+	i := cellIndex(first) * cells
+	return st.heatmap(g, st.secondCells[i:i+cells])
+}
+
+func (st *Stats) heatmap(g grid.Grid, counters []atomic.Int64) Heatmap {
+	h := make(Heatmap, g.Size)
+	for r := range h {
+		h[r] = make([]int64, g.Size)
+		for c := range h[r] {
+			h[r][c] = counters[cellIndex(grid.Point{Row: uint8(r), Col: uint8(c)})].Load()
+		}
+	}
+	return h
+}
+
+// Deepest returns a copy of the deepest partial placement reached so far.
+func (st *Stats) Deepest() grid.Placements {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return append(grid.Placements{}, st.deepest...)
+}
+
+// DepthStats describes the placers with a given number of stones placed that a search reached.
+type DepthStats struct {
+	Depth int
+	// Nodes is the number of placers reached, by placing a stone or as the start of a task.
+	Nodes int64
+	// Tried is the number of stones they tried to place, and Placed the number they placed successfully.
+	Tried  int64
+	Placed int64
+}
+
+// Branching returns the average number of candidates tried per node, or 0 if there were no nodes.
+func (d DepthStats) Branching() float64 {
+	if d.Nodes == 0 {
+		return 0
+	}
+	return float64(d.Tried) / float64(d.Nodes)
+}
+
+// SuccessRate returns the fraction of tried candidates that were placed, or 0 if none were tried.
+func (d DepthStats) SuccessRate() float64 {
+	if d.Tried == 0 {
+		return 0
+	}
+	return float64(d.Placed) / float64(d.Tried)
+}
+
+// Depths returns a snapshot of the statistics for every depth up to the deepest one that was reached.
+// They show which depths are worth splitting the search at, and where pruning costs more than it saves.
+func (st *Stats) Depths() []DepthStats {
+	return depthStats(st.depths[:])
+}
+
+// depthStats returns a snapshot of the counters for every depth up to the deepest one that was reached.
+func depthStats(counters []depthCounters) []DepthStats {
+	var depths []DepthStats
+	for i := range counters {
+		d := &counters[i]
+		depths = append(depths, DepthStats{Depth: i, Nodes: d.nodes.Load(), Tried: d.tried.Load(), Placed: d.placed.Load()})
+	}
+	// Trim the depths that weren't reached
+	for len(depths) > 0 && depths[len(depths)-1].Nodes == 0 {
+		depths = depths[:len(depths)-1]
+	}
+	return depths
+}
+
+// StartingPointStats describes the search below one starting point.
+type StartingPointStats struct {
+	StartingPoint grid.Placements
+	// Nodes is the number of stones placed below the starting point
+	Nodes int64
+	// Time is the time spent searching below the starting point. When its search is split between workers, as AsyncSplittingSolver and
+	// FixedDepthSplittingSolver do, it is the total time of all of them, which can be more than the wall time of the search.
+	Time time.Duration
+	// Solved is whether the solution was found below the starting point
+	Solved bool
+	// Subtrees is the number of subtrees the search below the starting point was split into, one unless a solver split it, and Finished
+	// the number of them that were searched to the end
+	Subtrees, Finished int64
+	// Depths are the statistics of the search below the starting point at each depth, up to the deepest it reached. Each task's root
+	// is only counted as a node once it tries to place a stone.
+	Depths []DepthStats
+}
+
+// StartingPoints returns a snapshot of the statistics for each starting point of the latest search, in the order they were provided.
+// The spread of their nodes and times shows how evenly the search is balanced between starting points.
+func (st *Stats) StartingPoints() []StartingPointStats {
+	st.mu.Lock()
+	startingPoints, counters := st.startingPoints, st.spCounters
+	st.mu.Unlock()
+	stats := make([]StartingPointStats, len(startingPoints))
+	for i, sp := range startingPoints {
+		c := &counters[i]
+		stats[i] = StartingPointStats{StartingPoint: sp, Nodes: c.nodes.Load(), Time: time.Duration(c.nanos.Load()), Solved: c.solved.Load(),
+			Subtrees: c.subtrees.Load(), Finished: c.finished.Load(), Depths: depthStats(c.depths[:])}
+	}
+	return stats
+}
+
+// Exhausted returns whether the search below the starting point finished without finding a solution, so that none exists below it.
+func (s StartingPointStats) Exhausted() bool {
+	return !s.Solved && s.Finished == s.Subtrees
+}
+
+// rejectionReasons are the reasons that Rejections counts rejected stones by
+var rejectionReasons = [...]placer.RejectionReason{placer.RejectedConstraintViolated, placer.RejectedCannotComplete, placer.RejectedOther}
+
+// rejectionIndex returns the index in rejectionReasons of the reason for an error returned by a placer
+func rejectionIndex(err error) int {
+	switch {
+	case errors.Is(err, placer.ErrConstraintViolated):
+		return 0
+	case errors.Is(err, placer.ErrCannotComplete):
+		return 1
+	}
+	return 2
+}
+
+// Rejections returns the number of stones that placers rejected for each reason: the unique separation constraint, or a bound showing
+// that the placements can't be completed. Placers that prune candidates with a pruner skip them without trying them, so they aren't
+// counted here but make the stones tried at each depth fewer.
+func (st *Stats) Rejections() map[placer.RejectionReason]int64 {
+	rejections := make(map[placer.RejectionReason]int64, len(rejectionReasons))
+	for i, reason := range rejectionReasons {
+		rejections[reason] = st.rejections[i].Load()
+	}
+	return rejections
+}
+
+// Progress is a snapshot of the statistics of a search in progress.
+type Progress struct {
+	Nodes      int64
+	TasksDone  int64
+	TasksTotal int64
+	// Deepest is the deepest partial placement reached so far. Its length is the greatest depth the search has reached.
+	Deepest grid.Placements
+}
+
+// Progress returns a snapshot of the statistics.
+func (st *Stats) Progress() Progress {
+	return Progress{
+		Nodes:      st.Nodes.Load(),
+		TasksDone:  st.TasksDone.Load(),
+		TasksTotal: st.TasksTotal.Load(),
+		Deepest:    st.Deepest(),
+	}
+}
+
+// ReportProgress sends a snapshot of the statistics on the returned channel every interval until the context is done, when the channel is closed.
+// Snapshots are dropped rather than blocking if the receiver falls behind, so a slow receiver, such as a network stream, sees the latest progress.
+func ReportProgress(ctx context.Context, st *Stats, interval time.Duration) <-chan Progress {
+	progress := make(chan Progress, 1)
+	go func() {
+		defer close(progress)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			p := st.Progress()
+			select {
+			case progress <- p:
+			default:
+				// Replace the unreceived snapshot with the newer one
+				select {
+				case <-progress:
+				default:
+				}
+				progress <- p
+			}
+		}
+	}()
+	return progress
+}
+
+// WithNodeBudget returns a context which is canceled, with ErrNodeBudget as the cause, once the search recording to st has made n more
+// placements, so that solvers given both stop as if interrupted. A budget of 0 or less is no budget. st must not be searching yet, and
+// only the latest budget applies. Call the returned cancel function when the search is finished.
+func WithNodeBudget(ctx context.Context, st *Stats, n int64) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	if n > 0 {
+		st.exhausted = func() { cancel(ErrNodeBudget) }
+		st.budget.Store(st.Nodes.Load() + n)
+	}
+	return ctx, func() {
+		st.budget.Store(0)
+		cancel(context.Canceled)
+	}
+}
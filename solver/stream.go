@@ -0,0 +1,74 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+// Solutions searches below each starting point in its own goroutine, like SolveContext, but carries on after the first solution.
+// It returns a channel which receives up to k distinct solutions, sorted, as they are found, or every solution if k is not positive.
+// The channel is closed once k solutions have been sent, the search is exhausted or the context is done, and only after every worker
+// has stopped, so that the statistics are complete. Callers that stop reading early must cancel the context.
+// Starting points that break symmetry only find some of each solution's rotations and reflections; use EmptyStartingPoint for all.
+func (s AsyncSolver) Solutions(ctx context.Context, g grid.Grid, k int) <-chan grid.Placements {
+	ctx, cancel := context.WithCancel(ctx)
+	done := ctx.Done()
+	out := make(chan grid.Placements)
+	found := make(chan grid.Placements)
+
+	startingPoints := s.StartingPointsProvider(g)
+	wg := sync.WaitGroup{}
+	for i := range startingPoints {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			if s.WorkerInit != nil {
+				s.WorkerInit(worker)
+			}
+			only := func(grid.Grid) []grid.Placements { return startingPoints[worker : worker+1] }
+			Enumerate(ctx, g, only, s.StonePlacerConstructor, s.Stats, func(p grid.Placements) bool {
+				p.Sort()
+				select {
+				case found <- p:
+					return true
+				case <-done:
+					return false
+				}
+			})
+		}(i)
+	}
+	go func() {
+		wg.Wait()
+		close(found)
+	}()
+
+	go func() {
+		defer close(out)
+		defer func() {
+			// Stop the workers, and wait for them to notice
+			cancel()
+			for range found {
+			}
+		}()
+		seen := make(map[string]bool)
+		for p := range found {
+			key := fmt.Sprint(p)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			select {
+			case out <- p:
+			case <-done:
+				return
+			}
+			if len(seen) == k {
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,63 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+func TestAsyncSolver_Solutions(t *testing.T) {
+	g := grid.Grid{Size: 6}
+	stats := &Stats{}
+	s := AsyncSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}, Stats: stats}
+	var all int
+	Enumerate(context.Background(), g, SingleOctantStartingPoints, placer.OrderedNoAllocStonePlacerProvider{}, nil, func(grid.Placements) bool {
+		all++
+		return true
+	})
+
+	tests := []struct {
+		name string
+		k    int
+		want int
+	}{
+		{name: "first_k", k: 10, want: 10},
+		{name: "all", k: 0, want: all},
+		{name: "more_than_all", k: all + 1, want: all},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen := make(map[string]bool)
+			for p := range s.Solutions(context.Background(), g, tt.k) {
+				if err := grid.CheckValidSolution(g, p); err != nil {
+					t.Errorf("Solutions() sent invalid solution %v: %v", p, err)
+				}
+				if seen[fmt.Sprint(p)] {
+					t.Errorf("Solutions() sent %v twice", p)
+				}
+				seen[fmt.Sprint(p)] = true
+			}
+			if len(seen) != tt.want {
+				t.Errorf("Solutions(k=%d) sent %d solutions, want %d", tt.k, len(seen), tt.want)
+			}
+		})
+	}
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		solutions := s.Solutions(ctx, g, 0)
+		<-solutions
+		cancel()
+		// The channel is closed once the workers stop, after at most the solutions already found
+		n := 1
+		for range solutions {
+			n++
+		}
+		if n >= all {
+			t.Errorf("Solutions() sent %d solutions after it was canceled, want fewer than %d", n, all)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package solver
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Throttle limits how often events are passed on to a slow consumer, such as a UI or a network stream, by coalescing the events that
+// arrive too soon after the last one into a single event, which is passed on once the interval has passed.
+// A nil *Throttle discards events, so code on a hot path can send events unconditionally at the cost of a nil check when nobody listens.
+type Throttle[T any] struct {
+	interval time.Duration
+	merge    func(pending, next T) T
+	emit     func(T)
+
+	mu         sync.Mutex
+	last       time.Time
+	pending    T
+	hasPending bool
+	timer      *time.Timer
+	// emitMu serializes calls to emit, so the consumer is never called concurrently
+	emitMu sync.Mutex
+}
+
+// NewThrottle returns a Throttle which calls emit at most perSecond times a second. Events that arrive too soon are combined with
+// merge, e.g. by adding counters or keeping the newest snapshot, and emitted when the interval has passed.
+func NewThrottle[T any](perSecond float64, merge func(pending, next T) T, emit func(T)) *Throttle[T] {
+	return &Throttle[T]{interval: time.Duration(float64(time.Second) / perSecond), merge: merge, emit: emit}
+}
+
+// Send emits the event now if the last was long enough ago, or otherwise coalesces it with the other pending events.
+func (t *Throttle[T]) Send(v T) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if t.hasPending {
+		v = t.merge(t.pending, v)
+	}
+	if wait := t.interval - time.Since(t.last); wait > 0 {
+		t.pending, t.hasPending = v, true
+		if t.timer == nil {
+			t.timer = time.AfterFunc(wait, t.Flush)
+		}
+		t.mu.Unlock()
+		return
+	}
+	t.take()
+	t.mu.Unlock()
+	t.send(v)
+}
+
+// Flush emits the pending events now, if there are any. Call it when the events stop, so the last of them isn't held back.
+func (t *Throttle[T]) Flush() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if !t.hasPending {
+		t.mu.Unlock()
+		return
+	}
+	v := t.pending
+	t.take()
+	t.mu.Unlock()
+	t.send(v)
+}
+
+// take clears the pending events and restarts the interval. t.mu must be held.
+func (t *Throttle[T]) take() {
+	var zero T
+	t.pending, t.hasPending = zero, false
+	t.last = time.Now()
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+}
+
+func (t *Throttle[T]) send(v T) {
+	t.emitMu.Lock()
+	defer t.emitMu.Unlock()
+	t.emit(v)
+}
+
+// WatchProgress calls f with a snapshot of the statistics at most perSecond times a second while the context isn't done, skipping
+// snapshots where nothing has changed, and once more with the final statistics when it is done. It returns once f has been called
+// for the last time. Nothing is added to the search's hot path: the solvers already count progress whether or not anyone is watching.
+func WatchProgress(ctx context.Context, st *Stats, perSecond float64, f func(Progress)) {
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / perSecond))
+	defer ticker.Stop()
+	var last Progress
+	for {
+		select {
+		case <-ctx.Done():
+			f(st.Progress())
+			return
+		case <-ticker.C:
+		}
+		p := st.Progress()
+		if p.Nodes == last.Nodes && p.TasksDone == last.TasksDone && slices.Equal(p.Deepest, last.Deepest) {
+			continue
+		}
+		last = p
+		f(p)
+	}
+}
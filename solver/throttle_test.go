@@ -0,0 +1,85 @@
+package solver
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	var mu sync.Mutex
+	var emitted []int
+	throttle := NewThrottle(20, func(pending, next int) int { return pending + next }, func(n int) {
+		mu.Lock()
+		defer mu.Unlock()
+		emitted = append(emitted, n)
+	})
+	start := time.Now()
+	for i := 0; i < 1000; i++ {
+		throttle.Send(1)
+	}
+	throttle.Flush()
+	elapsed := time.Since(start)
+
+	mu.Lock()
+	defer mu.Unlock()
+	total := 0
+	for _, n := range emitted {
+		total += n
+	}
+	if total != 1000 {
+		t.Errorf("Throttle emitted %v, which adds up to %d, want 1000", emitted, total)
+	}
+	// One event is emitted at the start of each interval, and the rest by Flush
+	if max := 2 + int(elapsed.Seconds()*20); len(emitted) > max {
+		t.Errorf("Throttle emitted %d events in %v, want at most %d", len(emitted), elapsed, max)
+	}
+}
+
+func TestThrottle_Trailing(t *testing.T) {
+	emitted := make(chan int, 10)
+	throttle := NewThrottle(100, func(pending, next int) int { return next }, func(n int) { emitted <- n })
+	throttle.Send(1)
+	throttle.Send(2)
+	throttle.Send(3)
+	if got := <-emitted; got != 1 {
+		t.Errorf("Throttle emitted %d first, want 1", got)
+	}
+	// The coalesced events are emitted once the interval passes, without a Flush
+	if got := <-emitted; got != 3 {
+		t.Errorf("Throttle emitted %d after the interval, want the newest event 3", got)
+	}
+}
+
+func TestThrottle_Nil(t *testing.T) {
+	var throttle *Throttle[int]
+	throttle.Send(1)
+	throttle.Flush()
+}
+
+func TestWatchProgress(t *testing.T) {
+	stats := &Stats{}
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan Progress, 100)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		WatchProgress(ctx, stats, 1000, func(p Progress) { updates <- p })
+	}()
+	stats.Nodes.Add(5)
+	if got := <-updates; got.Nodes != 5 {
+		t.Errorf("WatchProgress() sent %+v, want Nodes 5", got)
+	}
+	stats.Nodes.Add(2)
+	cancel()
+	<-finished
+	close(updates)
+	var last Progress
+	for p := range updates {
+		last = p
+	}
+	if last.Nodes != 7 {
+		t.Errorf("WatchProgress() sent %+v last, want the final Nodes 7", last)
+	}
+}
@@ -0,0 +1,152 @@
+package solver
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+// DefaultTimeSlice is the time TimeSlicedSolver spends below each starting point before moving on, if its Slice isn't set.
+const DefaultTimeSlice = 10 * time.Millisecond
+
+// timeSliceCheckInterval is the number of steps a TimeSlicedSolver takes between checks of the clock and the context.
+const timeSliceCheckInterval = 1024
+
+// TimeSlicedSolver searches below every starting point in turn for a slice of time each, suspending each search where it got to, until
+// one of them finds a solution. Solutions are often in subtrees that are quick to search, which exhausting the hard subtrees first,
+// in order or in parallel, delays. Each suspended search is an explicit stack of placers, so suspending and resuming it is free.
+type TimeSlicedSolver struct {
+	StartingPointsProvider StartingPointsProvider
+	StonePlacerConstructor placer.StonePlacerConstructor
+	// Slice is the time spent below each starting point in turn, or DefaultTimeSlice if it is not positive
+	Slice time.Duration
+	// Stats, if not nil, collects statistics during the search
+	Stats *Stats
+	// Logger, if not nil, receives diagnostics about the search, such as when it starts and finishes
+	Logger *slog.Logger
+	// Events, if not nil, receives the search's events, such as solutions found and subtrees finished
+	Events *EventBus
+	// Pool, if not nil, limits the tasks searched at once, sharing the workers with the other searches that use it. Each slice is a task.
+	Pool *Pool
+}
+
+// timeSlicedTask is the suspended search below a starting point.
+type timeSlicedTask struct {
+	index int
+	// stack holds the placers from the starting point down to the one being searched
+	stack []placer.StonePlacer
+	nodes int64
+	start time.Time
+}
+
+// run continues the depth first search of the task until the deadline passes, the done channel is closed, a solution is found or the
+// task is exhausted.
+func (s TimeSlicedSolver) run(t *timeSlicedTask, tc *taskCounter, deadline time.Time, done <-chan struct{}) (solution grid.Placements, exhausted bool) {
+	for steps := 1; len(t.stack) > 0; steps++ {
+		if steps%timeSliceCheckInterval == 0 {
+			select {
+			case <-done:
+				return nil, false
+			default:
+			}
+			if time.Now().After(deadline) {
+				return nil, false
+			}
+		}
+		sp := t.stack[len(t.stack)-1]
+		if sp.Remaining() == 0 {
+			// Only a starting point can be complete, since complete placements aren't pushed
+			return sp.AppendPlacements(make(grid.Placements, 0, sp.Grid().Size)), false
+		}
+		if sp.Done() {
+			t.stack = t.stack[:len(t.stack)-1]
+			continue
+		}
+		nextState, err := sp.Place()
+		if s.Stats != nil {
+			s.Stats.recordPlace(sp, nextState, err)
+			tc.record(sp, nextState, err)
+		}
+		if err != nil {
+			continue
+		}
+		t.nodes++
+		if nextState.Remaining() == 0 {
+			return nextState.AppendPlacements(make(grid.Placements, 0, nextState.Grid().Size)), false
+		}
+		t.stack = append(t.stack, nextState)
+	}
+	return nil, true
+}
+
+func (s TimeSlicedSolver) Solve(g grid.Grid) (grid.Placements, error) {
+	return s.SolveContext(context.Background(), g)
+}
+
+func (s TimeSlicedSolver) SolveContext(ctx context.Context, g grid.Grid) (solution grid.Placements, err error) {
+	ctx, span := startSolveSpan(ctx, "TimeSlicedSolver", g)
+	defer func() { endSolveSpan(span, solution, err) }()
+	logEnd := logSolve(ctx, s.Logger, "TimeSlicedSolver", g)
+	defer func() { logEnd(solution, err) }()
+
+	slice := s.Slice
+	if slice <= 0 {
+		slice = DefaultTimeSlice
+	}
+	startingPoints := s.StartingPointsProvider(g)
+	if s.Stats != nil {
+		s.Stats.TasksTotal.Add(int64(len(startingPoints)))
+		s.Stats.setStartingPoints(startingPoints)
+	}
+	tasks := make([]*timeSlicedTask, len(startingPoints))
+	for i, sp := range startingPoints {
+		start := s.StonePlacerConstructor.New(g, sp)
+		if s.Stats != nil {
+			s.Stats.recordStart(start)
+		}
+		tasks[i] = &timeSlicedTask{index: i, stack: []placer.StonePlacer{start}, start: time.Now()}
+	}
+
+	// Each round gives every unfinished task a slice, dropping the tasks that are exhausted
+	job := s.Pool.Job()
+	for round := 1; len(tasks) > 0; round++ {
+		unfinished := tasks[:0]
+		for _, t := range tasks {
+			// Each slice is counted as a task of its own, so the statistics add up the time and nodes of the slices
+			if err := job.Acquire(ctx); err != nil {
+				return nil, contextError(ctx)
+			}
+			tc := newTaskCounter(s.Stats, 0, t.index)
+			solution, exhausted := s.run(t, &tc, time.Now().Add(slice), ctx.Done())
+			job.Release()
+			tc.solved = solution != nil
+			if s.Stats != nil {
+				s.Stats.recordTask(&tc)
+			}
+			if ctx.Err() != nil {
+				return nil, contextError(ctx)
+			}
+			if solution != nil {
+				s.Events.publishSolution(&tc, solution)
+				loggerOrDiscard(s.Logger).DebugContext(ctx, "solution found", "starting_point", startingPoints[t.index], "round", round, "duration", time.Since(t.start))
+				return solution, nil
+			}
+			if !exhausted {
+				unfinished = append(unfinished, t)
+				continue
+			}
+			loggerOrDiscard(s.Logger).DebugContext(ctx, "starting point searched", "starting_point", startingPoints[t.index], "round", round, "duration", time.Since(t.start))
+			tc.nodes = t.nodes
+			s.Events.publishSubtreeDone(&tc, startingPoints[t.index])
+			if s.Stats != nil {
+				s.Stats.TasksDone.Add(1)
+				s.Stats.recordSubtreeDone(t.index)
+			}
+		}
+		tasks = unfinished
+	}
+	return nil, ErrNoSolution
+}
@@ -0,0 +1,57 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Spans are recorded with the global TracerProvider, which discards them unless the application installs one.
+// Spans are only started for coarse units of work (a solve, a subtree, a split), never per placement.
+const tracerName = "github.com/WillMorrison/pegboard-blog/solver"
+
+// tracer returns the package's tracer from the global TracerProvider. It is looked up for each span rather than once, so that spans
+// follow the provider when it is replaced, as tests do.
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// startSolveSpan starts the span covering a whole solve by the named solver.
+func startSolveSpan(ctx context.Context, name string, g grid.Grid) (context.Context, trace.Span) {
+	return tracer().Start(ctx, "Solve", trace.WithAttributes(
+		attribute.String("pegboard.solver", name),
+		attribute.Int("pegboard.grid.size", int(g.Size)),
+	))
+}
+
+// startSubtreeSpan starts a span covering the search below a starting point or a split off prefix.
+// Attributes are only formatted when the span is recorded, since work splits can be frequent.
+func startSubtreeSpan(ctx context.Context, name string, prefix grid.Placements) (context.Context, trace.Span) {
+	ctx, span := tracer().Start(ctx, name)
+	if span.IsRecording() {
+		span.SetAttributes(
+			attribute.String("pegboard.prefix", fmt.Sprint(prefix)),
+			attribute.Int("pegboard.prefix.stones", len(prefix)),
+		)
+	}
+	return ctx, span
+}
+
+// endSolveSpan records the outcome of a solve on its span and ends it. Proving that there are no solutions is a successful outcome.
+func endSolveSpan(span trace.Span, solution grid.Placements, err error) {
+	switch {
+	case err == nil:
+		span.SetAttributes(attribute.Bool("pegboard.solved", true), attribute.String("pegboard.solution", fmt.Sprint(solution)))
+	case errors.Is(err, ErrNoSolution):
+		span.SetAttributes(attribute.Bool("pegboard.solved", false))
+	default:
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,60 @@
+package solver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSolver_Spans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	// Restore the previous provider, so that the package's other tests don't record spans
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		provider.Shutdown(context.Background())
+	})
+	otel.SetTracerProvider(provider)
+
+	s := SingleThreadedSolver{
+		StartingPointsProvider: SingleOctantStartingPoints,
+		StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{},
+	}
+	if _, err := s.Solve(grid.Grid{Size: 6}); err != nil {
+		t.Fatalf("Solve() returned error %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	names := make(map[string]int)
+	for _, span := range spans {
+		names[span.Name]++
+	}
+	// Spans are exported as they end, so the Solve span is last
+	solveSpan := spans[len(spans)-1]
+	for _, span := range spans {
+		if span.Name == "StartingPoint" && span.Parent.SpanID() != solveSpan.SpanContext.SpanID() {
+			t.Errorf("StartingPoint span has parent %v, want the Solve span %v", span.Parent.SpanID(), solveSpan.SpanContext.SpanID())
+		}
+	}
+	if names["Solve"] != 1 {
+		t.Errorf("got %d Solve spans, want 1", names["Solve"])
+	}
+	if names["StartingPoint"] == 0 {
+		t.Errorf("got no StartingPoint spans, want at least 1")
+	}
+}
+
+func TestSolver_SpansRestored(t *testing.T) {
+	t.Run("recording", TestSolver_Spans)
+	_, span := startSolveSpan(context.Background(), SingleThreadedSolverName, grid.Grid{Size: 6})
+	defer span.End()
+	if span.IsRecording() {
+		t.Errorf("span started after the test provider was restored is recording, want the previous provider's")
+	}
+}
@@ -0,0 +1,123 @@
+package solver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/sets"
+)
+
+// WarmStartResult describes an attempt to extend known solutions for smaller grids.
+type WarmStartResult struct {
+	// Embeddings is the number of distinct ways the known solutions were placed on the grid as prefixes.
+	Embeddings int
+	// Solution is the first solution found by extending a prefix, or nil if there was none.
+	Solution grid.Placements
+	// From is the known solution that was extended into Solution.
+	From grid.Placements
+}
+
+// WarmStart tries to extend known solutions for smaller grids into a solution for g. Each known solution for an m x m grid
+// (which has m stones) is rotated, reflected and translated into every position where it fits on g, and the remaining
+// g.Size-m stones are searched for in the cells it leaves free.
+//
+// This is much quicker than a full search, but only finds solutions that contain a solution for a smaller grid. The stones it adds
+// may come before the embedded ones in grid order, which the placers never try as they only place stones after the last one, so it
+// searches without them. It counts the stones it places in stats, if not nil, so that WithNodeBudget limits it, and returns an error
+// wrapping ErrCanceled or ErrTimeout if ctx is done before it finishes.
+func WarmStart(ctx context.Context, g grid.Grid, known []grid.Placements, stats *Stats) (WarmStartResult, error) {
+	var result WarmStartResult
+	seen := make(map[string]bool)
+	for _, k := range known {
+		m := uint8(len(k))
+		if m > g.Size {
+			return result, fmt.Errorf("known solution %v has more stones than a %dx%d grid needs", k, g.Size, g.Size)
+		}
+		for _, p := range k {
+			if !grid.IsInBounds(grid.Grid{Size: m}, p) {
+				return result, fmt.Errorf("known solution %v is not a solution for a %dx%d grid", k, m, m)
+			}
+		}
+		for _, symmetry := range grid.Symmetries {
+			for dr := uint8(0); dr+m <= g.Size; dr++ {
+				for dc := uint8(0); dc+m <= g.Size; dc++ {
+					prefix := make(grid.Placements, len(k))
+					for i, p := range k {
+						prefix[i] = symmetry(grid.Grid{Size: m}, p).Offset(int(dr), int(dc))
+					}
+					prefix.Sort()
+					if key := fmt.Sprint(prefix); seen[key] {
+						continue
+					} else {
+						seen[key] = true
+					}
+					result.Embeddings++
+					if solution, ok := extend(ctx.Done(), g, prefix, stats); ok {
+						solution.Sort()
+						result.Solution = solution
+						result.From = k
+						return result, nil
+					}
+					if err := contextError(ctx); err != nil {
+						return result, err
+					}
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// extend searches for stones that can be added anywhere among the prefix to make a solution. It gives up if the done channel is closed.
+func extend(done <-chan struct{}, g grid.Grid, prefix grid.Placements, stats *Stats) (grid.Placements, bool) {
+	separations := sets.BitArraySeparationSet{}
+	for i, p1 := range prefix {
+		for _, p2 := range prefix[i+1:] {
+			s := grid.Separation(p1, p2)
+			if separations.Has(s) {
+				return nil, false
+			}
+			separations.Add(s)
+		}
+	}
+	stones := make(grid.Placements, len(prefix), g.Size)
+	copy(stones, prefix)
+	return extendFrom(done, g, stones, separations, grid.Point{}, stats)
+}
+
+// extendFrom adds stones at or after the given point, in order, until the placements are a solution.
+func extendFrom(done <-chan struct{}, g grid.Grid, stones grid.Placements, separations sets.BitArraySeparationSet, next grid.Point, stats *Stats) (grid.Placements, bool) {
+	if len(stones) == int(g.Size) {
+		return stones, true
+	}
+	for p := next; grid.IsInBounds(g, p); p = grid.AdvanceStone(g, p) {
+		select {
+		case <-done:
+			return nil, false
+		default:
+		}
+		newSeparations := separations
+		valid := true
+		for _, stone := range stones {
+			s := grid.Separation(p, stone)
+			if s == 0 || newSeparations.Has(s) {
+				valid = false
+				break
+			}
+			newSeparations.Add(s)
+		}
+		if !valid {
+			continue
+		}
+		if stats != nil {
+			if n := stats.Nodes.Add(1); n == stats.budget.Load() {
+				stats.exhausted()
+			}
+		}
+		if solution, ok := extendFrom(done, g, append(stones, p), newSeparations, grid.AdvanceStone(g, p), stats); ok {
+			return solution, true
+		}
+	}
+	return nil, false
+}
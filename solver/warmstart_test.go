@@ -0,0 +1,67 @@
+package solver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+func TestWarmStart(t *testing.T) {
+	t.Run("single stone", func(t *testing.T) {
+		g := grid.Grid{Size: 3}
+		got, err := WarmStart(context.Background(), g, []grid.Placements{{grid.Point{Row: 0, Col: 0}}}, nil)
+		if err != nil {
+			t.Fatalf("WarmStart() error = %v", err)
+		}
+		// A single stone can be embedded on every point of the grid
+		if got.Embeddings > 9 {
+			t.Errorf("WarmStart() tried %d embeddings, want at most 9", got.Embeddings)
+		}
+		if err := grid.CheckValidSolution(g, got.Solution); err != nil {
+			t.Errorf("WarmStart() = %v, want valid solution: %v", got.Solution, err)
+		}
+	})
+
+	t.Run("from smaller solution", func(t *testing.T) {
+		smaller, err := SingleThreadedSolver{StartingPointsProvider: SingleOctantStartingPoints, StonePlacerConstructor: placer.OrderedNoAllocStonePlacerProvider{}}.Solve(grid.Grid{Size: 5})
+		if err != nil {
+			t.Fatalf("Solve() error = %v", err)
+		}
+		g := grid.Grid{Size: 6}
+		got, err := WarmStart(context.Background(), g, []grid.Placements{smaller}, nil)
+		if err != nil {
+			t.Fatalf("WarmStart() error = %v", err)
+		}
+		if got.Embeddings == 0 || got.Embeddings > 8*4 {
+			t.Errorf("WarmStart() tried %d embeddings, want between 1 and %d", got.Embeddings, 8*4)
+		}
+		if got.Solution != nil {
+			if err := grid.CheckValidSolution(g, got.Solution); err != nil {
+				t.Errorf("WarmStart() = %v, want valid solution: %v", got.Solution, err)
+			}
+		}
+	})
+
+	t.Run("too many stones", func(t *testing.T) {
+		if _, err := WarmStart(context.Background(), grid.Grid{Size: 2}, []grid.Placements{{grid.Point{Row: 0, Col: 0}, grid.Point{Row: 0, Col: 1}, grid.Point{Row: 1, Col: 1}}}, nil); err == nil {
+			t.Errorf("WarmStart() error = nil, want error")
+		}
+	})
+
+	t.Run("node budget", func(t *testing.T) {
+		// Extending a single stone on a large grid takes far longer than the budget
+		stats := &Stats{}
+		ctx, cancel := WithNodeBudget(context.Background(), stats, 1000)
+		defer cancel()
+		_, err := WarmStart(ctx, grid.Grid{Size: 12}, []grid.Placements{{grid.Point{Row: 0, Col: 0}}}, stats)
+		if !errors.Is(err, ErrCanceled) || !errors.Is(err, ErrNodeBudget) {
+			t.Errorf("WarmStart() error = %v, want %v and %v", err, ErrCanceled, ErrNodeBudget)
+		}
+		if nodes := stats.Nodes.Load(); nodes < 1000 {
+			t.Errorf("WarmStart() counted %d nodes, want at least the budget of 1000", nodes)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package solver
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+// WeightKey returns the key identifying a starting point in a weights map.
+func WeightKey(p grid.Placements) string {
+	sorted := slices.Clone(p)
+	sorted.Sort()
+	return fmt.Sprint(sorted)
+}
+
+type weightedStartingPoint struct {
+	placements grid.Placements
+	weight     float64
+}
+
+// WeightedStartingPoints returns a StartingPointsProvider that balances the expected work of the given starting points across workers.
+// Weights are the expected subtree size of each starting point keyed by WeightKey, such as the estimates made by EstimateTreeSize.
+// Starting points without a weight are assumed to have the mean weight.
+//
+// Any starting point with more than its fair share (1/workers) of the total weight is replaced by its children, which share its weight equally,
+// until every starting point has a fair share or can't be split any further. This allocates workers proportionally to the expected subtree
+// sizes, as solvers that start a goroutine per starting point give each an equal share of the CPUs. The starting points are returned heaviest
+// first, so that solvers which queue them start the longest work earliest.
+func WeightedStartingPoints(spp StartingPointsProvider, spc placer.StonePlacerConstructor, weights map[string]float64, workers int) StartingPointsProvider {
+	return func(g grid.Grid) []grid.Placements {
+		points := spp(g)
+		known, sum := 0, 0.0
+		for _, p := range points {
+			if w, ok := weights[WeightKey(p)]; ok {
+				known++
+				sum += w
+			}
+		}
+		mean := 1.0
+		if known > 0 {
+			mean = sum / float64(known)
+		}
+
+		tasks := make([]weightedStartingPoint, len(points))
+		total := 0.0
+		for i, p := range points {
+			w, ok := weights[WeightKey(p)]
+			if !ok {
+				w = mean
+			}
+			tasks[i] = weightedStartingPoint{p, w}
+			total += w
+		}
+
+		fairShare := total / float64(max(workers, 1))
+		byWeight := func(a, b weightedStartingPoint) int {
+			if a.weight > b.weight {
+				return -1
+			} else if a.weight < b.weight {
+				return 1
+			}
+			return a.placements.Compare(b.placements)
+		}
+		slices.SortStableFunc(tasks, byWeight)
+		var final []weightedStartingPoint
+		for len(tasks) > 0 {
+			heaviest := tasks[0]
+			tasks = tasks[1:]
+			// Only split prefixes whose children are not complete placements, as solvers expect to place at least one stone below a starting point
+			if heaviest.weight <= fairShare || len(heaviest.placements)+1 >= int(g.Size) {
+				final = append(final, heaviest)
+				continue
+			}
+			var children []grid.Placements
+			for sp := spc.New(g, slices.Clone(heaviest.placements)); !sp.Done(); {
+				child, err := sp.Place()
+				if err != nil {
+					continue
+				}
+				children = append(children, child.AppendPlacements(make(grid.Placements, 0, g.Size)))
+			}
+			for _, c := range children {
+				tasks = append(tasks, weightedStartingPoint{c, heaviest.weight / float64(len(children))})
+			}
+			slices.SortStableFunc(tasks, byWeight)
+		}
+
+		slices.SortStableFunc(final, byWeight)
+		startingPoints := make([]grid.Placements, len(final))
+		for i, t := range final {
+			startingPoints[i] = t.placements
+		}
+		return startingPoints
+	}
+}
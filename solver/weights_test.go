@@ -0,0 +1,60 @@
+package solver
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+func TestWeightedStartingPoints(t *testing.T) {
+	g := grid.Grid{Size: 5}
+	spc := placer.OrderedNoAllocStonePlacerProvider{}
+	heavy := grid.Placements{grid.Point{Row: 1, Col: 1}}
+	weights := map[string]float64{
+		WeightKey(heavy): 100,
+		WeightKey(grid.Placements{grid.Point{Row: 0, Col: 0}}): 10,
+	}
+	spp := WeightedStartingPoints(SingleOctantStartingPoints, spc, weights, 4)
+	points := spp(g)
+
+	// The heavy starting point is split into its children, and the light ones are not
+	split := 0
+	for _, p := range points {
+		if len(p) == 1 && p[0] == heavy[0] {
+			t.Errorf("WeightedStartingPoints() returned %v, want it split", heavy)
+		}
+		if len(p) > 1 && p[0] == heavy[0] {
+			split++
+		}
+		if len(p) > 1 && p[0] != heavy[0] {
+			t.Errorf("WeightedStartingPoints() returned %v, want only %v split", p, heavy)
+		}
+	}
+	if split == 0 {
+		t.Errorf("WeightedStartingPoints() returned no children of %v", heavy)
+	}
+
+	// Splitting doesn't change the solutions that can be found
+	count := func(spp StartingPointsProvider) int {
+		n := 0
+		Enumerate(context.Background(), g, spp, spc, nil, func(grid.Placements) bool { n++; return true })
+		return n
+	}
+	if got, want := count(spp), count(SingleOctantStartingPoints); got != want {
+		t.Errorf("WeightedStartingPoints() found %d solutions, want %d", got, want)
+	}
+}
+
+func TestWeightedStartingPoints_Order(t *testing.T) {
+	g := grid.Grid{Size: 4}
+	weights := map[string]float64{"[A0]": 1, "[A1]": 3, "[B1]": 2}
+	// With a single worker nothing is more than its fair share, so nothing is split
+	got := WeightedStartingPoints(SingleOctantStartingPoints, placer.OrderedNoAllocStonePlacerProvider{}, weights, 1)(g)
+	want := []grid.Placements{{grid.Point{Row: 0, Col: 1}}, {grid.Point{Row: 1, Col: 1}}, {grid.Point{Row: 0, Col: 0}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WeightedStartingPoints() = %v, want %v", got, want)
+	}
+}
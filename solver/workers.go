@@ -0,0 +1,158 @@
+package solver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/placer"
+)
+
+// WorkerState is a snapshot of what a worker goroutine of a solver was doing.
+type WorkerState struct {
+	Worker int
+	// Idle is whether the worker was between tasks, so StartingPoint, Placements and TaskTime describe the last task it searched.
+	Idle bool
+	// Stale is whether the worker didn't respond to the snapshot request in time, so its state is from the last snapshot it took.
+	// A worker only takes snapshots between stone placements, so a stale worker may be stuck.
+	Stale         bool
+	StartingPoint grid.Placements
+	// Placements is the partial placement the worker had reached, whose length is its depth.
+	Placements grid.Placements
+	// Nodes is the number of successful stone placements the worker has made, over all its tasks.
+	Nodes int64
+	// TaskTime is how long the worker had spent on its current task.
+	TaskTime time.Duration
+}
+
+// workerState is shared between a worker, which updates it, and the goroutine taking snapshots.
+type workerState struct {
+	mu            sync.Mutex
+	idle          bool
+	startingPoint int
+	taskStart     time.Time
+	placements    grid.Placements
+	// doneNodes counts the nodes of the worker's finished tasks, and nodes those as well as the current task's at the last snapshot
+	doneNodes, nodes int64
+	// answered is the last snapshot request the worker has answered
+	answered uint64
+	// snapshotted is when the worker last took a snapshot
+	snapshotted time.Time
+}
+
+// EnableWorkerStates makes the solver's workers record their state when WorkerStates asks for it. Call it before the search starts.
+// Workers check for requests after each stone placement, which costs an atomic load.
+func (st *Stats) EnableWorkerStates() {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.workersEnabled = true
+}
+
+// worker returns the state of the worker with the given index, or nil if worker states aren't enabled.
+func (st *Stats) worker(worker int) *workerState {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if !st.workersEnabled {
+		return nil
+	}
+	for len(st.workers) <= worker {
+		st.workers = append(st.workers, &workerState{idle: true})
+	}
+	return st.workers[worker]
+}
+
+// newTaskCounter returns the counter for a task that the given worker searches below a starting point, and marks the worker busy.
+func newTaskCounter(st *Stats, worker, startingPoint int) taskCounter {
+	tc := taskCounter{workerIndex: worker, startingPoint: startingPoint, start: time.Now()}
+	if st == nil {
+		return tc
+	}
+	tc.stats = st
+	if tc.worker = st.worker(worker); tc.worker != nil {
+		tc.worker.mu.Lock()
+		tc.worker.idle = false
+		tc.worker.startingPoint = startingPoint
+		tc.worker.taskStart = tc.start
+		tc.worker.placements = tc.worker.placements[:0]
+		tc.worker.mu.Unlock()
+	}
+	return tc
+}
+
+// snapshot records the worker's current placement if a snapshot has been requested since it last took one.
+func (tc *taskCounter) snapshot(sp placer.PlacementState) {
+	request := tc.stats.workerRequest.Load()
+	if request == tc.answered {
+		return
+	}
+	tc.answered = request
+	ws := tc.worker
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.placements = sp.AppendPlacements(ws.placements[:0])
+	ws.nodes = ws.doneNodes + tc.nodes
+	ws.answered = request
+	ws.snapshotted = time.Now()
+}
+
+// finish marks the worker idle after a task.
+func (tc *taskCounter) finish() {
+	ws := tc.worker
+	if ws == nil {
+		return
+	}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.idle = true
+	ws.doneNodes += tc.nodes
+	ws.nodes = ws.doneNodes
+	ws.snapshotted = time.Now()
+}
+
+// WorkerStates asks every worker for a snapshot of its state and returns them, ordered by worker, without stopping the search.
+// Busy workers that don't take a snapshot within the timeout are reported as stale.
+// It returns nil if EnableWorkerStates wasn't called or no worker has started.
+func (st *Stats) WorkerStates(timeout time.Duration) []WorkerState {
+	request := st.workerRequest.Add(1)
+	st.mu.Lock()
+	workers := st.workers
+	startingPoints := st.startingPoints
+	st.mu.Unlock()
+
+	deadline := time.Now().Add(timeout)
+	answered := func(ws *workerState) bool {
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+		return ws.idle || ws.answered >= request
+	}
+	for _, ws := range workers {
+		for !answered(ws) && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	states := make([]WorkerState, len(workers))
+	now := time.Now()
+	for i, ws := range workers {
+		ws.mu.Lock()
+		states[i] = WorkerState{
+			Worker:     i,
+			Idle:       ws.idle,
+			Stale:      !ws.idle && ws.answered < request,
+			Placements: append(grid.Placements(nil), ws.placements...),
+			Nodes:      ws.nodes,
+		}
+		if !ws.taskStart.IsZero() {
+			end := now
+			if ws.idle {
+				end = ws.snapshotted
+			}
+			states[i].TaskTime = end.Sub(ws.taskStart)
+		}
+		if ws.startingPoint < len(startingPoints) {
+			states[i].StartingPoint = startingPoints[ws.startingPoint]
+		}
+		ws.mu.Unlock()
+	}
+	return states
+}
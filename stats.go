@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// writeDepthStats writes a table of the branching factor and success rate of the search at each depth.
+func writeDepthStats(w io.Writer, depths []solver.DepthStats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "depth\tnodes\ttried\tplaced\ttried/node\tsuccess\t")
+	for _, d := range depths {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%.2f\t%.1f%%\t\n", d.Depth, d.Nodes, d.Tried, d.Placed, d.Branching(), 100*d.SuccessRate())
+	}
+	tw.Flush()
+}
+
+// writeStartingPointStats writes a table of the nodes and time spent below each starting point, and whether the solution was found there,
+// followed by how imbalanced the work was: the share of the nodes below the largest starting point compared with an even split.
+func writeStartingPointStats(w io.Writer, startingPoints []solver.StartingPointStats) {
+	var totalNodes int64
+	largest := -1
+	for i, sp := range startingPoints {
+		totalNodes += sp.Nodes
+		if largest < 0 || sp.Nodes > startingPoints[largest].Nodes {
+			largest = i
+		}
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "starting point\tnodes\tshare\ttime\tsolved\t")
+	for _, sp := range startingPoints {
+		share := 0.0
+		if totalNodes > 0 {
+			share = float64(sp.Nodes) / float64(totalNodes)
+		}
+		solved := ""
+		if sp.Solved {
+			solved = "yes"
+		}
+		fmt.Fprintf(tw, "%v\t%d\t%.1f%%\t%v\t%s\t\n", sp.StartingPoint, sp.Nodes, 100*share, sp.Time.Round(time.Microsecond), solved)
+	}
+	tw.Flush()
+	if len(startingPoints) > 1 && totalNodes > 0 {
+		share := float64(startingPoints[largest].Nodes) / float64(totalNodes)
+		even := 1 / float64(len(startingPoints))
+		fmt.Fprintf(w, "Imbalance: %v has %.1f%% of the nodes, %.1f times an even split of %.1f%%\n", startingPoints[largest].StartingPoint, 100*share, share/even, 100*even)
+	}
+}
+
+// depthCSVHeader are the columns of the -depth_csv export. The size, placer and solver columns let the rows of many runs be combined.
+var depthCSVHeader = []string{"size", "placer", "solver", "starting_point", "depth", "nodes", "tried", "placed", "rejected", "starting_point_nodes", "starting_point_time_ns", "solved"}
+
+// writeDepthCSV writes a row for each depth below each starting point, for offline analysis of how the search scales. The rows of a
+// starting point repeat its totals.
+func writeDepthCSV(out io.Writer, size uint8, placerName, solverName string, startingPoints []solver.StartingPointStats) error {
+	w := csv.NewWriter(out)
+	w.Write(depthCSVHeader)
+	for _, sp := range startingPoints {
+		for _, d := range sp.Depths {
+			w.Write([]string{
+				strconv.Itoa(int(size)), placerName, solverName, fmt.Sprint(sp.StartingPoint),
+				strconv.Itoa(d.Depth), strconv.FormatInt(d.Nodes, 10), strconv.FormatInt(d.Tried, 10), strconv.FormatInt(d.Placed, 10), strconv.FormatInt(d.Tried-d.Placed, 10),
+				strconv.FormatInt(sp.Nodes, 10), strconv.FormatInt(sp.Time.Nanoseconds(), 10), strconv.FormatBool(sp.Solved),
+			})
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("writing depth statistics: %w", err)
+	}
+	return nil
+}
+
+// writeDepthCSVFile writes the -depth_csv export to the file.
+func writeDepthCSVFile(filename string, size uint8, placerName, solverName string, startingPoints []solver.StartingPointStats) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := writeDepthCSV(f, size, placerName, solverName, startingPoints); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeMemoryStats writes a table of the memory used during the search.
+func writeMemoryStats(w io.Writer, m solver.MemoryStats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "peak heap\tallocated\tallocations\tGC cycles\tGC pause\t")
+	fmt.Fprintf(tw, "%.1f MiB\t%.1f MiB\t%d\t%d\t%v\t\n", float64(m.PeakHeap)/(1<<20), float64(m.TotalAlloc)/(1<<20), m.Mallocs, m.GCCycles, m.GCPause.Round(time.Microsecond))
+	tw.Flush()
+}
+
+// writeCPUStats writes a table of the CPUs the search ran on and how fast it placed stones, so that runs with -gomaxprocs set to the
+// number of physical cores and of logical CPUs can be compared. This search is compute bound, so hyperthread siblings mostly
+// contend for the same execution units: on hyperthreaded machines the nodes per second per proc drop when GOMAXPROCS exceeds the
+// physical cores, without the total rising much.
+func writeCPUStats(w io.Writer, physicalCores, logicalCPUs, gomaxprocs int, nodes int64, duration time.Duration) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "physical cores\tlogical CPUs\tGOMAXPROCS\tnodes/s\tnodes/s/proc\t")
+	perSecond := float64(nodes) / duration.Seconds()
+	fmt.Fprintf(tw, "%d\t%d\t%d\t%.4g\t%.4g\t\n", physicalCores, logicalCPUs, gomaxprocs, perSecond, perSecond/float64(gomaxprocs))
+	tw.Flush()
+}
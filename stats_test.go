@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteStartingPointStats(t *testing.T) {
+	startingPoints := []solver.StartingPointStats{
+		{StartingPoint: grid.Placements{{Row: 0, Col: 0}}, Nodes: 300, Time: 3 * time.Millisecond},
+		{StartingPoint: grid.Placements{{Row: 0, Col: 1}}, Nodes: 100, Time: time.Millisecond, Solved: true},
+	}
+	want := `  starting point  nodes  share  time  solved
+            [A0]    300  75.0%   3ms        
+            [A1]    100  25.0%   1ms     yes
+Imbalance: [A0] has 75.0% of the nodes, 1.5 times an even split of 50.0%
+`
+	var b strings.Builder
+	writeStartingPointStats(&b, startingPoints)
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("writeStartingPointStats() output mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	NoTraceExporter       = "none"
+	StdoutTraceExporter   = "stdout"
+	OTLPHTTPTraceExporter = "otlp_http"
+)
+
+// setupTracing installs a global TracerProvider that sends spans to the chosen exporter, and returns a function which flushes and stops it.
+// The OTLP exporter is configured by the standard OTEL_EXPORTER_OTLP_* environment variables.
+// If $TRACEPARENT is set, e.g. by a pipeline that runs this binary, spans are recorded as part of that trace.
+func setupTracing(ctx context.Context, exporter string) (context.Context, func(context.Context) error, error) {
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case NoTraceExporter:
+		return ctx, func(context.Context) error { return nil }, nil
+	case StdoutTraceExporter:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	case OTLPHTTPTraceExporter:
+		spanExporter, err = otlptracehttp.New(ctx)
+	}
+	if err != nil {
+		return ctx, nil, err
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(spanExporter))
+	otel.SetTracerProvider(provider)
+	propagator := propagation.TraceContext{}
+	otel.SetTextMapPropagator(propagator)
+	ctx = propagator.Extract(ctx, propagation.MapCarrier{"traceparent": os.Getenv("TRACEPARENT")})
+	return ctx, provider.Shutdown, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+const (
+	// tuiRefresh is how often the watch view is redrawn
+	tuiRefresh = 250 * time.Millisecond
+	// tuiRateSamples is the number of refreshes that the rate graph of the watch view shows
+	tuiRateSamples = 60
+	// tuiWorkerTimeout is how long the watch view waits for busy workers to take a snapshot of their placements
+	tuiWorkerTimeout = 50 * time.Millisecond
+)
+
+// sparkLevels are the bars of a sparkline, from lowest to highest.
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws the values as a bar each, scaled so that the largest is a full bar.
+func sparkline(values []float64) string {
+	var largest float64
+	for _, v := range values {
+		largest = max(largest, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if largest > 0 {
+			level = min(int(v/largest*float64(len(sparkLevels))), len(sparkLevels)-1)
+		}
+		b.WriteRune(sparkLevels[level])
+	}
+	return b.String()
+}
+
+// tuiBoard is the board of one worker in the watch view, with a label above it.
+type tuiBoard struct {
+	Label      string
+	Placements grid.Placements
+}
+
+// tuiBoards returns a board for each worker's current partial placement, labelled with the worker and its depth, or one for the deepest
+// placement reached if the solver doesn't report its workers' states. The depth of a worker that didn't take a snapshot in time, whose
+// placement is out of date, is followed by a question mark.
+func tuiBoards(p solver.Progress, workers []solver.WorkerState) []tuiBoard {
+	if len(workers) == 0 {
+		return []tuiBoard{{Label: fmt.Sprintf("deepest %d", len(p.Deepest)), Placements: p.Deepest}}
+	}
+	boards := make([]tuiBoard, len(workers))
+	for i, s := range workers {
+		state := strconv.Itoa(len(s.Placements))
+		switch {
+		case s.Idle:
+			state = "idle"
+		case s.Stale:
+			state += "?"
+		}
+		boards[i] = tuiBoard{Label: fmt.Sprintf("w%d %s", s.Worker, state), Placements: s.Placements}
+	}
+	return boards
+}
+
+// writeTUIBoards draws the boards side by side, as many to a row as fit in the width, with a character for each cell styled as in the
+// color board.
+func writeTUIBoards(w io.Writer, g grid.Grid, boards []tuiBoard, width int) {
+	columnWidth := max(int(g.Size), 8) + 2
+	perRow := max(width/columnWidth, 1)
+	for first := 0; first < len(boards); first += perRow {
+		row := boards[first:min(first+perRow, len(boards))]
+		var b strings.Builder
+		for _, board := range row {
+			fmt.Fprintf(&b, "%-*.*s", columnWidth, columnWidth-1, board.Label)
+		}
+		fmt.Fprintln(w, strings.TrimRight(b.String(), " "))
+		states := make([][][]cellState, len(row))
+		for i, board := range row {
+			states[i] = cellStates(g, board.Placements)
+		}
+		for r := 0; r < int(g.Size); r++ {
+			b.Reset()
+			for i := range row {
+				if i > 0 {
+					b.WriteString(strings.Repeat(" ", columnWidth-int(g.Size)))
+				}
+				for _, state := range states[i][r] {
+					b.WriteString(cellStyles[state])
+				}
+			}
+			fmt.Fprintln(w, b.String())
+		}
+	}
+}
+
+// tuiView draws the watch view of a search: its counters, a graph of the recent rate, and the board of each worker.
+type tuiView struct {
+	g        grid.Grid
+	title    string
+	reporter *progressReporter
+	// rates are the nodes/s of the most recent refreshes, oldest first
+	rates []float64
+	// width is the number of columns of the terminal
+	width int
+}
+
+// newTUIView returns a view of a search on the grid that started at start. The title names the strategies, and estimatedNodes is the
+// estimated size of the search tree, or 0 if it wasn't estimated.
+func newTUIView(g grid.Grid, title string, start time.Time, estimatedNodes float64) *tuiView {
+	width, err := strconv.Atoi(os.Getenv("COLUMNS"))
+	if err != nil || width <= 0 {
+		width = 80
+	}
+	return &tuiView{g: g, title: title, reporter: newProgressReporter(start, estimatedNodes), width: width}
+}
+
+// write redraws the whole view at now, given a snapshot of the statistics and the workers' states.
+func (v *tuiView) write(w io.Writer, now time.Time, p solver.Progress, depths []solver.DepthStats, workers []solver.WorkerState) {
+	r := v.reporter.report(now, p, depths)
+	v.rates = append(v.rates, r.NodesPerSecond)
+	if len(v.rates) > tuiRateSamples {
+		v.rates = v.rates[len(v.rates)-tuiRateSamples:]
+	}
+	var peak float64
+	for _, rate := range v.rates {
+		peak = max(peak, rate)
+	}
+
+	var b strings.Builder
+	// Move to the top left and clear the screen, so each redraw replaces the last
+	b.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(&b, "%s, %v elapsed\n", v.title, r.Elapsed.Round(time.Second))
+	fmt.Fprintf(&b, "nodes %d  nodes/s %.4g  deepest %d", r.Nodes, r.NodesPerSecond, len(p.Deepest))
+	if r.TasksTotal > 0 {
+		fmt.Fprintf(&b, "  tasks %d/%d", r.TasksDone, r.TasksTotal)
+	}
+	if r.Completion >= 0 {
+		fmt.Fprintf(&b, "  ~%.1f%% done", 100*r.Completion)
+	}
+	if r.ETA > 0 {
+		fmt.Fprintf(&b, "  ETA %v", r.ETA)
+	}
+	fmt.Fprintf(&b, "\nnodes/s %s peak %.4g\n\n", sparkline(v.rates), peak)
+	writeTUIBoards(&b, v.g, tuiBoards(p, workers), v.width)
+	fmt.Fprintf(&b, "\n%s stone  %s pruned  %s candidate  %s passed by ordered placers\n",
+		cellStyles[cellStone], cellStyles[cellPruned], cellStyles[cellCandidate], cellStyles[cellSkipped])
+	io.WriteString(w, b.String())
+}
+
+// showTUI redraws the watch view of the search on w until the returned function is called. Call it before the search starts, so the
+// workers record their states.
+func showTUI(ctx context.Context, w io.Writer, st *solver.Stats, g grid.Grid, title string, estimatedNodes float64) (stop func()) {
+	st.EnableWorkerStates()
+	ctx, cancel := context.WithCancel(ctx)
+	v := newTUIView(g, title, time.Now(), estimatedNodes)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for p := range solver.ReportProgress(ctx, st, tuiRefresh) {
+			v.write(w, time.Now(), p, st.Depths(), st.WorkerStates(tuiWorkerTimeout))
+		}
+	}()
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
+
+// watch implements the watch subcommand, which searches like solve, but redraws a view of the search on stderr while it runs, with its
+// counters, a graph of the recent nodes/s and the partial placement of each worker.
+func watch(args []string) int {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	sf := addSearchFlags(fs, true)
+	rf := addResultFlags(fs)
+	parseFlags(fs, fs.Name(), args)
+	rf.output.setup()
+
+	r := sf.start()
+	defer r.close()
+	solution, duration, err := r.search()
+	return rf.report(r, solution, duration, err)
+}
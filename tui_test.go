@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/WillMorrison/pegboard-blog/solver"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   string
+	}{
+		{nil, ""},
+		{[]float64{0, 0}, "▁▁"},
+		{[]float64{1, 2, 4, 8}, "▂▃▅█"},
+	}
+	for _, tt := range tests {
+		if got := sparkline(tt.values); got != tt.want {
+			t.Errorf("sparkline(%v) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestTUIBoards(t *testing.T) {
+	deepest := grid.Placements{{Row: 0, Col: 0}, {Row: 1, Col: 2}}
+	if diff := cmp.Diff([]tuiBoard{{Label: "deepest 2", Placements: deepest}}, tuiBoards(solver.Progress{Deepest: deepest}, nil)); diff != "" {
+		t.Errorf("tuiBoards() without worker states mismatch (-want +got):\n%s", diff)
+	}
+	workers := []solver.WorkerState{
+		{Worker: 0, Placements: deepest},
+		{Worker: 1, Stale: true, Placements: deepest[:1]},
+		{Worker: 2, Idle: true},
+	}
+	want := []tuiBoard{{Label: "w0 2", Placements: deepest}, {Label: "w1 1?", Placements: deepest[:1]}, {Label: "w2 idle"}}
+	if diff := cmp.Diff(want, tuiBoards(solver.Progress{Deepest: deepest}, workers)); diff != "" {
+		t.Errorf("tuiBoards() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteTUIBoards(t *testing.T) {
+	g := grid.Grid{Size: 3}
+	boards := []tuiBoard{{Label: "w0 1", Placements: grid.Placements{{Row: 0, Col: 0}}}, {Label: "w1 idle"}, {Label: "w2 idle"}}
+	var b strings.Builder
+	// Two boards, 10 columns each, fit in the width
+	writeTUIBoards(&b, g, boards, 25)
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 2*(1+int(g.Size)) {
+		t.Fatalf("writeTUIBoards() wrote %d lines, want two rows of boards:\n%s", len(lines), b.String())
+	}
+	if want := "w0 1      w1 idle"; lines[0] != want {
+		t.Errorf("writeTUIBoards() labels = %q, want %q", lines[0], want)
+	}
+	if want := cellStyles[cellStone] + strings.Repeat(cellStyles[cellCandidate], 2) + strings.Repeat(" ", 7) + strings.Repeat(cellStyles[cellCandidate], 3); lines[1] != want {
+		t.Errorf("writeTUIBoards() first row = %q, want %q", lines[1], want)
+	}
+}
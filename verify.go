@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+)
+
+// writeVerification checks the placements against every constraint for the grid, writes what it found, and returns whether they are a
+// valid solution. Invalid placements are followed by every constraint they violate, and which stones to remove or move to fix them.
+func writeVerification(w io.Writer, g grid.Grid, p grid.Placements) bool {
+	d := grid.Diagnose(g, p)
+	if d.Valid() {
+		fmt.Fprintf(w, "%v is a valid solution for %+v\n", p, g)
+		return true
+	}
+	fmt.Fprintf(w, "%v is not a valid solution for %+v:\n", p, g)
+	for _, v := range d.Violations {
+		fmt.Fprintf(w, "  %s: %v\n", v.Constraint, v)
+	}
+	if len(d.Remove) > 0 {
+		fmt.Fprintf(w, "Removing %v would leave stones with unique separations\n", d.Remove)
+	}
+	for _, a := range d.Alternatives {
+		if len(a.Cells) == 0 {
+			fmt.Fprintf(w, "  %v has nowhere to move to\n", a.Stone)
+			continue
+		}
+		fmt.Fprintf(w, "  %v could move to any of %v\n", a.Stone, a.Cells)
+	}
+	return false
+}
+
+// verify implements the verify subcommand, which checks placements produced elsewhere, given as arguments or one per line on stdin, in
+// the format the solution is printed in, e.g. "[A0 A1 B3]". The grid size defaults to the number of stones, as an n×n solution has n
+// stones. Placements without any stones are invalid input rather than an invalid solution, as there is no grid to check them on.
+func verify(args []string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	size := fs.Uint("size", 0, "the side length of the grid, or 0 for the number of stones placed")
+	parseFlags(fs, fs.Name(), args)
+	if *size > grid.MaxGridSize {
+		fatalf("The size must be at most %d, got %d", grid.MaxGridSize, *size)
+	}
+
+	var inputs []string
+	if fs.NArg() > 0 {
+		inputs = []string{strings.Join(fs.Args(), " ")}
+	} else {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if text := strings.TrimSpace(scanner.Text()); text != "" && !strings.HasPrefix(text, "#") {
+				inputs = append(inputs, text)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			fatal(err)
+		}
+	}
+
+	if len(inputs) == 0 {
+		fatal("verify needs placements to check, as arguments or on stdin")
+	}
+	valid := true
+	for _, input := range inputs {
+		p, err := grid.ParsePlacements(input)
+		if err != nil {
+			fatal(err)
+		}
+		if len(p) == 0 {
+			fatalf("%q has no stones to check", input)
+		}
+		g := grid.Grid{Size: uint8(*size)}
+		if *size == 0 {
+			g.Size = uint8(min(len(p), grid.MaxGridSize))
+		}
+		valid = writeVerification(os.Stdout, g, p) && valid
+	}
+	if !valid {
+		os.Exit(1)
+	}
+}
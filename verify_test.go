@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteVerification(t *testing.T) {
+	tests := []struct {
+		name       string
+		placements string
+		wantValid  bool
+		want       string
+	}{
+		{"valid", "[A0 B1 B2]", true, "[A0 B1 B2] is a valid solution for {Size:3}\n"},
+		{"invalid", "A0 A1 A2", false, `[A0 A1 A2] is not a valid solution for {Size:3}:
+  unique_separations: Duplicated separation with squared distance 1 between both [A0 A1] and [A1 A2]
+Removing [A0] would leave stones with unique separations
+  A0 could move to any of [B0 C0 C1 C2]
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := grid.ParsePlacements(tt.placements)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			if got := writeVerification(&b, grid.Grid{Size: 3}, p); got != tt.wantValid {
+				t.Errorf("writeVerification(%v) = %t, want %t", p, got, tt.wantValid)
+			}
+			if diff := cmp.Diff(tt.want, b.String()); diff != "" {
+				t.Errorf("writeVerification(%v) output mismatch (-want +got):\n%s", p, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/solver"
+)
+
+// warmStart implements the warm-start subcommand, which tries to extend the known solutions of smaller grids to a solution, and falls
+// back to the search of solve if none of them extends. -max_nodes limits both.
+func warmStart(args []string) int {
+	fs := flag.NewFlagSet("warm-start", flag.ExitOnError)
+	knownFile := fs.String("known", "", "file of known solutions for smaller grids, one per line, such as the solution.txt of a -bundle")
+	sf := addSearchFlags(fs, false)
+	rf := addResultFlags(fs)
+	parseFlags(fs, fs.Name(), args)
+	if *knownFile == "" {
+		fatal("warm-start needs a -known file of solutions")
+	}
+	rf.output.setup()
+	known, err := readPlacements(*knownFile)
+	if err != nil {
+		fatal(err)
+	}
+
+	r := sf.start()
+	defer r.close()
+	startTime := time.Now()
+	ctx, stop := signal.NotifyContext(r.ctx, os.Interrupt)
+	warmCtx, stopBudget := solver.WithNodeBudget(ctx, r.stats, *sf.maxNodes)
+	result, err := solver.WarmStart(warmCtx, r.g, known, r.stats)
+	stopBudget()
+	stop()
+	if errors.Is(err, solver.ErrCanceled) || errors.Is(err, solver.ErrTimeout) {
+		fmt.Printf("Warm start stopped for %+v after %v and %d embeddings: %v\n", r.g, time.Since(startTime), result.Embeddings, err)
+		return exitStopped
+	}
+	if err != nil {
+		fatal(err)
+	}
+	if result.Solution != nil {
+		fmt.Printf("Solution found for %+v by warm start from %v in %v: %v\n", r.g, result.From, time.Since(startTime), result.Solution)
+		return exitSolved
+	}
+	fmt.Printf("Warm start tried %d embeddings of %d known solutions for %+v in %v without finding a solution. Falling back to full search.\n", result.Embeddings, len(known), r.g, time.Since(startTime))
+
+	solution, duration, err := r.search()
+	return rf.report(r, solution, duration, err)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+	"time"
+
+	"github.com/WillMorrison/pegboard-blog/grid"
+	"golang.org/x/net/websocket"
+)
+
+// liveInterval is how often the live view of a job is updated.
+const liveInterval = 200 * time.Millisecond
+
+// webUI is the page served at /, which starts jobs and shows their workers' boards filling in live.
+//
+//go:embed web/index.html
+var webUI []byte
+
+// handleIndex serves the web UI.
+func handleIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(webUI)
+}
+
+// liveWorker is a worker's current partial placement, as sent to the live view.
+type liveWorker struct {
+	Worker     int             `json:"worker"`
+	Idle       bool            `json:"idle"`
+	Placements grid.Placements `json:"placements"`
+}
+
+// liveUpdate is a message sent over the WebSocket of /jobs/{id}/live.
+type liveUpdate struct {
+	Job     jobStatus    `json:"job"`
+	Workers []liveWorker `json:"workers"`
+}
+
+// newLiveUpdate returns a snapshot of the job and the partial placements of its workers, waiting up to the timeout for busy workers to
+// take a snapshot.
+func newLiveUpdate(j *job, timeout time.Duration) liveUpdate {
+	u := liveUpdate{Job: j.status()}
+	for _, s := range j.stats.WorkerStates(timeout) {
+		u.Workers = append(u.Workers, liveWorker{Worker: s.Worker, Idle: s.Idle, Placements: s.Placements})
+	}
+	return u
+}
+
+// liveUpdates returns the handler of /jobs/{id}/live, which sends a liveUpdate as JSON over a WebSocket every interval while the job
+// runs, and a last one with its result when it finishes, before closing the connection.
+func liveUpdates(j *job, interval time.Duration) websocket.Handler {
+	return func(ws *websocket.Conn) {
+		defer ws.Close()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			u := newLiveUpdate(j, interval/4)
+			if err := websocket.JSON.Send(ws, u); err != nil || u.Job.State != JobRunning {
+				// The client went away, or the job is over
+				return
+			}
+			<-ticker.C
+		}
+	}
+}